@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/tools"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// runCall implements `htb-mcp-server call <tool> --args '{...}'`, running a
+// single tool without an MCP client and printing its result
+func runCall(cfg *config.Config, argv []string) int {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	argsJSON := fs.String("args", "{}", "tool arguments as a JSON object")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: htb-mcp-server call <tool> [--args '{\"machine_id\":42}']\n")
+		fs.PrintDefaults()
+	}
+
+	// Accept flags both before and after the tool name
+	if err := fs.Parse(argv); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	toolName := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return 2
+	}
+
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(*argsJSON), &args); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --args: %v\n", err)
+		return 2
+	}
+
+	client := htb.NewClient(cfg)
+	shared := tools.NewShared(cfg, client, cache.New(cfg.CacheTTL))
+	defer shared.Close()
+	registry := tools.NewRegistry(cfg, client, shared)
+	defer registry.Close()
+
+	if _, exists := registry.GetTool(toolName); !exists {
+		fmt.Fprintf(os.Stderr, "Unknown tool %q; run with --list-tools to see available tools\n", toolName)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout*4)
+	defer cancel()
+
+	result, err := registry.ExecuteTool(ctx, toolName, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing tool: %v\n", err)
+		return 1
+	}
+
+	printToolResult(os.Stdout, result)
+	if result.IsError {
+		return 1
+	}
+	return 0
+}
+
+// printToolResult prints each content block of a tool result in readable form
+func printToolResult(w io.Writer, result *mcp.CallToolResponse) {
+	for _, content := range result.Content {
+		switch {
+		case content.Type == "text":
+			fmt.Fprintln(w, content.Text)
+		case content.Resource != nil:
+			fmt.Fprintf(w, "[resource %s (%s)]\n", content.Resource.URI, content.Resource.MimeType)
+		default:
+			data, _ := json.MarshalIndent(content, "", "  ")
+			fmt.Fprintln(w, string(data))
+		}
+	}
+}
+
+// runListTools implements `htb-mcp-server --list-tools [--format json|markdown]`,
+// printing every built-in tool. It uses the default configuration, so it
+// works without an HTB token.
+func runListTools(argv []string) int {
+	fs := flag.NewFlagSet("--list-tools", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format: json or markdown")
+	if err := fs.Parse(argv); err != nil {
+		return 2
+	}
+
+	cfg := config.Default()
+	client := htb.NewClient(cfg)
+	shared := tools.NewShared(cfg, client, cache.New(cfg.CacheTTL))
+	defer shared.Close()
+	registry := tools.NewRegistry(cfg, client, shared)
+	defer registry.Close()
+
+	toolList := registry.GetTools()
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(toolList, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode tools: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	case "markdown", "md":
+		printToolsMarkdown(os.Stdout, toolList)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q: use json or markdown\n", *format)
+		return 2
+	}
+
+	return 0
+}
+
+// printToolsMarkdown prints tools as Markdown sections with a parameter table each
+func printToolsMarkdown(w io.Writer, toolList []mcp.Tool) {
+	for _, tool := range toolList {
+		fmt.Fprintf(w, "## `%s`\n\n%s\n\n", tool.Name, tool.Description)
+
+		if len(tool.InputSchema.Properties) == 0 {
+			fmt.Fprintf(w, "_No parameters._\n\n")
+			continue
+		}
+
+		required := make(map[string]bool)
+		for _, name := range tool.InputSchema.Required {
+			required[name] = true
+		}
+
+		names := make([]string, 0, len(tool.InputSchema.Properties))
+		for name := range tool.InputSchema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(w, "| Parameter | Type | Required | Description |\n")
+		fmt.Fprintf(w, "|-----------|------|----------|-------------|\n")
+		for _, name := range names {
+			prop := tool.InputSchema.Properties[name]
+			description := prop.Description
+			if len(prop.Enum) > 0 {
+				description += fmt.Sprintf(" (one of: %s)", strings.Join(prop.Enum, ", "))
+			}
+			fmt.Fprintf(w, "| `%s` | %s | %t | %s |\n", name, prop.Type, required[name], description)
+		}
+		fmt.Fprintln(w)
+	}
+}
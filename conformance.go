@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/NoASLR/htb-mcp-server/internal/conformance"
+)
+
+// runConformance implements `htb-mcp-server conformance [dir]`, replaying the
+// built-in MCP session transcripts, or those in dir, against a fresh server
+// backed by a stub HTB API
+func runConformance(argv []string) int {
+	fs := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "print every transcript, not only failures")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: htb-mcp-server conformance [-v] [transcript-dir]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(argv); err != nil {
+		return 2
+	}
+
+	transcripts, err := conformance.Builtin()
+	if fs.NArg() > 0 {
+		transcripts, err = conformance.Load(os.DirFS(fs.Arg(0)))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load transcripts: %v\n", err)
+		return 1
+	}
+
+	failed := 0
+	for _, t := range transcripts {
+		result, err := conformance.Replay(context.Background(), t)
+		if err != nil {
+			fmt.Printf("ERROR %s: %v\n", t.Name, err)
+			failed++
+			continue
+		}
+
+		if !result.Passed() {
+			fmt.Printf("FAIL  %s\n", t.Name)
+			for _, failure := range result.Failures {
+				fmt.Printf("      %s\n", failure)
+			}
+			failed++
+		} else if *verbose {
+			fmt.Printf("ok    %s\n", t.Name)
+		}
+	}
+
+	fmt.Printf("%d/%d transcripts passed\n", len(transcripts)-failed, len(transcripts))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+// Package cache provides caches with per-entry expiry: an in-memory cache,
+// and a Redis cache that instances serving the same token can share.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+)
+
+// Cache backends
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Cache holds values for a fixed time-to-live. Shared backends store values
+// as JSON and return them as json.RawMessage, so only values whose JSON
+// encoding callers can use should be cached.
+type Cache interface {
+	// Get returns the cached value for key if it has not expired
+	Get(key string) (interface{}, bool)
+
+	// Set stores value under key
+	Set(key string, value interface{})
+
+	// SetTTL stores value under key for ttl instead of the cache's own
+	// time-to-live, for entries that stay valid longer or shorter. A
+	// non-positive ttl stores nothing.
+	SetTTL(key string, value interface{}, ttl time.Duration)
+
+	// Delete removes key from the cache
+	Delete(key string)
+}
+
+// Open creates the cache backend selected by cfg. Redis keys are namespaced
+// by a fingerprint of the HTB token, so instances only share entries with
+// others serving the same account.
+func Open(cfg *config.Config) (Cache, error) {
+	switch cfg.CacheBackend {
+	case "", BackendMemory:
+		return New(cfg.CacheTTL), nil
+	case BackendRedis:
+		sum := sha256.Sum256([]byte(cfg.HTBToken))
+		return NewRedis(cfg.RedisURL, cfg.CacheTTL, "htb-mcp:"+hex.EncodeToString(sum[:6])+":")
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.CacheBackend)
+	}
+}
+
+// GetOrLoad returns the cached value for key, calling load and caching its
+// result on a miss. Errors are returned without being cached.
+func GetOrLoad(c Cache, key string, load func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
+
+// Decode stores a cached value in target, decoding the json.RawMessage
+// returned by shared backends. Values cached in memory are assigned directly
+// when target points to their type.
+func Decode(value interface{}, target interface{}) error {
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		dst := reflect.ValueOf(target)
+		if dst.Kind() == reflect.Pointer && !dst.IsNil() && value != nil && reflect.TypeOf(value).AssignableTo(dst.Elem().Type()) {
+			dst.Elem().Set(reflect.ValueOf(value))
+			return nil
+		}
+
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("failed to encode cached value: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("failed to decode cached value: %w", err)
+	}
+	return nil
+}
+
+// entry is a cached value and when it expires
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Memory is a Cache local to the process
+type Memory struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// New creates an in-memory cache whose entries expire after ttl. A
+// non-positive ttl disables caching.
+func New(ttl time.Duration) *Memory {
+	return &Memory{
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+}
+
+// Get returns the cached value for key if it has not expired
+func (c *Memory) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key
+func (c *Memory) Set(key string, value interface{}) {
+	c.SetTTL(key, value, c.ttl)
+}
+
+// SetTTL stores value under key for ttl
+func (c *Memory) SetTTL(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// Delete removes key from the cache
+func (c *Memory) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
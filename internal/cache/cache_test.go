@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiry(t *testing.T) {
+	c := New(time.Minute)
+	c.Set("listing", []string{"Lame"})
+	c.SetTTL("short", 1, 10*time.Millisecond)
+	c.SetTTL("never", 1, 0)
+
+	if value, ok := c.Get("listing"); !ok || value.([]string)[0] != "Lame" {
+		t.Errorf("Expected the cached listing, got %v, %v", value, ok)
+	}
+	if _, ok := c.Get("never"); ok {
+		t.Error("Expected a non-positive ttl to store nothing")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("short"); ok {
+		t.Error("Expected the short-lived entry to expire")
+	}
+
+	c.Delete("listing")
+	if _, ok := c.Get("listing"); ok {
+		t.Error("Expected the deleted entry to be gone")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type machine struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// Shared backends return JSON
+	var fromRedis machine
+	if err := Decode(json.RawMessage(`{"id":1,"name":"Lame"}`), &fromRedis); err != nil || fromRedis.Name != "Lame" {
+		t.Errorf("Expected the decoded machine, got %+v, %v", fromRedis, err)
+	}
+
+	// Memory values of the target type are assigned as they are
+	var fromMemory machine
+	if err := Decode(machine{ID: 2, Name: "Blue"}, &fromMemory); err != nil || fromMemory.Name != "Blue" {
+		t.Errorf("Expected the cached machine, got %+v, %v", fromMemory, err)
+	}
+
+	// Other values round-trip through JSON
+	var fromMap machine
+	if err := Decode(map[string]interface{}{"id": 3, "name": "Legacy"}, &fromMap); err != nil || fromMap.ID != 3 {
+		t.Errorf("Expected the converted machine, got %+v, %v", fromMap, err)
+	}
+
+	if err := Decode(json.RawMessage(`not json`), &fromMap); err == nil {
+		t.Error("Expected invalid JSON to fail")
+	}
+}
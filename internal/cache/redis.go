@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds connecting to Redis and each command
+const redisTimeout = 2 * time.Second
+
+// Redis is a Cache stored in a Redis server and shared by every instance
+// configured with it. Redis failures are logged and treated as cache misses,
+// so an unavailable server only costs extra HTB API requests.
+type Redis struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	db       int
+	ttl      time.Duration
+	prefix   string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	failing bool
+}
+
+// NewRedis creates a cache in the Redis server at rawURL, given as
+// redis://[user:password@]host[:port][/db] or rediss:// for TLS. Keys are
+// prefixed with prefix, and entries expire after ttl; a non-positive ttl
+// disables caching. The connection is made on first use.
+func NewRedis(rawURL string, ttl time.Duration, prefix string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("invalid Redis URL %q: scheme must be redis or rediss", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q: missing host", u.Redacted())
+	}
+
+	r := &Redis{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+		ttl:    ttl,
+		prefix: prefix,
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+// Get returns the cached value for key as json.RawMessage
+func (r *Redis) Get(key string) (interface{}, bool) {
+	reply, err := r.Do("GET", r.prefix+key)
+	if err != nil {
+		return nil, false
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false
+	}
+	return json.RawMessage(data), true
+}
+
+// Set stores the JSON encoding of value under key
+func (r *Redis) Set(key string, value interface{}) {
+	r.SetTTL(key, value, r.ttl)
+}
+
+// SetTTL stores the JSON encoding of value under key for ttl
+func (r *Redis) SetTTL(key string, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode cache entry %s: %v", key, err)
+		return
+	}
+	r.Do("SET", r.prefix+key, string(data), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+}
+
+// Delete removes key from the cache
+func (r *Redis) Delete(key string) {
+	r.Do("DEL", r.prefix+key)
+}
+
+// Close closes the connection to Redis. A later command reconnects.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// Do sends a command to Redis and returns its reply: a string for status
+// replies, int64, []byte or nil for bulk strings, or []interface{}. Error
+// replies are returned as errors. Connection failures are logged once until
+// Redis is reachable again.
+func (r *Redis) Do(args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reply, err := r.do(args)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		if r.conn != nil {
+			r.conn.Close()
+			r.conn = nil
+		}
+		if !r.failing {
+			log.Printf("Redis cache at %s is unavailable: %v", r.addr, err)
+			r.failing = true
+		}
+		return nil, err
+	}
+	if r.failing {
+		log.Printf("Redis cache at %s is available again", r.addr)
+		r.failing = false
+	}
+	return reply, err
+}
+
+// do sends a command, connecting first if needed; r.mu must be held
+func (r *Redis) do(args []string) (interface{}, error) {
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err := writeCommand(r.conn, args); err != nil {
+		return nil, err
+	}
+	return readReply(r.reader)
+}
+
+// connect dials Redis, authenticating and selecting the database; r.mu
+// must be held
+func (r *Redis) connect() error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", r.addr)
+	}
+	if err != nil {
+		return err
+	}
+	r.conn, r.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	switch {
+	case r.password != "" && r.username != "":
+		setup = append(setup, []string{"AUTH", r.username, r.password})
+	case r.password != "":
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := r.do(args); err != nil {
+			// Not wrapped: a rejected AUTH leaves the connection unusable
+			conn.Close()
+			r.conn = nil
+			return fmt.Errorf("%s failed: %v", args[0], err)
+		}
+	}
+	return nil
+}
+
+// redisError is an error reply from Redis
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// writeCommand writes a command as a RESP array of bulk strings
+func writeCommand(w io.Writer, args []string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// readReply reads one RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("malformed Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				var replyErr redisError
+				if !errors.As(err, &replyErr) {
+					return nil, err
+				}
+				items[i] = replyErr
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("malformed Redis reply %q", line)
+	}
+}
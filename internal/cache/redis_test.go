@@ -0,0 +1,269 @@
+package cache_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/tools"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// startRedis runs a minimal in-memory Redis server understanding the
+// commands the cache and throttle use, returning its URL
+func startRedis(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	expires := make(map[string]time.Time)
+	live := func(key string) (string, bool) {
+		if at, ok := expires[key]; ok && time.Now().After(at) {
+			delete(data, key)
+			delete(expires, key)
+		}
+		value, ok := data[key]
+		return value, ok
+	}
+	reply := func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			if value, ok := live(args[1]); ok {
+				return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			}
+			return "$-1\r\n"
+		case "SET":
+			data[args[1]] = args[2]
+			delete(expires, args[1])
+			if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+				ms, _ := strconv.Atoi(args[4])
+				expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			return "+OK\r\n"
+		case "DEL":
+			delete(data, args[1])
+			return ":1\r\n"
+		case "INCR":
+			value, _ := live(args[1])
+			n, _ := strconv.Atoi(value)
+			data[args[1]] = strconv.Itoa(n + 1)
+			return fmt.Sprintf(":%d\r\n", n+1)
+		case "PEXPIRE":
+			ms, _ := strconv.Atoi(args[2])
+			expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			return ":1\r\n"
+		case "PTTL":
+			if _, ok := live(args[1]); !ok {
+				return ":-2\r\n"
+			}
+			if at, ok := expires[args[1]]; ok {
+				return fmt.Sprintf(":%d\r\n", time.Until(at).Milliseconds())
+			}
+			return ":-1\r\n"
+		default:
+			return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					var n int
+					if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+						return
+					}
+					args := make([]string, n)
+					for i := range args {
+						var size int
+						fmt.Fscanf(r, "$%d\r\n", &size)
+						buf := make([]byte, size+2)
+						io.ReadFull(r, buf)
+						args[i] = string(buf[:size])
+					}
+					io.WriteString(conn, reply(args))
+				}
+			}()
+		}
+	}()
+
+	return "redis://" + ln.Addr().String()
+}
+
+// openRedis opens the cache of an instance serving token from the Redis
+// server at redisURL
+func openRedis(t *testing.T, redisURL, token string) cache.Cache {
+	t.Helper()
+
+	c, err := cache.Open(&config.Config{
+		HTBToken:     token,
+		CacheTTL:     time.Minute,
+		CacheBackend: cache.BackendRedis,
+		RedisURL:     redisURL,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open Redis cache: %v", err)
+	}
+	t.Cleanup(func() { c.(io.Closer).Close() })
+	return c
+}
+
+func TestRedisSharedByInstances(t *testing.T) {
+	type machine struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	redisURL := startRedis(t)
+	first := openRedis(t, redisURL, "first.account.token")
+	second := openRedis(t, redisURL, "first.account.token")
+	other := openRedis(t, redisURL, "other.account.token")
+
+	// Instances serving the same token read each other's entries as JSON
+	first.Set("machine:1", machine{ID: 1, Name: "Lame"})
+	value, ok := second.Get("machine:1")
+	var cached machine
+	if !ok || cache.Decode(value, &cached) != nil || cached.Name != "Lame" {
+		t.Fatalf("Expected the first instance's entry, got %v, %v", value, ok)
+	}
+
+	// Other accounts are kept apart
+	if value, ok := other.Get("machine:1"); ok {
+		t.Errorf("Expected another account's entry to stay hidden, got %s", value)
+	}
+
+	first.SetTTL("short", 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := second.Get("short"); ok {
+		t.Error("Expected the short-lived entry to expire")
+	}
+
+	// Closing the connection only costs a reconnect
+	first.(io.Closer).Close()
+	second.Delete("machine:1")
+	if _, ok := first.Get("machine:1"); ok {
+		t.Error("Expected the deleted entry to be gone for every instance")
+	}
+}
+
+func TestRedisUnavailableIsAMiss(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	redisURL := "redis://" + ln.Addr().String()
+	ln.Close()
+
+	c := openRedis(t, redisURL, "header.payload.signature")
+	c.Set("listing", []string{"Lame"})
+	if value, ok := c.Get("listing"); ok {
+		t.Errorf("Expected a miss while Redis is down, got %v", value)
+	}
+}
+
+// machineCatalogAPI serves two pages of active machines and one of retired
+// machines, counting listing requests
+func machineCatalogAPI(listCalls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/paginated/":
+			listCalls.Add(1)
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`{"data":[{"id":3,"name":"Cap","os":"Linux","difficultyText":"Easy"}],"meta":{"last_page":2}}`))
+				return
+			}
+			w.Write([]byte(`{"data":[{"id":1,"name":"Lame","os":"Linux","difficultyText":"Easy"},{"id":2,"name":"Blue","os":"Windows","difficultyText":"Easy"}],"meta":{"last_page":2}}`))
+		case "/machine/list/retired/paginated/":
+			listCalls.Add(1)
+			w.Write([]byte(`{"data":[{"id":4,"name":"Legacy","os":"Windows","difficultyText":"Easy"}],"meta":{"last_page":1}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}
+}
+
+func TestSharedToolCaches(t *testing.T) {
+	var listCalls atomic.Int32
+	api := httptest.NewServer(machineCatalogAPI(&listCalls))
+	defer api.Close()
+	redisURL := startRedis(t)
+
+	// newInstance creates the tools of an instance caching in the shared Redis
+	newInstance := func() *tools.Registry {
+		cfg := config.Default()
+		cfg.HTBToken = "header.payload.signature"
+		cfg.HTBBaseURL = api.URL
+		cfg.CacheTTL = time.Minute
+		cfg.CacheBackend = cache.BackendRedis
+		cfg.RedisURL = redisURL
+		client := htb.NewClient(cfg)
+		c, err := cache.Open(cfg)
+		if err != nil {
+			t.Fatalf("Failed to open Redis cache: %v", err)
+		}
+		shared := tools.NewShared(cfg, client, c)
+		t.Cleanup(shared.Close)
+		registry := tools.NewRegistry(cfg, client, shared)
+		t.Cleanup(registry.Close)
+		return registry
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := newInstance()
+	if _, err := first.ExecuteTool(ctx, "list_machines", map[string]interface{}{"refresh": true}); err != nil {
+		t.Fatalf("list_machines failed: %v", err)
+	}
+	if _, err := first.ExecuteTool(ctx, "export_all_machines", map[string]interface{}{}); err != nil {
+		t.Fatalf("export_all_machines failed: %v", err)
+	}
+	calls := listCalls.Load()
+
+	// A second instance serving the same token reuses the first one's
+	// listing pages and exported catalog
+	second := newInstance()
+	result, err := second.ExecuteTool(ctx, "list_machines", map[string]interface{}{"refresh": true})
+	if err != nil || !strings.Contains(result.Content[0].Text, "Lame") {
+		t.Fatalf("list_machines failed: %+v, %v", result, err)
+	}
+	if listCalls.Load() != calls {
+		t.Errorf("Expected the listing from the shared cache, got %d more page requests", listCalls.Load()-calls)
+	}
+
+	result, err = second.ExecuteTool(ctx, "list_machines", map[string]interface{}{"status": "retired"})
+	if err != nil || !strings.Contains(result.Content[0].Text, "Served from the local machines export") {
+		t.Errorf("Expected the shared export to serve the listing, got %+v, %v", result, err)
+	}
+	encoded, _ := json.Marshal(result.Content)
+	if !strings.Contains(string(encoded), "Legacy") {
+		t.Errorf("Expected the retired machine from the export, got %s", encoded)
+	}
+}
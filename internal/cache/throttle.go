@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// SharedThrottle is an htb.Throttle kept in Redis, so the combined requests
+// of every instance serving the same token stay under the limit, and a 429
+// seen by one instance holds back the others. Windows follow each
+// instance's clock, so clocks should be roughly in sync. While Redis is
+// unavailable, each instance paces itself.
+type SharedThrottle struct {
+	redis     *Redis
+	perMinute int
+	local     htb.Throttle
+}
+
+// NewSharedThrottle creates a throttle allowing perMinute requests per clock
+// minute across all instances using r
+func NewSharedThrottle(r *Redis, perMinute int) *SharedThrottle {
+	return &SharedThrottle{redis: r, perMinute: perMinute, local: htb.NewThrottle(perMinute)}
+}
+
+// Reserve takes a request slot in the current minute's shared counter, or
+// returns how long to wait
+func (t *SharedThrottle) Reserve() time.Duration {
+	reply, err := t.redis.Do("PTTL", t.redis.prefix+"throttle:backoff")
+	if err != nil {
+		return t.local.Reserve()
+	}
+	if ms, ok := reply.(int64); ok && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	now := time.Now()
+	window := now.Unix() / 60
+	key := fmt.Sprintf("%sthrottle:%d", t.redis.prefix, window)
+	reply, err = t.redis.Do("INCR", key)
+	if err != nil {
+		return t.local.Reserve()
+	}
+	count, _ := reply.(int64)
+	if count == 1 {
+		// Keep the counter a little past its window for slow clocks
+		t.redis.Do("PEXPIRE", key, "120000")
+	}
+	if count > int64(t.perMinute) {
+		return time.Unix((window+1)*60, 0).Sub(now)
+	}
+	return 0
+}
+
+// Backoff holds requests of every instance until until
+func (t *SharedThrottle) Backoff(until time.Time) {
+	t.local.Backoff(until)
+	if d := time.Until(until); d > 0 {
+		t.redis.Do("SET", t.redis.prefix+"throttle:backoff", "1", "PX", strconv.FormatInt(d.Milliseconds(), 10))
+	}
+}
@@ -0,0 +1,47 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+)
+
+// sharedThrottle creates the throttle of an instance pacing requests through
+// the Redis server at redisURL
+func sharedThrottle(t *testing.T, redisURL string, perMinute int) *cache.SharedThrottle {
+	t.Helper()
+	return cache.NewSharedThrottle(openRedis(t, redisURL, "header.payload.signature").(*cache.Redis), perMinute)
+}
+
+func TestSharedThrottleBudget(t *testing.T) {
+	redisURL := startRedis(t)
+	first := sharedThrottle(t, redisURL, 2)
+	second := sharedThrottle(t, redisURL, 2)
+
+	window := time.Now().Unix() / 60
+	waits := []time.Duration{first.Reserve(), second.Reserve(), first.Reserve()}
+	if time.Now().Unix()/60 != window {
+		t.Skip("Clock minute changed while reserving")
+	}
+
+	// Both instances draw on one budget per minute
+	if waits[0] != 0 || waits[1] != 0 {
+		t.Errorf("Expected the first two requests to go ahead, got %v", waits)
+	}
+	if waits[2] <= 0 || waits[2] > time.Minute {
+		t.Errorf("Expected the third request to wait for the next minute, got %v", waits[2])
+	}
+}
+
+func TestSharedThrottleBackoff(t *testing.T) {
+	redisURL := startRedis(t)
+	first := sharedThrottle(t, redisURL, 100)
+	second := sharedThrottle(t, redisURL, 100)
+
+	// A 429 seen by the first instance holds back the second
+	first.Backoff(time.Now().Add(time.Second))
+	if wait := second.Reserve(); wait < 500*time.Millisecond || wait > time.Second {
+		t.Errorf("Expected the second instance to wait for the shared backoff, got %v", wait)
+	}
+}
@@ -0,0 +1,344 @@
+// Package conformance replays recorded MCP session transcripts against the
+// server and checks its responses, guarding the hand-rolled protocol
+// implementation against regressions as transports and methods are added.
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/server"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+)
+
+//go:embed transcripts/*.json
+var builtin embed.FS
+
+// Any is a placeholder in expected responses that matches any value
+const Any = "<any>"
+
+// Match modes for a step's expected response
+const (
+	// MatchSemantic requires every value in the expectation to be present in
+	// the response; extra object fields in the response are allowed
+	MatchSemantic = "semantic"
+
+	// MatchExact requires the response line to equal the compacted
+	// expectation byte for byte
+	MatchExact = "exact"
+)
+
+// stepTimeout bounds how long a step waits for its response
+const stepTimeout = 5 * time.Second
+
+// Transcript is a recorded MCP session
+type Transcript struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// API maps HTB API paths to the response bodies served by a stub API.
+	// Unlisted paths answer 404.
+	API map[string]json.RawMessage `json:"api,omitempty"`
+
+	Steps []Step `json:"steps"`
+}
+
+// Step sends one message to the server and optionally checks the response
+type Step struct {
+	// Send is the message sent to the server; SendRaw is sent verbatim
+	// instead, for malformed input
+	Send    json.RawMessage `json:"send,omitempty"`
+	SendRaw string          `json:"send_raw,omitempty"`
+
+	// Expect is the response to the sent request, matched by request ID.
+	// Steps without an expectation (e.g. notifications) only send.
+	Expect json.RawMessage `json:"expect,omitempty"`
+
+	// Match is MatchSemantic (default) or MatchExact
+	Match string `json:"match,omitempty"`
+}
+
+// Result is the outcome of replaying a transcript
+type Result struct {
+	Name     string
+	Failures []string
+
+	// Responses holds the actual response to each step, or nil for steps
+	// without an expectation
+	Responses []json.RawMessage
+}
+
+// Passed reports whether every step matched its expectation
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Builtin returns the transcripts shipped with the server
+func Builtin() ([]Transcript, error) {
+	sub, err := fs.Sub(builtin, "transcripts")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads every *.json transcript in fsys, sorted by file name
+func Load(fsys fs.FS) ([]Transcript, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	transcripts := make([]Transcript, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transcript %s: %w", name, err)
+		}
+
+		var t Transcript
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript %s: %w", name, err)
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(path.Base(name), ".json")
+		}
+		transcripts = append(transcripts, t)
+	}
+	return transcripts, nil
+}
+
+// Replay runs a fresh server against a stub HTB API, sends the transcript's
+// messages in order and compares each response with its expectation
+func Replay(ctx context.Context, t Transcript) (*Result, error) {
+	api := httptest.NewServer(stubAPI(t.API))
+	defer api.Close()
+
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     api.URL,
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Hour,
+	}
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	srv := server.NewWithIO(cfg, serverIn, serverOut)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go srv.Serve(ctx)
+	defer srv.Close()
+	defer clientIn.Close()
+	defer clientOut.Close()
+
+	responses := readResponses(ctx, clientIn)
+
+	result := &Result{Name: t.Name, Responses: make([]json.RawMessage, len(t.Steps))}
+	for i, step := range t.Steps {
+		line := []byte(step.SendRaw)
+		if step.SendRaw == "" {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, step.Send); err != nil {
+				return nil, fmt.Errorf("step %d: invalid message: %w", i+1, err)
+			}
+			line = buf.Bytes()
+		}
+		if _, err := clientOut.Write(append(line, '\n')); err != nil {
+			return nil, fmt.Errorf("step %d: failed to send message: %w", i+1, err)
+		}
+
+		if len(step.Expect) == 0 {
+			continue
+		}
+
+		actual, err := responses.wait(ctx, requestID(line))
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("step %d: %v", i+1, err))
+			continue
+		}
+		result.Responses[i] = actual
+
+		if err := compare(step, actual); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("step %d: %v", i+1, err))
+		}
+	}
+
+	return result, nil
+}
+
+// stubAPI serves canned HTB API responses
+func stubAPI(routes map[string]json.RawMessage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// requestID returns the canonical ID of a request, or "null" when it has none
+// (responses to unparseable messages carry no ID)
+func requestID(msg json.RawMessage) string {
+	var envelope struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(msg, &envelope); err != nil || len(envelope.ID) == 0 {
+		return "null"
+	}
+	return string(envelope.ID)
+}
+
+// responseSet collects server responses by request ID. Tool calls run
+// concurrently, so responses may arrive out of order.
+type responseSet struct {
+	ch       chan json.RawMessage
+	received map[string][]json.RawMessage
+}
+
+// readResponses reads server output until it is closed, keeping responses
+// and dropping notifications and server-initiated requests
+func readResponses(ctx context.Context, r io.Reader) *responseSet {
+	set := &responseSet{
+		ch:       make(chan json.RawMessage, 100),
+		received: make(map[string][]json.RawMessage),
+	}
+
+	go func() {
+		defer close(set.ch)
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var envelope struct {
+				Method string `json:"method"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil || envelope.Method != "" {
+				continue
+			}
+			select {
+			case set.ch <- append(json.RawMessage(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return set
+}
+
+// wait returns the next response to the request with the given ID
+func (s *responseSet) wait(ctx context.Context, id string) (json.RawMessage, error) {
+	timer := time.NewTimer(stepTimeout)
+	defer timer.Stop()
+
+	for {
+		if queued := s.received[id]; len(queued) > 0 {
+			s.received[id] = queued[1:]
+			return queued[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, fmt.Errorf("no response to request %s within %s", id, stepTimeout)
+		case msg, ok := <-s.ch:
+			if !ok {
+				return nil, fmt.Errorf("server closed its output before responding to request %s", id)
+			}
+			got := requestID(msg)
+			s.received[got] = append(s.received[got], msg)
+		}
+	}
+}
+
+// compare checks a response against a step's expectation
+func compare(step Step, actual json.RawMessage) error {
+	switch step.Match {
+	case MatchExact:
+		var want bytes.Buffer
+		if err := json.Compact(&want, step.Expect); err != nil {
+			return fmt.Errorf("invalid expectation: %w", err)
+		}
+		if !bytes.Equal(want.Bytes(), actual) {
+			return fmt.Errorf("response differs:\n  want %s\n  got  %s", want.Bytes(), actual)
+		}
+		return nil
+	case "", MatchSemantic:
+		var want, got interface{}
+		if err := json.Unmarshal(step.Expect, &want); err != nil {
+			return fmt.Errorf("invalid expectation: %w", err)
+		}
+		if err := json.Unmarshal(actual, &got); err != nil {
+			return fmt.Errorf("invalid response: %w", err)
+		}
+		if err := contains(want, got, "$"); err != nil {
+			return fmt.Errorf("%v\n  got %s", err, actual)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown match mode %q", step.Match)
+	}
+}
+
+// contains reports the first place where got does not satisfy want. Objects
+// may carry extra fields, arrays must have the same length, and Any matches
+// any present value.
+func contains(want, got interface{}, at string) error {
+	if want == Any {
+		return nil
+	}
+
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %v", at, got)
+		}
+		for key, value := range w {
+			actual, ok := g[key]
+			if !ok {
+				return fmt.Errorf("%s.%s: missing", at, key)
+			}
+			if err := contains(value, actual, at+"."+key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return fmt.Errorf("%s: expected an array of %d items, got %v", at, len(w), got)
+		}
+		for i := range w {
+			if err := contains(w[i], g[i], fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return fmt.Errorf("%s: expected %v, got %v", at, want, got)
+		}
+		return nil
+	}
+}
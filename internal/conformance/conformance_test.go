@@ -0,0 +1,28 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConformanceTranscripts(t *testing.T) {
+	transcripts, err := Builtin()
+	if err != nil {
+		t.Fatalf("Failed to load transcripts: %v", err)
+	}
+	if len(transcripts) == 0 {
+		t.Fatal("Expected built-in transcripts")
+	}
+
+	for _, transcript := range transcripts {
+		t.Run(transcript.Name, func(t *testing.T) {
+			result, err := Replay(context.Background(), transcript)
+			if err != nil {
+				t.Fatalf("Replay failed: %v", err)
+			}
+			for _, failure := range result.Failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package extensions runs third-party tools as subprocesses.
+//
+// An extension is any executable. Run with --describe, it prints a JSON array
+// of tool definitions ({"name", "description", "inputSchema"}). To run a tool
+// the server starts the executable without arguments, writes a JSON request
+// {"tool": name, "arguments": {...}} to its stdin and reads the result from
+// stdout: either an MCP tools/call result ({"content": [...]}) or plain text.
+// A non-zero exit status is reported as a tool error using stderr.
+package extensions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Tool is a tool provided by an extension executable
+type Tool struct {
+	path       string
+	definition mcp.Tool
+	timeout    time.Duration
+}
+
+// request is written to an extension's stdin to run a tool
+type request struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Discover runs each extension with --describe and returns the tools they provide
+func Discover(ctx context.Context, path string, timeout time.Duration) ([]*Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--describe")
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe extension %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var definitions []mcp.Tool
+	if err := json.Unmarshal(out, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid tool definitions from extension %s: %w", path, err)
+	}
+
+	tools := make([]*Tool, 0, len(definitions))
+	for _, definition := range definitions {
+		if definition.Name == "" {
+			return nil, fmt.Errorf("extension %s describes a tool without a name", path)
+		}
+		if definition.InputSchema.Type == "" {
+			definition.InputSchema.Type = "object"
+		}
+		tools = append(tools, &Tool{path: path, definition: definition, timeout: timeout})
+	}
+
+	return tools, nil
+}
+
+// Path returns the extension executable providing the tool
+func (t *Tool) Path() string {
+	return t.path
+}
+
+func (t *Tool) Name() string {
+	return t.definition.Name
+}
+
+func (t *Tool) Description() string {
+	return t.definition.Description
+}
+
+func (t *Tool) Schema() mcp.ToolSchema {
+	return t.definition.InputSchema
+}
+
+// Annotations returns the behavior hints declared by the extension, if any
+func (t *Tool) Annotations() *mcp.ToolAnnotations {
+	return t.definition.Annotations
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	input, err := json.Marshal(request{Tool: t.definition.Name, Arguments: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extension request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{mcp.CreateTextContent(message)},
+			IsError: true,
+		}, nil
+	}
+
+	var result mcp.CallToolResponse
+	if err := json.Unmarshal(stdout.Bytes(), &result); err == nil && len(result.Content) > 0 {
+		return &result, nil
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{mcp.CreateTextContent(strings.TrimSpace(stdout.String()))},
+	}, nil
+}
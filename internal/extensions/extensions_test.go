@@ -0,0 +1,134 @@
+package extensions
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeExtension writes a shell script extension and returns its path
+func writeExtension(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell script extensions need a Unix shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "extension")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("Failed to write extension: %v", err)
+	}
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	path := writeExtension(t, `
+if [ "$1" = "--describe" ]; then
+	echo '[{"name":"whois","description":"Look up a domain","inputSchema":{"properties":{"domain":{"type":"string"}}},"annotations":{"readOnlyHint":true}},{"name":"ping_host"}]'
+fi
+`)
+
+	tools, err := Discover(context.Background(), path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name() != "whois" || tools[1].Name() != "ping_host" {
+		t.Fatalf("Expected the two described tools, got %+v", tools)
+	}
+	if tools[0].Description() != "Look up a domain" || tools[0].Path() != path {
+		t.Errorf("Unexpected tool %+v", tools[0])
+	}
+	if schema := tools[0].Schema(); schema.Type != "object" || schema.Properties["domain"].Type != "string" {
+		t.Errorf("Expected the declared properties in an object schema, got %+v", schema)
+	}
+	if annotations := tools[0].Annotations(); annotations == nil || annotations.ReadOnlyHint == nil || !*annotations.ReadOnlyHint {
+		t.Errorf("Expected the declared annotations, got %+v", annotations)
+	}
+	if tools[1].Annotations() != nil || tools[1].Schema().Type != "object" {
+		t.Errorf("Expected a tool without a schema to take no arguments, got %+v", tools[1])
+	}
+}
+
+func TestDiscoverFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"non-zero exit", "echo 'unknown flag' >&2\nexit 2\n", "unknown flag"},
+		{"invalid JSON", "echo 'whois: look up a domain'\n", "invalid tool definitions"},
+		{"unnamed tool", `echo '[{"description":"nameless"}]'` + "\n", "without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeExtension(t, tt.script)
+			if _, err := Discover(context.Background(), path, 5*time.Second); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// describedTool discovers the single tool of an extension answering runs
+// with script
+func describedTool(t *testing.T, script string) *Tool {
+	t.Helper()
+	path := writeExtension(t, `
+if [ "$1" = "--describe" ]; then
+	echo '[{"name":"whois"}]'
+	exit 0
+fi
+`+script)
+
+	tools, err := Discover(context.Background(), path, 5*time.Second)
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("Discover failed: %+v, %v", tools, err)
+	}
+	return tools[0]
+}
+
+func TestExecuteMCPResult(t *testing.T) {
+	// The request arrives on stdin
+	tool := describedTool(t, `read request
+case "$request" in
+*'"tool":"whois"'*'"domain":"hackthebox.com"'*)
+	echo '{"content":[{"type":"text","text":"registered"}],"isError":false}' ;;
+*)
+	echo "unexpected request $request" >&2
+	exit 1 ;;
+esac
+`)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"domain": "hackthebox.com"})
+	if err != nil || result.IsError || len(result.Content) != 1 || result.Content[0].Text != "registered" {
+		t.Errorf("Expected the extension's MCP result, got %+v, %v", result, err)
+	}
+}
+
+func TestExecutePlainText(t *testing.T) {
+	tool := describedTool(t, "echo 'hackthebox.com is registered'\n")
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || result.IsError || len(result.Content) != 1 || result.Content[0].Text != "hackthebox.com is registered" {
+		t.Errorf("Expected the output as text, got %+v, %v", result, err)
+	}
+}
+
+func TestExecuteNonZeroExit(t *testing.T) {
+	tool := describedTool(t, "echo 'partial output'\necho 'whois: connection refused' >&2\nexit 1\n")
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || !result.IsError || len(result.Content) != 1 || result.Content[0].Text != "whois: connection refused" {
+		t.Errorf("Expected stderr as a tool error, got %+v, %v", result, err)
+	}
+
+	// Without stderr the exit status is reported
+	tool = describedTool(t, "exit 3\n")
+	result, err = tool.Execute(context.Background(), map[string]interface{}{})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "exit status 3") {
+		t.Errorf("Expected the exit status as a tool error, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package fanout runs independent HTB API fetches concurrently with bounded
+// parallelism, so tools aggregating several endpoints wait for the slowest
+// one rather than the sum of all of them.
+package fanout
+
+import (
+	"context"
+	"sync"
+)
+
+// Fetch loads one value
+type Fetch func(ctx context.Context) (interface{}, error)
+
+// Results holds the value or error of each fetch by name
+type Results struct {
+	Values map[string]interface{}
+	Errors map[string]error
+}
+
+// Run runs fetches with at most limit in flight (all at once if limit is not
+// positive) and returns once every fetch has finished. A failing fetch does
+// not stop the others; fetches not yet started when ctx is done fail with
+// its error.
+func Run(ctx context.Context, limit int, fetches map[string]Fetch) Results {
+	if limit <= 0 || limit > len(fetches) {
+		limit = len(fetches)
+	}
+
+	results := Results{
+		Values: make(map[string]interface{}),
+		Errors: make(map[string]error),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+
+	for name, fetch := range fetches {
+		wg.Add(1)
+		go func(name string, fetch Fetch) {
+			defer wg.Done()
+
+			var value interface{}
+			var err error
+			select {
+			case slots <- struct{}{}:
+				value, err = fetch(ctx)
+				<-slots
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				results.Errors[name] = err
+			} else {
+				results.Values[name] = value
+			}
+		}(name, fetch)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,132 @@
+// Package logging sends server log output to the MCP client as
+// notifications/message, filtered by the level the client selected with
+// logging/setLevel, in addition to the process log.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/internal/redact"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Notifier delivers notifications to the connected client
+type Notifier interface {
+	Notify(method string, params interface{}) error
+}
+
+// Logger writes to the process log and forwards messages at or above its
+// level to the client. A nil Logger only writes to the process log.
+type Logger struct {
+	redactor *redact.Redactor
+
+	mu       sync.RWMutex
+	notifier Notifier
+	level    string
+}
+
+// New creates a logger sending messages at level or above, redacting them
+// with redactor (which may be nil)
+func New(level string, redactor *redact.Redactor) *Logger {
+	return &Logger{level: level, redactor: redactor}
+}
+
+// ParseLevel converts a LOG_LEVEL setting such as INFO or WARN to an MCP log
+// level, reporting whether it is known
+func ParseLevel(s string) (string, bool) {
+	level := strings.ToLower(strings.TrimSpace(s))
+	if level == "warn" {
+		level = mcp.LogLevelWarning
+	}
+	return level, mcp.LogSeverity(level) >= 0
+}
+
+// SetNotifier sets where client notifications are sent; without one,
+// messages only go to the process log
+func (l *Logger) SetNotifier(notifier Notifier) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.notifier = notifier
+}
+
+// SetLevel sets the minimum level of messages sent to the client
+func (l *Logger) SetLevel(level string) error {
+	if mcp.LogSeverity(level) < 0 {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+	return nil
+}
+
+// Level returns the minimum level of messages sent to the client
+func (l *Logger) Level() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// Notify sends data to the client if level is at or above the selected
+// level, without writing to the process log
+func (l *Logger) Notify(level, logger string, data interface{}) {
+	if l == nil {
+		return
+	}
+
+	l.mu.RLock()
+	notifier, min := l.notifier, l.level
+	l.mu.RUnlock()
+	if notifier == nil || mcp.LogSeverity(level) < mcp.LogSeverity(min) {
+		return
+	}
+
+	if l.redactor != nil {
+		if encoded, err := json.Marshal(data); err == nil {
+			if redacted := l.redactor.String(string(encoded)); json.Valid([]byte(redacted)) {
+				data = json.RawMessage(redacted)
+			}
+		}
+	}
+
+	params := mcp.LoggingMessageNotification{
+		Level:  level,
+		Logger: logger,
+		Data:   data,
+	}
+	if err := notifier.Notify(mcp.MethodNotificationMessage, params); err != nil {
+		log.Printf("Failed to send notification: %v", err)
+	}
+}
+
+// Printf writes a message to the process log and sends it to the client
+func (l *Logger) Printf(level, logger, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	l.Notify(level, logger, msg)
+}
+
+// Debugf logs a debug message
+func (l *Logger) Debugf(logger, format string, args ...interface{}) {
+	l.Printf(mcp.LogLevelDebug, logger, format, args...)
+}
+
+// Infof logs an informational message
+func (l *Logger) Infof(logger, format string, args ...interface{}) {
+	l.Printf(mcp.LogLevelInfo, logger, format, args...)
+}
+
+// Warnf logs a warning
+func (l *Logger) Warnf(logger, format string, args ...interface{}) {
+	l.Printf(mcp.LogLevelWarning, logger, format, args...)
+}
+
+// Errorf logs an error
+func (l *Logger) Errorf(logger, format string, args ...interface{}) {
+	l.Printf(mcp.LogLevelError, logger, format, args...)
+}
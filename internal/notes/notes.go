@@ -0,0 +1,185 @@
+package notes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry kinds recorded in the notes store
+const (
+	KindNote   = "note"
+	KindSpawn  = "spawn"
+	KindFlag   = "flag"
+	KindExtend = "extend"
+	KindStop   = "stop"
+)
+
+// Entry represents a single note or session event for a machine
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	MachineID   int       `json:"machine_id"`
+	MachineName string    `json:"machine_name,omitempty"`
+	Text        string    `json:"text"`
+}
+
+// Store keeps notes and session events accumulated while the server runs.
+// A session's store is layered on the store of work not tied to a session,
+// such as background polling, whose entries it also reads.
+type Store struct {
+	parent *Store
+
+	mu       sync.RWMutex
+	entries  []Entry
+	released bool
+}
+
+// NewStore creates an empty notes store
+func NewStore() *Store {
+	return &Store{}
+}
+
+// NewSessionStore creates an empty store for one session's entries on top of
+// parent, which may be nil. Entries also returns parent's entries, but the
+// session's entries never reach parent, so other sessions can't read them.
+func NewSessionStore(parent *Store) *Store {
+	return &Store{parent: parent}
+}
+
+// Add records a new entry, stamping it with the current time if unset.
+// Entries added after Release are dropped.
+func (s *Store) Add(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.released {
+		s.entries = append(s.entries, entry)
+	}
+}
+
+// Entries returns a copy of all recorded entries, including the parent
+// store's, in time order
+func (s *Store) Entries() []Entry {
+	s.mu.RLock()
+	entries := make([]Entry, len(s.entries))
+	copy(entries, s.entries)
+	s.mu.RUnlock()
+
+	if s.parent == nil {
+		return entries
+	}
+	entries = append(s.parent.Entries(), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// Release discards the store's entries when its session ends, along with
+// any added later, e.g. by a scheduled spawn the session set up
+func (s *Store) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	s.released = true
+}
+
+// ByMachine returns all entries grouped by machine ID
+func (s *Store) ByMachine() map[int][]Entry {
+	grouped := make(map[int][]Entry)
+	for _, entry := range s.Entries() {
+		grouped[entry.MachineID] = append(grouped[entry.MachineID], entry)
+	}
+	return grouped
+}
+
+// Document is the Markdown rendering of one machine's entries
+type Document struct {
+	MachineID   int
+	MachineName string
+	Markdown    string
+}
+
+// Documents renders each machine's entries as Markdown with YAML
+// frontmatter, in machine ID order
+func (s *Store) Documents() []Document {
+	grouped := s.ByMachine()
+	ids := make([]int, 0, len(grouped))
+	for id := range grouped {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	docs := make([]Document, 0, len(ids))
+	for _, id := range ids {
+		entries := grouped[id]
+		docs = append(docs, Document{
+			MachineID:   id,
+			MachineName: machineName(entries),
+			Markdown:    renderMarkdown(id, entries),
+		})
+	}
+	return docs
+}
+
+// machineName returns the most recently recorded name for a machine
+func machineName(entries []Entry) string {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].MachineName != "" {
+			return entries[i].MachineName
+		}
+	}
+	return ""
+}
+
+// renderMarkdown renders a machine's entries as Markdown with YAML frontmatter
+func renderMarkdown(id int, entries []Entry) string {
+	name := machineName(entries)
+	title := name
+	if title == "" {
+		title = fmt.Sprintf("Machine %d", id)
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "machine_id: %d\n", id)
+	if name != "" {
+		fmt.Fprintf(&b, "machine: %q\n", name)
+	}
+	fmt.Fprintf(&b, "created: %s\n", entries[0].Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated: %s\n", entries[len(entries)-1].Time.Format(time.RFC3339))
+	b.WriteString("tags: [htb, machine]\n")
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n", title)
+
+	var notes, events []Entry
+	for _, entry := range entries {
+		if entry.Kind == KindNote {
+			notes = append(notes, entry)
+		} else {
+			events = append(events, entry)
+		}
+	}
+
+	if len(events) > 0 {
+		b.WriteString("\n## Timeline\n\n")
+		for _, entry := range events {
+			fmt.Fprintf(&b, "- %s **%s** %s\n", entry.Time.Format(time.RFC3339), entry.Kind, entry.Text)
+		}
+	}
+
+	if len(notes) > 0 {
+		b.WriteString("\n## Notes\n")
+		for _, entry := range notes {
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", entry.Time.Format(time.RFC3339), entry.Text)
+		}
+	}
+
+	return b.String()
+}
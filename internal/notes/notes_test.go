@@ -0,0 +1,39 @@
+package notes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionStoreLayersOnParent(t *testing.T) {
+	start := time.Now()
+	shared := NewStore()
+	first := NewSessionStore(shared)
+	second := NewSessionStore(shared)
+
+	first.Add(Entry{Time: start, Kind: KindSpawn, MachineID: 1, Text: "Machine started"})
+	shared.Add(Entry{Time: start.Add(time.Minute), Kind: KindExtend, MachineID: 1, Text: "Machine automatically extended"})
+	second.Add(Entry{Time: start.Add(2 * time.Minute), Kind: KindNote, MachineID: 2, Text: "other session"})
+
+	// Sessions read background entries in time order, but not each other's
+	entries := first.Entries()
+	if len(entries) != 2 || entries[0].Kind != KindSpawn || entries[1].Kind != KindExtend {
+		t.Fatalf("Expected the session's spawn then the background extension, got %+v", entries)
+	}
+
+	// Ending a session discards its entries, including late ones, rather
+	// than handing them to later sessions
+	first.Add(Entry{Time: start.Add(3 * time.Minute), Kind: KindFlag, MachineID: 1, Text: "Submitted user flag"})
+	first.Release()
+	first.Add(Entry{Time: start.Add(4 * time.Minute), Kind: KindSpawn, MachineID: 1, Text: "Scheduled spawn"})
+	if entries := first.Entries(); len(entries) != 1 || entries[0].Kind != KindExtend {
+		t.Errorf("Expected only background entries once released, got %+v", entries)
+	}
+	if entries := shared.Entries(); len(entries) != 1 {
+		t.Errorf("Expected the shared store to hold only background entries, got %+v", entries)
+	}
+	entries = second.Entries()
+	if len(entries) != 2 || entries[0].Kind != KindExtend || entries[1].Text != "other session" {
+		t.Fatalf("Expected the ended session's entries to stay hidden, got %+v", entries)
+	}
+}
@@ -0,0 +1,81 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ExpiryWarner notifies the client as the active machine approaches expiry
+type ExpiryWarner struct {
+	thresholds []time.Duration
+	notify     MachineNotify
+
+	mu    sync.Mutex
+	fired map[string]bool
+}
+
+// NewExpiryWarner creates a warner that fires once per threshold per expiry
+func NewExpiryWarner(thresholds []time.Duration, notify MachineNotify) *ExpiryWarner {
+	sorted := make([]time.Duration, len(thresholds))
+	copy(sorted, thresholds)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &ExpiryWarner{
+		thresholds: sorted,
+		notify:     notify,
+		fired:      make(map[string]bool),
+	}
+}
+
+// Handle is a poller Handler that emits warnings at the configured thresholds
+func (w *ExpiryWarner) Handle(ctx context.Context, machine *htb.ActiveMachineInfo) {
+	if machine == nil || machine.ExpiresAt == "" {
+		return
+	}
+
+	expires, err := machine.ExpiresTime()
+	if err != nil {
+		log.Printf("Expiry warner: cannot parse expiry for machine %d: %v", machine.ID, err)
+		return
+	}
+
+	remaining := time.Until(expires)
+
+	// Only the tightest crossed threshold is reported, so a late first poll
+	// does not emit a burst of stale warnings
+	for _, threshold := range w.thresholds {
+		if remaining > threshold {
+			continue
+		}
+
+		key := fmt.Sprintf("%d|%s|%s", machine.ID, machine.ExpiresAt, threshold)
+		w.mu.Lock()
+		alreadyFired := w.fired[key]
+		w.fired[key] = true
+		for _, wider := range w.thresholds {
+			if wider > threshold {
+				w.fired[fmt.Sprintf("%d|%s|%s", machine.ID, machine.ExpiresAt, wider)] = true
+			}
+		}
+		w.mu.Unlock()
+
+		if !alreadyFired {
+			w.notify(machine.ID, mcp.LogLevelWarning, "expiry", map[string]interface{}{
+				"event":             "machine_expiring",
+				"machine_id":        machine.ID,
+				"machine_name":      machine.Name,
+				"expires_at":        machine.ExpiresAt,
+				"remaining_minutes": int(remaining.Minutes()),
+				"message":           fmt.Sprintf("Machine %s expires in %s", machine.Name, remaining.Round(time.Minute)),
+			})
+		}
+		return
+	}
+}
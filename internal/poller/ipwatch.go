@@ -0,0 +1,65 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// IPWatcher tracks the active machine's IP address, which can change after a
+// reset or a VPN server switch, so clients stop targeting a stale address
+type IPWatcher struct {
+	notify  MachineNotify
+	changed func()
+
+	mu        sync.Mutex
+	machineID int
+	ip        string
+}
+
+// NewIPWatcher creates a watcher that warns through notify when the active
+// machine's IP changes, and calls changed whenever the active machine or its
+// IP differs from the last poll
+func NewIPWatcher(notify MachineNotify, changed func()) *IPWatcher {
+	return &IPWatcher{notify: notify, changed: changed}
+}
+
+// Handle is a poller Handler comparing the active machine with the last one
+// seen. Machines still spawning have no IP yet and are skipped, so a reset
+// is reported once the machine is reachable again.
+func (w *IPWatcher) Handle(ctx context.Context, machine *htb.ActiveMachineInfo) {
+	if machine != nil && (machine.IsSpawning || machine.IP == "") {
+		return
+	}
+
+	var machineID int
+	var ip string
+	if machine != nil {
+		machineID, ip = machine.ID, machine.IP
+	}
+
+	w.mu.Lock()
+	previousID, previousIP := w.machineID, w.ip
+	w.machineID, w.ip = machineID, ip
+	w.mu.Unlock()
+
+	if machineID == previousID && ip == previousIP {
+		return
+	}
+	w.changed()
+
+	if machine == nil || machineID != previousID {
+		return
+	}
+	w.notify(machine.ID, mcp.LogLevelWarning, "machine", map[string]interface{}{
+		"event":        "machine_ip_changed",
+		"machine_id":   machine.ID,
+		"machine_name": machine.Name,
+		"previous_ip":  previousIP,
+		"ip":           ip,
+		"message":      fmt.Sprintf("Machine %s moved from %s to %s; target the new address", machine.Name, previousIP, ip),
+	})
+}
@@ -0,0 +1,40 @@
+package poller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestIPWatcherHandle(t *testing.T) {
+	var warnings []map[string]interface{}
+	changes := 0
+	w := NewIPWatcher(func(machineID int, level, logger string, data interface{}) {
+		warnings = append(warnings, data.(map[string]interface{}))
+	}, func() {
+		changes++
+	})
+	ctx := context.Background()
+
+	// The first machine seen is a change, but not a move
+	w.Handle(ctx, &htb.ActiveMachineInfo{ID: 42, Name: "Lame", IP: "10.10.10.3"})
+	w.Handle(ctx, &htb.ActiveMachineInfo{ID: 42, Name: "Lame", IP: "10.10.10.3"})
+	if changes != 1 || len(warnings) != 0 {
+		t.Fatalf("Expected one change and no warning, got %d changes and %+v", changes, warnings)
+	}
+
+	// A reset is reported once the machine is reachable again
+	w.Handle(ctx, &htb.ActiveMachineInfo{ID: 42, Name: "Lame", IsSpawning: true})
+	w.Handle(ctx, &htb.ActiveMachineInfo{ID: 42, Name: "Lame", IP: "10.10.10.4"})
+	if changes != 2 || len(warnings) != 1 || warnings[0]["previous_ip"] != "10.10.10.3" || warnings[0]["ip"] != "10.10.10.4" {
+		t.Fatalf("Expected a warning about the move, got %d changes and %+v", changes, warnings)
+	}
+
+	// Switching or stopping machines changes the resource without a warning
+	w.Handle(ctx, &htb.ActiveMachineInfo{ID: 7, Name: "Blue", IP: "10.10.10.40"})
+	w.Handle(ctx, nil)
+	if changes != 4 || len(warnings) != 1 {
+		t.Errorf("Expected two more changes and no more warnings, got %d changes and %+v", changes, warnings)
+	}
+}
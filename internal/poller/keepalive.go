@@ -0,0 +1,78 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// Keepalive extends the active machine when it is about to expire
+type Keepalive struct {
+	client    *htb.Client
+	notes     *notes.Store
+	threshold time.Duration
+
+	mu        sync.Mutex
+	attempted map[int]string
+}
+
+// NewKeepalive creates a keepalive that extends machines within threshold of expiry
+func NewKeepalive(client *htb.Client, store *notes.Store, threshold time.Duration) *Keepalive {
+	return &Keepalive{
+		client:    client,
+		notes:     store,
+		threshold: threshold,
+		attempted: make(map[int]string),
+	}
+}
+
+// Handle is a poller Handler that extends the machine if needed
+func (k *Keepalive) Handle(ctx context.Context, machine *htb.ActiveMachineInfo) {
+	if machine == nil || machine.ExpiresAt == "" {
+		return
+	}
+
+	expires, err := machine.ExpiresTime()
+	if err != nil {
+		log.Printf("Keepalive: cannot parse expiry for machine %d: %v", machine.ID, err)
+		return
+	}
+
+	remaining := time.Until(expires)
+	if remaining > k.threshold {
+		return
+	}
+
+	// Only attempt one extension per expiry value so a refused extension
+	// (e.g. maximum reached) is not retried on every poll
+	k.mu.Lock()
+	if k.attempted[machine.ID] == machine.ExpiresAt {
+		k.mu.Unlock()
+		return
+	}
+	k.attempted[machine.ID] = machine.ExpiresAt
+	k.mu.Unlock()
+
+	payload := htb.MachineActionRequest{MachineID: machine.ID}
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := k.client.PostJSON(ctx, "/vm/extend", payload, &result); err != nil {
+		log.Printf("Keepalive: failed to extend machine %d (%s): %v", machine.ID, machine.Name, err)
+		return
+	}
+
+	log.Printf("Keepalive: extended machine %d (%s), previous expiry %s (%s remaining)",
+		machine.ID, machine.Name, machine.ExpiresAt, remaining.Round(time.Second))
+	k.notes.Add(notes.Entry{
+		Kind:        notes.KindExtend,
+		MachineID:   machine.ID,
+		MachineName: machine.Name,
+		Text:        fmt.Sprintf("Machine automatically extended (previous expiry %s)", machine.ExpiresAt),
+	})
+}
@@ -0,0 +1,117 @@
+package poller
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// Handler is invoked after every poll with the current active machine
+// (nil when no machine is running)
+type Handler func(ctx context.Context, machine *htb.ActiveMachineInfo)
+
+// MachineNotify delivers a notification about the machine with the given ID
+type MachineNotify func(machineID int, level, logger string, data interface{})
+
+// Poller periodically checks the active machine and dispatches to handlers
+type Poller struct {
+	client   *htb.Client
+	interval time.Duration
+
+	mu       sync.RWMutex
+	handlers []Handler
+	active   *htb.ActiveMachineInfo
+	lastPoll time.Time
+	cancel   context.CancelFunc
+}
+
+// New creates a new active machine poller
+func New(client *htb.Client, interval time.Duration) *Poller {
+	return &Poller{
+		client:   client,
+		interval: interval,
+	}
+}
+
+// AddHandler registers a handler to run after every poll
+func (p *Poller) AddHandler(handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// HasHandlers reports whether any handlers are registered
+func (p *Poller) HasHandlers() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.handlers) > 0
+}
+
+// Start begins polling in the background until Stop is called or ctx is done.
+// It does nothing if the poller is already running.
+func (p *Poller) Start(ctx context.Context) {
+	p.mu.Lock()
+	if p.cancel != nil {
+		p.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		p.Poll(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Poll(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops background polling
+func (p *Poller) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+// Poll fetches the active machine once and runs all handlers
+func (p *Poller) Poll(ctx context.Context) {
+	machine, err := p.client.GetActiveMachine(ctx)
+	if err != nil {
+		log.Printf("Poller: failed to get active machine: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	p.active = machine
+	p.lastPoll = time.Now()
+	handlers := make([]Handler, len(p.handlers))
+	copy(handlers, p.handlers)
+	p.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(ctx, machine)
+	}
+}
+
+// Active returns the active machine seen on the last poll and when it was polled
+func (p *Poller) Active() (*htb.ActiveMachineInfo, time.Time) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active, p.lastPoll
+}
@@ -0,0 +1,71 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// spawnWatchTimeout is how long a queued spawn is watched before giving up
+const spawnWatchTimeout = 2 * time.Hour
+
+// SpawnWatcher notifies the client when queued machine spawns become available
+type SpawnWatcher struct {
+	notify MachineNotify
+
+	mu      sync.Mutex
+	pending map[int]time.Time
+}
+
+// NewSpawnWatcher creates a watcher for queued spawns
+func NewSpawnWatcher(notify MachineNotify) *SpawnWatcher {
+	return &SpawnWatcher{
+		notify:  notify,
+		pending: make(map[int]time.Time),
+	}
+}
+
+// Watch starts watching a queued spawn of the given machine
+func (w *SpawnWatcher) Watch(machineID int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[machineID] = time.Now()
+}
+
+// Handle is a poller Handler that notifies once a watched machine has
+// finished spawning and has an IP address
+func (w *SpawnWatcher) Handle(ctx context.Context, machine *htb.ActiveMachineInfo) {
+	w.mu.Lock()
+	for id, since := range w.pending {
+		if time.Since(since) > spawnWatchTimeout {
+			log.Printf("Spawn watcher: machine %d still unavailable after %s, giving up", id, spawnWatchTimeout)
+			delete(w.pending, id)
+		}
+	}
+
+	if machine == nil || machine.IsSpawning || machine.IP == "" {
+		w.mu.Unlock()
+		return
+	}
+	since, watched := w.pending[machine.ID]
+	delete(w.pending, machine.ID)
+	w.mu.Unlock()
+
+	if !watched {
+		return
+	}
+
+	w.notify(machine.ID, mcp.LogLevelInfo, "spawn", map[string]interface{}{
+		"event":        "machine_available",
+		"machine_id":   machine.ID,
+		"machine_name": machine.Name,
+		"ip":           machine.IP,
+		"waited":       time.Since(since).Round(time.Second).String(),
+		"message":      fmt.Sprintf("Machine %s is now available at %s", machine.Name, machine.IP),
+	})
+}
@@ -0,0 +1,107 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ErrNotFound is returned when a prompt name is not registered
+var ErrNotFound = errors.New("prompt not found")
+
+// Prompt interface that all HTB prompts must implement
+type Prompt interface {
+	Name() string
+	Description() string
+	Arguments() []mcp.PromptArgument
+	Get(ctx context.Context, args map[string]string) (*mcp.GetPromptResponse, error)
+}
+
+// Registry manages all available MCP prompts
+type Registry struct {
+	mu        sync.RWMutex
+	prompts   map[string]Prompt
+	htbClient *htb.Client
+	notes     *notes.Store
+}
+
+// NewRegistry creates a new prompt registry
+func NewRegistry(htbClient *htb.Client, store *notes.Store) *Registry {
+	registry := &Registry{
+		prompts:   make(map[string]Prompt),
+		htbClient: htbClient,
+		notes:     store,
+	}
+
+	// Register all available prompts
+	registry.registerPrompts()
+
+	return registry
+}
+
+// registerPrompts registers all built-in HTB prompts
+func (r *Registry) registerPrompts() {
+	r.Register(NewMachineWriteup(r.htbClient, r.notes))
+	r.Register(NewEnumerateMachine(r.htbClient))
+	r.Register(NewPrivescChecklist())
+	r.Register(NewReviewSubmissions(r.notes))
+}
+
+// Register registers a prompt in the registry
+func (r *Registry) Register(prompt Prompt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prompts[prompt.Name()] = prompt
+}
+
+// ListPrompts returns all registered prompts in MCP format
+func (r *Registry) ListPrompts() []mcp.Prompt {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	prompts := make([]mcp.Prompt, 0, len(r.prompts))
+	for _, prompt := range r.prompts {
+		prompts = append(prompts, mcp.Prompt{
+			Name:        prompt.Name(),
+			Description: prompt.Description(),
+			Arguments:   prompt.Arguments(),
+		})
+	}
+
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+
+	return prompts
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (r *Registry) GetPrompt(ctx context.Context, name string, args map[string]string) (*mcp.GetPromptResponse, error) {
+	r.mu.RLock()
+	prompt, exists := r.prompts[name]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	for _, arg := range prompt.Arguments() {
+		if arg.Required && args[arg.Name] == "" {
+			return nil, fmt.Errorf("%s is required", arg.Name)
+		}
+	}
+
+	return prompt.Get(ctx, args)
+}
+
+// userMessage builds a single user-role prompt message
+func userMessage(text string) mcp.PromptMessage {
+	return mcp.PromptMessage{
+		Role:    "user",
+		Content: mcp.CreateTextContent(text),
+	}
+}
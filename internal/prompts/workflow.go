@@ -0,0 +1,234 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// placeholder matches {name} placeholders in prompt templates
+var placeholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// interpolate replaces {name} placeholders in tmpl with vars; placeholders
+// without a value are left as they are
+func interpolate(tmpl string, vars map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		if value, ok := vars[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// enumerationTemplate is the text of the enumerate_machine prompt
+const enumerationTemplate = `Enumerate the HackTheBox machine {name} ({os}, {difficulty}) at {ip} and plan the path to a foothold.
+
+1. Run a full TCP port scan, then a service and version scan of the open ports. Add a top-ports UDP scan if TCP looks thin.
+2. For every service, note the version and search for known vulnerabilities and default credentials.
+3. For web services, fingerprint the stack, brute-force directories and virtual hosts, and read the page source.
+4. Enumerate SMB, LDAP, SNMP, DNS or other protocol-specific services that are exposed.
+5. Record each finding with add_note as you go, then rank the attack vectors by likelihood and propose the first one to try.
+
+Stay within the HackTheBox lab scope: only target {ip}.`
+
+// EnumerateMachine prompt for a structured enumeration plan of a machine
+type EnumerateMachine struct {
+	client *htb.Client
+}
+
+func NewEnumerateMachine(client *htb.Client) *EnumerateMachine {
+	return &EnumerateMachine{client: client}
+}
+
+func (p *EnumerateMachine) Name() string {
+	return "enumerate_machine"
+}
+
+func (p *EnumerateMachine) Description() string {
+	return "Step-by-step enumeration plan for a HackTheBox machine, filled in with its OS, difficulty and IP address"
+}
+
+func (p *EnumerateMachine) Arguments() []mcp.PromptArgument {
+	return []mcp.PromptArgument{
+		{
+			Name:        "machine_id",
+			Description: "The ID or name of the machine",
+			Required:    true,
+		},
+	}
+}
+
+func (p *EnumerateMachine) Get(ctx context.Context, args map[string]string) (*mcp.GetPromptResponse, error) {
+	profile, err := p.client.GetMachineProfile(ctx, args["machine_id"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine profile: %w", err)
+	}
+
+	ip := profile.IP
+	if ip == "" {
+		ip = "its IP address (spawn the machine first, then use get_machine_ip)"
+	}
+
+	text := interpolate(enumerationTemplate, map[string]string{
+		"name":       profile.Name,
+		"os":         profile.OS,
+		"difficulty": string(profile.DifficultyText),
+		"ip":         ip,
+	})
+
+	return &mcp.GetPromptResponse{
+		Description: fmt.Sprintf("Enumeration plan for %s", profile.Name),
+		Messages:    []mcp.PromptMessage{userMessage(text)},
+	}, nil
+}
+
+// privescChecklists holds the privilege escalation checks per operating system
+var privescChecklists = map[string][]string{
+	"linux": {
+		"Check sudo rights with sudo -l, and look up allowed binaries on GTFOBins",
+		"Find SUID/SGID binaries and files with capabilities (getcap -r /)",
+		"Review cron jobs, systemd timers and writable scripts they run",
+		"Look for credentials in config files, history files, environment variables and databases",
+		"Check group memberships (docker, lxd, disk, adm) and writable /etc/passwd or service files",
+		"List listening services bound to localhost that may run as root",
+		"Check the kernel and sudo versions for known local exploits as a last resort",
+	},
+	"windows": {
+		"Check token privileges with whoami /priv (SeImpersonate, SeBackup, SeDebug)",
+		"Review group memberships and local administrators",
+		"Look for unquoted service paths, weak service permissions and modifiable service binaries",
+		"Search for stored credentials: saved creds (cmdkey /list), unattend files, registry autologon, PowerShell history",
+		"Check scheduled tasks and AlwaysInstallElevated",
+		"Enumerate Active Directory paths (Kerberoasting, AS-REP roasting, ACL abuse) on domain-joined hosts",
+		"Check the patch level for known local exploits as a last resort",
+	},
+}
+
+// PrivescChecklist prompt for an OS-specific privilege escalation checklist
+type PrivescChecklist struct{}
+
+func NewPrivescChecklist() *PrivescChecklist {
+	return &PrivescChecklist{}
+}
+
+func (p *PrivescChecklist) Name() string {
+	return "privesc_checklist"
+}
+
+func (p *PrivescChecklist) Description() string {
+	return "Privilege escalation checklist for a Linux or Windows foothold"
+}
+
+func (p *PrivescChecklist) Arguments() []mcp.PromptArgument {
+	return []mcp.PromptArgument{
+		{
+			Name:        "os",
+			Description: "Operating system of the foothold: Linux or Windows",
+			Required:    true,
+		},
+		{
+			Name:        "context",
+			Description: "What you have so far, e.g. the current user and interesting findings",
+		},
+	}
+}
+
+func (p *PrivescChecklist) Get(ctx context.Context, args map[string]string) (*mcp.GetPromptResponse, error) {
+	os := strings.TrimSpace(args["os"])
+	checks, ok := privescChecklists[strings.ToLower(os)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported os %q: expected Linux or Windows", os)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "I have a foothold on a %s HackTheBox machine. Work through this privilege escalation checklist in order, ", os)
+	b.WriteString("give the exact commands for each step, and stop to analyze anything promising before moving on.\n\n")
+	for i, check := range checks {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, check)
+	}
+	if extra := strings.TrimSpace(args["context"]); extra != "" {
+		fmt.Fprintf(&b, "\nWhat I have so far:\n\n%s\n", extra)
+	}
+
+	return &mcp.GetPromptResponse{
+		Description: fmt.Sprintf("%s privilege escalation checklist", os),
+		Messages:    []mcp.PromptMessage{userMessage(b.String())},
+	}, nil
+}
+
+// ReviewSubmissions prompt for reviewing the session's flag submission history
+type ReviewSubmissions struct {
+	notes *notes.Store
+}
+
+func NewReviewSubmissions(store *notes.Store) *ReviewSubmissions {
+	return &ReviewSubmissions{notes: store}
+}
+
+func (p *ReviewSubmissions) Name() string {
+	return "review_flag_submissions"
+}
+
+func (p *ReviewSubmissions) Description() string {
+	return "Review your flag submission history from this session, spotting rejected flags and machines left half-owned"
+}
+
+func (p *ReviewSubmissions) Arguments() []mcp.PromptArgument {
+	return []mcp.PromptArgument{
+		{
+			Name:        "machine_id",
+			Description: "Only review submissions for this machine ID",
+		},
+	}
+}
+
+func (p *ReviewSubmissions) Get(ctx context.Context, args map[string]string) (*mcp.GetPromptResponse, error) {
+	machineID := 0
+	if raw := strings.TrimSpace(args["machine_id"]); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("machine_id must be a numeric machine ID, got %q", raw)
+		}
+		machineID = id
+	}
+
+	var submissions []notes.Entry
+	for _, entry := range session.NotesFrom(ctx, p.notes).Entries() {
+		if entry.Kind == notes.KindFlag && (machineID == 0 || entry.MachineID == machineID) {
+			submissions = append(submissions, entry)
+		}
+	}
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].Time.Before(submissions[j].Time)
+	})
+
+	var b strings.Builder
+	b.WriteString("Review my HackTheBox flag submission history below. Summarize what was accepted and rejected per machine, ")
+	b.WriteString("point out patterns in rejected flags (wrong flag type, whitespace, stale flags after a reset), ")
+	b.WriteString("and list machines where only the user or only the root flag was accepted.\n\n")
+
+	if len(submissions) == 0 {
+		b.WriteString("No flag submissions are recorded for this session.\n")
+	}
+	for _, entry := range submissions {
+		name := entry.MachineName
+		if name == "" {
+			name = fmt.Sprintf("machine %d", entry.MachineID)
+		}
+		fmt.Fprintf(&b, "- %s %s: %s\n", entry.Time.Format(time.RFC3339), name, entry.Text)
+	}
+
+	return &mcp.GetPromptResponse{
+		Description: fmt.Sprintf("Review of %d flag submissions", len(submissions)),
+		Messages:    []mcp.PromptMessage{userMessage(b.String())},
+	}, nil
+}
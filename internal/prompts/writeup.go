@@ -0,0 +1,101 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// MachineWriteup prompt for drafting a structured machine writeup
+type MachineWriteup struct {
+	client *htb.Client
+	notes  *notes.Store
+}
+
+func NewMachineWriteup(client *htb.Client, store *notes.Store) *MachineWriteup {
+	return &MachineWriteup{client: client, notes: store}
+}
+
+func (p *MachineWriteup) Name() string {
+	return "machine_writeup"
+}
+
+func (p *MachineWriteup) Description() string {
+	return "Draft a structured writeup (recon, foothold, privesc, loot) for a HackTheBox machine, pre-filled with live machine metadata and your own session timeline"
+}
+
+func (p *MachineWriteup) Arguments() []mcp.PromptArgument {
+	return []mcp.PromptArgument{
+		{
+			Name:        "machine_id",
+			Description: "The ID or name of the machine",
+			Required:    true,
+		},
+	}
+}
+
+func (p *MachineWriteup) Get(ctx context.Context, args map[string]string) (*mcp.GetPromptResponse, error) {
+	profile, err := p.client.GetMachineProfile(ctx, args["machine_id"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine profile: %w", err)
+	}
+
+	entries := session.NotesFrom(ctx, p.notes).ByMachine()[profile.ID]
+
+	var b strings.Builder
+	b.WriteString("Write a penetration test writeup for the HackTheBox machine below. ")
+	b.WriteString("Complete every section of the template using the session timeline and notes, ")
+	b.WriteString("keep commands in fenced code blocks, and mark anything you cannot infer as TODO.\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", profile.Name)
+	b.WriteString("## Machine Info\n\n")
+	fmt.Fprintf(&b, "- **ID:** %d\n", profile.ID)
+	fmt.Fprintf(&b, "- **OS:** %s\n", profile.OS)
+	fmt.Fprintf(&b, "- **Difficulty:** %s\n", profile.DifficultyText)
+	fmt.Fprintf(&b, "- **Points:** %d\n", profile.Points)
+	if profile.Release != "" {
+		fmt.Fprintf(&b, "- **Released:** %s\n", profile.Release)
+	}
+	if profile.IP != "" {
+		fmt.Fprintf(&b, "- **IP:** %s\n", profile.IP)
+	}
+	fmt.Fprintf(&b, "- **User owned:** %t\n", profile.AuthUserInUserOwns)
+	fmt.Fprintf(&b, "- **Root owned:** %t\n", profile.AuthUserInRootOwns)
+
+	b.WriteString("\n## Timeline\n\n")
+	var findings []notes.Entry
+	for _, entry := range entries {
+		if entry.Kind == notes.KindNote {
+			findings = append(findings, entry)
+			continue
+		}
+		fmt.Fprintf(&b, "- %s **%s** %s\n", entry.Time.Format(time.RFC3339), entry.Kind, entry.Text)
+	}
+	if len(entries) == len(findings) {
+		b.WriteString("- No session events recorded for this machine\n")
+	}
+
+	if len(findings) > 0 {
+		b.WriteString("\n## Session Notes\n")
+		for _, entry := range findings {
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", entry.Time.Format(time.RFC3339), entry.Text)
+		}
+	}
+
+	b.WriteString("\n## Recon\n\nTODO: port scan results, service enumeration, interesting findings\n")
+	b.WriteString("\n## Foothold\n\nTODO: initial access vector and exploitation steps\n")
+	b.WriteString("\n## Privilege Escalation\n\nTODO: path from user to root/SYSTEM\n")
+	b.WriteString("\n## Loot\n\nTODO: flags, credentials, hashes and other artifacts (redact flag values)\n")
+	b.WriteString("\n## Lessons Learned\n\nTODO: key takeaways\n")
+
+	return &mcp.GetPromptResponse{
+		Description: fmt.Sprintf("Writeup template for %s", profile.Name),
+		Messages:    []mcp.PromptMessage{userMessage(b.String())},
+	}, nil
+}
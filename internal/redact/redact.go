@@ -0,0 +1,191 @@
+// Package redact removes sensitive values (flags, tokens, email addresses)
+// from tool output and logs, for users recording or sharing agent sessions.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Categories of sensitive values that can be redacted
+const (
+	CategoryFlags  = "flags"
+	CategoryTokens = "tokens"
+	CategoryEmails = "emails"
+
+	// CategoryAll enables every category
+	CategoryAll = "all"
+)
+
+// rule replaces matches of a pattern
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+var rules = map[string][]rule{
+	CategoryFlags: {
+		{regexp.MustCompile(`HTB\{[^}\s]*\}`), "HTB{[REDACTED]}"},
+		// User and root flags are 32 hex characters
+		{regexp.MustCompile(`\b[0-9a-fA-F]{32}\b`), "[REDACTED FLAG]"},
+	},
+	CategoryTokens: {
+		{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), "[REDACTED TOKEN]"},
+		{regexp.MustCompile(`(?i)("[a-z_]*(?:token|secret|password|api_key)"\s*:\s*")[^"]+(")`), "${1}[REDACTED]${2}"},
+		{regexp.MustCompile(`(?i)(Bearer\s+)[A-Za-z0-9._~+/-]+=*`), "${1}[REDACTED]"},
+	},
+	CategoryEmails: {
+		{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[REDACTED EMAIL]"},
+	},
+}
+
+// Redactor replaces sensitive values in text. A nil Redactor leaves text
+// unchanged.
+type Redactor struct {
+	rules   []rule
+	secrets []string
+}
+
+// New creates a redactor for the given categories. With the tokens category,
+// secrets (e.g. the configured API tokens) are redacted verbatim as well.
+// It returns nil if no known category is enabled.
+func New(categories []string, secrets ...string) *Redactor {
+	enabled := make(map[string]bool)
+	for _, category := range categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category == CategoryAll {
+			for name := range rules {
+				enabled[name] = true
+			}
+			continue
+		}
+		if _, ok := rules[category]; ok {
+			enabled[category] = true
+		}
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+
+	r := &Redactor{}
+	// Apply categories in a fixed order so output is deterministic
+	for _, category := range []string{CategoryTokens, CategoryFlags, CategoryEmails} {
+		if enabled[category] {
+			r.rules = append(r.rules, rules[category]...)
+		}
+	}
+	if enabled[CategoryTokens] {
+		for _, secret := range secrets {
+			if secret != "" {
+				r.secrets = append(r.secrets, secret)
+			}
+		}
+	}
+	return r
+}
+
+// String returns s with sensitive values replaced
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "[REDACTED TOKEN]")
+	}
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// Response redacts the text and structured content of a tool response in
+// place and returns it.
+// Binary content (images, blobs) is passed through unchanged.
+func (r *Redactor) Response(resp *mcp.CallToolResponse) *mcp.CallToolResponse {
+	if r == nil || resp == nil {
+		return resp
+	}
+
+	for i := range resp.Content {
+		content := &resp.Content[i]
+		content.Text = r.String(content.Text)
+		if content.Resource != nil {
+			content.Resource.Text = r.String(content.Resource.Text)
+		}
+	}
+	resp.RewriteStructuredContent(r.String)
+	return resp
+}
+
+// Resource redacts the text contents of a resources/read response in place
+// and returns it. Blob contents are passed through unchanged.
+func (r *Redactor) Resource(resp *mcp.ReadResourceResponse) *mcp.ReadResourceResponse {
+	if r == nil || resp == nil {
+		return resp
+	}
+
+	for i := range resp.Contents {
+		resp.Contents[i].Text = r.String(resp.Contents[i].Text)
+	}
+	return resp
+}
+
+// Prompt redacts the description and message text of a prompts/get response
+// in place and returns it.
+func (r *Redactor) Prompt(resp *mcp.GetPromptResponse) *mcp.GetPromptResponse {
+	if r == nil || resp == nil {
+		return resp
+	}
+
+	resp.Description = r.String(resp.Description)
+	for i := range resp.Messages {
+		content := &resp.Messages[i].Content
+		content.Text = r.String(content.Text)
+		if content.Resource != nil {
+			content.Resource.Text = r.String(content.Resource.Text)
+		}
+	}
+	return resp
+}
+
+// Error returns err with its message redacted, still unwrapping to err
+func (r *Redactor) Error(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	return &redactedError{msg: r.String(err.Error()), err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Writer returns a writer that redacts each write before passing it to w,
+// e.g. for log output. Log writes are whole lines, so values are not split
+// across writes.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,38 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+func TestResourceAndPrompt(t *testing.T) {
+	r := New([]string{CategoryFlags})
+
+	resource := r.Resource(&mcp.ReadResourceResponse{Contents: []mcp.ResourceContent{
+		{URI: "htb://user/profile", Text: `{"flag":"HTB{s3cr3t}"}`},
+		{URI: "htb://challenge/9/files", Blob: "SFRCe3MzY3IzdH0="},
+	}})
+	if got := resource.Contents[0].Text; got != `{"flag":"HTB{[REDACTED]}"}` {
+		t.Errorf("Expected the resource text to be redacted, got %s", got)
+	}
+	if got := resource.Contents[1].Blob; got != "SFRCe3MzY3IzdH0=" {
+		t.Errorf("Expected blobs to pass through, got %s", got)
+	}
+
+	prompt := r.Prompt(&mcp.GetPromptResponse{
+		Description: "Writeup for HTB{s3cr3t}",
+		Messages: []mcp.PromptMessage{
+			{Role: "user", Content: mcp.CreateTextContent("The flag was HTB{s3cr3t}")},
+		},
+	})
+	if prompt.Description != "Writeup for HTB{[REDACTED]}" || prompt.Messages[0].Content.Text != "The flag was HTB{[REDACTED]}" {
+		t.Errorf("Expected the prompt to be redacted, got %+v", prompt)
+	}
+
+	// A nil redactor leaves output unchanged
+	var disabled *Redactor
+	if got := disabled.Resource(resource); got != resource {
+		t.Errorf("Expected a nil redactor to return the response, got %+v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// readMachine reads htb://machine/{id}
+func (r *Registry) readMachine(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	profile, err := r.htbClient.GetMachineProfile(ctx, params["id"])
+	if err != nil {
+		return nil, notFoundOr(err, uri)
+	}
+
+	return jsonResource(uri, profile)
+}
+
+// readActiveMachine reads htb://machine/active
+func (r *Registry) readActiveMachine(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	machine, err := r.htbClient.GetActiveMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active machine: %w", err)
+	}
+
+	if machine == nil {
+		return jsonResource(uri, map[string]interface{}{
+			"active":  false,
+			"message": "No machine is currently running",
+		})
+	}
+	return jsonResource(uri, machine)
+}
+
+// listingMaxPages bounds how many pages of a machine listing are read
+const listingMaxPages = 20
+
+// readMachineListing returns a handler reading every page of a paginated
+// machine listing, cached like other aggregated resources
+func (r *Registry) readMachineListing(endpoint string) Handler {
+	return func(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+		machines, err := cache.GetOrLoad(r.cache, uri, func() (interface{}, error) {
+			return r.loadMachineListing(ctx, endpoint)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonResource(uri, machines)
+	}
+}
+
+// loadMachineListing fetches every page of a paginated machine listing
+func (r *Registry) loadMachineListing(ctx context.Context, endpoint string) ([]interface{}, error) {
+	machines := []interface{}{}
+	for page := 1; page <= listingMaxPages; page++ {
+		var result struct {
+			Data []interface{} `json:"data"`
+			Meta struct {
+				LastPage int `json:"last_page"`
+			} `json:"meta"`
+		}
+		if err := r.htbClient.GetJSON(ctx, fmt.Sprintf("%s&page=%d", endpoint, page), &result); err != nil {
+			return nil, fmt.Errorf("failed to list machines: %w", err)
+		}
+
+		machines = append(machines, result.Data...)
+		if len(result.Data) == 0 || page >= result.Meta.LastPage {
+			break
+		}
+	}
+	htb.AddPerceivedDifficulty(machines)
+	return machines, nil
+}
+
+// readUserProfile reads htb://user/profile
+func (r *Registry) readUserProfile(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	user, err := r.htbClient.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	profile, err := r.htbClient.GetUserProfile(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	return jsonResource(uri, profile)
+}
+
+// readChallenge reads htb://challenge/{id}
+func (r *Registry) readChallenge(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	data, err := r.htbClient.GetWithParsing(ctx, "/challenge/info/"+url.PathEscape(params["id"]), "challenge")
+	if err != nil {
+		return nil, notFoundOr(err, uri)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+
+	return jsonResource(uri, data)
+}
+
+// notFoundOr maps HTB 404 responses to ErrNotFound
+func notFoundOr(err error, uri string) error {
+	var apiErr *htb.HTBAPIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+	return err
+}
+
+// readChallengeFiles reads htb://challenge/{id}/files
+func (r *Registry) readChallengeFiles(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	data, contentType, err := r.htbClient.GetRaw(ctx, "/challenge/download/"+url.PathEscape(params["id"]))
+	if err != nil {
+		return nil, notFoundOr(err, uri)
+	}
+
+	return blobResource(uri, detectMimeType(contentType, data, "application/zip"), data), nil
+}
+
+// readSherlockEvidence reads htb://sherlock/{id}/evidence
+func (r *Registry) readSherlockEvidence(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	link, err := r.htbClient.GetSherlockDownloadLink(ctx, params["id"])
+	if err != nil {
+		return nil, notFoundOr(err, uri)
+	}
+	if link.URL == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+
+	data, contentType, err := r.htbClient.Download(ctx, link.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download sherlock evidence: %w", err)
+	}
+
+	return blobResource(uri, detectMimeType(contentType, data, "application/zip"), data), nil
+}
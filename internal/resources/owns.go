@@ -0,0 +1,129 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ownedMachine summarizes the owns of a single machine
+type ownedMachine struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	UserOwnedAt string `json:"user_owned_at,omitempty"`
+	RootOwnedAt string `json:"root_owned_at,omitempty"`
+	FirstBlood  bool   `json:"first_blood,omitempty"`
+	Points      int    `json:"points"`
+}
+
+// solvedChallenge summarizes a solved challenge
+type solvedChallenge struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Category   string `json:"category,omitempty"`
+	SolvedAt   string `json:"solved_at"`
+	FirstBlood bool   `json:"first_blood,omitempty"`
+	Points     int    `json:"points"`
+}
+
+// userOwns is the content of htb://user/owns
+type userOwns struct {
+	UserID     int                `json:"user_id"`
+	Username   string             `json:"username"`
+	Machines   []ownedMachine     `json:"machines"`
+	Challenges []solvedChallenge  `json:"challenges"`
+	Other      []htb.ActivityItem `json:"other,omitempty"`
+}
+
+// readUserOwns reads htb://user/owns
+func (r *Registry) readUserOwns(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	owns, err := cache.GetOrLoad(r.cache, uri, func() (interface{}, error) {
+		return r.loadUserOwns(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResource(uri, owns)
+}
+
+// loadUserOwns aggregates the authenticated user's activity into owned
+// machines and solved challenges
+func (r *Registry) loadUserOwns(ctx context.Context) (*userOwns, error) {
+	user, err := r.htbClient.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	activity, err := r.htbClient.GetUserActivity(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	return aggregateOwns(user, activity), nil
+}
+
+// aggregateOwns groups activity items by owned content, oldest first
+func aggregateOwns(user *htb.User, activity []htb.ActivityItem) *userOwns {
+	owns := &userOwns{
+		UserID:     user.ID,
+		Username:   user.Username,
+		Machines:   []ownedMachine{},
+		Challenges: []solvedChallenge{},
+	}
+
+	machines := make(map[int]*ownedMachine)
+	for _, item := range activity {
+		switch item.ObjectType {
+		case "machine":
+			machine, ok := machines[item.ID]
+			if !ok {
+				machine = &ownedMachine{ID: item.ID, Name: item.Name}
+				machines[item.ID] = machine
+			}
+			if item.Type == "root" {
+				machine.RootOwnedAt = item.Date
+			} else {
+				machine.UserOwnedAt = item.Date
+			}
+			machine.FirstBlood = machine.FirstBlood || item.FirstBlood
+			machine.Points += item.Points
+		case "challenge":
+			owns.Challenges = append(owns.Challenges, solvedChallenge{
+				ID:         item.ID,
+				Name:       item.Name,
+				Category:   item.ChallengeCat,
+				SolvedAt:   item.Date,
+				FirstBlood: item.FirstBlood,
+				Points:     item.Points,
+			})
+		default:
+			owns.Other = append(owns.Other, item)
+		}
+	}
+
+	for _, machine := range machines {
+		owns.Machines = append(owns.Machines, *machine)
+	}
+
+	sort.Slice(owns.Machines, func(i, j int) bool {
+		return firstOwn(owns.Machines[i]) < firstOwn(owns.Machines[j])
+	})
+	sort.Slice(owns.Challenges, func(i, j int) bool {
+		return owns.Challenges[i].SolvedAt < owns.Challenges[j].SolvedAt
+	})
+
+	return owns
+}
+
+// firstOwn returns the date of the earliest own of a machine
+func firstOwn(m ownedMachine) string {
+	if m.UserOwnedAt == "" || (m.RootOwnedAt != "" && m.RootOwnedAt < m.UserOwnedAt) {
+		return m.RootOwnedAt
+	}
+	return m.UserOwnedAt
+}
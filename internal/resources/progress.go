@@ -0,0 +1,112 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ChallengeProgressURI is the resource summarizing the user's challenge
+// completion per category
+const ChallengeProgressURI = "htb://user/challenge-progress"
+
+// categoryProgress is the completion of one challenge category
+type categoryProgress struct {
+	Category   string  `json:"category"`
+	Solved     int     `json:"solved"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+// challengeProgress is the content of htb://user/challenge-progress
+type challengeProgress struct {
+	UserID     int                `json:"user_id"`
+	Solved     int                `json:"solved"`
+	Total      int                `json:"total"`
+	Percentage float64            `json:"percentage"`
+	Categories []categoryProgress `json:"categories"`
+
+	// Weakest is the category with the lowest completion, ties broken by
+	// the most unsolved challenges
+	Weakest string `json:"weakest,omitempty"`
+}
+
+// readChallengeProgress reads htb://user/challenge-progress
+func (r *Registry) readChallengeProgress(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error) {
+	progress, err := cache.GetOrLoad(r.cache, uri, func() (interface{}, error) {
+		return r.loadChallengeProgress(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonResource(uri, progress)
+}
+
+// loadChallengeProgress fetches the authenticated user's challenge progress
+func (r *Registry) loadChallengeProgress(ctx context.Context) (*challengeProgress, error) {
+	user, err := r.htbClient.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	progress, err := r.htbClient.GetChallengeProgress(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge progress: %w", err)
+	}
+
+	return summarizeChallengeProgress(user.ID, progress), nil
+}
+
+// summarizeChallengeProgress computes per-category percentages from owned
+// and total flags, one per challenge, sorted by category name
+func summarizeChallengeProgress(userID int, progress *htb.ChallengeProgress) *challengeProgress {
+	summary := &challengeProgress{
+		UserID:     userID,
+		Solved:     progress.ChallengeOwns.Solved,
+		Total:      progress.ChallengeOwns.Total,
+		Percentage: percentage(progress.ChallengeOwns.Solved, progress.ChallengeOwns.Total),
+		Categories: []categoryProgress{},
+	}
+
+	for _, category := range progress.ChallengeCategories {
+		summary.Categories = append(summary.Categories, categoryProgress{
+			Category:   category.Name,
+			Solved:     category.OwnedFlags,
+			Total:      category.TotalFlags,
+			Percentage: percentage(category.OwnedFlags, category.TotalFlags),
+		})
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		return summary.Categories[i].Category < summary.Categories[j].Category
+	})
+
+	var weakest *categoryProgress
+	for i, category := range summary.Categories {
+		if category.Total == 0 {
+			continue
+		}
+		if weakest == nil || category.Percentage < weakest.Percentage ||
+			(category.Percentage == weakest.Percentage && category.Total-category.Solved > weakest.Total-weakest.Solved) {
+			weakest = &summary.Categories[i]
+		}
+	}
+	if weakest != nil {
+		summary.Weakest = weakest.Category
+	}
+
+	return summary
+}
+
+// percentage returns solved as a percentage of total, rounded to one decimal
+func percentage(solved, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(solved)/float64(total)*1000) / 10
+}
@@ -0,0 +1,49 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestSummarizeChallengeProgress(t *testing.T) {
+	progress := &htb.ChallengeProgress{
+		ChallengeOwns: htb.ProgressCount{Solved: 6, Total: 30},
+		ChallengeCategories: []htb.CategoryProgress{
+			{Name: "Web", OwnedFlags: 4, TotalFlags: 10},
+			{Name: "Crypto", OwnedFlags: 0, TotalFlags: 8},
+			{Name: "Pwn", OwnedFlags: 2, TotalFlags: 12},
+			{Name: "Hardware", OwnedFlags: 0, TotalFlags: 0},
+		},
+	}
+
+	summary := summarizeChallengeProgress(42, progress)
+	if summary.UserID != 42 || summary.Percentage != 20 {
+		t.Errorf("Expected 20%% overall for user 42, got %+v", summary)
+	}
+	if len(summary.Categories) != 4 || summary.Categories[0].Category != "Crypto" || summary.Categories[3].Category != "Web" {
+		t.Fatalf("Expected categories sorted by name, got %+v", summary.Categories)
+	}
+	if pwn := summary.Categories[2]; pwn.Category != "Pwn" || pwn.Percentage != 16.7 {
+		t.Errorf("Expected Pwn at 16.7%%, got %+v", pwn)
+	}
+
+	// Empty categories can't be the weakest
+	if summary.Weakest != "Crypto" {
+		t.Errorf("Expected Crypto as the weakest category, got %q", summary.Weakest)
+	}
+}
+
+func TestSummarizeChallengeProgressTies(t *testing.T) {
+	progress := &htb.ChallengeProgress{
+		ChallengeCategories: []htb.CategoryProgress{
+			{Name: "Forensics", OwnedFlags: 1, TotalFlags: 2},
+			{Name: "Reversing", OwnedFlags: 5, TotalFlags: 10},
+		},
+	}
+
+	// Equal completion is broken by the most unsolved challenges
+	if summary := summarizeChallengeProgress(1, progress); summary.Weakest != "Reversing" {
+		t.Errorf("Expected Reversing as the weakest category, got %q", summary.Weakest)
+	}
+}
@@ -0,0 +1,289 @@
+package resources
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ErrNotFound is returned when no resource matches a URI
+var ErrNotFound = errors.New("resource not found")
+
+// ActiveMachineURI is the resource holding the machine currently running for
+// the user; clients subscribed to it are notified when its IP changes
+const ActiveMachineURI = "htb://machine/active"
+
+// Handler reads a resource; params holds the variables matched from a URI template
+type Handler func(ctx context.Context, uri string, params map[string]string) (*mcp.ReadResourceResponse, error)
+
+// staticResource is a resource with a fixed URI
+type staticResource struct {
+	resource mcp.Resource
+	handler  Handler
+}
+
+// template is a resource family addressed by a URI template such as htb://machine/{id}
+type template struct {
+	template mcp.ResourceTemplate
+	segments []string
+	handler  Handler
+}
+
+// Registry manages all available MCP resources
+type Registry struct {
+	mu        sync.RWMutex
+	static    map[string]staticResource
+	templates []template
+	htbClient *htb.Client
+	cache     cache.Cache
+}
+
+// NewRegistry creates a new resource registry; aggregated resources are
+// cached in c
+func NewRegistry(htbClient *htb.Client, c cache.Cache) *Registry {
+	registry := &Registry{
+		static:    make(map[string]staticResource),
+		htbClient: htbClient,
+		cache:     c,
+	}
+
+	// Register all available resources
+	registry.registerResources()
+
+	return registry
+}
+
+// registerResources registers all built-in HTB resources
+func (r *Registry) registerResources() {
+	// Account-wide resources
+	r.Register(mcp.Resource{
+		URI:         "htb://user/owns",
+		Name:        "Owned content",
+		Description: "All machines owned and challenges solved by the authenticated user, with dates",
+		MimeType:    "application/json",
+	}, r.readUserOwns)
+
+	r.Register(mcp.Resource{
+		URI:         "htb://user/profile",
+		Name:        "User profile",
+		Description: "Profile of the authenticated user: rank, points, owns and team",
+		MimeType:    "application/json",
+	}, r.readUserProfile)
+
+	r.Register(mcp.Resource{
+		URI:         ChallengeProgressURI,
+		Name:        "Challenge progress",
+		Description: "Solved/total challenges and completion percentage per category for the authenticated user, with the weakest category, cached for CACHE_TTL_SECONDS",
+		MimeType:    "application/json",
+	}, r.readChallengeProgress)
+
+	r.Register(mcp.Resource{
+		URI:         ActiveMachineURI,
+		Name:        "Active machine",
+		Description: "The machine currently running for the authenticated user, with its current IP. Subscribe to be notified when it changes, e.g. after a reset",
+		MimeType:    "application/json",
+	}, r.readActiveMachine)
+
+	// Content listings
+	r.Register(mcp.Resource{
+		URI:         "htb://machines/active",
+		Name:        "Active machines",
+		Description: "All active HackTheBox machines, cached for CACHE_TTL_SECONDS",
+		MimeType:    "application/json",
+	}, r.readMachineListing("/machine/paginated/?per_page=100"))
+
+	r.Register(mcp.Resource{
+		URI:         "htb://machines/retired",
+		Name:        "Retired machines",
+		Description: "All retired HackTheBox machines, cached for CACHE_TTL_SECONDS",
+		MimeType:    "application/json",
+	}, r.readMachineListing("/machine/list/retired/paginated/?per_page=100&sort_by=release-date"))
+
+	// Machine and challenge resources by ID
+	r.RegisterTemplate(mcp.ResourceTemplate{
+		URITemplate: "htb://machine/{id}",
+		Name:        "HTB machine",
+		Description: "Profile and metadata of a HackTheBox machine by ID or name",
+		MimeType:    "application/json",
+	}, r.readMachine)
+
+	r.RegisterTemplate(mcp.ResourceTemplate{
+		URITemplate: "htb://machines/{id}",
+		Name:        "HTB machine",
+		Description: "Alias of htb://machine/{id} alongside the machine listings",
+		MimeType:    "application/json",
+	}, r.readMachine)
+
+	r.RegisterTemplate(mcp.ResourceTemplate{
+		URITemplate: "htb://challenge/{id}",
+		Name:        "HTB challenge",
+		Description: "Details and metadata of a HackTheBox challenge by ID",
+		MimeType:    "application/json",
+	}, r.readChallenge)
+
+	// Downloadable artifacts
+	r.RegisterTemplate(mcp.ResourceTemplate{
+		URITemplate: "htb://challenge/{id}/files",
+		Name:        "HTB challenge files",
+		Description: "Downloadable files of a HackTheBox challenge (zip archives use the password \"hackthebox\")",
+		MimeType:    "application/zip",
+	}, r.readChallengeFiles)
+
+	r.RegisterTemplate(mcp.ResourceTemplate{
+		URITemplate: "htb://sherlock/{id}/evidence",
+		Name:        "HTB Sherlock evidence",
+		Description: "Evidence archive of a HackTheBox Sherlock investigation",
+		MimeType:    "application/zip",
+	}, r.readSherlockEvidence)
+}
+
+// Register registers a resource with a fixed URI
+func (r *Registry) Register(resource mcp.Resource, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.static[resource.URI] = staticResource{resource: resource, handler: handler}
+}
+
+// RegisterTemplate registers a family of resources addressed by a URI template
+func (r *Registry) RegisterTemplate(tmpl mcp.ResourceTemplate, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates = append(r.templates, template{
+		template: tmpl,
+		segments: strings.Split(tmpl.URITemplate, "/"),
+		handler:  handler,
+	})
+}
+
+// ListResources returns all fixed-URI resources in MCP format
+func (r *Registry) ListResources() []mcp.Resource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	resources := make([]mcp.Resource, 0, len(r.static))
+	for _, res := range r.static {
+		resources = append(resources, res.resource)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+
+	return resources
+}
+
+// ListTemplates returns all resource templates in MCP format
+func (r *Registry) ListTemplates() []mcp.ResourceTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	templates := make([]mcp.ResourceTemplate, 0, len(r.templates))
+	for _, tmpl := range r.templates {
+		templates = append(templates, tmpl.template)
+	}
+	return templates
+}
+
+// Read reads the resource identified by uri
+func (r *Registry) Read(ctx context.Context, uri string) (*mcp.ReadResourceResponse, error) {
+	handler, params, ok := r.lookup(uri)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uri)
+	}
+
+	// Handlers fetch from HTB, so they run without the lock
+	return handler(ctx, uri, params)
+}
+
+// lookup returns the handler of the resource identified by uri and the
+// parameters matched by its template
+func (r *Registry) lookup(uri string) (Handler, map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if res, ok := r.static[uri]; ok {
+		return res.handler, nil, true
+	}
+
+	for _, tmpl := range r.templates {
+		if params, ok := matchTemplate(tmpl.segments, uri); ok {
+			return tmpl.handler, params, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+// matchTemplate matches uri against the "/"-separated segments of a URI
+// template, where a segment of the form {name} matches any non-empty value
+func matchTemplate(segments []string, uri string) (map[string]string, bool) {
+	parts := strings.Split(uri, "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if parts[i] == "" {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = parts[i]
+			continue
+		}
+		if segment != parts[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// jsonResource builds a single-content JSON resource response
+func jsonResource(uri string, data interface{}) (*mcp.ReadResourceResponse, error) {
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.ReadResourceResponse{
+		Contents: []mcp.ResourceContent{{
+			URI:      uri,
+			MimeType: "application/json",
+			Text:     content.Text,
+		}},
+	}, nil
+}
+
+// blobResource builds a single-content binary resource response
+func blobResource(uri, mimeType string, data []byte) *mcp.ReadResourceResponse {
+	return &mcp.ReadResourceResponse{
+		Contents: []mcp.ResourceContent{{
+			URI:      uri,
+			MimeType: mimeType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		}},
+	}
+}
+
+// detectMimeType returns the media type of a downloaded artifact, sniffing the
+// content when the server only reports a generic type
+func detectMimeType(contentType string, data []byte, fallback string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+
+	if sniffed := http.DetectContentType(data); sniffed != "application/octet-stream" {
+		return sniffed
+	}
+
+	return fallback
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Task represents a pending scheduled action
+type Task struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	RunAt       time.Time `json:"run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	timer *time.Timer
+}
+
+// Scheduler runs one-shot tasks at a given time
+type Scheduler struct {
+	mu     sync.Mutex
+	tasks  map[int]*Task
+	nextID int
+}
+
+// New creates a new scheduler
+func New() *Scheduler {
+	return &Scheduler{
+		tasks:  make(map[int]*Task),
+		nextID: 1,
+	}
+}
+
+// Schedule registers fn to run at runAt and returns the pending task
+func (s *Scheduler) Schedule(kind, description string, runAt time.Time, fn func()) (Task, error) {
+	if fn == nil {
+		return Task{}, fmt.Errorf("task function is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task := &Task{
+		ID:          s.nextID,
+		Kind:        kind,
+		Description: description,
+		RunAt:       runAt,
+		CreatedAt:   time.Now(),
+	}
+	s.nextID++
+
+	id := task.ID
+	task.timer = time.AfterFunc(time.Until(runAt), func() {
+		s.mu.Lock()
+		_, pending := s.tasks[id]
+		delete(s.tasks, id)
+		s.mu.Unlock()
+
+		if pending {
+			fn()
+		}
+	})
+	s.tasks[id] = task
+
+	return *task, nil
+}
+
+// Cancel stops a pending task
+func (s *Scheduler) Cancel(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[id]
+	if !exists {
+		return fmt.Errorf("no pending task with id %d", id)
+	}
+
+	task.timer.Stop()
+	delete(s.tasks, id)
+	return nil
+}
+
+// Pending returns all pending tasks ordered by run time
+func (s *Scheduler) Pending() []Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		tasks = append(tasks, *task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].RunAt.Before(tasks[j].RunAt)
+	})
+
+	return tasks
+}
+
+// Stop cancels all pending tasks
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, task := range s.tasks {
+		task.timer.Stop()
+		delete(s.tasks, id)
+	}
+}
+
+// NextRelease returns the next weekly HTB machine release time after now
+// (Saturdays at 19:00 UTC)
+func NextRelease(now time.Time) time.Time {
+	return NextWeekly(now, time.Saturday, 19)
+}
+
+// NextWeekly returns the next occurrence of weekday at hour:00 UTC after now
+func NextWeekly(now time.Time, weekday time.Weekday, hour int) time.Time {
+	now = now.UTC()
+	daysUntil := (int(weekday) - int(now.Weekday()) + 7) % 7
+	next := time.Date(now.Year(), now.Month(), now.Day()+daysUntil, hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
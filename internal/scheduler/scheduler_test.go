@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleRunsAtRunTime(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	ran := make(chan time.Time, 1)
+	runAt := time.Now().Add(50 * time.Millisecond)
+	task, err := s.Schedule("machine_spawn", "Spawn machine 7", runAt, func() { ran <- time.Now() })
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if task.ID != 1 || task.Kind != "machine_spawn" || !task.RunAt.Equal(runAt) {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	select {
+	case at := <-ran:
+		if at.Before(runAt) {
+			t.Errorf("Expected the task to run at %v, ran at %v", runAt, at)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Task never ran")
+	}
+
+	// A task that ran is no longer pending
+	if pending := s.Pending(); len(pending) != 0 {
+		t.Errorf("Expected no pending tasks, got %+v", pending)
+	}
+	if err := s.Cancel(task.ID); err == nil {
+		t.Error("Expected cancelling a task that ran to fail")
+	}
+}
+
+func TestScheduleRequiresFunc(t *testing.T) {
+	if _, err := New().Schedule("machine_spawn", "Spawn machine 7", time.Now(), nil); err == nil {
+		t.Error("Expected a task without a function to be refused")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	ran := make(chan struct{}, 1)
+	task, err := s.Schedule("machine_spawn", "Spawn machine 7", time.Now().Add(50*time.Millisecond), func() { ran <- struct{}{} })
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if err := s.Cancel(task.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if err := s.Cancel(task.ID); err == nil {
+		t.Error("Expected cancelling twice to fail")
+	}
+	if err := s.Cancel(42); err == nil {
+		t.Error("Expected cancelling an unknown task to fail")
+	}
+
+	select {
+	case <-ran:
+		t.Error("Expected the cancelled task not to run")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestPendingOrderAndStop(t *testing.T) {
+	s := New()
+	now := time.Now()
+	ran := make(chan struct{}, 3)
+	for _, delay := range []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour} {
+		if _, err := s.Schedule("machine_spawn", "Spawn", now.Add(delay), func() { ran <- struct{}{} }); err != nil {
+			t.Fatalf("Schedule failed: %v", err)
+		}
+	}
+
+	pending := s.Pending()
+	if len(pending) != 3 || pending[0].ID != 2 || pending[1].ID != 3 || pending[2].ID != 1 {
+		t.Fatalf("Expected tasks in run time order, got %+v", pending)
+	}
+
+	s.Stop()
+	if pending := s.Pending(); len(pending) != 0 {
+		t.Errorf("Expected Stop to cancel every task, got %+v", pending)
+	}
+	if task, err := s.Schedule("machine_spawn", "Spawn", now.Add(time.Hour), func() {}); err != nil || task.ID != 4 {
+		t.Errorf("Expected IDs to keep increasing after Stop, got %+v, %v", task, err)
+	}
+	s.Stop()
+}
+
+func TestNextWeekly(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{"earlier in the week", "2025-01-01T12:00:00Z", "2025-01-04T19:00:00Z"},
+		{"release day before the hour", "2025-01-04T18:59:59Z", "2025-01-04T19:00:00Z"},
+		{"release time itself", "2025-01-04T19:00:00Z", "2025-01-11T19:00:00Z"},
+		{"release day after the hour", "2025-01-04T20:00:00Z", "2025-01-11T19:00:00Z"},
+		{"across a month", "2025-01-30T00:00:00Z", "2025-02-01T19:00:00Z"},
+		{"Sunday locally, Saturday in UTC", "2025-01-05T02:00:00+09:00", "2025-01-04T19:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextRelease(at(tt.now)); !got.Equal(at(tt.want)) {
+				t.Errorf("NextRelease(%s) = %s, want %s", tt.now, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+
+	// Weekly jobs such as the digest reschedule to the following week
+	first := NextWeekly(at("2025-01-06T09:00:00Z"), time.Monday, 9)
+	if want := at("2025-01-13T09:00:00Z"); !first.Equal(want) {
+		t.Errorf("Expected the run time to move to the next week, got %s", first.Format(time.RFC3339))
+	}
+	if next := NextWeekly(first, time.Monday, 9); !next.Equal(first.AddDate(0, 0, 7)) {
+		t.Errorf("Expected a week between runs, got %s", next.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,252 @@
+// Package search provides a small in-memory full-text index over machine and
+// challenge metadata, so searches and argument completion can be answered
+// without calling the HTB API on every keystroke.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field weights: a match in a name counts more than one in a tag, which
+// counts more than one in a description
+const (
+	weightName        = 3
+	weightTag         = 2
+	weightDescription = 1
+
+	// Bonuses for whole-name matches, so "Lame" ranks the machine Lame above
+	// machines mentioning it
+	bonusExactName  = 10
+	bonusNamePrefix = 5
+)
+
+// Document is an indexed machine or challenge
+type Document struct {
+	Kind        string
+	ID          int
+	Name        string
+	Tags        []string
+	Description string
+
+	// Item is returned with search results unchanged, e.g. the cached API item
+	Item interface{}
+}
+
+// Result is a document matching a search
+type Result struct {
+	Document
+	Score int
+}
+
+// Index is an immutable inverted index; build a new one when content changes
+type Index struct {
+	docs []Document
+
+	// postings maps each term to the documents containing it and the weight
+	// of the best field it occurs in
+	postings map[string]map[int]int
+
+	// terms holds every term, sorted, for prefix lookups
+	terms []string
+}
+
+// New indexes docs
+func New(docs []Document) *Index {
+	idx := &Index{
+		docs:     docs,
+		postings: make(map[string]map[int]int),
+	}
+
+	for i, doc := range docs {
+		idx.add(i, doc.Name, weightName)
+		for _, tag := range doc.Tags {
+			idx.add(i, tag, weightTag)
+		}
+		idx.add(i, doc.Description, weightDescription)
+	}
+
+	idx.terms = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+
+	return idx
+}
+
+// add records the terms of text for document i
+func (idx *Index) add(i int, text string, weight int) {
+	for _, term := range Tokenize(text) {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[int]int)
+			idx.postings[term] = docs
+		}
+		if weight > docs[i] {
+			docs[i] = weight
+		}
+	}
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.docs)
+}
+
+// Count returns the number of indexed documents of a kind
+func (idx *Index) Count(kind string) int {
+	if idx == nil {
+		return 0
+	}
+	count := 0
+	for _, doc := range idx.docs {
+		if doc.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Search returns documents of the given kind ("" for any) containing every
+// query term, best matches first. The last term also matches as a prefix, so
+// partially typed queries find results.
+func (idx *Index) Search(query, kind string, limit int) []Result {
+	terms := Tokenize(query)
+	if idx == nil || len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]int
+	for n, term := range terms {
+		matches := idx.match(term, n == len(terms)-1 && !endsWithSpace(query))
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for i, score := range scores {
+			weight, ok := matches[i]
+			if !ok {
+				delete(scores, i)
+				continue
+			}
+			scores[i] = score + weight
+		}
+	}
+
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	results := make([]Result, 0, len(scores))
+	for i, score := range scores {
+		doc := idx.docs[i]
+		if kind != "" && doc.Kind != kind {
+			continue
+		}
+
+		name := strings.ToLower(doc.Name)
+		switch {
+		case name == lowerQuery:
+			score += bonusExactName
+		case strings.HasPrefix(name, lowerQuery):
+			score += bonusNamePrefix
+		}
+		results = append(results, Result{Document: doc, Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// match returns the documents containing term, or a term starting with it
+// if prefix is set, with the best weight found
+func (idx *Index) match(term string, prefix bool) map[int]int {
+	matches := make(map[int]int)
+	if !prefix {
+		for i, weight := range idx.postings[term] {
+			matches[i] = weight
+		}
+		return matches
+	}
+
+	for t := sort.SearchStrings(idx.terms, term); t < len(idx.terms) && strings.HasPrefix(idx.terms[t], term); t++ {
+		for i, weight := range idx.postings[idx.terms[t]] {
+			if weight > matches[i] {
+				matches[i] = weight
+			}
+		}
+	}
+	return matches
+}
+
+// Complete returns the names of documents of a kind that start with prefix,
+// or contain a word starting with it, and the total number of matches.
+// Names starting with prefix come first.
+func (idx *Index) Complete(kind, prefix string, limit int) ([]string, int) {
+	if idx == nil {
+		return nil, 0
+	}
+
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	var leading, inner []string
+	seen := make(map[string]bool)
+	for _, doc := range idx.docs {
+		if doc.Kind != kind || seen[doc.Name] {
+			continue
+		}
+
+		name := strings.ToLower(doc.Name)
+		switch {
+		case strings.HasPrefix(name, prefix):
+			leading = append(leading, doc.Name)
+		case hasWordPrefix(name, prefix):
+			inner = append(inner, doc.Name)
+		default:
+			continue
+		}
+		seen[doc.Name] = true
+	}
+
+	sort.Slice(leading, func(i, j int) bool { return strings.ToLower(leading[i]) < strings.ToLower(leading[j]) })
+	sort.Slice(inner, func(i, j int) bool { return strings.ToLower(inner[i]) < strings.ToLower(inner[j]) })
+
+	names := append(leading, inner...)
+	total := len(names)
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+	return names, total
+}
+
+// hasWordPrefix reports whether any word of text starts with prefix
+func hasWordPrefix(text, prefix string) bool {
+	for _, word := range Tokenize(text) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithSpace reports whether the user finished typing the last term
+func endsWithSpace(query string) bool {
+	return query != "" && unicode.IsSpace(rune(query[len(query)-1]))
+}
+
+// Tokenize splits text into lower-cased alphanumeric terms
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
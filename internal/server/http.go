@@ -0,0 +1,689 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/internal/tools"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// sessionHeader carries the MCP session ID on the HTTP transport
+const sessionHeader = "Mcp-Session-Id"
+
+// errDuplicateID is returned for a request reusing the ID of one still
+// awaiting its response
+var errDuplicateID = errors.New("id is already in use by a request in progress")
+
+// streamBuffer is how many server-initiated messages are held for a session's
+// event stream before further messages are dropped
+const streamBuffer = 64
+
+// HTTP serves MCP over the streamable HTTP transport. Clients POST each
+// JSON-RPC message to /mcp and get the response in the reply, and receive
+// server-initiated requests and notifications on a GET /mcp event stream.
+// Every MCP session is served by its own Server sharing one HTB client, and
+// one state file, cache and set of background workers.
+type HTTP struct {
+	config    *config.Config
+	htbClient *htb.Client
+	shared    *tools.Shared
+	handler   http.Handler
+
+	// Base context of session servers, cancelled by Close
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession is an MCP session on the HTTP transport, bridging HTTP
+// requests to the newline-delimited messages of its Server
+type httpSession struct {
+	server *Server
+	input  *io.PipeWriter
+	output *io.PipeWriter
+	cancel context.CancelFunc
+
+	// Requests awaiting their response, keyed by mcp.IDKey of their ID
+	mu      sync.Mutex
+	waiting map[string]chan json.RawMessage
+
+	// Server-initiated messages for the event stream
+	stream chan json.RawMessage
+
+	// When the client last made a request, and how many event streams it
+	// has open; sessions with an open stream are never idle
+	activityMu sync.Mutex
+	lastActive time.Time
+	streams    int
+}
+
+// NewHTTP creates an HTTP transport serving sessions with htbClient, or a
+// client created from the config if nil
+func NewHTTP(cfg *config.Config, htbClient *htb.Client) *HTTP {
+	if htbClient == nil {
+		htbClient = htb.NewClient(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &HTTP{
+		config:    cfg,
+		htbClient: htbClient,
+		shared:    NewShared(cfg, htbClient),
+		ctx:       ctx,
+		cancel:    cancel,
+		sessions:  make(map[string]*httpSession),
+	}
+	h.shared.Start(ctx)
+	if cfg.SessionIdleTimeout > 0 {
+		go h.reapIdleSessions(cfg.SessionIdleTimeout)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", h.handleMCP)
+	mux.HandleFunc("/health", h.handleHealth)
+	h.handler = h.checkOrigin(h.authenticate(mux))
+	return h
+}
+
+// listenHost returns the configured listen address, loopback by default
+func (h *HTTP) listenHost() string {
+	if h.config.ServerHost == "" {
+		return "127.0.0.1"
+	}
+	return h.config.ServerHost
+}
+
+// ServeHTTP implements http.Handler
+func (h *HTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the transport on the configured port, over TLS if a
+// certificate is configured, until ctx is cancelled
+func (h *HTTP) ListenAndServe(ctx context.Context) error {
+	// Verify HTB API connection
+	if err := h.htbClient.HealthCheck(ctx); err != nil {
+		return fmt.Errorf("HTB API health check failed: %w", err)
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(h.listenHost(), strconv.Itoa(h.config.ServerPort)),
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	useTLS := h.config.TLSCertFile != ""
+	if useTLS {
+		tlsConfig, err := serverTLSConfig(h.config)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+	} else if h.config.AuthToken != "" && !config.IsLoopback(h.listenHost()) {
+		log.Printf("Warning: serving HTTP without TLS, so the bearer token is sent in the clear")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if useTLS {
+			errCh <- srv.ListenAndServeTLS(h.config.TLSCertFile, h.config.TLSKeyFile)
+		} else {
+			errCh <- srv.ListenAndServe()
+		}
+	}()
+
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	log.Printf("HTB MCP Server starting on streamable HTTP transport at %s://%s/mcp", scheme, srv.Addr)
+	log.Printf("HTB API connection verified")
+
+	select {
+	case err := <-errCh:
+		h.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down HTB MCP Server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	h.Close()
+	return err
+}
+
+// serverTLSConfig loads the TLS settings, requiring client certificates
+// signed by the configured CA for mTLS
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA %s", cfg.TLSClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// Close ends every session and stops the shared background work
+func (h *HTTP) Close() {
+	h.mu.Lock()
+	sessions := h.sessions
+	h.sessions = make(map[string]*httpSession)
+	h.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.close()
+	}
+	h.cancel()
+	h.shared.Close()
+}
+
+// reapIdleSessions ends sessions that made no request for timeout, so
+// clients that never send DELETE don't leak them, until Close is called
+func (h *HTTP) reapIdleSessions(timeout time.Duration) {
+	interval := timeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case now := <-ticker.C:
+			var idle []*httpSession
+			h.mu.Lock()
+			for id, sess := range h.sessions {
+				if sess.idle(now, timeout) {
+					idle = append(idle, sess)
+					delete(h.sessions, id)
+				}
+			}
+			h.mu.Unlock()
+
+			for _, sess := range idle {
+				log.Printf("Ending HTTP session %s after %v without requests", sess.server.SessionID(), timeout)
+				sess.close()
+			}
+		}
+	}
+}
+
+// checkOrigin rejects requests from browser pages on origins other than
+// loopback and the configured ones. While listening on loopback, requests
+// must also name a loopback Host, so a page on a domain rebound to
+// 127.0.0.1 (DNS rebinding) can't reach the server.
+func (h *HTTP) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.IsLoopback(h.listenHost()) {
+			host := r.Host
+			if hostname, _, err := net.SplitHostPort(host); err == nil {
+				host = hostname
+			}
+			if !config.IsLoopback(host) {
+				http.Error(w, "invalid Host header", http.StatusForbidden)
+				return
+			}
+		}
+
+		if origin := r.Header.Get("Origin"); origin != "" && !h.allowedOrigin(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin reports whether a browser origin may call the server
+func (h *HTTP) allowedOrigin(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range h.config.AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return config.IsLoopback(u.Hostname())
+}
+
+// authenticate requires the configured bearer token on every request but
+// health checks. Client certificates are verified during the TLS handshake.
+func (h *HTTP) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.config.AuthToken != "" && r.URL.Path != "/health" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AuthToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="htb-mcp-server"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHealth reports that the transport is up
+func (h *HTTP) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// handleMCP serves the MCP endpoint
+func (h *HTTP) handleMCP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleStream(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost passes a client message to its session, starting a session on
+// initialize, and replies with the response to requests
+func (h *HTTP) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxMessageSize {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	msg, rpcErr := mcp.ParseMessage(body)
+	if rpcErr != nil && msg != nil && msg.IsResponse() {
+		// JSON-RPC forbids replying to responses, malformed or not
+		http.Error(w, "malformed response: "+fmt.Sprint(rpcErr.Data), http.StatusBadRequest)
+		return
+	}
+	if rpcErr != nil {
+		var id interface{}
+		if msg != nil {
+			id = msg.ID
+		}
+		writeRPCError(w, mcp.NewErrorResponse(id, rpcErr.Code, rpcErr.Message, rpcErr.Data))
+		return
+	}
+
+	// Sessions read one message per line
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+
+	var sess *httpSession
+	if id := r.Header.Get(sessionHeader); id != "" {
+		if sess = h.lookupSession(w, r); sess == nil {
+			return
+		}
+	} else if msg.Method == mcp.MethodInitialize {
+		if sess = h.newSession(w); sess == nil {
+			return
+		}
+	} else {
+		http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+
+	// Notifications and responses to server-initiated requests need no reply
+	if msg.Method == "" || msg.ID == nil {
+		if err := sess.send(body); err != nil {
+			http.Error(w, "session closed", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	resp, err := sess.call(r.Context(), msg.ID, body)
+	if errors.Is(err, errDuplicateID) {
+		writeRPCError(w, mcp.NewErrorResponse(msg.ID, mcp.ErrorCodeInvalidRequest, "Invalid Request", err.Error()))
+		return
+	}
+	if err != nil {
+		if r.Context().Err() == nil {
+			http.Error(w, "session closed", http.StatusNotFound)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// writeRPCError replies to a message refused before reaching its session
+func writeRPCError(w http.ResponseWriter, resp *mcp.Message) {
+	data, _ := json.Marshal(resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(data)
+}
+
+// handleStream streams server-initiated messages as server-sent events
+func (h *HTTP) handleStream(w http.ResponseWriter, r *http.Request) {
+	sess := h.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	sess.openStream()
+	defer sess.closeStream()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case data, ok := <-sess.stream:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleDelete ends a session at the client's request
+func (h *HTTP) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(sessionHeader)
+	if !validSessionID(id) {
+		http.Error(w, "invalid "+sessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	sess := h.sessions[id]
+	delete(h.sessions, id)
+	h.mu.Unlock()
+
+	if sess == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupSession returns the session named by the request's session header,
+// or writes an error and returns nil if it is malformed or unknown
+func (h *HTTP) lookupSession(w http.ResponseWriter, r *http.Request) *httpSession {
+	id := r.Header.Get(sessionHeader)
+	if !validSessionID(id) {
+		http.Error(w, "invalid "+sessionHeader+" header", http.StatusBadRequest)
+		return nil
+	}
+
+	h.mu.Lock()
+	sess := h.sessions[id]
+	h.mu.Unlock()
+
+	if sess == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return nil
+	}
+	sess.touch()
+	return sess
+}
+
+// validSessionID reports whether id has the form of the session IDs this
+// server issues
+func validSessionID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// newSession starts a session and sets its ID on the response, or writes
+// an error and returns nil if the maximum number of sessions is open
+func (h *HTTP) newSession(w http.ResponseWriter) *httpSession {
+	h.mu.Lock()
+	full := h.full()
+	h.mu.Unlock()
+	if full {
+		h.refuseSession(w)
+		return nil
+	}
+
+	id := session.NewID()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	sess := &httpSession{
+		server: NewWithOptions(h.config, Options{
+			HTBClient: h.htbClient,
+			Input:     inR,
+			Output:    outW,
+			SessionID: id,
+			Shared:    h.shared,
+		}),
+		input:      inW,
+		output:     outW,
+		cancel:     cancel,
+		waiting:    make(map[string]chan json.RawMessage),
+		stream:     make(chan json.RawMessage, streamBuffer),
+		lastActive: time.Now(),
+	}
+	go func() {
+		sess.server.Serve(ctx)
+		// Fail later messages rather than block on a pipe nobody reads
+		inR.CloseWithError(io.ErrClosedPipe)
+	}()
+	go sess.readOutput(outR)
+
+	// Other sessions may have started while this one was created
+	h.mu.Lock()
+	if h.full() {
+		h.mu.Unlock()
+		sess.close()
+		h.refuseSession(w)
+		return nil
+	}
+	h.sessions[id] = sess
+	h.mu.Unlock()
+
+	w.Header().Set(sessionHeader, id)
+	return sess
+}
+
+// full reports whether the maximum number of sessions is open; h.mu must
+// be held
+func (h *HTTP) full() bool {
+	return h.config.MaxSessions > 0 && len(h.sessions) >= h.config.MaxSessions
+}
+
+// refuseSession answers an initialize request when no session can be started
+func (h *HTTP) refuseSession(w http.ResponseWriter) {
+	http.Error(w, fmt.Sprintf("too many sessions: at most %d may be open at once", h.config.MaxSessions), http.StatusServiceUnavailable)
+}
+
+// touch records a request from the session's client
+func (s *httpSession) touch() {
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+	s.lastActive = time.Now()
+}
+
+// openStream records an event stream opened by the client
+func (s *httpSession) openStream() {
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+	s.streams++
+}
+
+// closeStream records the end of an event stream, which counts as activity
+func (s *httpSession) closeStream() {
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+	s.streams--
+	s.lastActive = time.Now()
+}
+
+// idle reports whether the client has made no request for timeout as of
+// now, has no event stream open and is not waiting for a response
+func (s *httpSession) idle(now time.Time, timeout time.Duration) bool {
+	s.mu.Lock()
+	waiting := len(s.waiting)
+	s.mu.Unlock()
+
+	s.activityMu.Lock()
+	defer s.activityMu.Unlock()
+	return waiting == 0 && s.streams == 0 && now.Sub(s.lastActive) >= timeout
+}
+
+// send passes a message to the session's server
+func (s *httpSession) send(data []byte) error {
+	_, err := s.input.Write(append(data, '\n'))
+	return err
+}
+
+// call sends a request and waits for its response. If ctx ends first, the
+// server is told to abandon the request. A request whose ID is already
+// awaiting a response is refused with errDuplicateID.
+func (s *httpSession) call(ctx context.Context, id interface{}, data []byte) (json.RawMessage, error) {
+	key := mcp.IDKey(id)
+	ch := make(chan json.RawMessage, 1)
+
+	s.mu.Lock()
+	if _, ok := s.waiting[key]; ok {
+		s.mu.Unlock()
+		return nil, errDuplicateID
+	}
+	s.waiting[key] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.waiting, key)
+		s.mu.Unlock()
+	}()
+
+	if err := s.send(data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, io.ErrClosedPipe
+		}
+		return resp, nil
+	case <-ctx.Done():
+		cancelled, err := json.Marshal(mcp.NewNotification(mcp.MethodNotificationCancelled, mcp.CancelledNotification{
+			RequestID: id,
+			Reason:    "HTTP request closed",
+		}))
+		if err == nil {
+			s.send(cancelled)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// readOutput routes the server's messages: responses to the waiting HTTP
+// request, everything else to the event stream
+func (s *httpSession) readOutput(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize*4)
+
+	for scanner.Scan() {
+		data := json.RawMessage(append([]byte(nil), scanner.Bytes()...))
+
+		var msg mcp.Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		if msg.Method == "" && msg.ID != nil {
+			s.mu.Lock()
+			ch := s.waiting[mcp.IDKey(msg.ID)]
+			s.mu.Unlock()
+			if ch != nil {
+				ch <- data
+				continue
+			}
+		}
+
+		select {
+		case s.stream <- data:
+		default:
+			log.Printf("Dropping %s message: no client is reading the event stream", msg.Method)
+		}
+	}
+
+	// Fail requests still waiting and end the event stream
+	s.mu.Lock()
+	for key, ch := range s.waiting {
+		close(ch)
+		delete(s.waiting, key)
+	}
+	s.mu.Unlock()
+	close(s.stream)
+}
+
+// close stops the session's server
+func (s *httpSession) close() {
+	s.input.Close()
+	s.cancel()
+	s.server.Close()
+	s.output.Close()
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// startHTTP serves the streamable HTTP transport in front of a stub HTB API
+func startHTTP(t *testing.T, handler http.HandlerFunc, configure ...func(cfg *config.Config)) *httptest.Server {
+	t.Helper()
+
+	api := httptest.NewServer(handler)
+	t.Cleanup(api.Close)
+
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     api.URL,
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Minute,
+		Transport:      "http",
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	transport := NewHTTP(cfg, nil)
+	srv := httptest.NewServer(transport)
+	t.Cleanup(func() {
+		srv.Close()
+		transport.Close()
+	})
+	return srv
+}
+
+// postMCP POSTs a JSON-RPC message to the HTTP transport
+func postMCP(t *testing.T, url, token, sessionID string, msg *mcp.Message) *http.Response {
+	t.Helper()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url+"/mcp", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPTransportAuth(t *testing.T) {
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.AuthToken = "s3cret"
+	})
+
+	initialize := mcp.NewRequest(1, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "http-test", Version: "1.0.0"},
+	})
+
+	for _, token := range []string{"", "wrong"} {
+		resp := postMCP(t, srv.URL, token, "", initialize)
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("Expected 401 with a challenge for token %q, got %d", token, resp.StatusCode)
+		}
+	}
+
+	resp := postMCP(t, srv.URL, "s3cret", "", initialize)
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected a new session, got %d", resp.StatusCode)
+	}
+	var initResp struct {
+		Result mcp.InitializeResponse `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&initResp); err != nil || initResp.Result.ServerInfo.Name == "" {
+		t.Fatalf("Failed to decode initialize response: %+v, %v", initResp, err)
+	}
+
+	resp = postMCP(t, srv.URL, "s3cret", sessionID, mcp.NewNotification(mcp.MethodNotificationInitialized, nil))
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	resp = postMCP(t, srv.URL, "s3cret", sessionID, mcp.NewRequest(2, mcp.MethodCallTool, mcp.CallToolRequest{Name: "get_machine_ip", Arguments: map[string]interface{}{}}))
+	var callResp struct {
+		Result mcp.CallToolResponse `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&callResp); err != nil || len(callResp.Result.Content) == 0 {
+		t.Fatalf("Failed to decode tool call response: %+v, %v", callResp, err)
+	}
+	if !strings.Contains(callResp.Result.Content[0].Text, "10.10.10.3") {
+		t.Errorf("Expected the active machine IP, got %s", callResp.Result.Content[0].Text)
+	}
+
+	resp = postMCP(t, srv.URL, "s3cret", strings.Repeat("0", 32), mcp.NewRequest(3, mcp.MethodListTools, nil))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPSessionLimits(t *testing.T) {
+	var polls atomic.Int32
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/machine/active" {
+			polls.Add(1)
+		}
+		w.Write([]byte(`{"info":null}`))
+	}, func(cfg *config.Config) {
+		cfg.IPChangeWatch = true
+		cfg.MaxSessions = 2
+		cfg.SessionIdleTimeout = 200 * time.Millisecond
+	})
+
+	initialize := mcp.NewRequest(1, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "http-test", Version: "1.0.0"},
+	})
+
+	var sessionIDs []string
+	for i := 0; i < 2; i++ {
+		resp := postMCP(t, srv.URL, "", "", initialize)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected session %d to start, got %d", i+1, resp.StatusCode)
+		}
+		sessionIDs = append(sessionIDs, resp.Header.Get("Mcp-Session-Id"))
+	}
+	if resp := postMCP(t, srv.URL, "", "", initialize); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 beyond the session cap, got %d", resp.StatusCode)
+	}
+
+	// Sessions share one poller rather than polling once each
+	time.Sleep(50 * time.Millisecond)
+	if n := polls.Load(); n != 1 {
+		t.Errorf("Expected one shared poll of the active machine, got %d", n)
+	}
+
+	// Idle sessions are ended, making room for new ones
+	time.Sleep(600 * time.Millisecond)
+	if resp := postMCP(t, srv.URL, "", sessionIDs[0], mcp.NewRequest(2, mcp.MethodPing, nil)); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a reaped session, got %d", resp.StatusCode)
+	}
+	if resp := postMCP(t, srv.URL, "", "", initialize); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a new session after idle ones were ended, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransportOrigin(t *testing.T) {
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":null}`))
+	}, func(cfg *config.Config) {
+		cfg.AllowedOrigins = []string{"https://app.example.com"}
+	})
+
+	initialize := mcp.NewRequest(1, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "http-test", Version: "1.0.0"},
+	})
+	body, err := json.Marshal(initialize)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		status int
+	}{
+		{"no origin", "", "", http.StatusOK},
+		{"loopback origin", "", "http://localhost:5173", http.StatusOK},
+		{"allowed origin", "", "https://app.example.com", http.StatusOK},
+		{"foreign origin", "", "https://evil.example", http.StatusForbidden},
+		{"rebound host", "evil.example:3000", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/mcp", strings.NewReader(string(body)))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("POST failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+
+	resp := postMCP(t, srv.URL, "", "../../etc", mcp.NewRequest(2, mcp.MethodListTools, nil))
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a malformed session ID, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransportRejectsMalformedMessages(t *testing.T) {
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":null}`))
+	})
+
+	resp, err := http.Post(srv.URL+"/mcp", "application/json", strings.NewReader(`{"id":1,"method":"initialize","params":{}}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var reply mcp.Message
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("Failed to decode reply: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || reply.Error == nil || reply.Error.Code != mcp.ErrorCodeInvalidRequest || reply.ID != float64(1) {
+		t.Errorf("Expected an invalid request error for id 1, got %d %+v", resp.StatusCode, reply)
+	}
+	if resp.Header.Get("Mcp-Session-Id") != "" {
+		t.Error("Expected no session for a malformed initialize")
+	}
+}
+
+func TestHTTPTransportLargeMessage(t *testing.T) {
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":null}`))
+	})
+
+	resp := postMCP(t, srv.URL, "", "", mcp.NewRequest(1, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "http-test", Version: "1.0.0"},
+	}))
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected a new session, got %d", resp.StatusCode)
+	}
+
+	// Messages past the default 64KB line limit of bufio.Scanner are read whole
+	done := make(chan *http.Response, 1)
+	go func() {
+		done <- postMCP(t, srv.URL, "", sessionID, mcp.NewRequest(2, mcp.MethodPing, map[string]interface{}{
+			"_meta": map[string]interface{}{"padding": strings.Repeat("a", 100<<10)},
+		}))
+	}()
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("POST of a large message hung")
+	}
+	var reply mcp.Message
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Error != nil || reply.ID != float64(2) {
+		t.Fatalf("Expected the ping to be answered, got %d %+v, %v", resp.StatusCode, reply, err)
+	}
+
+	if resp := postMCP(t, srv.URL, "", sessionID, mcp.NewRequest(3, mcp.MethodPing, nil)); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the session to keep serving requests, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransportRequestIDs(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := startHTTP(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/machine/active" {
+			received <- struct{}{}
+			<-release
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+			return
+		}
+		w.Write([]byte(`{"info":null}`))
+	})
+	var releaseOnce sync.Once
+	t.Cleanup(func() { releaseOnce.Do(func() { close(release) }) })
+
+	resp := postMCP(t, srv.URL, "", "", mcp.NewRequest(1, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "http-test", Version: "1.0.0"},
+	}))
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	postMCP(t, srv.URL, "", sessionID, mcp.NewNotification(mcp.MethodNotificationInitialized, nil))
+
+	done := make(chan *http.Response, 1)
+	go func() {
+		done <- postMCP(t, srv.URL, "", sessionID, mcp.NewRequest(1, mcp.MethodCallTool, mcp.CallToolRequest{Name: "get_machine_ip", Arguments: map[string]interface{}{}}))
+	}()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tool call never reached the HTB API")
+	}
+
+	// A request reusing the ID of one in progress is refused
+	var reply mcp.Message
+	resp = postMCP(t, srv.URL, "", sessionID, mcp.NewRequest(1, mcp.MethodPing, nil))
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || resp.StatusCode != http.StatusBadRequest ||
+		reply.Error == nil || reply.Error.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("Expected an invalid request error for a duplicate id, got %d %+v, %v", resp.StatusCode, reply, err)
+	}
+
+	// The string "1" is a different ID from the number 1
+	reply = mcp.Message{}
+	resp = postMCP(t, srv.URL, "", sessionID, mcp.NewRequest("1", mcp.MethodPing, nil))
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Error != nil || reply.ID != "1" {
+		t.Errorf("Expected the ping with id \"1\" to get its own response, got %d %+v, %v", resp.StatusCode, reply, err)
+	}
+
+	releaseOnce.Do(func() { close(release) })
+	var callResp struct {
+		ID     interface{}          `json:"id"`
+		Result mcp.CallToolResponse `json:"result"`
+	}
+	select {
+	case resp = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tool call never completed")
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&callResp); err != nil || callResp.ID != float64(1) ||
+		len(callResp.Result.Content) == 0 || !strings.Contains(callResp.Result.Content[0].Text, "10.10.10.3") {
+		t.Errorf("Expected the tool call's own result, got %+v, %v", callResp, err)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// maxMessageSize bounds the size of a JSON-RPC message read from a client,
+// over stdio or POSTed to the HTTP transport
+const maxMessageSize = 4 << 20
+
+// errMessageTooLarge is returned for a message over the reader's limit
+var errMessageTooLarge = errors.New("message too large")
+
+// messageReader reads newline-delimited messages from an input. A message
+// over the size limit is discarded up to the next newline rather than
+// ending the stream, so the client can be told and later messages still
+// get read.
+type messageReader struct {
+	src   *bufio.Reader
+	limit int
+}
+
+// newMessageReader creates a message reader on src for messages of at most
+// limit bytes
+func newMessageReader(src io.Reader, limit int) *messageReader {
+	return &messageReader{
+		src:   bufio.NewReader(src),
+		limit: limit,
+	}
+}
+
+// ReadMessage returns the next line of the input without its line ending.
+// It returns errMessageTooLarge, once the line is discarded, for a line
+// over the limit, and io.EOF once the input ends.
+func (r *messageReader) ReadMessage() ([]byte, error) {
+	var line []byte
+	tooLarge := false
+	for {
+		chunk, err := r.src.ReadSlice('\n')
+		if !tooLarge {
+			line = append(line, chunk...)
+			// Leave room for a \r\n line ending
+			if len(line) > r.limit+2 {
+				line, tooLarge = nil, true
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if tooLarge || len(line) > r.limit {
+			return nil, errMessageTooLarge
+		}
+		if err == io.EOF && len(line) > 0 {
+			return line, nil
+		}
+		return line, err
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageReaderSkipsOversizeMessages(t *testing.T) {
+	input := `{"id":1}` + "\n" + strings.Repeat("x", 100) + "\r\n" + `{"id":2}` + "\r\n" + `{"id":3}`
+	r := newMessageReader(strings.NewReader(input), 32)
+
+	want := []struct {
+		line string
+		err  error
+	}{
+		{`{"id":1}`, nil},
+		{"", errMessageTooLarge},
+		{`{"id":2}`, nil},
+		{`{"id":3}`, nil},
+		{"", io.EOF},
+	}
+	for i, w := range want {
+		line, err := r.ReadMessage()
+		if string(line) != w.line || !errors.Is(err, w.err) {
+			t.Errorf("Message %d: expected %q, %v, got %q, %v", i+1, w.line, w.err, line, err)
+		}
+	}
+}
+
+func TestMessageReaderBeyondBuffer(t *testing.T) {
+	// Lines longer than the bufio buffer are read whole up to the limit
+	long := `{"data":"` + strings.Repeat("a", 70000) + `"}`
+	r := newMessageReader(strings.NewReader(long+"\n"+long+"x\n"), len(long))
+
+	if line, err := r.ReadMessage(); err != nil || string(line) != long {
+		t.Errorf("Expected the long message, got %d bytes, %v", len(line), err)
+	}
+	if _, err := r.ReadMessage(); !errors.Is(err, errMessageTooLarge) {
+		t.Errorf("Expected a message one byte over the limit to be refused, got %v", err)
+	}
+	if _, err := r.ReadMessage(); err != io.EOF {
+		t.Errorf("Expected the end of the input, got %v", err)
+	}
+}
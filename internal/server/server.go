@@ -1,17 +1,24 @@
 package server
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/logging"
+	"github.com/NoASLR/htb-mcp-server/internal/prompts"
+	"github.com/NoASLR/htb-mcp-server/internal/resources"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
 	"github.com/NoASLR/htb-mcp-server/internal/tools"
 	"github.com/NoASLR/htb-mcp-server/pkg/config"
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
@@ -23,23 +30,162 @@ type Server struct {
 	config       *config.Config
 	htbClient    *htb.Client
 	toolRegistry *tools.Registry
-	startTime    time.Time
+	resources    *resources.Registry
+	prompts      *prompts.Registry
+	logger       *logging.Logger
+	router       *mcp.Router
 	input        io.Reader
-	output       io.Writer
+	output       *messageWriter
+
+	// State and background work shared with other servers for the same
+	// account, closed with this server if it created them
+	shared     *tools.Shared
+	ownsShared bool
+
+	// Per-client state, keyed by MCP session ID
+	sessions  *session.Manager
+	sessionID string
+
+	// Outstanding server-to-client requests awaiting a response
+	pendingMu sync.Mutex
+	pending   map[string]chan *mcp.Message
+	nextID    int64
+
+	// Cancel functions of in-flight client requests, keyed by mcp.IDKey of
+	// their ID
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelCauseFunc
 }
 
-// New creates a new MCP server instance
+// errRequestCancelled is the cause of contexts cancelled by notifications/cancelled
+var errRequestCancelled = errors.New("request cancelled by the client")
+
+// ErrSamplingUnsupported is returned when the client did not advertise sampling support
+var ErrSamplingUnsupported = errors.New("client does not support sampling")
+
+// ErrElicitationUnsupported is returned when the client did not advertise elicitation support
+var ErrElicitationUnsupported = errors.New("client does not support elicitation")
+
+// Options customizes a server created with NewWithOptions
+type Options struct {
+	// HTBClient is the HTB API client used by tools; one is created from the config if nil
+	HTBClient *htb.Client
+
+	// Input and Output carry newline-delimited JSON-RPC messages; stdio if nil
+	Input  io.Reader
+	Output io.Writer
+
+	// SessionID identifies the client connected over Input and Output; a
+	// random ID is generated if empty
+	SessionID string
+
+	// Shared is the state, cache and background work shared with other
+	// servers for the same account, as created by NewShared. One is created
+	// for the server, and closed with it, if nil.
+	Shared *tools.Shared
+}
+
+// New creates a new MCP server instance on stdio
 func New(cfg *config.Config) *Server {
-	htbClient := htb.NewClient(cfg)
+	return NewWithOptions(cfg, Options{})
+}
+
+// NewWithIO creates a new MCP server instance that reads requests from in
+// and writes responses to out
+func NewWithIO(cfg *config.Config, in io.Reader, out io.Writer) *Server {
+	return NewWithOptions(cfg, Options{Input: in, Output: out})
+}
 
-	return &Server{
+// NewWithOptions creates a new MCP server instance with custom options
+func NewWithOptions(cfg *config.Config, opts Options) *Server {
+	htbClient := opts.HTBClient
+	if htbClient == nil {
+		htbClient = htb.NewClient(cfg)
+	}
+
+	in, out := opts.Input, opts.Output
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+
+	shared, ownsShared := opts.Shared, false
+	if shared == nil {
+		shared, ownsShared = NewShared(cfg, htbClient), true
+	}
+
+	srv := &Server{
 		config:       cfg,
 		htbClient:    htbClient,
-		toolRegistry: tools.NewRegistry(htbClient),
-		startTime:    time.Now(),
-		input:        os.Stdin,
-		output:       os.Stdout,
+		toolRegistry: tools.NewRegistry(cfg, htbClient, shared),
+		resources:    resources.NewRegistry(htbClient, shared.Cache()),
+		input:        in,
+		output:       newMessageWriter(out, defaultWriteTimeout),
+		shared:       shared,
+		ownsShared:   ownsShared,
+		pending:      make(map[string]chan *mcp.Message),
+		inflight:     make(map[string]context.CancelCauseFunc),
+		sessions: session.NewManager(session.Limits{
+			ToolCallsPerMinute: cfg.RateLimitPerMinute,
+			RequestsPerMinute:  cfg.MaxRequestsPerMinute,
+			MaxInFlightCalls:   cfg.MaxInFlightCalls,
+		}, shared.Notes()),
+		sessionID: opts.SessionID,
+	}
+	if srv.sessionID == "" {
+		srv.sessionID = session.NewID()
+	}
+	srv.toolRegistry.SetNotifier(srv)
+	srv.toolRegistry.SetSession(srv.session())
+	srv.toolRegistry.SetSampler(srv)
+	srv.toolRegistry.SetRooter(srv)
+	srv.toolRegistry.SetElicitor(srv)
+	srv.logger = srv.toolRegistry.Logger()
+	srv.prompts = prompts.NewRegistry(htbClient, srv.toolRegistry.Notes())
+	srv.router = mcp.NewRouter(srv)
+	srv.router.Handle(mcp.MethodComplete, mcp.Method(srv.Complete))
+	srv.router.Handle(mcp.MethodNotificationCancelled, mcp.Method(srv.Cancelled))
+	srv.router.Handle(mcp.MethodSetLevel, mcp.Method(srv.SetLevel))
+	srv.router.Handle(mcp.MethodPing, mcp.NoParams(srv.Ping))
+	srv.router.Handle(mcp.MethodSubscribe, mcp.Method(srv.Subscribe))
+	srv.router.Handle(mcp.MethodUnsubscribe, mcp.Method(srv.Unsubscribe))
+	srv.router.Handle(mcp.MethodNotificationRootsChanged, mcp.NoParams(srv.RootsChanged))
+
+	return srv
+}
+
+// NewShared opens the configured cache, falling back to memory, paces
+// htbClient together with the other instances sharing it, and creates the
+// state and background workers that servers for the account share
+func NewShared(cfg *config.Config, htbClient *htb.Client) *tools.Shared {
+	// Share cached listings, lookups and rate limits with other instances if
+	// a shared backend is configured
+	c, err := cache.Open(cfg)
+	if err != nil {
+		log.Printf("Failed to open %s cache, falling back to memory: %v", cfg.CacheBackend, err)
+		c = cache.New(cfg.CacheTTL)
+	}
+
+	// Pace HTB API requests together with the other instances sharing Redis
+	if redis, ok := c.(*cache.Redis); ok && cfg.HTBRateLimitPerMinute > 0 {
+		htbClient.SetThrottle(cache.NewSharedThrottle(redis, cfg.HTBRateLimitPerMinute))
 	}
+
+	return tools.NewShared(cfg, htbClient, c)
+}
+
+// RegisterTool registers an additional tool alongside the built-in HTB tools.
+// It must be called before the server starts processing messages. A tool
+// whose name is already registered is skipped with a warning rather than
+// replacing the existing tool.
+func (s *Server) RegisterTool(tool tools.Tool) {
+	if _, exists := s.toolRegistry.GetTool(tool.Name()); exists {
+		s.logger.Warnf("server", "Skipping tool %s: name already registered", tool.Name())
+		return
+	}
+	s.toolRegistry.RegisterTool(tool)
 }
 
 // Start begins the MCP server operation
@@ -53,11 +199,32 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Printf("HTB API connection verified")
 
 	// Start processing messages
-	go s.processMessages(ctx)
+	go s.Serve(ctx)
 
 	return nil
 }
 
+// Serve starts background work and processes messages until the input is
+// closed. Unlike Start it does not verify the HTB API connection first.
+func (s *Server) Serve(ctx context.Context) {
+	s.StartBackground(ctx)
+	s.processMessages(ctx)
+}
+
+// StartBackground starts background work (scheduler, active machine poller)
+// without processing messages, for servers driven through Handler
+func (s *Server) StartBackground(ctx context.Context) {
+	s.toolRegistry.Start(ctx)
+}
+
+// Handler returns the server's MCP methods for use by another protocol
+// implementation. Requests are scoped to the server's session.
+func (s *Server) Handler() mcp.Handler {
+	return mcp.WithContext(s, func(ctx context.Context) context.Context {
+		return session.NewContext(ctx, s.session())
+	})
+}
+
 // Wait waits for shutdown signals
 func (s *Server) Wait() {
 	sigChan := make(chan os.Signal, 1)
@@ -65,115 +232,375 @@ func (s *Server) Wait() {
 
 	<-sigChan
 	log.Println("Shutting down HTB MCP Server...")
+	s.Close()
+}
+
+// Close stops background work owned by the server
+func (s *Server) Close() {
+	s.toolRegistry.Close()
+	if s.ownsShared {
+		s.shared.Close()
+	}
+	s.sessions.Remove(s.sessionID)
+}
+
+// SessionID returns the ID of the MCP session served by this server
+func (s *Server) SessionID() string {
+	return s.sessionID
+}
+
+// session returns the state of the MCP session served by this server
+func (s *Server) session() *session.Session {
+	return s.sessions.Get(s.sessionID)
 }
 
 // processMessages handles incoming MCP messages
 func (s *Server) processMessages(ctx context.Context) {
-	scanner := bufio.NewScanner(s.input)
+	reader := newMessageReader(s.input, maxMessageSize)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+	// Scope notes, submission history and rate limits to the client session
+	ctx = session.NewContext(ctx, s.session())
+
+	for {
+		line, err := reader.ReadMessage()
+		if errors.Is(err, errMessageTooLarge) {
+			// The message's ID is unknown, so the error can't carry it
+			s.sendErrorResponse(nil, mcp.ErrorCodeInvalidRequest, "Invalid Request", fmt.Sprintf("message exceeds %d bytes", maxMessageSize))
 			continue
 		}
 
-		if err := s.handleMessage(ctx, line); err != nil {
-			log.Printf("Error handling message: %v", err)
+		if len(line) > 0 {
+			if err := s.handleMessage(ctx, string(line)); err != nil {
+				s.logger.Errorf("server", "Error handling message: %v", err)
+			}
 		}
-	}
 
-	if err := scanner.Err(); err != nil {
-		log.Printf("Error reading from stdin: %v", err)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Error reading from stdin: %v", err)
+			}
+			return
+		}
 	}
 }
 
 // handleMessage processes a single MCP message
 func (s *Server) handleMessage(ctx context.Context, line string) error {
-	var msg mcp.Message
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		s.sendErrorResponse(nil, mcp.ErrorCodeParseError, "Parse error", err.Error())
+	msg, rpcErr := mcp.ParseMessage([]byte(line))
+	if rpcErr != nil {
+		// Notifications never get a response, so ones with bad params are only logged
+		if msg != nil && msg.ID == nil && rpcErr.Code == mcp.ErrorCodeInvalidParams {
+			s.logger.Warnf("server", "Ignoring notification with invalid params: %v", rpcErr)
+			return nil
+		}
+		// Neither do responses, so malformed ones are dropped
+		if msg != nil && msg.IsResponse() {
+			s.logger.Warnf("server", "Ignoring malformed response: %v", rpcErr)
+			return nil
+		}
+		var id interface{}
+		if msg != nil {
+			id = msg.ID
+		}
+		s.sendErrorResponse(id, rpcErr.Code, rpcErr.Message, fmt.Sprint(rpcErr.Data))
 		return nil
 	}
 
-	switch msg.Method {
-	case mcp.MethodInitialize:
-		return s.handleInitialize(ctx, &msg)
-	case mcp.MethodListTools:
-		return s.handleListTools(ctx, &msg)
-	case mcp.MethodCallTool:
-		return s.handleCallTool(ctx, &msg)
-	default:
-		s.sendErrorResponse(msg.ID, mcp.ErrorCodeMethodNotFound, "Method not found", fmt.Sprintf("Unknown method: %s", msg.Method))
+	// Responses to server-initiated requests carry an ID but no method
+	if msg.Method == "" && msg.ID != nil {
+		s.handleClientResponse(msg)
+		return nil
+	}
+
+	// Refuse requests over the session's budget before doing any work
+	if msg.ID != nil && !s.session().Requests.Allow() {
+		s.sendErrorResponse(msg.ID, mcp.ErrorCodeRateLimited, "Rate limit exceeded",
+			fmt.Sprintf("at most %d requests per minute for this session", s.config.MaxRequestsPerMinute))
+		return nil
+	}
+
+	// A reused ID would make responses and cancellations ambiguous
+	if msg.ID != nil && s.inFlight(msg.ID) {
+		s.sendErrorResponse(msg.ID, mcp.ErrorCodeInvalidRequest, "Invalid Request", "id is already in use by a request in progress")
 		return nil
 	}
-}
 
-// handleInitialize handles the initialize request
-func (s *Server) handleInitialize(ctx context.Context, msg *mcp.Message) error {
-	var req mcp.InitializeRequest
-	if err := s.parseParams(msg.Params, &req); err != nil {
-		s.sendErrorResponse(msg.ID, mcp.ErrorCodeInvalidParams, "Invalid params", err.Error())
+	// Tools may issue requests back to the client (e.g. sampling), so run
+	// them off the read loop to keep receiving responses and cancellations
+	if msg.Method == mcp.MethodCallTool {
+		ctx, done := s.track(ctx, msg.ID)
+		go func() {
+			defer done()
+			s.dispatchCancellable(ctx, msg)
+		}()
 		return nil
 	}
 
-	// Verify protocol version compatibility
-	if req.ProtocolVersion != mcp.MCPVersion {
-		log.Printf("Warning: Client protocol version %s differs from server version %s", req.ProtocolVersion, mcp.MCPVersion)
+	s.dispatch(ctx, msg)
+	return nil
+}
+
+// dispatch routes a request or notification to its handler and sends the response
+func (s *Server) dispatch(ctx context.Context, msg *mcp.Message) {
+	if response := s.router.Dispatch(ctx, msg); response != nil {
+		if err := s.sendMessage(response); err != nil {
+			s.logger.Errorf("server", "Error handling message: %v", err)
+		}
+	}
+}
+
+// track registers an in-flight request so notifications/cancelled can abort
+// its context. It is called on the read loop, before a cancellation for the
+// request can be read, and after requests reusing the ID of one in flight
+// were refused; done must be called once the request finishes.
+func (s *Server) track(ctx context.Context, id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if id == nil {
+		return ctx, func() { cancel(nil) }
+	}
+
+	key := mcp.IDKey(id)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+
+	return ctx, func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// inFlight reports whether a tracked request with id has not finished yet
+func (s *Server) inFlight(id interface{}) bool {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	_, ok := s.inflight[mcp.IDKey(id)]
+	return ok
+}
+
+// dispatchCancellable dispatches a tracked request. Cancelling aborts the
+// request's context, and with it any HTB API calls in flight; cancelled
+// requests get no response.
+func (s *Server) dispatchCancellable(ctx context.Context, msg *mcp.Message) {
+	response := s.router.Dispatch(ctx, msg)
+	if errors.Is(context.Cause(ctx), errRequestCancelled) {
+		s.logger.Debugf("server", "Request %v cancelled by the client", msg.ID)
+		return
+	}
+	if response != nil {
+		if err := s.sendMessage(response); err != nil {
+			s.logger.Errorf("server", "Error handling message: %v", err)
+		}
+	}
+}
+
+// Cancelled handles notifications/cancelled by aborting the named request.
+// Requests that already finished or are unknown are ignored.
+func (s *Server) Cancelled(ctx context.Context, req *mcp.CancelledNotification) (interface{}, error) {
+	id := mcp.IDKey(req.RequestID)
+
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[id]
+	s.inflightMu.Unlock()
+
+	if ok {
+		s.logger.Debugf("server", "Cancelling request %s: %s", id, req.Reason)
+		cancel(errRequestCancelled)
+	}
+	return nil, nil
+}
+
+// SetLevel handles logging/setLevel, choosing the least severe log messages
+// sent to the client
+func (s *Server) SetLevel(ctx context.Context, req *mcp.SetLevelRequest) (struct{}, error) {
+	if err := s.logger.SetLevel(req.Level); err != nil {
+		return struct{}{}, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid params", err.Error())
 	}
+	return struct{}{}, nil
+}
+
+// Ping handles ping, letting clients and proxies check the server is alive
+func (s *Server) Ping(ctx context.Context) (struct{}, error) {
+	if sess, ok := session.FromContext(ctx); ok {
+		sess.Pinged()
+	}
+	return struct{}{}, nil
+}
+
+// Initialize handles the initialize request
+func (s *Server) Initialize(ctx context.Context, req *mcp.InitializeRequest) (*mcp.InitializeResponse, error) {
+	// Answer with the client's protocol version if we speak it
+	version := mcp.NegotiateVersion(req.ProtocolVersion)
+	if version != req.ProtocolVersion {
+		s.logger.Warnf("server", "Client protocol version %s is not supported, using %s", req.ProtocolVersion, version)
+	}
+
+	// Record the client so features are only used when it declared them
+	s.session().SetClient(req)
+	s.logger.Debugf("server", "Client %s %s connected (features: %s)", req.ClientInfo.Name, req.ClientInfo.Version, strings.Join(req.Capabilities.Features(), ", "))
 
-	response := mcp.InitializeResponse{
-		ProtocolVersion: mcp.MCPVersion,
+	return &mcp.InitializeResponse{
+		ProtocolVersion: version,
 		Capabilities: mcp.ServerCapabilities{
 			Tools: &mcp.ToolsCapability{
-				ListChanged: false,
+				ListChanged: true,
 			},
+			Resources: &mcp.ResourcesCapability{Subscribe: true},
+			Prompts:   &mcp.PromptsCapability{},
+			Logging:   &mcp.LoggingCapability{},
 		},
 		ServerInfo: mcp.ServerInfo{
 			Name:    "htb-mcp-server",
 			Version: "1.0.0",
 		},
-	}
-
-	return s.sendResponse(msg.ID, response)
+	}, nil
 }
 
-// handleListTools handles the list tools request
-func (s *Server) handleListTools(ctx context.Context, msg *mcp.Message) error {
-	tools := s.toolRegistry.GetTools()
-	response := map[string]interface{}{
-		"tools": tools,
+// Initialized handles notifications/initialized, which completes the
+// initialize handshake and lets the client call tools
+func (s *Server) Initialized(ctx context.Context) error {
+	if !s.session().SetInitialized() {
+		s.logger.Warnf("server", "Ignoring notifications/initialized received before initialize")
+		return nil
 	}
 
-	return s.sendResponse(msg.ID, response)
+	// Annotate tools the account's subscription doesn't include
+	s.toolRegistry.DetectSubscription()
+	return nil
 }
 
-// handleCallTool handles tool call requests
-func (s *Server) handleCallTool(ctx context.Context, msg *mcp.Message) error {
-	var req mcp.CallToolRequest
-	if err := s.parseParams(msg.Params, &req); err != nil {
-		s.sendErrorResponse(msg.ID, mcp.ErrorCodeInvalidParams, "Invalid params", err.Error())
-		return nil
+// ListTools handles the list tools request
+func (s *Server) ListTools(ctx context.Context) (*mcp.ListToolsResponse, error) {
+	return &mcp.ListToolsResponse{Tools: tools.NegotiateTools(ctx, s.toolRegistry.GetTools())}, nil
+}
+
+// CallTool handles tool call requests. Tool failures are reported to the
+// client as error results rather than protocol errors. Tools only run once
+// the client has finished the initialize handshake.
+func (s *Server) CallTool(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResponse, error) {
+	if !s.session().Ready() {
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidRequest, "Server not initialized",
+			"tools/call is only allowed after initialize and notifications/initialized")
 	}
 
-	// Execute the tool
+	ctx = tools.WithProgressToken(ctx, req.ProgressToken())
 	result, err := s.toolRegistry.ExecuteTool(ctx, req.Name, req.Arguments)
 	if err != nil {
-		response := mcp.CallToolResponse{
+		return &mcp.CallToolResponse{
 			Content: []mcp.Content{
 				mcp.CreateTextContent(fmt.Sprintf("Error executing tool: %v", err)),
 			},
 			IsError: true,
+		}, nil
+	}
+
+	return result, nil
+}
+
+// ListResources handles the list resources request
+func (s *Server) ListResources(ctx context.Context) (*mcp.ListResourcesResponse, error) {
+	return &mcp.ListResourcesResponse{Resources: s.resources.ListResources()}, nil
+}
+
+// ListResourceTemplates handles the list resource templates request
+func (s *Server) ListResourceTemplates(ctx context.Context) (*mcp.ListResourceTemplatesResponse, error) {
+	return &mcp.ListResourceTemplatesResponse{ResourceTemplates: s.resources.ListTemplates()}, nil
+}
+
+// ReadResource handles resource read requests
+func (s *Server) ReadResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResponse, error) {
+	redactor := s.toolRegistry.Redactor()
+	result, err := s.resources.Read(ctx, req.URI)
+	if err != nil {
+		if errors.Is(err, resources.ErrNotFound) {
+			return nil, mcp.NewError(mcp.ErrorCodeResourceNotFound, "Resource not found", req.URI)
 		}
-		return s.sendResponse(msg.ID, response)
+		return nil, mcp.NewError(mcp.ErrorCodeInternalError, "Failed to read resource", redactor.Error(err).Error())
 	}
 
-	return s.sendResponse(msg.ID, result)
+	return redactor.Resource(result), nil
 }
 
-// sendResponse sends a successful response
-func (s *Server) sendResponse(id interface{}, result interface{}) error {
-	response := mcp.NewResponse(id, result)
-	return s.sendMessage(response)
+// Subscribe handles resources/subscribe, after which the client is sent
+// notifications/resources/updated when the resource changes
+func (s *Server) Subscribe(ctx context.Context, req *mcp.SubscribeRequest) (struct{}, error) {
+	if req.URI == "" {
+		return struct{}{}, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid params", "uri is required")
+	}
+	s.session().Subscribe(req.URI)
+	return struct{}{}, nil
+}
+
+// Unsubscribe handles resources/unsubscribe
+func (s *Server) Unsubscribe(ctx context.Context, req *mcp.SubscribeRequest) (struct{}, error) {
+	s.session().Unsubscribe(req.URI)
+	return struct{}{}, nil
+}
+
+// ListPrompts handles the list prompts request
+func (s *Server) ListPrompts(ctx context.Context) (*mcp.ListPromptsResponse, error) {
+	return &mcp.ListPromptsResponse{Prompts: s.prompts.ListPrompts()}, nil
+}
+
+// GetPrompt handles prompt get requests
+func (s *Server) GetPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResponse, error) {
+	redactor := s.toolRegistry.Redactor()
+	result, err := s.prompts.GetPrompt(ctx, req.Name, req.Arguments)
+	if err != nil {
+		if errors.Is(err, prompts.ErrNotFound) {
+			return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Prompt not found", req.Name)
+		}
+		return nil, mcp.NewError(mcp.ErrorCodeInternalError, "Failed to get prompt", redactor.Error(err).Error())
+	}
+
+	return redactor.Prompt(result), nil
+}
+
+// Complete handles argument completion requests. Machine and challenge
+// arguments of prompts and resource templates complete to names from the
+// local content index; other arguments have no suggestions.
+func (s *Server) Complete(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResponse, error) {
+	var kind string
+	switch req.Ref.Type {
+	case mcp.RefPrompt:
+		if !s.hasPrompt(req.Ref.Name) {
+			return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Prompt not found", req.Ref.Name)
+		}
+		kind = completionKind(req.Argument.Name)
+	case mcp.RefResource:
+		kind = completionKind(req.Ref.URI)
+	default:
+		return nil, mcp.NewError(mcp.ErrorCodeInvalidParams, "Invalid params", fmt.Sprintf("unknown reference type %q", req.Ref.Type))
+	}
+
+	if kind == "" {
+		return &mcp.CompleteResponse{Completion: mcp.Completion{Values: []string{}}}, nil
+	}
+	return &mcp.CompleteResponse{Completion: s.toolRegistry.Complete(kind, req.Argument.Value)}, nil
+}
+
+// hasPrompt reports whether a prompt is registered
+func (s *Server) hasPrompt(name string) bool {
+	for _, prompt := range s.prompts.ListPrompts() {
+		if prompt.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// completionKind returns the content kind named by a prompt argument or
+// resource template URI, or "" if it names none
+func completionKind(name string) string {
+	switch {
+	case strings.Contains(name, "machine"):
+		return tools.CompleteMachines
+	case strings.Contains(name, "challenge"):
+		return tools.CompleteChallenges
+	}
+	return ""
 }
 
 // sendErrorResponse sends an error response
@@ -182,39 +609,152 @@ func (s *Server) sendErrorResponse(id interface{}, code int, message, data strin
 	return s.sendMessage(response)
 }
 
-// sendMessage sends a message to the output
-func (s *Server) sendMessage(msg *mcp.Message) error {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+// Notify sends a server-initiated notification to the client. Resource
+// updates are only sent for resources the client subscribed to.
+func (s *Server) Notify(method string, params interface{}) error {
+	if update, ok := params.(mcp.ResourceUpdatedNotification); ok && !s.session().Subscribed(update.URI) {
+		return nil
 	}
+	return s.sendMessage(mcp.NewNotification(method, params))
+}
 
-	if _, err := fmt.Fprintf(s.output, "%s\n", data); err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
+// CreateMessage asks the client to sample an LLM completion
+func (s *Server) CreateMessage(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResponse, error) {
+	if !s.session().Supports(mcp.FeatureSampling) {
+		return nil, ErrSamplingUnsupported
 	}
 
-	return nil
+	var result mcp.CreateMessageResponse
+	if err := s.request(ctx, mcp.MethodCreateMessage, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
 }
 
-// parseParams parses message parameters into a struct
-func (s *Server) parseParams(params interface{}, target interface{}) error {
-	if params == nil {
-		return fmt.Errorf("missing parameters")
+// Elicit asks the client to collect input from the user
+func (s *Server) Elicit(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResponse, error) {
+	if !s.session().Supports(mcp.FeatureElicitation) {
+		return nil, ErrElicitationUnsupported
 	}
 
-	data, err := json.Marshal(params)
-	if err != nil {
-		return fmt.Errorf("failed to marshal params: %w", err)
+	var result mcp.ElicitResponse
+	if err := s.request(ctx, mcp.MethodElicit, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ListRoots returns the client's workspace roots, asking it with roots/list
+// on first use and after it reports a change. Clients that did not declare
+// the roots capability have none.
+func (s *Server) ListRoots(ctx context.Context) ([]mcp.Root, error) {
+	sess := s.session()
+	if !sess.Supports(mcp.FeatureRoots) {
+		return nil, nil
+	}
+	if roots, ok := sess.Roots(); ok {
+		return roots, nil
 	}
 
-	if err := json.Unmarshal(data, target); err != nil {
-		return fmt.Errorf("failed to unmarshal params: %w", err)
+	var result mcp.ListRootsResponse
+	if err := s.request(ctx, mcp.MethodListRoots, nil, &result); err != nil {
+		return nil, err
 	}
+	sess.SetRoots(result.Roots)
+	return result.Roots, nil
+}
 
-	return nil
+// RootsChanged handles notifications/roots/list_changed
+func (s *Server) RootsChanged(ctx context.Context) (interface{}, error) {
+	s.session().RootsChanged()
+	return nil, nil
+}
+
+// clientRequestTimeout bounds how long a server-initiated request waits for
+// the client's response when the caller set no deadline
+const clientRequestTimeout = 5 * time.Minute
+
+// request sends a server-initiated request and waits for the client's
+// response. If ctx ends first, the client is told to abandon the request.
+func (s *Server) request(ctx context.Context, method string, params interface{}, target interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, clientRequestTimeout)
+		defer cancel()
+	}
+
+	s.pendingMu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("srv-%d", s.nextID)
+	ch := make(chan *mcp.Message, 1)
+	s.pending[id] = ch
+	s.pendingMu.Unlock()
+
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, id)
+		s.pendingMu.Unlock()
+	}()
+
+	if err := s.sendMessage(mcp.NewRequest(id, method, params)); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		s.Notify(mcp.MethodNotificationCancelled, mcp.CancelledNotification{
+			RequestID: id,
+			Reason:    context.Cause(ctx).Error(),
+		})
+		return fmt.Errorf("no response to %s: %w", method, ctx.Err())
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("client returned error %d: %s", resp.Error.Code, resp.Error.Message)
+		}
+		return mcp.DecodeParams(resp.Result, target)
+	}
+}
+
+// handleClientResponse routes a client response to the waiting request
+func (s *Server) handleClientResponse(msg *mcp.Message) {
+	id := fmt.Sprint(msg.ID)
+
+	s.pendingMu.Lock()
+	ch, ok := s.pending[id]
+	s.pendingMu.Unlock()
+
+	if !ok {
+		s.logger.Warnf("server", "Ignoring response to unknown request %s", id)
+		return
+	}
+
+	// The channel holds one response; a duplicate must not block the read loop
+	select {
+	case ch <- msg:
+	default:
+		s.logger.Warnf("server", "Ignoring duplicate response to request %s", id)
+	}
+}
+
+// sendMessage sends a message to the output
+func (s *Server) sendMessage(msg *mcp.Message) error {
+	if err := msg.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	// Responses and notifications are sent from concurrent goroutines; the
+	// writer flushes each message whole so they never interleave
+	return s.output.WriteMessage(data)
 }
 
 // GetUptime returns the server uptime
 func (s *Server) GetUptime() time.Duration {
-	return time.Since(s.startTime)
+	return s.toolRegistry.Uptime()
 }
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// stdioConn is the client end of a server reading newline-delimited messages
+type stdioConn struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out chan *mcp.Message
+}
+
+// startStdio serves a server in front of a stub HTB API served by handler
+// and completes the initialize handshake
+func startStdio(t *testing.T, handler http.HandlerFunc) *stdioConn {
+	t.Helper()
+
+	api := httptest.NewServer(handler)
+	t.Cleanup(api.Close)
+
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     api.URL,
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Minute,
+	}
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	srv := NewWithIO(cfg, inR, outW)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Serve(ctx)
+	t.Cleanup(func() {
+		inW.Close()
+		cancel()
+		srv.Close()
+		outR.Close()
+	})
+
+	conn := &stdioConn{t: t, in: inW, out: make(chan *mcp.Message, 16)}
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg mcp.Message
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+				conn.out <- &msg
+			}
+		}
+	}()
+
+	conn.send(mcp.NewRequest(0, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		ClientInfo:      mcp.ClientInfo{Name: "stdio-test", Version: "1.0.0"},
+	}))
+	conn.receive()
+	conn.send(mcp.NewNotification(mcp.MethodNotificationInitialized, nil))
+	return conn
+}
+
+// send writes a message to the server
+func (c *stdioConn) send(msg *mcp.Message) {
+	c.t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatalf("Failed to encode message: %v", err)
+	}
+	if _, err := c.in.Write(append(data, '\n')); err != nil {
+		c.t.Fatalf("Failed to send message: %v", err)
+	}
+}
+
+// receive returns the next response, skipping notifications
+func (c *stdioConn) receive() *mcp.Message {
+	c.t.Helper()
+	for {
+		select {
+		case msg := <-c.out:
+			if msg.Method == "" {
+				return msg
+			}
+		case <-time.After(5 * time.Second):
+			c.t.Fatal("No response from the server")
+		}
+	}
+}
+
+func TestInFlightRequestIDs(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	conn := startStdio(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/machine/active" {
+			received <- struct{}{}
+			<-release
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+			return
+		}
+		w.Write([]byte(`{"info":null}`))
+	})
+	var releaseOnce sync.Once
+	t.Cleanup(func() { releaseOnce.Do(func() { close(release) }) })
+
+	conn.send(mcp.NewRequest(1, mcp.MethodCallTool, mcp.CallToolRequest{Name: "get_machine_ip", Arguments: map[string]interface{}{}}))
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tool call never reached the HTB API")
+	}
+
+	// A request reusing the ID of one in flight is refused
+	conn.send(mcp.NewRequest(1, mcp.MethodCallTool, mcp.CallToolRequest{Name: "get_machine_ip", Arguments: map[string]interface{}{}}))
+	if reply := conn.receive(); reply.ID != float64(1) || reply.Error == nil || reply.Error.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("Expected an invalid request error for a duplicate id, got %+v", reply)
+	}
+
+	// Cancelling the string "1" leaves the request with the number 1 alone
+	conn.send(mcp.NewNotification(mcp.MethodNotificationCancelled, mcp.CancelledNotification{RequestID: "1", Reason: "wrong request"}))
+	conn.send(mcp.NewRequest("1", mcp.MethodPing, nil))
+	if reply := conn.receive(); reply.ID != "1" || reply.Error != nil {
+		t.Errorf("Expected the ping with id \"1\" to be answered, got %+v", reply)
+	}
+
+	releaseOnce.Do(func() { close(release) })
+	reply := conn.receive()
+	var result mcp.CallToolResponse
+	if reply.ID != float64(1) || reply.Error != nil || json.Unmarshal(reply.Result, &result) != nil ||
+		len(result.Content) == 0 || !strings.Contains(result.Content[0].Text, "10.10.10.3") {
+		t.Errorf("Expected the tool call to complete, got %+v", reply)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultWriteTimeout bounds how long a single message write may block on a stalled client
+const defaultWriteTimeout = 10 * time.Second
+
+// deadlineWriter is implemented by outputs that support write deadlines, such as pipes and sockets
+type deadlineWriter interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// messageWriter serializes newline-delimited messages onto an output. Each
+// message is written as a whole under a mutex so concurrent responses and
+// notifications never interleave partial writes. A write that fails after
+// part of a message reached the output leaves the client mid-line with no
+// way to resynchronize, so the writer then refuses every later message.
+type messageWriter struct {
+	mu      sync.Mutex
+	dst     io.Writer
+	timeout time.Duration
+	broken  error
+}
+
+// newMessageWriter creates a message writer on dst
+func newMessageWriter(dst io.Writer, timeout time.Duration) *messageWriter {
+	return &messageWriter{
+		dst:     dst,
+		timeout: timeout,
+	}
+}
+
+// WriteMessage writes data followed by a newline to the output
+func (w *messageWriter) WriteMessage(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.broken != nil {
+		return w.broken
+	}
+
+	if dw, ok := w.dst.(deadlineWriter); ok && w.timeout > 0 {
+		// Outputs without deadline support (e.g. a terminal) report ErrNoDeadline
+		if err := dw.SetWriteDeadline(time.Now().Add(w.timeout)); err == nil {
+			defer dw.SetWriteDeadline(time.Time{})
+		} else if !errors.Is(err, os.ErrNoDeadline) {
+			return fmt.Errorf("failed to set write deadline: %w", err)
+		}
+	}
+
+	line := make([]byte, 0, len(data)+1)
+	line = append(append(line, data...), '\n')
+	n, err := w.dst.Write(line)
+	if err == nil && n < len(line) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if n > 0 {
+			w.broken = fmt.Errorf("output stream corrupted by a partial write: %w", err)
+			return w.broken
+		}
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return nil
+}
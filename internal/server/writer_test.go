@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// flakyWriter writes at most limit bytes of each write, failing when it cuts one short
+type flakyWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.limit {
+		return w.Buffer.Write(p)
+	}
+	n, _ := w.Buffer.Write(p[:w.limit])
+	return n, errors.New("write interrupted")
+}
+
+func TestMessageWriterFailedWriteWithoutOutput(t *testing.T) {
+	dst := &flakyWriter{limit: 0}
+	w := newMessageWriter(dst, 0)
+
+	if err := w.WriteMessage([]byte(`{"id":1}`)); err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+
+	// Nothing reached the output, so the stream is still usable
+	dst.limit = 100
+	if err := w.WriteMessage([]byte(`{"id":2}`)); err != nil {
+		t.Fatalf("Expected the next write to succeed, got %v", err)
+	}
+	if got := dst.String(); got != "{\"id\":2}\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+}
+
+func TestMessageWriterPartialWriteIsFatal(t *testing.T) {
+	dst := &flakyWriter{limit: 4}
+	w := newMessageWriter(dst, 0)
+
+	if err := w.WriteMessage([]byte(`{"id":1}`)); err == nil {
+		t.Fatal("Expected the partial write to fail")
+	}
+
+	// Half a line is on the output, so nothing more may follow it
+	dst.limit = 100
+	if err := w.WriteMessage([]byte(`{"id":2}`)); err == nil {
+		t.Error("Expected writes after a partial write to fail")
+	}
+	if got := dst.String(); got != `{"id` {
+		t.Errorf("Expected only the partial write on the output, got %q", got)
+	}
+}
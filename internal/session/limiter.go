@@ -0,0 +1,84 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket allowing a burst of up to perMinute operations
+// and refilling at perMinute operations per minute
+type Limiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	perSec   float64
+	last     time.Time
+}
+
+// NewLimiter creates a limiter, or returns nil if perMinute is not positive
+func NewLimiter(perMinute int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		tokens:   float64(perMinute),
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		last:     time.Now(),
+	}
+}
+
+// Allow consumes a token and reports whether the operation may proceed. A nil
+// limiter always allows.
+func (l *Limiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.perSec)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Remaining returns the number of operations currently available
+func (l *Limiter) Remaining() int {
+	if l == nil {
+		return -1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(min(l.capacity, l.tokens+time.Since(l.last).Seconds()*l.perSec))
+}
+
+// Capacity returns the maximum burst of operations, or -1 for a nil limiter
+func (l *Limiter) Capacity() int {
+	if l == nil {
+		return -1
+	}
+	return int(l.capacity)
+}
+
+// FullIn returns how long until the limiter is refilled to capacity
+func (l *Limiter) FullIn() time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tokens := min(l.capacity, l.tokens+time.Since(l.last).Seconds()*l.perSec)
+	return time.Duration((l.capacity - tokens) / l.perSec * float64(time.Second))
+}
@@ -0,0 +1,318 @@
+// Package session scopes per-client state to MCP sessions so that clients
+// sharing one server process don't see each other's history or exhaust each
+// other's rate budget.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Session holds the state owned by a single MCP client session
+type Session struct {
+	ID        string
+	CreatedAt time.Time
+
+	// Notes holds the session's notes and submission history, on top of
+	// the entries of work not tied to a session
+	Notes *notes.Store
+
+	// Limiter bounds the session's tool calls per minute, and Requests all
+	// of its requests per minute; nil means unlimited
+	Limiter  *Limiter
+	Requests *Limiter
+
+	// MaxInFlightCalls bounds the session's concurrent tool calls; 0 means
+	// unlimited
+	MaxInFlightCalls int
+
+	callsMu  sync.Mutex
+	inFlight int
+
+	clientMu    sync.RWMutex
+	client      *Client
+	initialized bool
+	lastPing    time.Time
+
+	subscriptionsMu sync.RWMutex
+	subscriptions   map[string]bool
+
+	rootsMu    sync.RWMutex
+	roots      []mcp.Root
+	rootsKnown bool
+
+	// Machine the session's client last spawned, which background
+	// notifications about it are sent to
+	machineMu sync.RWMutex
+	machineID int
+}
+
+// Client describes the client connected to a session, as declared in its
+// initialize request. ProtocolVersion is the version negotiated with it.
+type Client struct {
+	Info            mcp.ClientInfo         `json:"client_info"`
+	ProtocolVersion string                 `json:"protocol_version"`
+	Capabilities    mcp.ClientCapabilities `json:"capabilities"`
+	Features        []string               `json:"features"`
+	InitializedAt   time.Time              `json:"initialized_at"`
+}
+
+// SetClient records the client declared by an initialize request
+func (s *Session) SetClient(req *mcp.InitializeRequest) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	s.client = &Client{
+		Info:            req.ClientInfo,
+		ProtocolVersion: mcp.NegotiateVersion(req.ProtocolVersion),
+		Capabilities:    req.Capabilities,
+		Features:        req.Capabilities.Features(),
+		InitializedAt:   time.Now(),
+	}
+}
+
+// Client returns the connected client, or false before initialize
+func (s *Session) Client() (*Client, bool) {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.client, s.client != nil
+}
+
+// SetInitialized records the client's notifications/initialized, reporting
+// false if it arrived before initialize
+func (s *Session) SetInitialized() bool {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	if s.client == nil {
+		return false
+	}
+	s.initialized = true
+	return true
+}
+
+// Ready reports whether the session completed the initialize handshake:
+// initialize was answered and the client sent notifications/initialized
+func (s *Session) Ready() bool {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.initialized
+}
+
+// Pinged records a ping from the session's client
+func (s *Session) Pinged() {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	s.lastPing = time.Now()
+}
+
+// LastPing returns when the client last pinged, or false if it never has
+func (s *Session) LastPing() (time.Time, bool) {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+	return s.lastPing, !s.lastPing.IsZero()
+}
+
+// SetMachine records the machine the session's client spawned
+func (s *Session) SetMachine(machineID int) {
+	s.machineMu.Lock()
+	defer s.machineMu.Unlock()
+	s.machineID = machineID
+}
+
+// Machine returns the machine the session's client last spawned, or false
+// if it has not spawned one
+func (s *Session) Machine() (int, bool) {
+	s.machineMu.RLock()
+	defer s.machineMu.RUnlock()
+	return s.machineID, s.machineID != 0
+}
+
+// Subscribe records the client's subscription to updates of a resource
+func (s *Session) Subscribe(uri string) {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = make(map[string]bool)
+	}
+	s.subscriptions[uri] = true
+}
+
+// Unsubscribe removes a subscription recorded by Subscribe
+func (s *Session) Unsubscribe(uri string) {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	delete(s.subscriptions, uri)
+}
+
+// Subscribed reports whether the client subscribed to updates of a resource
+func (s *Session) Subscribed(uri string) bool {
+	s.subscriptionsMu.RLock()
+	defer s.subscriptionsMu.RUnlock()
+	return s.subscriptions[uri]
+}
+
+// SetRoots caches the workspace roots listed by the client
+func (s *Session) SetRoots(roots []mcp.Root) {
+	s.rootsMu.Lock()
+	defer s.rootsMu.Unlock()
+	s.roots, s.rootsKnown = roots, true
+}
+
+// Roots returns the cached workspace roots, or false if they have not been
+// listed since the client last reported a change
+func (s *Session) Roots() ([]mcp.Root, bool) {
+	s.rootsMu.RLock()
+	defer s.rootsMu.RUnlock()
+	return s.roots, s.rootsKnown
+}
+
+// RootsChanged drops the cached roots so they are listed again on next use
+func (s *Session) RootsChanged() {
+	s.rootsMu.Lock()
+	defer s.rootsMu.Unlock()
+	s.roots, s.rootsKnown = nil, false
+}
+
+// StartCall reserves a slot for a tool call, reporting false if the session
+// already has MaxInFlightCalls in progress. EndCall must be called once a
+// reserved call finishes.
+func (s *Session) StartCall() bool {
+	s.callsMu.Lock()
+	defer s.callsMu.Unlock()
+
+	if s.MaxInFlightCalls > 0 && s.inFlight >= s.MaxInFlightCalls {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// EndCall releases a slot reserved by StartCall
+func (s *Session) EndCall() {
+	s.callsMu.Lock()
+	defer s.callsMu.Unlock()
+	s.inFlight--
+}
+
+// Supports reports whether the session's client declared the given feature
+func (s *Session) Supports(feature string) bool {
+	client, ok := s.Client()
+	return ok && client.Capabilities.Supports(feature)
+}
+
+// SupportsStructuredContent reports whether the session's client understands
+// structuredContent results and resource_link content, either from its
+// protocol version or by declaring the experimental capability
+func (s *Session) SupportsStructuredContent() bool {
+	client, ok := s.Client()
+	if !ok {
+		return false
+	}
+	return mcp.SupportsStructuredContent(client.ProtocolVersion) || client.Capabilities.Supports(mcp.FeatureStructuredContent)
+}
+
+// Limits bounds the load each session may put on the server and the HTB
+// account; zero values disable a limit
+type Limits struct {
+	ToolCallsPerMinute int
+	RequestsPerMinute  int
+	MaxInFlightCalls   int
+}
+
+// Manager creates and tracks sessions by ID
+type Manager struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	limits   Limits
+	notes    *notes.Store
+}
+
+// NewManager creates a session manager whose sessions are bound by limits.
+// Session notes are layered on shared, the store of work not tied to a
+// session; other sessions never see them.
+func NewManager(limits Limits, shared *notes.Store) *Manager {
+	return &Manager{
+		sessions: make(map[string]*Session),
+		limits:   limits,
+		notes:    shared,
+	}
+}
+
+// Get returns the session with the given ID, creating it on first use
+func (m *Manager) Get(id string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		return s
+	}
+
+	s := &Session{
+		ID:        id,
+		CreatedAt: time.Now(),
+		Notes:     notes.NewSessionStore(m.notes),
+		Limiter:   NewLimiter(m.limits.ToolCallsPerMinute),
+		Requests:  NewLimiter(m.limits.RequestsPerMinute),
+
+		MaxInFlightCalls: m.limits.MaxInFlightCalls,
+	}
+	m.sessions[id] = s
+	return s
+}
+
+// Remove discards a session and its state, including its notes
+func (m *Manager) Remove(id string) {
+	m.mu.Lock()
+	s := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if s != nil {
+		s.Notes.Release()
+	}
+}
+
+// NewID returns a random session ID
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying s
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the session carried by ctx, if any
+func FromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(*Session)
+	return s, ok && s != nil
+}
+
+// NotesFrom returns the notes store of the session carried by ctx, or
+// fallback for work not tied to a session (e.g. background jobs)
+func NotesFrom(ctx context.Context, fallback *notes.Store) *notes.Store {
+	if s, ok := FromContext(ctx); ok {
+		return s.Notes
+	}
+	return fallback
+}
+
+// ClientSupports reports whether the client of the session carried by ctx
+// declared the given feature. Work not tied to a session has no client.
+func ClientSupports(ctx context.Context, feature string) bool {
+	s, ok := FromContext(ctx)
+	return ok && s.Supports(feature)
+}
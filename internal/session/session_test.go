@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+)
+
+func TestManagerDiscardsNotes(t *testing.T) {
+	shared := notes.NewStore()
+	m := NewManager(Limits{}, shared)
+
+	first, second := m.Get("first"), m.Get("second")
+	if m.Get("first") != first {
+		t.Fatal("Expected the same session for the same ID")
+	}
+
+	first.Notes.Add(notes.Entry{Kind: notes.KindFlag, MachineID: 1, Text: "Submitted user flag"})
+	if entries := second.Notes.Entries(); len(entries) != 0 {
+		t.Errorf("Expected sessions not to see each other's notes, got %+v", entries)
+	}
+
+	// Ended sessions don't leak their history to later ones
+	m.Remove("first")
+	if entries := second.Notes.Entries(); len(entries) != 0 {
+		t.Errorf("Expected the ended session's notes to stay hidden, got %+v", entries)
+	}
+	if entries := m.Get("third").Notes.Entries(); len(entries) != 0 {
+		t.Errorf("Expected a new session to start without notes, got %+v", entries)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(2)
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("Expected the budget to allow two calls")
+	}
+	if l.Allow() {
+		t.Error("Expected a third call within the minute to be refused")
+	}
+	if l.Remaining() != 0 || l.Capacity() != 2 {
+		t.Errorf("Expected an exhausted budget of 2, got %d of %d", l.Remaining(), l.Capacity())
+	}
+
+	unlimited := NewLimiter(0)
+	for i := 0; i < 100; i++ {
+		if !unlimited.Allow() {
+			t.Fatal("Expected a zero limit to allow every call")
+		}
+	}
+}
+
+func TestSessionMachine(t *testing.T) {
+	s := NewManager(Limits{}, nil).Get("id")
+	if _, ok := s.Machine(); ok {
+		t.Error("Expected no machine before a spawn")
+	}
+	s.SetMachine(42)
+	if id, ok := s.Machine(); !ok || id != 42 {
+		t.Errorf("Expected machine 42, got %d, %v", id, ok)
+	}
+}
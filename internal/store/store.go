@@ -0,0 +1,119 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// record is a single persisted value with the time it was saved
+type record struct {
+	SavedAt time.Time       `json:"saved_at"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Store is a small persistent key/value store backed by a JSON file. With an
+// empty path it keeps values in memory only.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]record
+}
+
+// Open loads the store at path, starting empty if the file does not exist or cannot be read
+func Open(path string) *Store {
+	s := &Store{
+		path:    path,
+		records: make(map[string]record),
+	}
+
+	if path == "" {
+		return s
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read state file %s: %v", path, err)
+		}
+		return s
+	}
+
+	if err := json.Unmarshal(data, &s.records); err != nil {
+		log.Printf("Ignoring corrupt state file %s: %v", path, err)
+		s.records = make(map[string]record)
+	}
+
+	return s
+}
+
+// Put stores value under key and persists the store to disk
+func (s *Store) Put(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record{SavedAt: time.Now().UTC(), Data: data}
+	return s.save()
+}
+
+// Get decodes the value stored under key into target and returns when it was saved
+func (s *Store) Get(key string, target interface{}) (time.Time, bool, error) {
+	s.mu.Lock()
+	rec, ok := s.records[key]
+	s.mu.Unlock()
+
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if err := json.Unmarshal(rec.Data, target); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to unmarshal stored value: %w", err)
+	}
+
+	return rec.SavedAt, true, nil
+}
+
+// SavedAt returns when the value under key was saved, without decoding it
+func (s *Store) SavedAt(key string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	return rec.SavedAt, ok
+}
+
+// save atomically writes the store to disk; callers must hold mu
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// errAcademyNotConfigured is returned by Academy tools when no Academy token is set
+var errAcademyNotConfigured = fmt.Errorf("HTB Academy access is not configured: set ACADEMY_TOKEN")
+
+// GetCertificationStatus tool for tracking Academy certification exam readiness
+type GetCertificationStatus struct {
+	client *htb.Client
+}
+
+// NewGetCertificationStatus creates the tool; client is nil when Academy access is not configured
+func NewGetCertificationStatus(client *htb.Client) *GetCertificationStatus {
+	return &GetCertificationStatus{client: client}
+}
+
+func (t *GetCertificationStatus) Name() string {
+	return "get_certification_status"
+}
+
+func (t *GetCertificationStatus) Description() string {
+	return "Report HTB Academy certification (CPTS, CBBH, CDSA, ...) voucher status, exam attempts and prerequisite module completion to track exam readiness"
+}
+
+func (t *GetCertificationStatus) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"certification": {
+				Type:        "string",
+				Description: "Certification short name such as CPTS, CBBH or CDSA (defaults to all)",
+			},
+		},
+	}
+}
+
+func (t *GetCertificationStatus) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	if t.client == nil {
+		return nil, errAcademyNotConfigured
+	}
+
+	filter, _ := args["certification"].(string)
+
+	exams, err := t.client.GetCertificationExams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certification exams: %w", err)
+	}
+
+	var results []map[string]interface{}
+	for _, exam := range exams {
+		if filter != "" && !strings.EqualFold(exam.ShortName, filter) {
+			continue
+		}
+
+		status := map[string]interface{}{
+			"certification":      exam.ShortName,
+			"name":               exam.Name,
+			"exam_status":        exam.Status,
+			"voucher_status":     exam.VoucherStatus,
+			"attempts_used":      exam.AttemptsUsed,
+			"attempts_total":     exam.AttemptsTotal,
+			"attempts_remaining": max(exam.AttemptsTotal-exam.AttemptsUsed, 0),
+		}
+		if exam.VoucherExpiresAt != "" {
+			status["voucher_expires_at"] = exam.VoucherExpiresAt
+		}
+
+		if exam.PathID != 0 {
+			modules, err := t.client.GetPathModules(ctx, exam.PathID)
+			if err != nil {
+				status["prerequisites_error"] = err.Error()
+			} else {
+				status["prerequisites"] = prerequisiteSummary(modules)
+			}
+		}
+
+		results = append(results, status)
+	}
+
+	if filter != "" && len(results) == 0 {
+		return nil, fmt.Errorf("certification %q not found", filter)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// prerequisiteSummary summarizes completion of a certification's required path modules
+func prerequisiteSummary(modules []htb.PathModule) map[string]interface{} {
+	completed := 0
+	incomplete := []string{}
+	for _, module := range modules {
+		if module.Completed {
+			completed++
+		} else {
+			incomplete = append(incomplete, module.Name)
+		}
+	}
+
+	percentage := 0.0
+	if len(modules) > 0 {
+		percentage = float64(completed) / float64(len(modules)) * 100
+	}
+
+	return map[string]interface{}{
+		"completed":          completed,
+		"total":              len(modules),
+		"percentage":         percentage,
+		"incomplete_modules": incomplete,
+		"all_complete":       len(modules) > 0 && len(incomplete) == 0,
+	}
+}
@@ -0,0 +1,369 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/fanout"
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// analyticsMaxProfiles bounds the machine profile lookups made per analytics run
+const analyticsMaxProfiles = 100
+
+// analyticsConcurrency bounds the profile lookups get_solve_analytics has in flight
+const analyticsConcurrency = 8
+
+// GetSolveAnalytics tool for computing historical solve rates
+type GetSolveAnalytics struct {
+	client *htb.Client
+	notes  *notes.Store
+}
+
+func NewGetSolveAnalytics(client *htb.Client, store *notes.Store) *GetSolveAnalytics {
+	return &GetSolveAnalytics{client: client, notes: store}
+}
+
+func (t *GetSolveAnalytics) Name() string {
+	return "get_solve_analytics"
+}
+
+func (t *GetSolveAnalytics) Description() string {
+	return "Compute your historical machine success rate (root owns over machines owned at all) from your HTB owns, and median time-to-own from local session data, per difficulty and OS, to support data-driven target selection"
+}
+
+func (t *GetSolveAnalytics) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+// machineAttempt is what is known about one machine the user has worked on
+type machineAttempt struct {
+	spawnedAt   time.Time
+	userOwnedAt time.Time
+	rootOwnedAt time.Time
+}
+
+// solveStats aggregates attempts within one difficulty or OS bucket
+type solveStats struct {
+	Owned              int      `json:"owned"`
+	UserOwned          int      `json:"user_owned"`
+	RootOwned          int      `json:"root_owned"`
+	SuccessRate        float64  `json:"success_rate"`
+	MedianMinutesToOwn *float64 `json:"median_minutes_to_own,omitempty"`
+	TimedOwns          int      `json:"timed_owns"`
+	minutesToOwn       []float64
+}
+
+func (t *GetSolveAnalytics) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	// HTB owns provide the full own history, and so the success rate
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	activity, err := t.client.GetUserActivity(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+	attempts := machineAttempts(activity, session.NotesFrom(ctx, t.notes).Entries())
+
+	ids := make([]int, 0, len(attempts))
+	for id := range attempts {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	skipped := 0
+	if len(ids) > analyticsMaxProfiles {
+		skipped = len(ids) - analyticsMaxProfiles
+		ids = ids[:analyticsMaxProfiles]
+	}
+
+	// Profiles provide difficulty and OS; look them up concurrently
+	fetches := make(map[string]fanout.Fetch, len(ids))
+	for _, id := range ids {
+		id := strconv.Itoa(id)
+		fetches[id] = func(ctx context.Context) (interface{}, error) {
+			return t.client.GetMachineProfile(ctx, id)
+		}
+	}
+	profiles := fanout.Run(ctx, analyticsConcurrency, fetches)
+
+	byDifficulty := make(map[string]*solveStats)
+	byOS := make(map[string]*solveStats)
+	for _, id := range ids {
+		profile, ok := profiles.Values[strconv.Itoa(id)].(*htb.MachineProfile)
+		if !ok {
+			skipped++
+			continue
+		}
+		addAttempt(byDifficulty, string(profile.DifficultyText), attempts[id])
+		addAttempt(byOS, profile.OS, attempts[id])
+	}
+
+	result := map[string]interface{}{
+		"machines_considered": len(attempts) - skipped,
+		"by_difficulty":       finishStats(byDifficulty),
+		"by_os":               finishStats(byOS),
+		"definitions":         "success_rate is root owns over machines with any own in the HTB owns history, so footholds that never led to root count against it; time to own runs from the first spawn recorded in this session to the root (or user) own, so only machines spawned through this server are timed",
+	}
+	if skipped > 0 {
+		result["machines_skipped"] = skipped
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// machineAttempts collects the machines in the user's own history, with the
+// first spawn recorded for each so their owns can be timed. Spawns without an
+// own are left out: session notes only cover this session, so counting them
+// would skew the historical rate.
+func machineAttempts(activity []htb.ActivityItem, entries []notes.Entry) map[int]*machineAttempt {
+	attempts := make(map[int]*machineAttempt)
+	for _, item := range activity {
+		if item.ObjectType != "machine" {
+			continue
+		}
+		ownedAt, err := htb.ParseTime(item.Date)
+		if err != nil {
+			continue
+		}
+		a, ok := attempts[item.ID]
+		if !ok {
+			a = &machineAttempt{}
+			attempts[item.ID] = a
+		}
+		if item.Type == "root" {
+			a.rootOwnedAt = ownedAt
+		} else {
+			a.userOwnedAt = ownedAt
+		}
+	}
+
+	for _, entry := range entries {
+		a, ok := attempts[entry.MachineID]
+		if entry.Kind != notes.KindSpawn || !ok {
+			continue
+		}
+		if a.spawnedAt.IsZero() || entry.Time.Before(a.spawnedAt) {
+			a.spawnedAt = entry.Time
+		}
+	}
+
+	return attempts
+}
+
+// addAttempt records a machine attempt in the bucket for key
+func addAttempt(buckets map[string]*solveStats, key string, a *machineAttempt) {
+	if key == "" {
+		key = "Unknown"
+	}
+	stats, ok := buckets[key]
+	if !ok {
+		stats = &solveStats{}
+		buckets[key] = stats
+	}
+
+	stats.Owned++
+	if !a.userOwnedAt.IsZero() {
+		stats.UserOwned++
+	}
+	if !a.rootOwnedAt.IsZero() {
+		stats.RootOwned++
+	}
+
+	ownedAt := a.rootOwnedAt
+	if ownedAt.IsZero() {
+		ownedAt = a.userOwnedAt
+	}
+	if !a.spawnedAt.IsZero() && ownedAt.After(a.spawnedAt) {
+		stats.minutesToOwn = append(stats.minutesToOwn, ownedAt.Sub(a.spawnedAt).Minutes())
+	}
+}
+
+// finishStats computes rates and medians for every bucket
+func finishStats(buckets map[string]*solveStats) map[string]*solveStats {
+	for _, stats := range buckets {
+		if stats.Owned > 0 {
+			stats.SuccessRate = float64(stats.RootOwned) / float64(stats.Owned)
+		}
+		stats.TimedOwns = len(stats.minutesToOwn)
+		if len(stats.minutesToOwn) > 0 {
+			m := median(stats.minutesToOwn)
+			stats.MedianMinutesToOwn = &m
+		}
+	}
+	return buckets
+}
+
+// median returns the median of values
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// solvePeriod counts the solves of a challenge in one week or month
+type solvePeriod struct {
+	Period     string `json:"period"`
+	Solves     int    `json:"solves"`
+	Cumulative int    `json:"cumulative"`
+}
+
+// GetChallengeStats tool for challenge solve statistics
+type GetChallengeStats struct {
+	client     *htb.Client
+	challenges *challengeResolver
+}
+
+func NewGetChallengeStats(client *htb.Client, challenges *challengeResolver) *GetChallengeStats {
+	return &GetChallengeStats{client: client, challenges: challenges}
+}
+
+func (t *GetChallengeStats) Name() string {
+	return "get_challenge_stats"
+}
+
+func (t *GetChallengeStats) Description() string {
+	return "Get solve statistics for a challenge: solve counts over time, first blood holder and time to blood, average rating and likes"
+}
+
+func (t *GetChallengeStats) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"challenge_id":   challengeIDProperty,
+			"challenge_name": challengeNameProperty,
+			"interval": {
+				Type:        "string",
+				Description: "Period to group solves by",
+				Enum:        []string{"week", "month"},
+				Default:     "month",
+			},
+		},
+	}
+}
+
+func (t *GetChallengeStats) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	challengeID, err := t.challenges.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := "month"
+	if i, ok := args["interval"].(string); ok && i != "" {
+		if i != "week" && i != "month" {
+			return nil, fmt.Errorf("interval must be week or month, got %q", i)
+		}
+		interval = i
+	}
+
+	info, err := t.client.GetChallengeInfo(ctx, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge info: %w", err)
+	}
+
+	activity, err := t.client.GetChallengeActivity(ctx, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get challenge activity: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"challenge": map[string]interface{}{
+			"id":         info.ID,
+			"name":       info.Name,
+			"category":   info.Category,
+			"difficulty": info.Difficulty,
+			"released":   info.ReleaseDate,
+			"retired":    info.Retired,
+		},
+		"solves":           info.Solves,
+		"average_rating":   float64(info.Stars),
+		"likes":            info.Likes,
+		"dislikes":         info.Dislikes,
+		"solves_over_time": solvesOverTime(activity, interval),
+		"activity_solves":  len(activity),
+	}
+	if votes := info.Likes + info.Dislikes; votes > 0 {
+		result["like_ratio"] = float64(info.Likes) / float64(votes)
+	}
+
+	if info.FirstBloodUser != "" {
+		blood := map[string]interface{}{
+			"user":    info.FirstBloodUser,
+			"user_id": info.FirstBloodUserID,
+			"time":    info.FirstBloodTime,
+		}
+		released, relErr := htb.ParseTime(info.ReleaseDate)
+		blooded, bloodErr := htb.ParseTime(info.FirstBloodTime)
+		if relErr == nil && bloodErr == nil && blooded.After(released) {
+			blood["time_to_blood"] = blooded.Sub(released).Round(time.Second).String()
+		}
+		result["first_blood"] = blood
+	}
+	if len(activity) < info.Solves {
+		result["note"] = "solves_over_time only covers solves returned by the activity feed, which may omit older solves"
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// solvesOverTime groups solves by week (ISO week) or month, oldest first
+func solvesOverTime(activity []htb.ChallengeActivity, interval string) []solvePeriod {
+	counts := make(map[string]int)
+	for _, item := range activity {
+		solvedAt, err := htb.ParseTime(item.CreatedAt)
+		if err != nil {
+			continue
+		}
+		period := solvedAt.Format("2006-01")
+		if interval == "week" {
+			year, week := solvedAt.ISOWeek()
+			period = fmt.Sprintf("%d-W%02d", year, week)
+		}
+		counts[period]++
+	}
+
+	periods := make([]solvePeriod, 0, len(counts))
+	for period, solves := range counts {
+		periods = append(periods, solvePeriod{Period: period, Solves: solves})
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period < periods[j].Period
+	})
+
+	cumulative := 0
+	for i := range periods {
+		cumulative += periods[i].Solves
+		periods[i].Cumulative = cumulative
+	}
+	return periods
+}
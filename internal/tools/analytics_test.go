@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestSolveRateFromOwnHistory(t *testing.T) {
+	activity := []htb.ActivityItem{
+		{ObjectType: "machine", Type: "user", ID: 1, Date: "2026-01-01T10:00:00Z"},
+		{ObjectType: "machine", Type: "root", ID: 1, Date: "2026-01-01T12:00:00Z"},
+		{ObjectType: "machine", Type: "user", ID: 2, Date: "2026-02-01T10:00:00Z"},
+		{ObjectType: "challenge", ID: 3, Date: "2026-02-02T10:00:00Z"},
+	}
+	spawned := time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)
+	entries := []notes.Entry{
+		{Kind: notes.KindSpawn, MachineID: 1, Time: spawned},
+		{Kind: notes.KindSpawn, MachineID: 1, Time: spawned.Add(10 * time.Minute)},
+		// Spawned this session but never owned: not part of the historical rate
+		{Kind: notes.KindSpawn, MachineID: 9, Time: spawned},
+	}
+
+	attempts := machineAttempts(activity, entries)
+	if len(attempts) != 2 || attempts[9] != nil {
+		t.Fatalf("Expected the two owned machines, got %+v", attempts)
+	}
+	if !attempts[1].spawnedAt.Equal(spawned) {
+		t.Errorf("Expected the first spawn to time the own, got %v", attempts[1].spawnedAt)
+	}
+
+	buckets := make(map[string]*solveStats)
+	for _, a := range attempts {
+		addAttempt(buckets, "Easy", a)
+	}
+	stats := finishStats(buckets)["Easy"]
+	if stats.Owned != 2 || stats.RootOwned != 1 || stats.SuccessRate != 0.5 {
+		t.Errorf("Expected one root of two owned machines, got %+v", stats)
+	}
+	if stats.TimedOwns != 1 || stats.MedianMinutesToOwn == nil || *stats.MedianMinutesToOwn != 60 {
+		t.Errorf("Expected one own timed at 60 minutes, got %+v", stats)
+	}
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// sampleText asks the client's LLM to answer prompt and returns the reply
+// text and the model used
+func sampleText(ctx context.Context, sample sampleFunc, systemPrompt, prompt string, maxTokens int) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, samplingTimeout)
+	defer cancel()
+
+	resp, err := sample(ctx, &mcp.CreateMessageRequest{
+		SystemPrompt: systemPrompt,
+		Messages: []mcp.SamplingMessage{
+			{
+				Role:    "user",
+				Content: mcp.CreateTextContent(prompt),
+			},
+		},
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	if resp.Content.Type != "text" || resp.Content.Text == "" {
+		return "", "", fmt.Errorf("client returned no text content")
+	}
+
+	return strings.TrimSpace(resp.Content.Text), resp.Model, nil
+}
+
+// maxTokensArg returns the max_tokens argument, or def if unset
+func maxTokensArg(args map[string]interface{}, def int) int {
+	if m, ok := args["max_tokens"].(float64); ok && m > 0 {
+		return int(m)
+	}
+	return def
+}
+
+// defaultActivitySummaryMaxTokens is the default sampling budget for activity summaries
+const defaultActivitySummaryMaxTokens = 300
+
+// SummarizeActivity tool for summarizing a user's activity feed
+type SummarizeActivity struct {
+	client *htb.Client
+	sample sampleFunc
+}
+
+func NewSummarizeActivity(client *htb.Client, sample sampleFunc) *SummarizeActivity {
+	return &SummarizeActivity{client: client, sample: sample}
+}
+
+func (t *SummarizeActivity) Name() string {
+	return "summarize_activity"
+}
+
+func (t *SummarizeActivity) Description() string {
+	return "Summarize a HackTheBox user's recent activity feed (owns, bloods, focus areas) using client-side sampling, falling back to the raw feed when sampling is unavailable"
+}
+
+func (t *SummarizeActivity) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "User ID (defaults to the authenticated user)",
+			},
+			"max_tokens": {
+				Type:        "integer",
+				Description: "Maximum tokens for the summary",
+				Default:     defaultActivitySummaryMaxTokens,
+			},
+		},
+	}
+}
+
+func (t *SummarizeActivity) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var userID int
+	if id, ok := args["user_id"].(float64); ok {
+		userID = int(id)
+	} else {
+		user, err := t.client.GetUserInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user info: %w", err)
+		}
+		userID = user.ID
+	}
+
+	// Make API request
+	activity, err := t.client.GetUserActivity(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"user_id":        userID,
+		"activity_count": len(activity),
+	}
+
+	if len(activity) == 0 {
+		result["summary"] = "No recent activity"
+	} else {
+		var b strings.Builder
+		for _, item := range activity {
+			fmt.Fprintf(&b, "- %s: %s %s own of %s", item.Date, item.ObjectType, item.Type, item.Name)
+			if item.ChallengeCat != "" {
+				fmt.Fprintf(&b, " (%s)", item.ChallengeCat)
+			}
+			if item.FirstBlood {
+				b.WriteString(", first blood")
+			}
+			b.WriteString("\n")
+		}
+
+		summary, model, err := sampleText(ctx, t.sample,
+			"You summarize HackTheBox activity feeds. Reply with a short paragraph covering the pace of owns, notable achievements such as first bloods, and which operating systems or challenge categories the user has been focusing on.",
+			"Summarize this activity feed:\n\n"+b.String(),
+			maxTokensArg(args, defaultActivitySummaryMaxTokens))
+		if err != nil {
+			result["summary_unavailable"] = err.Error()
+			result["activity"] = activity
+		} else {
+			result["summary"] = summary
+			result["model"] = model
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// defaultReconPlanMaxTokens is the default sampling budget for recon plans
+const defaultReconPlanMaxTokens = 600
+
+// PlanRecon tool for drafting a reconnaissance plan for a machine
+type PlanRecon struct {
+	client   *htb.Client
+	machines *machineResolver
+	sample   sampleFunc
+}
+
+func NewPlanRecon(client *htb.Client, machines *machineResolver, sample sampleFunc) *PlanRecon {
+	return &PlanRecon{client: client, machines: machines, sample: sample}
+}
+
+func (t *PlanRecon) Name() string {
+	return "plan_recon"
+}
+
+func (t *PlanRecon) Description() string {
+	return "Draft a spoiler-free reconnaissance plan for a HackTheBox machine from its profile (OS, difficulty, IP) using client-side sampling"
+}
+
+func (t *PlanRecon) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id":   machineIDProperty,
+			"machine_name": machineNameProperty,
+			"max_tokens": {
+				Type:        "integer",
+				Description: "Maximum tokens for the plan",
+				Default:     defaultReconPlanMaxTokens,
+			},
+		},
+	}
+}
+
+func (t *PlanRecon) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make API request
+	profile, err := t.client.GetMachineProfile(ctx, fmt.Sprint(machineID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine profile: %w", err)
+	}
+
+	target := profile.IP
+	if target == "" {
+		target = "<target IP, not assigned until the machine is spawned>"
+	}
+	prompt := fmt.Sprintf("Machine: %s\nOS: %s\nDifficulty: %s\nTarget: %s\n", profile.Name, profile.OS, profile.DifficultyText, target)
+
+	plan, model, err := sampleText(ctx, t.sample,
+		"You plan reconnaissance for HackTheBox machines. Reply with a numbered list of enumeration steps with example commands (port scanning, service and version detection, web and share enumeration) suited to the OS and difficulty. Do not guess at or reveal the intended exploitation path.",
+		"Draft a recon plan for:\n\n"+prompt,
+		maxTokensArg(args, defaultReconPlanMaxTokens))
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft recon plan: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"machine_id":   profile.ID,
+		"machine_name": profile.Name,
+		"os":           profile.OS,
+		"difficulty":   profile.DifficultyText,
+		"ip":           profile.IP,
+		"plan":         plan,
+		"model":        model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
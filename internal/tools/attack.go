@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// GetAttackContext tool for bundling attacker and target details for exploit generation
+type GetAttackContext struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewGetAttackContext(client *htb.Client, machines *machineResolver) *GetAttackContext {
+	return &GetAttackContext{client: client, machines: machines}
+}
+
+func (t *GetAttackContext) Name() string {
+	return "get_attack_context"
+}
+
+func (t *GetAttackContext) Description() string {
+	return "Get attacker VPN IP, target machine IP and OS, and running instance details in one structured response, ready to drop into exploit-generation prompts"
+}
+
+func (t *GetAttackContext) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "Optional target machine ID. Defaults to the active (or release-arena) machine",
+			},
+			"machine_name": machineNameProperty,
+		},
+	}
+}
+
+// attackTarget describes the target machine of an attack context
+type attackTarget struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	IP         string `json:"ip,omitempty"`
+	OS         string `json:"os,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Running    bool   `json:"running"`
+	Source     string `json:"source,omitempty"`
+	Type       string `json:"type,omitempty"`
+	LabServer  string `json:"lab_server,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	Spawning   bool   `json:"spawning,omitempty"`
+}
+
+// attackContext is the response of get_attack_context
+type attackContext struct {
+	AttackerIP string        `json:"attacker_ip,omitempty"`
+	TargetIP   string        `json:"target_ip,omitempty"`
+	TargetOS   string        `json:"target_os,omitempty"`
+	Target     *attackTarget `json:"target,omitempty"`
+	Attacker   *labIPInfo    `json:"attacker"`
+	Warnings   []string      `json:"warnings,omitempty"`
+}
+
+func (t *GetAttackContext) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	result := attackContext{
+		Attacker: detectLabIP(ctx, t.client),
+	}
+	result.AttackerIP = result.Attacker.LabIP
+	if result.AttackerIP == "" {
+		result.Warnings = append(result.Warnings, "Attacker VPN IP unknown; reverse shells need a lab VPN connection")
+	}
+
+	wantID := 0
+	if hasMachineTarget(args) {
+		machineID, err := t.machines.resolve(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		wantID = machineID
+	}
+
+	target, err := t.target(ctx, wantID)
+	if err != nil {
+		return nil, err
+	}
+	result.Target = target
+
+	switch {
+	case target == nil:
+		result.Warnings = append(result.Warnings, "No machine is running; start one or pass machine_id")
+	case !target.Running:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Machine %s is not running; start it to get a target IP", target.Name))
+	case target.Spawning || target.IP == "":
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Machine %s is still spawning; the target IP may not be assigned yet", target.Name))
+	}
+	if target != nil {
+		result.TargetIP = target.IP
+		result.TargetOS = target.OS
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// target returns the running instance matching machineID (any running machine
+// if 0), enriched with the machine profile. It returns nil if no machine is
+// wanted and none is running.
+func (t *GetAttackContext) target(ctx context.Context, machineID int) (*attackTarget, error) {
+	var running *htb.ActiveMachineInfo
+	source := ""
+	active, err := t.client.GetActiveMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active machine: %w", err)
+	}
+	if active != nil && (machineID == 0 || active.ID == machineID) {
+		running, source = active, "active"
+	} else {
+		arena, err := t.client.GetArenaMachine(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get release arena machine: %w", err)
+		}
+		if arena != nil && (machineID == 0 || arena.ID == machineID) {
+			running, source = arena, "release_arena"
+		}
+	}
+
+	if running == nil && machineID == 0 {
+		return nil, nil
+	}
+
+	target := &attackTarget{ID: machineID}
+	if running != nil {
+		target.ID = running.ID
+		target.Name = running.Name
+		target.IP = running.IP
+		target.Running = true
+		target.Source = source
+		target.Type = running.Type
+		target.LabServer = running.LabServer
+		target.ExpiresAt = running.ExpiresAt
+		target.Spawning = running.IsSpawning
+	}
+
+	profile, err := t.client.GetMachineProfile(ctx, strconv.Itoa(target.ID))
+	if err != nil {
+		if running == nil {
+			return nil, fmt.Errorf("failed to get machine profile: %w", err)
+		}
+		return target, nil
+	}
+	target.Name = profile.Name
+	target.OS = profile.OS
+	target.Difficulty = string(profile.DifficultyText)
+	if target.IP == "" {
+		target.IP = profile.IP
+	}
+
+	return target, nil
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// GetBattlegroundsStatus tool for checking Battlegrounds availability and queue state
+type GetBattlegroundsStatus struct {
+	client *htb.Client
+}
+
+func NewGetBattlegroundsStatus(client *htb.Client) *GetBattlegroundsStatus {
+	return &GetBattlegroundsStatus{client: client}
+}
+
+func (t *GetBattlegroundsStatus) Name() string {
+	return "get_battlegrounds_status"
+}
+
+func (t *GetBattlegroundsStatus) Description() string {
+	return "Get HackTheBox Battlegrounds availability and the user's current lobby/match status"
+}
+
+func (t *GetBattlegroundsStatus) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetBattlegroundsStatus) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	// Get Battlegrounds availability
+	availability, err := t.client.GetWithParsing(ctx, "/battlegrounds/status", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Battlegrounds status: %w", err)
+	}
+
+	// Get the user's current lobby or match
+	lobby, err := t.client.GetWithParsing(ctx, "/battlegrounds/lobby", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Battlegrounds lobby: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"availability": availability,
+		"lobby":        lobby,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetBattlegroundsHistory tool for listing past Battlegrounds matches
+type GetBattlegroundsHistory struct {
+	client *htb.Client
+}
+
+func NewGetBattlegroundsHistory(client *htb.Client) *GetBattlegroundsHistory {
+	return &GetBattlegroundsHistory{client: client}
+}
+
+func (t *GetBattlegroundsHistory) Name() string {
+	return "get_battlegrounds_history"
+}
+
+func (t *GetBattlegroundsHistory) Description() string {
+	return "Get the user's HackTheBox Battlegrounds match history"
+}
+
+func (t *GetBattlegroundsHistory) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"page": {
+				Type:        "integer",
+				Description: "Page number for pagination",
+				Default:     1,
+			},
+		},
+	}
+}
+
+func (t *GetBattlegroundsHistory) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	page := 1
+	if p, ok := args["page"].(float64); ok {
+		page = int(p)
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, fmt.Sprintf("/battlegrounds/matches/history?page=%d", page), "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Battlegrounds match history: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
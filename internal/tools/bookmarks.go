@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// bookmarkProperties are the schema properties for targeting a machine or
+// challenge to favorite
+var bookmarkProperties = map[string]mcp.Property{
+	"machine_id":     machineIDProperty,
+	"machine_name":   machineNameProperty,
+	"challenge_id":   challengeIDProperty,
+	"challenge_name": challengeNameProperty,
+}
+
+// bookmarkTarget returns the type and ID of the machine or challenge
+// identified by args
+func bookmarkTarget(ctx context.Context, machines *machineResolver, challenges *challengeResolver, args map[string]interface{}) (string, int, error) {
+	isChallenge := args["challenge_id"] != nil || args["challenge_name"] != nil
+	switch {
+	case hasMachineTarget(args) && isChallenge:
+		return "", 0, fmt.Errorf("pass either a machine or a challenge, not both")
+	case hasMachineTarget(args):
+		id, err := machines.resolve(ctx, args)
+		return htb.BookmarkMachine, id, err
+	case isChallenge:
+		id, err := challenges.resolve(ctx, args)
+		return htb.BookmarkChallenge, id, err
+	}
+	return "", 0, fmt.Errorf("machine_id, machine_name, challenge_id or challenge_name is required")
+}
+
+// FavoriteContent tool for adding a machine or challenge to the user's favorites
+type FavoriteContent struct {
+	client     *htb.Client
+	machines   *machineResolver
+	challenges *challengeResolver
+}
+
+func NewFavoriteContent(client *htb.Client, machines *machineResolver, challenges *challengeResolver) *FavoriteContent {
+	return &FavoriteContent{client: client, machines: machines, challenges: challenges}
+}
+
+func (t *FavoriteContent) Name() string {
+	return "favorite_content"
+}
+
+func (t *FavoriteContent) Description() string {
+	return "Add a HackTheBox machine or challenge to the user's favorites (bookmarks), e.g. to curate a list of targets"
+}
+
+func (t *FavoriteContent) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: bookmarkProperties,
+	}
+}
+
+func (t *FavoriteContent) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	itemType, id, err := bookmarkTarget(ctx, t.machines, t.challenges, args)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := t.client.GetBookmarks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	message := fmt.Sprintf("The %s is already a favorite", itemType)
+	if !bookmarks.Has(itemType, id) {
+		// Make API request
+		if message, err = t.client.AddBookmark(ctx, itemType, id); err != nil {
+			return nil, fmt.Errorf("failed to add favorite: %w", err)
+		}
+		if message == "" {
+			message = fmt.Sprintf("Added the %s to favorites", itemType)
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"type":     itemType,
+		"id":       id,
+		"favorite": true,
+		"message":  message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// UnfavoriteContent tool for removing a machine or challenge from the user's favorites
+type UnfavoriteContent struct {
+	client     *htb.Client
+	machines   *machineResolver
+	challenges *challengeResolver
+}
+
+func NewUnfavoriteContent(client *htb.Client, machines *machineResolver, challenges *challengeResolver) *UnfavoriteContent {
+	return &UnfavoriteContent{client: client, machines: machines, challenges: challenges}
+}
+
+func (t *UnfavoriteContent) Name() string {
+	return "unfavorite_content"
+}
+
+func (t *UnfavoriteContent) Description() string {
+	return "Remove a HackTheBox machine or challenge from the user's favorites (bookmarks)"
+}
+
+func (t *UnfavoriteContent) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: bookmarkProperties,
+	}
+}
+
+func (t *UnfavoriteContent) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	itemType, id, err := bookmarkTarget(ctx, t.machines, t.challenges, args)
+	if err != nil {
+		return nil, err
+	}
+
+	bookmarks, err := t.client.GetBookmarks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	message := fmt.Sprintf("The %s is not a favorite", itemType)
+	if bookmarks.Has(itemType, id) {
+		// Make API request
+		if message, err = t.client.RemoveBookmark(ctx, itemType, id); err != nil {
+			return nil, fmt.Errorf("failed to remove favorite: %w", err)
+		}
+		if message == "" {
+			message = fmt.Sprintf("Removed the %s from favorites", itemType)
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"type":     itemType,
+		"id":       id,
+		"favorite": false,
+		"message":  message,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ListFavorites tool for listing the user's favorite machines and challenges
+type ListFavorites struct {
+	client *htb.Client
+}
+
+func NewListFavorites(client *htb.Client) *ListFavorites {
+	return &ListFavorites{client: client}
+}
+
+func (t *ListFavorites) Name() string {
+	return "list_favorites"
+}
+
+func (t *ListFavorites) Description() string {
+	return "List the machines and challenges the user has favorited (bookmarked) on HackTheBox"
+}
+
+func (t *ListFavorites) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"type": {
+				Type:        "string",
+				Description: "Type of favorites to list",
+				Enum:        []string{"all", "machines", "challenges"},
+				Default:     "all",
+			},
+		},
+	}
+}
+
+func (t *ListFavorites) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	favoriteType := "all"
+	if ft, ok := args["type"].(string); ok {
+		favoriteType = ft
+	}
+
+	// Make API request
+	bookmarks, err := t.client.GetBookmarks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	result := map[string]interface{}{}
+	count := 0
+	if favoriteType != "challenges" {
+		result["machines"] = nonNilBookmarks(bookmarks.Machines)
+		count += len(bookmarks.Machines)
+	}
+	if favoriteType != "machines" {
+		result["challenges"] = nonNilBookmarks(bookmarks.Challenges)
+		count += len(bookmarks.Challenges)
+	}
+	result["count"] = count
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// nonNilBookmarks returns items, or an empty list so it encodes as []
+func nonNilBookmarks(items []htb.BookmarkedItem) []htb.BookmarkedItem {
+	if items == nil {
+		return []htb.BookmarkedItem{}
+	}
+	return items
+}
@@ -2,9 +2,12 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"net/http"
 
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
 	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
 )
@@ -12,10 +15,12 @@ import (
 // ListChallenges tool for listing HTB challenges
 type ListChallenges struct {
 	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
 }
 
-func NewListChallenges(client *htb.Client) *ListChallenges {
-	return &ListChallenges{client: client}
+func NewListChallenges(client *htb.Client, state *store.Store, shared cache.Cache) *ListChallenges {
+	return &ListChallenges{client: client, state: state, shared: shared}
 }
 
 func (t *ListChallenges) Name() string {
@@ -29,7 +34,7 @@ func (t *ListChallenges) Description() string {
 func (t *ListChallenges) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
-		Properties: map[string]mcp.Property{
+		Properties: withCatalogProperties(map[string]mcp.Property{
 			"category": {
 				Type:        "string",
 				Description: "Filter by challenge category (Web, Pwn, Crypto, Forensics, etc.)",
@@ -55,11 +60,44 @@ func (t *ListChallenges) Schema() mcp.ToolSchema {
 				Description: "Number of challenges per page",
 				Default:     20,
 			},
-		},
+			"cursor": {
+				Type:        "string",
+				Description: "Continuation cursor from a previous chunked response",
+			},
+			"chunk_size": {
+				Type:        "integer",
+				Description: "Maximum number of challenges returned per response",
+				Default:     defaultChunkSize,
+			},
+		}),
 	}
 }
 
+// challengeChunk is the structured content of list_challenges
+type challengeChunk struct {
+	Items []htb.Challenge `json:"items"`
+	chunkInfo
+}
+
+func (t *ListChallenges) OutputSchema() mcp.ToolSchema {
+	return mcp.SchemaFor(challengeChunk{})
+}
+
 func (t *ListChallenges) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	result, err := t.list(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return typedChunk(result, &challengeChunk{})
+}
+
+// list returns the requested chunk of challenges
+func (t *ListChallenges) list(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	// Serve from the exported catalog when there is a fresh one
+	if items, notice, ok := catalogListing(t.state, t.shared, catalogChallenges, "category", args); ok {
+		return chunkedResponse(items, notice, args)
+	}
+
 	// Extract parameters
 	status := "active"
 	if s, ok := args["status"].(string); ok {
@@ -72,30 +110,23 @@ func (t *ListChallenges) Execute(ctx context.Context, args map[string]interface{
 		endpoint = "/challenge/list/retired"
 	}
 
-	// Make API request
-	data, err := t.client.GetWithParsing(ctx, endpoint, "challenges")
+	// Make API request, falling back to the cached listing when offline
+	data, notice, err := fetchListing(ctx, t.client, t.state, t.shared, endpoint, "challenges")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch challenges: %w", err)
 	}
 
-	// Create JSON content
-	content, err := mcp.CreateJSONContent(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JSON content: %w", err)
-	}
-
-	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
-	}, nil
+	return chunkedResponse(data, notice, args)
 }
 
 // StartChallenge tool for starting a HTB challenge
 type StartChallenge struct {
-	client *htb.Client
+	client     *htb.Client
+	challenges *challengeResolver
 }
 
-func NewStartChallenge(client *htb.Client) *StartChallenge {
-	return &StartChallenge{client: client}
+func NewStartChallenge(client *htb.Client, challenges *challengeResolver) *StartChallenge {
+	return &StartChallenge{client: client, challenges: challenges}
 }
 
 func (t *StartChallenge) Name() string {
@@ -103,30 +134,27 @@ func (t *StartChallenge) Name() string {
 }
 
 func (t *StartChallenge) Description() string {
-	return "Start a HackTheBox challenge by ID to initialize the challenge environment"
+	return "Start a HackTheBox challenge by ID or name to initialize the challenge environment"
 }
 
 func (t *StartChallenge) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
 		Properties: map[string]mcp.Property{
-			"challenge_id": {
-				Type:        "string",
-				Description: "The ID of the challenge to start",
-			},
+			"challenge_id":   challengeIDProperty,
+			"challenge_name": challengeNameProperty,
 		},
-		Required: []string{"challenge_id"},
 	}
 }
 
 func (t *StartChallenge) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	challengeID, ok := args["challenge_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("challenge_id is required")
+	challengeID, err := t.challenges.resolve(ctx, args)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build endpoint URL
-	endpoint := fmt.Sprintf("/challenge/%s/start", challengeID)
+	endpoint := fmt.Sprintf("/challenge/%d/start", challengeID)
 
 	// Make API request
 	data, err := t.client.PostWithParsing(ctx, endpoint, nil, "")
@@ -147,11 +175,13 @@ func (t *StartChallenge) Execute(ctx context.Context, args map[string]interface{
 
 // SubmitChallengeFlag tool for submitting challenge flags
 type SubmitChallengeFlag struct {
-	client *htb.Client
+	client     *htb.Client
+	challenges *challengeResolver
+	queue      *flagQueue
 }
 
-func NewSubmitChallengeFlag(client *htb.Client) *SubmitChallengeFlag {
-	return &SubmitChallengeFlag{client: client}
+func NewSubmitChallengeFlag(client *htb.Client, challenges *challengeResolver, queue *flagQueue) *SubmitChallengeFlag {
+	return &SubmitChallengeFlag{client: client, challenges: challenges, queue: queue}
 }
 
 func (t *SubmitChallengeFlag) Name() string {
@@ -166,27 +196,31 @@ func (t *SubmitChallengeFlag) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
 		Properties: map[string]mcp.Property{
-			"challenge_id": {
-				Type:        "string",
-				Description: "The ID of the challenge",
-			},
+			"challenge_id":   challengeIDProperty,
+			"challenge_name": challengeNameProperty,
 			"flag": {
 				Type:        "string",
 				Description: "The flag to submit",
 			},
 			"difficulty": {
 				Type:        "integer",
-				Description: "Difficulty rating (1-10)",
+				Description: "Optional perceived difficulty rating (1-10)",
+				Default:     defaultDifficultyRating,
 			},
+			"on_outage": onOutageProperty,
 		},
-		Required: []string{"challenge_id", "flag", "difficulty"},
+		Required: []string{"flag"},
 	}
 }
 
+func (t *SubmitChallengeFlag) OutputSchema() mcp.ToolSchema {
+	return flagSubmissionSchema
+}
+
 func (t *SubmitChallengeFlag) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	challengeID, ok := args["challenge_id"].(string)
-	if !ok {
-		return nil, fmt.Errorf("challenge_id is required")
+	challengeID, err := t.challenges.resolve(ctx, args)
+	if err != nil {
+		return nil, err
 	}
 
 	flag, ok := args["flag"].(string)
@@ -194,30 +228,184 @@ func (t *SubmitChallengeFlag) Execute(ctx context.Context, args map[string]inter
 		return nil, fmt.Errorf("flag is required")
 	}
 
-	difficulty, ok := args["difficulty"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("difficulty is required")
+	difficulty, err := difficultyRating(args)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := outageMode(args)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := newOwnVerifier(ctx, t.client, flagTargetChallenge, challengeID, ownSolve)
+
+	// Make API request (HTB API expects difficulty * 10)
+	result, queued, err := t.queue.submit(ctx, mode, flagTargetChallenge, challengeID, flag, difficulty*10)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit flag: %w", err)
+	}
+	if queued != nil {
+		return queuedSubmissionResponse(queued)
+	}
+
+	response, err := flagSubmissionResponse(result, verifier.verify(ctx, result))
+	if err != nil || !result.Success {
+		return response, err
+	}
+
+	// Embed the solved challenge with its updated solve count, best effort
+	if info, err := t.client.GetChallengeInfo(ctx, challengeID); err == nil {
+		if content, err := mcp.CreateJSONResourceContent(fmt.Sprintf("htb://challenge/%d", challengeID), info); err == nil {
+			response.Content = append(response.Content, content)
+		}
+	}
+	return response, nil
+}
+
+// GetChallengeWriteup tool for downloading the official writeup of a retired challenge
+type GetChallengeWriteup struct {
+	client     *htb.Client
+	challenges *challengeResolver
+}
+
+func NewGetChallengeWriteup(client *htb.Client, challenges *challengeResolver) *GetChallengeWriteup {
+	return &GetChallengeWriteup{client: client, challenges: challenges}
+}
+
+func (t *GetChallengeWriteup) Name() string {
+	return "get_challenge_writeup"
+}
+
+func (t *GetChallengeWriteup) Description() string {
+	return "Download the official writeup/solution for a retired HackTheBox challenge (requires a subscription that allows writeup access)"
+}
+
+func (t *GetChallengeWriteup) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"challenge_id":   challengeIDProperty,
+			"challenge_name": challengeNameProperty,
+		},
 	}
+}
 
-	// Convert difficulty to string (HTB API expects difficulty * 10)
-	difficultyStr := strconv.Itoa(int(difficulty) * 10)
+func (t *GetChallengeWriteup) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	challengeID, err := t.challenges.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
 
-	// Build request payload
-	payload := htb.FlagSubmissionRequest{
-		ChallengeID: challengeID,
-		Flag:        flag,
-		Difficulty:  difficultyStr,
+	// Make API request
+	data, mimeType, err := t.client.GetRaw(ctx, fmt.Sprintf("/challenge/writeup/%d", challengeID))
+	if err != nil {
+		var apiErr *htb.HTBAPIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound) {
+			return nil, fmt.Errorf("official writeup not available for challenge %d: it must be retired and your subscription must include writeup access", challengeID)
+		}
+		return nil, fmt.Errorf("failed to download challenge writeup: %w", err)
+	}
+
+	if mimeType == "" {
+		mimeType = "application/pdf"
+	}
+
+	uri := fmt.Sprintf("htb://challenge/%d/writeup", challengeID)
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{
+			mcp.CreateTextContent(fmt.Sprintf("Official writeup for challenge %d (%s, %d bytes)", challengeID, mimeType, len(data))),
+			mcp.CreateBlobResourceContent(uri, mimeType, data),
+		},
+	}, nil
+}
+
+// DownloadChallengeFiles tool for saving a challenge's files into the workspace
+type DownloadChallengeFiles struct {
+	client      *htb.Client
+	challenges  *challengeResolver
+	downloadDir string
+	roots       rootsFunc
+}
+
+func NewDownloadChallengeFiles(client *htb.Client, challenges *challengeResolver, downloadDir string, roots rootsFunc) *DownloadChallengeFiles {
+	return &DownloadChallengeFiles{client: client, challenges: challenges, downloadDir: downloadDir, roots: roots}
+}
+
+func (t *DownloadChallengeFiles) Name() string {
+	return "download_challenge_files"
+}
+
+func (t *DownloadChallengeFiles) Description() string {
+	return "Download a HackTheBox challenge's files into the client's workspace (htb-challenges in its first root by default), or return the zip inline with inline=true. The zip password is \"hackthebox\""
+}
+
+func (t *DownloadChallengeFiles) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"challenge_id":   challengeIDProperty,
+			"challenge_name": challengeNameProperty,
+			"directory":      directoryProperty,
+			"inline": {
+				Type:        "boolean",
+				Description: "Return the zip as an embedded base64 resource instead of writing it to disk",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *DownloadChallengeFiles) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	challengeID, err := t.challenges.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	inline, _ := args["inline"].(bool)
+	var dir string
+	if !inline {
+		requested, _ := args["directory"].(string)
+		if dir, err = workspaceDir(ctx, t.roots, requested, t.downloadDir, "htb-challenges"); err != nil {
+			return nil, err
+		}
 	}
 
 	// Make API request
-	data, err := t.client.PostWithParsing(ctx, "/challenge/own", payload, "message")
+	data, _, err := t.client.GetRaw(ctx, fmt.Sprintf("/challenge/download/%d", challengeID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to submit flag: %w", err)
+		return nil, fmt.Errorf("failed to download challenge files: %w", err)
+	}
+
+	if inline {
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{
+				mcp.CreateTextContent(fmt.Sprintf("Files of challenge %d (application/zip, %d bytes, password \"hackthebox\")", challengeID, len(data))),
+				mcp.CreateBlobResourceContent(fmt.Sprintf("htb://challenge/%d/files", challengeID), "application/zip", data),
+			},
+		}, nil
+	}
+
+	name := fmt.Sprintf("challenge-%d", challengeID)
+	if info, err := t.client.GetChallengeInfo(ctx, challengeID); err == nil && info.Name != "" {
+		name = safeFileName(info.Name)
 	}
 
-	// Create text content with result
-	message := fmt.Sprintf("Flag submission result: %v", data)
-	content := mcp.CreateTextContent(message)
+	path, err := writeWorkspaceFile(dir, name+".zip", data, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"challenge_id": challengeID,
+		"path":         path,
+		"bytes":        len(data),
+		"zip_password": "hackthebox",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
 
 	return &mcp.CallToolResponse{
 		Content: []mcp.Content{content},
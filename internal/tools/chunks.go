@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// defaultChunkSize is the default number of list items returned per response
+const defaultChunkSize = 50
+
+// chunkBlockSize is the number of items placed in each content block of a chunk
+const chunkBlockSize = 25
+
+// maxChunkPages bounds the listing pages fetched to fill a single chunk
+const maxChunkPages = 10
+
+// cursorPrefix versions the opaque continuation cursor format
+const cursorPrefix = "page:"
+
+// chunkInfo describes a chunk of a larger list and how to continue it. Total
+// is only known for lists held in full, not for paginated HTB listings.
+type chunkInfo struct {
+	Total      int    `json:"total,omitempty"`
+	Offset     int    `json:"offset"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listingChunk is the structured content of a chunk: its items along with
+// where it lies in the list
+type listingChunk struct {
+	Items []interface{} `json:"items"`
+	chunkInfo
+}
+
+// listingCursor is a position in a listing: an item offset within a page of
+// the HTB pagination, numbered from 1. Lists held in full are a single page.
+type listingCursor struct {
+	page   int
+	offset int
+}
+
+// listingPage fetches one page of a paginated listing, returning its items
+// and a notice if they were served from a stale cache
+type listingPage func(ctx context.Context, page int) ([]interface{}, string, error)
+
+// chunkedResponse returns list data in chunks of chunk_size items, starting at
+// the offset encoded in cursor. Each chunk is split across several content
+// blocks and followed by a block carrying the continuation cursor. Lists that
+// fit in a single chunk are returned as one block, unchanged.
+func chunkedResponse(data interface{}, notice string, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	items, isList := data.([]interface{})
+	if !isList {
+		return singleBlock(data, notice)
+	}
+
+	fetch := func(ctx context.Context, page int) ([]interface{}, string, error) {
+		if page > 1 {
+			return nil, "", nil
+		}
+		return items, notice, nil
+	}
+	return chunkedListing(context.Background(), fetch, 0, args, len(items))
+}
+
+// chunkedListing aggregates the pages of a listing, perPage items each, into
+// chunks of chunk_size items. The first chunk starts at the page argument,
+// later ones where the previous one ended, as encoded in cursor. total is the
+// number of items if known, or 0. A perPage of 0 means fetch returns the whole
+// list as page 1.
+func chunkedListing(ctx context.Context, fetch listingPage, perPage int, args map[string]interface{}, total int) (*mcp.CallToolResponse, error) {
+	chunkSize := defaultChunkSize
+	if cs, ok := args["chunk_size"].(float64); ok && cs > 0 {
+		chunkSize = int(cs)
+	}
+
+	start := listingCursor{page: 1}
+	if p, ok := args["page"].(float64); ok && p > 1 && perPage > 0 {
+		start.page = int(p)
+	}
+	cursor, _ := args["cursor"].(string)
+	if cursor != "" {
+		var err error
+		if start, err = decodeCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Collect items page by page until the chunk is full or the listing ends
+	next := start
+	var items []interface{}
+	var notice string
+	more := false
+	for fetched := 0; len(items) < chunkSize && fetched < maxChunkPages; fetched++ {
+		data, pageNotice, err := fetch(ctx, next.page)
+		if err != nil {
+			return nil, err
+		}
+		if notice == "" {
+			notice = pageNotice
+		}
+
+		offset := min(next.offset, len(data))
+		taken := min(len(data)-offset, chunkSize-len(items))
+		items = append(items, data[offset:offset+taken]...)
+		if offset+taken < len(data) {
+			next.offset = offset + taken
+			more = true
+			break
+		}
+
+		next = listingCursor{page: next.page + 1}
+		more = perPage > 0 && len(data) >= perPage
+		if !more {
+			break
+		}
+	}
+
+	if items == nil {
+		items = []interface{}{}
+	}
+	info := chunkInfo{Total: total, Offset: start.offset, Count: len(items)}
+	if perPage > 0 {
+		info.Offset = (start.page-1)*perPage + start.offset
+	}
+	if more {
+		info.NextCursor = encodeCursor(next)
+	}
+	structured := &listingChunk{Items: items, chunkInfo: info}
+
+	// Lists that fit in a single chunk are returned unchanged
+	if cursor == "" && !more {
+		result, err := singleBlock(items, notice)
+		if err != nil {
+			return nil, err
+		}
+		result.StructuredContent = structured
+		return result, nil
+	}
+
+	var contents []mcp.Content
+	if notice != "" {
+		contents = append(contents, mcp.CreateTextContent(notice))
+	}
+	for i := 0; i < len(items); i += chunkBlockSize {
+		content, err := mcp.CreateJSONContent(items[i:min(i+chunkBlockSize, len(items))])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JSON content: %w", err)
+		}
+		contents = append(contents, content)
+	}
+
+	content, err := mcp.CreateJSONContent(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content:           append(contents, content),
+		StructuredContent: structured,
+	}, nil
+}
+
+// typedChunk replaces the structured content of a chunked listing with
+// chunk, a pointer to a struct typing the items with the listing's model, so
+// it matches the tool's output schema. The text keeps every field HTB returns.
+func typedChunk(result *mcp.CallToolResponse, chunk interface{}) (*mcp.CallToolResponse, error) {
+	if result.StructuredContent == nil {
+		return nil, fmt.Errorf("unexpected listing format: not a list")
+	}
+
+	encoded, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode listing: %w", err)
+	}
+	if err := json.Unmarshal(encoded, chunk); err != nil {
+		return nil, fmt.Errorf("failed to decode listing: %w", err)
+	}
+
+	result.StructuredContent = chunk
+	return result, nil
+}
+
+// singleBlock returns data as one JSON content block, after notice if set
+func singleBlock(data interface{}, notice string) (*mcp.CallToolResponse, error) {
+	var contents []mcp.Content
+	if notice != "" {
+		contents = append(contents, mcp.CreateTextContent(notice))
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+	return &mcp.CallToolResponse{Content: append(contents, content)}, nil
+}
+
+// encodeCursor encodes a listing position as an opaque continuation cursor
+func encodeCursor(c listingCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s%d,offset:%d", cursorPrefix, c.page, c.offset)))
+}
+
+// decodeCursor decodes a continuation cursor into a listing position
+func decodeCursor(cursor string) (listingCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return listingCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	page, offset, ok := strings.Cut(strings.TrimPrefix(string(raw), cursorPrefix), ",offset:")
+	if !ok {
+		return listingCursor{}, fmt.Errorf("invalid cursor")
+	}
+	c := listingCursor{}
+	if c.page, err = strconv.Atoi(page); err != nil || c.page < 1 {
+		return listingCursor{}, fmt.Errorf("invalid cursor")
+	}
+	if c.offset, err = strconv.Atoi(offset); err != nil || c.offset < 0 {
+		return listingCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return c, nil
+}
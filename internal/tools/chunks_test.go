@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// numbered returns n items numbered from first
+func numbered(first, n int) []interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = float64(first + i)
+	}
+	return items
+}
+
+// chunkItems returns the items and chunk info of a chunked response
+func chunkItems(t *testing.T, args map[string]interface{}, fetch listingPage, perPage int) ([]interface{}, chunkInfo) {
+	t.Helper()
+	result, err := chunkedListing(context.Background(), fetch, perPage, args, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var items []interface{}
+	var info chunkInfo
+	last := len(result.Content) - 1
+	for i, content := range result.Content {
+		if i == last {
+			if err := json.Unmarshal([]byte(content.Text), &info); err != nil {
+				t.Fatalf("Failed to decode chunk info: %v", err)
+			}
+			continue
+		}
+		var block []interface{}
+		if err := json.Unmarshal([]byte(content.Text), &block); err != nil {
+			t.Fatalf("Failed to decode chunk block: %v", err)
+		}
+		items = append(items, block...)
+	}
+	return items, info
+}
+
+func TestChunkedListingAggregatesPages(t *testing.T) {
+	// Three pages of 20, then a partial last page
+	var fetched []int
+	fetch := func(ctx context.Context, page int) ([]interface{}, string, error) {
+		fetched = append(fetched, page)
+		if page > 4 {
+			return nil, "", fmt.Errorf("page %d fetched past the end", page)
+		}
+		if page == 4 {
+			return numbered(60, 5), "", nil
+		}
+		return numbered((page-1)*20, 20), "", nil
+	}
+
+	items, info := chunkItems(t, map[string]interface{}{}, fetch, 20)
+	if len(items) != 50 || items[0] != 0.0 || items[49] != 49.0 {
+		t.Fatalf("Expected items 0-49 aggregated from three pages, got %v", items)
+	}
+	if len(fetched) != 3 || info.Offset != 0 || info.Count != 50 || info.NextCursor == "" {
+		t.Fatalf("Unexpected chunk %+v after fetching pages %v", info, fetched)
+	}
+
+	// The cursor continues mid-page rather than re-fetching the first page
+	fetched = nil
+	items, info = chunkItems(t, map[string]interface{}{"cursor": info.NextCursor}, fetch, 20)
+	if len(items) != 15 || items[0] != 50.0 || items[14] != 64.0 {
+		t.Fatalf("Expected items 50-64, got %v", items)
+	}
+	if fetched[0] != 3 || info.Offset != 50 || info.NextCursor != "" {
+		t.Errorf("Expected the last chunk to start on page 3, got %+v after fetching pages %v", info, fetched)
+	}
+}
+
+func TestChunkedResponseSingleBlock(t *testing.T) {
+	result, err := chunkedResponse(numbered(0, 10), "", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var block []interface{}
+	if len(result.Content) != 1 || json.Unmarshal([]byte(result.Content[0].Text), &block) != nil || len(block) != 10 {
+		t.Errorf("Expected a short list unchanged in one block, got %+v", result.Content)
+	}
+
+	items, info := chunkItems(t, map[string]interface{}{"chunk_size": 4.0, "cursor": encodeCursor(listingCursor{page: 1, offset: 8})},
+		func(ctx context.Context, page int) ([]interface{}, string, error) {
+			if page > 1 {
+				return nil, "", nil
+			}
+			return numbered(0, 10), "", nil
+		}, 0)
+	if len(items) != 2 || items[0] != 8.0 || info.NextCursor != "" {
+		t.Errorf("Expected the last two items without a cursor, got %v, %+v", items, info)
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	want := listingCursor{page: 3, offset: 10}
+	if got, err := decodeCursor(encodeCursor(want)); err != nil || got != want {
+		t.Errorf("Expected %+v, got %+v, %v", want, got, err)
+	}
+	for _, cursor := range []string{"", "bm9wZQ", encodeCursor(listingCursor{page: 0})} {
+		if _, err := decodeCursor(cursor); err == nil {
+			t.Errorf("Expected cursor %q to be invalid", cursor)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// CleanupSession tool for stopping everything spawned during a session
+type CleanupSession struct {
+	client *htb.Client
+	notes  *notes.Store
+}
+
+func NewCleanupSession(client *htb.Client, store *notes.Store) *CleanupSession {
+	return &CleanupSession{client: client, notes: store}
+}
+
+func (t *CleanupSession) Name() string {
+	return "cleanup_session"
+}
+
+func (t *CleanupSession) Description() string {
+	return "End-of-session cleanup: terminate the active and release-arena machines, stop running challenge containers and optionally terminate Pwnbox, reporting what was cleaned up"
+}
+
+func (t *CleanupSession) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"include_pwnbox": {
+				Type:        "boolean",
+				Description: "Also terminate a running Pwnbox instance",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *CleanupSession) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(true),
+	}
+}
+
+// cleanupAction reports the outcome of stopping a single resource
+type cleanupAction struct {
+	Resource string `json:"resource"`
+	ID       int    `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Stopped  bool   `json:"stopped"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (t *CleanupSession) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	includePwnbox, _ := args["include_pwnbox"].(bool)
+
+	// A failing step should not stop the rest of the cleanup, so each
+	// resource is attempted and reported independently
+	var actions []cleanupAction
+
+	if machine, err := t.client.GetActiveMachine(ctx); err != nil {
+		actions = append(actions, cleanupAction{Resource: "machine", Error: err.Error()})
+	} else if machine != nil {
+		payload := htb.MachineActionRequest{MachineID: machine.ID}
+		actions = append(actions, t.stop(ctx, "machine", machine.ID, machine.Name, "/vm/terminate", payload))
+	}
+
+	if arena, err := t.client.GetArenaMachine(ctx); err != nil {
+		actions = append(actions, cleanupAction{Resource: "release_arena", Error: err.Error()})
+	} else if arena != nil {
+		payload := htb.MachineActionRequest{MachineID: arena.ID}
+		actions = append(actions, t.stop(ctx, "release_arena", arena.ID, arena.Name, "/arena/stop", payload))
+	}
+
+	var active struct {
+		Info interface{} `json:"info"`
+	}
+	if err := t.client.GetJSON(ctx, "/challenge/active", &active); err != nil {
+		actions = append(actions, cleanupAction{Resource: "challenge", Error: err.Error()})
+	} else {
+		for _, challenge := range activeChallenges(active.Info) {
+			payload := map[string]int{"challenge_id": challenge.ID}
+			actions = append(actions, t.stop(ctx, "challenge", challenge.ID, challenge.Name, "/challenge/stop", payload))
+		}
+	}
+
+	if includePwnbox {
+		var pwnbox struct {
+			Data interface{} `json:"data"`
+		}
+		if err := t.client.GetJSON(ctx, "/pwnbox/status", &pwnbox); err != nil {
+			actions = append(actions, cleanupAction{Resource: "pwnbox", Error: err.Error()})
+		} else if pwnbox.Data != nil {
+			actions = append(actions, t.stop(ctx, "pwnbox", 0, "", "/pwnbox/terminate", nil))
+		}
+	}
+
+	if len(actions) == 0 {
+		content := mcp.CreateTextContent("Nothing to clean up: no machines, challenges or Pwnbox instances are running")
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{content},
+		}, nil
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"cleaned_up": actions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// stop posts a stop request for a resource and records stopped machines in the session notes
+func (t *CleanupSession) stop(ctx context.Context, resource string, id int, name, endpoint string, payload interface{}) cleanupAction {
+	action := cleanupAction{Resource: resource, ID: id, Name: name}
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := t.client.PostJSON(ctx, endpoint, payload, &result); err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	action.Stopped = true
+	action.Message = result.Message
+
+	if resource == "machine" || resource == "release_arena" {
+		session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+			Kind:        notes.KindStop,
+			MachineID:   id,
+			MachineName: name,
+			Text:        "Machine terminated during session cleanup",
+		})
+	}
+
+	return action
+}
+
+// activeChallenges extracts the running challenges from the /challenge/active
+// info field, which holds a single challenge object or a list of them
+func activeChallenges(data interface{}) []namedItem {
+	var raw []interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		raw = v
+	case map[string]interface{}:
+		raw = []interface{}{v}
+	}
+
+	var challenges []namedItem
+	for _, entry := range raw {
+		obj, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, ok := numericID(obj["id"])
+		if !ok {
+			continue
+		}
+		name, _ := obj["name"].(string)
+		challenges = append(challenges, namedItem{ID: id, Name: name})
+	}
+	return challenges
+}
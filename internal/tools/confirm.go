@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// confirmationSchema asks the user for a single yes/no answer
+var confirmationSchema = mcp.ToolSchema{
+	Type: "object",
+	Properties: map[string]mcp.Property{
+		"confirm": {
+			Type:        "boolean",
+			Description: "Run the action",
+		},
+	},
+	Required: []string{"confirm"},
+}
+
+// confirm asks the user to approve a call to a tool listed in the
+// confirmation policy. Calls are refused unless the user accepts, or, in
+// ConfirmAsk mode, the client cannot ask.
+func (r *Registry) confirm(ctx context.Context, name string, args map[string]interface{}) error {
+	mode := r.config.ConfirmationPolicy[name]
+	if mode == "" || mode == config.ConfirmOff {
+		return nil
+	}
+
+	r.mu.RLock()
+	elicitor := r.elicitor
+	r.mu.RUnlock()
+
+	sess, ok := session.FromContext(ctx)
+	if elicitor == nil || !ok || !sess.Supports(mcp.FeatureElicitation) {
+		if mode == config.ConfirmRequire {
+			return fmt.Errorf("%s requires user confirmation, but %s does not support elicitation", name, clientNameFrom(ctx))
+		}
+		return nil
+	}
+
+	resp, err := elicitor.Elicit(ctx, &mcp.ElicitRequest{
+		Message:         confirmationMessage(name, args),
+		RequestedSchema: confirmationSchema,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get confirmation for %s: %w", name, err)
+	}
+
+	if resp.Action != mcp.ElicitAccept {
+		return fmt.Errorf("%s was not run: the user chose to %s", name, resp.Action)
+	}
+	if confirmed, _ := resp.Content["confirm"].(bool); !confirmed {
+		return fmt.Errorf("%s was not run: the user did not confirm it", name)
+	}
+	return nil
+}
+
+// confirmationMessage describes a tool call for the user to approve
+func confirmationMessage(name string, args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil || len(args) == 0 {
+		return fmt.Sprintf("Allow the assistant to run %s?", name)
+	}
+	return fmt.Sprintf("Allow the assistant to run %s with %s?", name, data)
+}
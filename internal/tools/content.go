@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// contentSnapshotKey is the persistent store key of the last get_new_content snapshot
+const contentSnapshotKey = "snapshot:active_content"
+
+// snapshotKey returns the store key of the last snapshot taken for the
+// client of the session carried by ctx, so clients sharing the server each
+// see what changed since their own last check
+func snapshotKey(ctx context.Context) string {
+	if s, ok := session.FromContext(ctx); ok {
+		if client, ok := s.Client(); ok && client.Info.Name != "" {
+			return contentSnapshotKey + ":" + strings.ToLower(client.Info.Name)
+		}
+	}
+	return contentSnapshotKey
+}
+
+// contentSource describes an active content listing tracked by get_new_content
+type contentSource struct {
+	category string
+	endpoint string
+	field    string
+}
+
+var contentSources = []contentSource{
+	{category: "machines", endpoint: "/machine/paginated/?per_page=100", field: "data"},
+	{category: "challenges", endpoint: "/challenge/list", field: "challenges"},
+	{category: "sherlocks", endpoint: "/sherlocks?state=active&per_page=100", field: "data"},
+}
+
+// contentSnapshot maps category to content ID to name
+type contentSnapshot map[string]map[string]string
+
+// contentItem is a released or retired item reported by get_new_content
+type contentItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetNewContent tool for reporting content released or retired since the last check
+type GetNewContent struct {
+	client *htb.Client
+	state  *store.Store
+}
+
+func NewGetNewContent(client *htb.Client, state *store.Store) *GetNewContent {
+	return &GetNewContent{client: client, state: state}
+}
+
+func (t *GetNewContent) Name() string {
+	return "get_new_content"
+}
+
+func (t *GetNewContent) Description() string {
+	return "Report machines, challenges and Sherlocks released or retired since your last check, then remember the current state for next time"
+}
+
+func (t *GetNewContent) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetNewContent) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	key := snapshotKey(ctx)
+	var previous contentSnapshot
+	lastCheck, hasPrevious, err := t.state.Get(key, &previous)
+	if err != nil {
+		log.Printf("Discarding unreadable content snapshot: %v", err)
+		hasPrevious = false
+	}
+
+	current := make(contentSnapshot)
+	errs := make(map[string]string)
+	for _, source := range contentSources {
+		var result map[string]interface{}
+		if err := t.client.GetJSON(ctx, source.endpoint, &result); err != nil {
+			errs[source.category] = err.Error()
+			// Keep the previous state so a failed fetch isn't reported as mass retirement
+			current[source.category] = previous[source.category]
+			continue
+		}
+		current[source.category] = contentIndex(result[source.field])
+	}
+
+	response := map[string]interface{}{
+		"checked_at": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if hasPrevious {
+		response["last_check"] = lastCheck.Format(time.RFC3339)
+		released := make(map[string][]contentItem)
+		retired := make(map[string][]contentItem)
+		for _, source := range contentSources {
+			if _, failed := errs[source.category]; failed {
+				continue
+			}
+			released[source.category] = contentDiff(current[source.category], previous[source.category])
+			retired[source.category] = contentDiff(previous[source.category], current[source.category])
+		}
+		response["released"] = released
+		response["retired"] = retired
+	} else {
+		response["message"] = "No previous check found; recorded a baseline snapshot for next time"
+	}
+
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+
+	if err := t.state.Put(key, current); err != nil {
+		return nil, fmt.Errorf("failed to save content snapshot: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// contentIndex indexes a listing of {id, name} objects by ID
+func contentIndex(data interface{}) map[string]string {
+	index := make(map[string]string)
+	items, _ := data.([]interface{})
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok || obj["id"] == nil {
+			continue
+		}
+		name, _ := obj["name"].(string)
+		index[fmt.Sprint(obj["id"])] = name
+	}
+	return index
+}
+
+// contentDiff returns the items in a that are not in b, sorted by name
+func contentDiff(a, b map[string]string) []contentItem {
+	items := []contentItem{}
+	for id, name := range a {
+		if _, ok := b[id]; !ok {
+			items = append(items, contentItem{ID: id, Name: name})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+
+	return items
+}
+
+// retirementEntry is an upcoming release and the machine it retires
+type retirementEntry struct {
+	RetiresAt  string            `json:"retires_at"`
+	TimeUntil  string            `json:"time_until,omitempty"`
+	Retiring   *retiringMachine  `json:"retiring,omitempty"`
+	ReplacedBy retirementMachine `json:"replaced_by"`
+	Prioritize bool              `json:"prioritize"`
+	release    time.Time
+}
+
+// retirementMachine identifies a machine in the retirement schedule
+type retirementMachine struct {
+	ID         int                 `json:"id"`
+	Name       string              `json:"name"`
+	OS         string              `json:"os"`
+	Difficulty htb.DifficultyLevel `json:"difficulty"`
+}
+
+// retiringMachine is a retiring machine with the user's progress on it
+type retiringMachine struct {
+	retirementMachine
+	UserOwned *bool `json:"user_owned,omitempty"`
+	RootOwned *bool `json:"root_owned,omitempty"`
+}
+
+// GetRetirementSchedule tool for listing upcoming machine retirements
+type GetRetirementSchedule struct {
+	client *htb.Client
+}
+
+func NewGetRetirementSchedule(client *htb.Client) *GetRetirementSchedule {
+	return &GetRetirementSchedule{client: client}
+}
+
+func (t *GetRetirementSchedule) Name() string {
+	return "get_retirement_schedule"
+}
+
+func (t *GetRetirementSchedule) Description() string {
+	return "List upcoming machine retirements with the releases replacing them and whether you still need to own each retiring machine before it stops awarding points"
+}
+
+func (t *GetRetirementSchedule) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetRetirementSchedule) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	unreleased, err := t.client.GetUnreleasedMachines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unreleased machines: %w", err)
+	}
+
+	// Ownership is best effort; the schedule is still useful without it
+	owns, err := t.activeOwns(ctx)
+	if err != nil {
+		log.Printf("Retirement schedule without ownership: %v", err)
+	}
+
+	now := time.Now()
+	entries := make([]retirementEntry, 0, len(unreleased))
+	for _, machine := range unreleased {
+		entry := retirementEntry{
+			RetiresAt: machine.Release,
+			ReplacedBy: retirementMachine{
+				ID:         machine.ID,
+				Name:       machine.Name,
+				OS:         machine.OS,
+				Difficulty: machine.DifficultyText,
+			},
+		}
+		if release, err := time.Parse(time.RFC3339Nano, machine.Release); err == nil {
+			entry.release = release
+			if release.After(now) {
+				entry.TimeUntil = release.Sub(now).Round(time.Minute).String()
+			}
+		}
+
+		if retiring := machine.Retiring; retiring != nil {
+			entry.Retiring = &retiringMachine{retirementMachine: retirementMachine{
+				ID:         retiring.ID,
+				Name:       retiring.Name,
+				OS:         retiring.OS,
+				Difficulty: retiring.DifficultyText,
+			}}
+			entry.Prioritize = true
+			if own, ok := owns[retiring.ID]; ok {
+				entry.Retiring.UserOwned = &own.user
+				entry.Retiring.RootOwned = &own.root
+				entry.Prioritize = !own.user || !own.root
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].release.Before(entries[j].release)
+	})
+
+	response := map[string]interface{}{
+		"checked_at":  now.UTC().Format(time.RFC3339),
+		"retirements": entries,
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// machineOwnership records whether the user owns a machine's user and root flags
+type machineOwnership struct {
+	user, root bool
+}
+
+// activeOwns returns the user's ownership of each active machine by ID
+func (t *GetRetirementSchedule) activeOwns(ctx context.Context) (map[int]machineOwnership, error) {
+	var result struct {
+		Data []struct {
+			ID                 int  `json:"id"`
+			AuthUserInUserOwns bool `json:"authUserInUserOwns"`
+			AuthUserInRootOwns bool `json:"authUserInRootOwns"`
+		} `json:"data"`
+	}
+	if err := t.client.GetJSON(ctx, "/machine/paginated/?per_page=100", &result); err != nil {
+		return nil, err
+	}
+
+	owns := make(map[int]machineOwnership, len(result.Data))
+	for _, machine := range result.Data {
+		owns[machine.ID] = machineOwnership{user: machine.AuthUserInUserOwns, root: machine.AuthUserInRootOwns}
+	}
+	return owns, nil
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ListMySubmissions tool for listing content the user has submitted as a creator
+type ListMySubmissions struct {
+	client *htb.Client
+}
+
+func NewListMySubmissions(client *htb.Client) *ListMySubmissions {
+	return &ListMySubmissions{client: client}
+}
+
+func (t *ListMySubmissions) Name() string {
+	return "list_my_submissions"
+}
+
+func (t *ListMySubmissions) Description() string {
+	return "List machines and challenges the user has submitted to HackTheBox as a content creator"
+}
+
+func (t *ListMySubmissions) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"type": {
+				Type:        "string",
+				Description: "Type of submissions to list",
+				Enum:        []string{"all", "machines", "challenges"},
+				Default:     "all",
+			},
+		},
+	}
+}
+
+func (t *ListMySubmissions) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	submissionType := "all"
+	if st, ok := args["type"].(string); ok {
+		submissionType = st
+	}
+
+	endpoint := "/submissions"
+	if submissionType != "all" {
+		endpoint = fmt.Sprintf("/submissions?type=%s", submissionType)
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, endpoint, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetSubmissionStatus tool for checking the review status of a submission
+type GetSubmissionStatus struct {
+	client *htb.Client
+}
+
+func NewGetSubmissionStatus(client *htb.Client) *GetSubmissionStatus {
+	return &GetSubmissionStatus{client: client}
+}
+
+func (t *GetSubmissionStatus) Name() string {
+	return "get_submission_status"
+}
+
+func (t *GetSubmissionStatus) Description() string {
+	return "Get the review status and reviewer comments of a content-creator submission"
+}
+
+func (t *GetSubmissionStatus) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"submission_id": {
+				Type:        "integer",
+				Description: "The ID of the submission",
+			},
+		},
+		Required: []string{"submission_id"},
+	}
+}
+
+func (t *GetSubmissionStatus) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	submissionID, ok := args["submission_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("submission_id is required")
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, fmt.Sprintf("/submissions/%d", int(submissionID)), "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission status: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetSubmissionFeedback tool for viewing play-test feedback on a submission
+type GetSubmissionFeedback struct {
+	client *htb.Client
+}
+
+func NewGetSubmissionFeedback(client *htb.Client) *GetSubmissionFeedback {
+	return &GetSubmissionFeedback{client: client}
+}
+
+func (t *GetSubmissionFeedback) Name() string {
+	return "get_submission_feedback"
+}
+
+func (t *GetSubmissionFeedback) Description() string {
+	return "Get play-test feedback left by testers on a content-creator submission"
+}
+
+func (t *GetSubmissionFeedback) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"submission_id": {
+				Type:        "integer",
+				Description: "The ID of the submission",
+			},
+		},
+		Required: []string{"submission_id"},
+	}
+}
+
+func (t *GetSubmissionFeedback) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	submissionID, ok := args["submission_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("submission_id is required")
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, fmt.Sprintf("/submissions/%d/feedback", int(submissionID)), "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission feedback: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
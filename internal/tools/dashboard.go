@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/fanout"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// dashboardConcurrency bounds the HTB API requests get_dashboard has in flight
+const dashboardConcurrency = 4
+
+// dashboardReleaseWindow is how far back get_dashboard looks for new releases
+const dashboardReleaseWindow = 7 * 24 * time.Hour
+
+// GetDashboard tool for an at-a-glance overview of the account
+type GetDashboard struct {
+	client *htb.Client
+}
+
+func NewGetDashboard(client *htb.Client) *GetDashboard {
+	return &GetDashboard{client: client}
+}
+
+func (t *GetDashboard) Name() string {
+	return "get_dashboard"
+}
+
+func (t *GetDashboard) Description() string {
+	return "Profile, active machine, current season rank and the past week's releases in one call, fetched concurrently"
+}
+
+func (t *GetDashboard) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetDashboard) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	started := time.Now()
+
+	results := fanout.Run(ctx, dashboardConcurrency, map[string]fanout.Fetch{
+		"profile": func(ctx context.Context) (interface{}, error) {
+			return t.client.GetUserInfo(ctx)
+		},
+		"active_machine": func(ctx context.Context) (interface{}, error) {
+			return t.client.GetActiveMachine(ctx)
+		},
+		"season": t.seasonRank,
+		"new_releases": func(ctx context.Context) (interface{}, error) {
+			return recentReleases(ctx, t.client, time.Now().Add(-dashboardReleaseWindow))
+		},
+	})
+
+	// A failing section should not hide the others, so errors are reported per section
+	result := make(map[string]interface{}, len(results.Values)+2)
+	for name, value := range results.Values {
+		result[name] = value
+	}
+	if len(results.Errors) > 0 {
+		errs := make(map[string]string, len(results.Errors))
+		for name, err := range results.Errors {
+			errs[name] = err.Error()
+		}
+		result["errors"] = errs
+	}
+	result["fetched_in_ms"] = time.Since(started).Milliseconds()
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// seasonRank fetches the active season and the user's rank in it
+func (t *GetDashboard) seasonRank(ctx context.Context) (interface{}, error) {
+	seasons, err := t.client.GetSeasons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons: %w", err)
+	}
+
+	season := selectSeason(seasons, nil)
+	if season == nil {
+		return map[string]interface{}{"active": false}, nil
+	}
+
+	rank, err := t.client.GetSeasonRank(ctx, season.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season rank: %w", err)
+	}
+
+	return map[string]interface{}{
+		"season_id":   season.ID,
+		"season_name": season.Name,
+		"active":      season.Active,
+		"rank":        rank,
+	}, nil
+}
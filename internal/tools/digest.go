@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// digestPeriod is the span covered by a weekly digest
+const digestPeriod = 7 * 24 * time.Hour
+
+// Scheduled digests are compiled on Mondays at 08:00 UTC
+const (
+	digestWeekday = time.Monday
+	digestHour    = 8
+)
+
+// digestTimeout bounds how long compiling and delivering a scheduled digest may take
+const digestTimeout = 2 * time.Minute
+
+// taskKindDigest identifies scheduled weekly digests
+const taskKindDigest = "weekly_digest"
+
+// digestRelease is newly released content included in a digest
+type digestRelease struct {
+	Type     string `json:"type"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Detail   string `json:"detail,omitempty"`
+	Released string `json:"released"`
+}
+
+// weeklyDigest summarizes a week of practice
+type weeklyDigest struct {
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	Owns         []htb.ActivityItem `json:"owns"`
+	PointsGained int                `json:"points_gained"`
+	RankStart    float64            `json:"rank_start,omitempty"`
+	RankEnd      float64            `json:"rank_end,omitempty"`
+	RankChange   float64            `json:"rank_change,omitempty"`
+	NewReleases  []digestRelease    `json:"new_releases"`
+	Interests    []string           `json:"interests,omitempty"`
+	Errors       map[string]string  `json:"errors,omitempty"`
+}
+
+// compileDigest builds the digest for the week ending at now. Releases are
+// filtered to those matching interests (OS, difficulty or category) if any.
+func compileDigest(ctx context.Context, client *htb.Client, interests []string, now time.Time) (*weeklyDigest, error) {
+	since := now.Add(-digestPeriod)
+	digest := &weeklyDigest{
+		From:        since.UTC().Format(time.RFC3339),
+		To:          now.UTC().Format(time.RFC3339),
+		Owns:        []htb.ActivityItem{},
+		NewReleases: []digestRelease{},
+		Interests:   interests,
+		Errors:      make(map[string]string),
+	}
+
+	user, err := client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	// A failing source should not drop the whole digest, so errors are collected per section
+	if activity, err := client.GetUserActivity(ctx, user.ID); err != nil {
+		digest.Errors["owns"] = err.Error()
+	} else {
+		for _, item := range activity {
+			if ownedAt, err := htb.ParseTime(item.Date); err == nil && ownedAt.After(since) {
+				digest.Owns = append(digest.Owns, item)
+				digest.PointsGained += item.Points
+			}
+		}
+	}
+
+	if graph, err := client.GetRankGraph(ctx, user.ID, "1W"); err != nil {
+		digest.Errors["rank"] = err.Error()
+	} else if n := len(graph.Rank); n > 0 {
+		digest.RankStart = float64(graph.Rank[0])
+		digest.RankEnd = float64(graph.Rank[n-1])
+		// Positive means the user climbed (a lower rank number is better)
+		digest.RankChange = digest.RankStart - digest.RankEnd
+	}
+
+	releases, err := recentReleases(ctx, client, since)
+	if err != nil {
+		digest.Errors["new_releases"] = err.Error()
+	}
+	for _, release := range releases {
+		if matchesInterests(release, interests) {
+			digest.NewReleases = append(digest.NewReleases, release)
+		}
+	}
+
+	if len(digest.Errors) == 0 {
+		digest.Errors = nil
+	}
+	return digest, nil
+}
+
+// recentReleases lists active machines and challenges released after since
+func recentReleases(ctx context.Context, client *htb.Client, since time.Time) ([]digestRelease, error) {
+	var machines struct {
+		Data []struct {
+			ID         int                 `json:"id"`
+			Name       string              `json:"name"`
+			OS         string              `json:"os"`
+			Difficulty htb.DifficultyLevel `json:"difficultyText"`
+			Release    string              `json:"release"`
+		} `json:"data"`
+	}
+	if err := client.GetJSON(ctx, "/machine/paginated/?per_page=100", &machines); err != nil {
+		return nil, fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	var challenges struct {
+		Challenges []struct {
+			ID         int                 `json:"id"`
+			Name       string              `json:"name"`
+			Category   string              `json:"category_name"`
+			Difficulty htb.DifficultyLevel `json:"difficulty"`
+			Release    string              `json:"release_date"`
+		} `json:"challenges"`
+	}
+	if err := client.GetJSON(ctx, "/challenge/list", &challenges); err != nil {
+		return nil, fmt.Errorf("failed to list challenges: %w", err)
+	}
+
+	var releases []digestRelease
+	for _, m := range machines.Data {
+		if released, err := htb.ParseTime(m.Release); err == nil && released.After(since) {
+			releases = append(releases, digestRelease{
+				Type:     "machine",
+				ID:       m.ID,
+				Name:     m.Name,
+				Detail:   strings.TrimSpace(m.OS + " " + string(m.Difficulty)),
+				Released: m.Release,
+			})
+		}
+	}
+	for _, c := range challenges.Challenges {
+		if released, err := htb.ParseTime(c.Release); err == nil && released.After(since) {
+			releases = append(releases, digestRelease{
+				Type:     "challenge",
+				ID:       c.ID,
+				Name:     c.Name,
+				Detail:   strings.TrimSpace(c.Category + " " + string(c.Difficulty)),
+				Released: c.Release,
+			})
+		}
+	}
+
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Released < releases[j].Released
+	})
+	return releases, nil
+}
+
+// matchesInterests reports whether a release matches any interest keyword
+func matchesInterests(release digestRelease, interests []string) bool {
+	if len(interests) == 0 {
+		return true
+	}
+
+	fields := strings.Fields(strings.ToLower(release.Type + " " + release.Detail))
+	for _, interest := range interests {
+		for _, field := range fields {
+			if strings.EqualFold(field, interest) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// postWebhook delivers a JSON payload to a webhook URL
+func postWebhook(ctx context.Context, url string, payload interface{}, timeout time.Duration) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetWeeklyDigest tool for compiling the weekly practice digest on demand
+type GetWeeklyDigest struct {
+	client    *htb.Client
+	interests []string
+}
+
+func NewGetWeeklyDigest(client *htb.Client, interests []string) *GetWeeklyDigest {
+	return &GetWeeklyDigest{client: client, interests: interests}
+}
+
+func (t *GetWeeklyDigest) Name() string {
+	return "get_weekly_digest"
+}
+
+func (t *GetWeeklyDigest) Description() string {
+	return "Compile a digest of the past week: owns, points gained, rank movement and new releases matching your interests"
+}
+
+func (t *GetWeeklyDigest) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"interests": {
+				Type:        "array",
+				Description: "OS, difficulty or category keywords to filter new releases by (e.g. Linux, Hard, Web). Defaults to DIGEST_INTERESTS",
+				Items:       &mcp.Property{Type: "string"},
+			},
+		},
+	}
+}
+
+func (t *GetWeeklyDigest) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	interests := t.interests
+	if raw, ok := args["interests"].([]interface{}); ok {
+		interests = nil
+		for _, v := range raw {
+			if s, ok := v.(string); ok && s != "" {
+				interests = append(interests, s)
+			}
+		}
+	}
+
+	digest, err := compileDigest(ctx, t.client, interests, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
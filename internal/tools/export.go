@@ -0,0 +1,475 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Catalog kinds exported by the bulk export tools
+const (
+	catalogMachines   = "machines"
+	catalogChallenges = "challenges"
+)
+
+// catalogMaxAge is how long an exported catalog serves list and search
+// requests before they go back to the HTB API
+const catalogMaxAge = 24 * time.Hour
+
+// exportPerPage is the page size requested when walking paginated listings
+const exportPerPage = 100
+
+// exportMaxPages bounds how many pages of a single listing are walked
+const exportMaxPages = 200
+
+// exportMaxRetries bounds retries of a single page after HTTP 429
+const exportMaxRetries = 5
+
+// exportInitialBackoff is the first wait after HTTP 429 without a Retry-After
+const exportInitialBackoff = 2 * time.Second
+
+// exportMaxWait caps a single wait for the API's rate limit window to reset
+const exportMaxWait = 2 * time.Minute
+
+// catalog is a complete local snapshot of machines or challenges
+type catalog struct {
+	Kind       string                   `json:"kind"`
+	Items      []map[string]interface{} `json:"items"`
+	ExportedAt time.Time                `json:"exported_at"`
+}
+
+// catalogSource is a listing walked by an export
+type catalogSource struct {
+	status    string
+	endpoint  string
+	field     string
+	paginated bool
+}
+
+var catalogSources = map[string][]catalogSource{
+	catalogMachines: {
+		{status: "active", endpoint: "/machine/paginated/?per_page=%d", field: "data", paginated: true},
+		{status: "retired", endpoint: "/machine/list/retired/paginated/?per_page=%d&sort_by=release-date", field: "data", paginated: true},
+	},
+	catalogChallenges: {
+		{status: "active", endpoint: "/challenge/list", field: "challenges"},
+		{status: "retired", endpoint: "/challenge/list/retired", field: "challenges"},
+	},
+}
+
+// catalogKey returns the cache and persistent store key for an exported catalog
+func catalogKey(kind string) string {
+	return "catalog:" + kind
+}
+
+// catalogStampKey returns the cache key holding when a shared catalog was
+// exported, so it can be checked for changes without decoding it
+func catalogStampKey(kind string) string {
+	return catalogKey(kind) + ":exported_at"
+}
+
+// shareCatalog caches an exported catalog until it expires, so instances
+// serving the same account list and search it without exporting again.
+// Catalogs are cached as JSON so callers may modify the items they load.
+func shareCatalog(shared cache.Cache, cat *catalog) {
+	data, err := json.Marshal(cat)
+	if err != nil {
+		log.Printf("Failed to cache %s catalog: %v", cat.Kind, err)
+		return
+	}
+	ttl := catalogMaxAge - time.Since(cat.ExportedAt)
+	shared.SetTTL(catalogKey(cat.Kind), json.RawMessage(data), ttl)
+	shared.SetTTL(catalogStampKey(cat.Kind), cat.ExportedAt, ttl)
+}
+
+// loadCatalog returns the exported catalog of a kind if one exists and is
+// younger than catalogMaxAge, preferring one shared by another instance
+func loadCatalog(state *store.Store, shared cache.Cache, kind string) (*catalog, bool) {
+	var cat catalog
+	if cached, ok := shared.Get(catalogKey(kind)); ok {
+		if err := cache.Decode(cached, &cat); err == nil && time.Since(cat.ExportedAt) <= catalogMaxAge {
+			return &cat, true
+		}
+	}
+
+	savedAt, ok, err := state.Get(catalogKey(kind), &cat)
+	if err != nil || !ok || time.Since(savedAt) > catalogMaxAge {
+		return nil, false
+	}
+	return &cat, true
+}
+
+// catalogStamp returns when the catalog loadCatalog would return was saved,
+// or false if there is no fresh catalog of the kind
+func catalogStamp(state *store.Store, shared cache.Cache, kind string) (time.Time, bool) {
+	if cached, ok := shared.Get(catalogStampKey(kind)); ok {
+		var exportedAt time.Time
+		if err := cache.Decode(cached, &exportedAt); err == nil && time.Since(exportedAt) <= catalogMaxAge {
+			return exportedAt, true
+		}
+	}
+
+	savedAt, ok := state.SavedAt(catalogKey(kind))
+	return savedAt, ok && time.Since(savedAt) <= catalogMaxAge
+}
+
+// exportSummary describes a completed export
+type exportSummary struct {
+	Kind       string    `json:"kind"`
+	Total      int       `json:"total"`
+	Active     int       `json:"active"`
+	Retired    int       `json:"retired"`
+	Requests   int       `json:"requests"`
+	Retries    int       `json:"rate_limit_retries"`
+	Duration   string    `json:"duration"`
+	ExportedAt time.Time `json:"exported_at"`
+	ValidUntil time.Time `json:"valid_until"`
+}
+
+// exporter walks every listing of a catalog kind, pacing itself by the
+// API's rate limit headers and backing off on HTTP 429
+type exporter struct {
+	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
+
+	requests int
+	retries  int
+}
+
+// export fetches the complete catalog of a kind and stores it
+func (e *exporter) export(ctx context.Context, kind string) (*exportSummary, error) {
+	started := time.Now()
+	cat := catalog{Kind: kind, Items: []map[string]interface{}{}}
+	summary := &exportSummary{Kind: kind}
+
+	for _, source := range catalogSources[kind] {
+		items, err := e.walk(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s %s: %w", source.status, kind, err)
+		}
+		for _, item := range items {
+			item["status"] = source.status
+		}
+		cat.Items = append(cat.Items, items...)
+
+		if source.status == "retired" {
+			summary.Retired += len(items)
+		} else {
+			summary.Active += len(items)
+		}
+	}
+
+	cat.ExportedAt = time.Now().UTC()
+	if err := e.state.Put(catalogKey(kind), cat); err != nil {
+		return nil, fmt.Errorf("failed to store %s catalog: %w", kind, err)
+	}
+	shareCatalog(e.shared, &cat)
+
+	summary.Total = len(cat.Items)
+	summary.Requests = e.requests
+	summary.Retries = e.retries
+	summary.Duration = time.Since(started).Round(time.Millisecond).String()
+	summary.ExportedAt = cat.ExportedAt
+	summary.ValidUntil = cat.ExportedAt.Add(catalogMaxAge)
+	return summary, nil
+}
+
+// walk fetches every item of a listing, following pagination if it has any
+func (e *exporter) walk(ctx context.Context, source catalogSource) ([]map[string]interface{}, error) {
+	if !source.paginated {
+		var result map[string]interface{}
+		if err := e.get(ctx, source.endpoint, &result); err != nil {
+			return nil, err
+		}
+		return listItems(result[source.field]), nil
+	}
+
+	endpoint := fmt.Sprintf(source.endpoint, exportPerPage)
+	var items []map[string]interface{}
+	for page := 1; page <= exportMaxPages; page++ {
+		var result struct {
+			Data []interface{} `json:"data"`
+			Meta struct {
+				LastPage int `json:"last_page"`
+			} `json:"meta"`
+		}
+		if err := e.get(ctx, fmt.Sprintf("%s&page=%d", endpoint, page), &result); err != nil {
+			return nil, err
+		}
+
+		items = append(items, listItems(result.Data)...)
+		if len(result.Data) == 0 || page >= result.Meta.LastPage {
+			break
+		}
+	}
+	return items, nil
+}
+
+// get performs a GET request once the rate limit allows it, retrying with
+// exponential backoff after HTTP 429
+func (e *exporter) get(ctx context.Context, endpoint string, target interface{}) error {
+	backoff := exportInitialBackoff
+	for attempt := 0; ; attempt++ {
+		if err := e.pace(ctx); err != nil {
+			return err
+		}
+
+		e.requests++
+		err := e.client.GetJSON(ctx, endpoint, target)
+		if err == nil || !isRateLimited(err) || attempt >= exportMaxRetries {
+			return err
+		}
+
+		e.retries++
+		log.Printf("Export rate limited on %s, retrying in %s", endpoint, backoff)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// pace waits until the API is expected to accept another request: past the
+// Retry-After of the latest 429, or past the window reset once the remaining
+// request budget is spent
+func (e *exporter) pace(ctx context.Context) error {
+	status := e.client.RateLimitStatus()
+	now := time.Now()
+
+	var until time.Time
+	if status.RetryAfter != nil && status.RetryAfter.After(now) {
+		until = *status.RetryAfter
+	}
+	if status.Remaining != nil && *status.Remaining <= 0 && status.ResetAt != nil && status.ResetAt.After(until) {
+		until = *status.ResetAt
+	}
+	if !until.After(now) {
+		return nil
+	}
+
+	return sleepContext(ctx, min(until.Sub(now), exportMaxWait))
+}
+
+// listItems returns the object items of a decoded JSON list
+func listItems(data interface{}) []map[string]interface{} {
+	list, _ := data.([]interface{})
+	items := make([]map[string]interface{}, 0, len(list))
+	for _, raw := range list {
+		if item, ok := raw.(map[string]interface{}); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// catalogFilter selects catalog items by status, difficulty, a kind-specific
+// field (OS or category) and a name substring
+type catalogFilter struct {
+	status     string
+	difficulty htb.DifficultyLevel
+	field      string
+	value      string
+	query      string
+}
+
+// catalogFilterFromArgs builds a filter from list tool arguments; fieldArg
+// names the kind-specific argument ("os" or "category")
+func catalogFilterFromArgs(args map[string]interface{}, fieldArg string) catalogFilter {
+	filter := catalogFilter{status: "active", field: fieldArg}
+	if s, ok := args["status"].(string); ok && s != "" {
+		filter.status = s
+	}
+	if d, ok := args["difficulty"].(string); ok && d != "" {
+		filter.difficulty = htb.NormalizeDifficulty(d)
+	}
+	if v, ok := args[fieldArg].(string); ok {
+		filter.value = strings.TrimSpace(v)
+	}
+	if q, ok := args["query"].(string); ok {
+		filter.query = strings.ToLower(strings.TrimSpace(q))
+	}
+	return filter
+}
+
+// catalogFields maps a filter field to the item fields that may hold it
+var catalogFields = map[string][]string{
+	"os":         {"os"},
+	"category":   {"category_name", "category"},
+	"difficulty": {"difficultyText", "difficulty_text", "difficulty"},
+}
+
+// apply returns the catalog items matching the filter, sorted by name
+func (f catalogFilter) apply(items []map[string]interface{}) []interface{} {
+	matched := make([]interface{}, 0)
+	for _, item := range items {
+		if f.status != "all" && itemString(item, "status") != f.status {
+			continue
+		}
+		if f.difficulty != "" && htb.NormalizeDifficulty(itemString(item, catalogFields["difficulty"]...)) != f.difficulty {
+			continue
+		}
+		if f.value != "" && !strings.EqualFold(itemString(item, catalogFields[f.field]...), f.value) {
+			continue
+		}
+		if f.query != "" && !strings.Contains(strings.ToLower(itemString(item, "name")), f.query) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return strings.ToLower(itemString(matched[i].(map[string]interface{}), "name")) <
+			strings.ToLower(itemString(matched[j].(map[string]interface{}), "name"))
+	})
+	return matched
+}
+
+// itemString returns the first non-empty string among an item's fields
+func itemString(item map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := item[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// catalogNotice describes the snapshot a response was served from
+func catalogNotice(cat *catalog) string {
+	return fmt.Sprintf("Served from the local %s export of %s; pass refresh=true to query the HTB API instead",
+		cat.Kind, cat.ExportedAt.Format(time.RFC3339))
+}
+
+// catalogListing returns the items of the exported catalog of a kind matching
+// args and a notice naming the export, or false if there is no fresh catalog
+// or refresh was requested
+func catalogListing(state *store.Store, shared cache.Cache, kind, fieldArg string, args map[string]interface{}) ([]interface{}, string, bool) {
+	if refresh, _ := args["refresh"].(bool); refresh {
+		return nil, "", false
+	}
+	cat, ok := loadCatalog(state, shared, kind)
+	if !ok {
+		return nil, "", false
+	}
+
+	return catalogFilterFromArgs(args, fieldArg).apply(cat.Items), catalogNotice(cat), true
+}
+
+// catalogSchemaProperties are the list tool properties for querying an export
+var catalogSchemaProperties = map[string]mcp.Property{
+	"query": {
+		Type:        "string",
+		Description: "Only return items whose name contains this text (requires an export)",
+	},
+	"refresh": {
+		Type:        "boolean",
+		Description: "Query the HTB API even when a local export is available",
+		Default:     false,
+	},
+}
+
+// withCatalogProperties adds the catalog query properties to a list tool schema
+func withCatalogProperties(properties map[string]mcp.Property) map[string]mcp.Property {
+	for name, property := range catalogSchemaProperties {
+		properties[name] = property
+	}
+	return properties
+}
+
+// ExportAllMachines tool for exporting every active and retired machine
+type ExportAllMachines struct {
+	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
+}
+
+func NewExportAllMachines(client *htb.Client, state *store.Store, shared cache.Cache) *ExportAllMachines {
+	return &ExportAllMachines{client: client, state: state, shared: shared}
+}
+
+func (t *ExportAllMachines) Name() string {
+	return "export_all_machines"
+}
+
+func (t *ExportAllMachines) Description() string {
+	return "Download every active and retired HackTheBox machine, respecting rate limits, into a local snapshot that list_machines and search_content then query without calling the HTB API"
+}
+
+func (t *ExportAllMachines) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ExportAllMachines) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	e := &exporter{client: t.client, state: t.state, shared: t.shared}
+	summary, err := e.export(ctx, catalogMachines)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ExportAllChallenges tool for exporting every active and retired challenge
+type ExportAllChallenges struct {
+	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
+}
+
+func NewExportAllChallenges(client *htb.Client, state *store.Store, shared cache.Cache) *ExportAllChallenges {
+	return &ExportAllChallenges{client: client, state: state, shared: shared}
+}
+
+func (t *ExportAllChallenges) Name() string {
+	return "export_all_challenges"
+}
+
+func (t *ExportAllChallenges) Description() string {
+	return "Download every active and retired HackTheBox challenge, respecting rate limits, into a local snapshot that list_challenges and search_content then query without calling the HTB API"
+}
+
+func (t *ExportAllChallenges) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ExportAllChallenges) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	e := &exporter{client: t.client, state: t.state, shared: t.shared}
+	summary, err := e.export(ctx, catalogChallenges)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
@@ -0,0 +1,443 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// flagQueueKey is the persistent store key of the flag retry queue
+const flagQueueKey = "flag_queue"
+
+// What flag submission tools do when HTB can't be reached
+const (
+	outageFail  = "fail"
+	outageQueue = "queue"
+	outageRetry = "retry"
+)
+
+var onOutageProperty = mcp.Property{
+	Type:        "string",
+	Description: "What to do if HTB can't be reached: fail (default), queue the flag to submit later with retry_queued_flags, or retry it automatically once HTB is reachable again",
+	Enum:        []string{outageFail, outageQueue, outageRetry},
+	Default:     outageFail,
+}
+
+// outageMode returns the on_outage argument of a flag submission
+func outageMode(args map[string]interface{}) (string, error) {
+	mode, _ := args["on_outage"].(string)
+	switch mode {
+	case "":
+		return outageFail, nil
+	case outageFail, outageQueue, outageRetry:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("on_outage must be fail, queue or retry, got %q", mode)
+	}
+}
+
+// queuedFlag is a flag submission kept in the retry queue
+type queuedFlag struct {
+	ID         int       `json:"id"`
+	Target     string    `json:"target"`
+	TargetID   int       `json:"target_id"`
+	Flag       string    `json:"flag"`
+	Difficulty int       `json:"difficulty,omitempty"`
+	AutoRetry  bool      `json:"auto_retry"`
+	QueuedAt   time.Time `json:"queued_at"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// queuedFlagResult is the outcome of retrying a queued flag
+type queuedFlagResult struct {
+	Flag    queuedFlag            `json:"flag"`
+	Result  *htb.SubmissionResult `json:"result,omitempty"`
+	Error   string                `json:"error,omitempty"`
+	Pending bool                  `json:"pending"`
+}
+
+// flagQueue is a write-ahead queue of flag submissions. A submission that
+// may be queued is written to the persistent store before it is sent and
+// removed once HTB answers, so flags survive both API outages and restarts.
+type flagQueue struct {
+	client *htb.Client
+	state  *store.Store
+	notify func(level, logger string, data interface{})
+
+	// watch starts the automatic retry of queued flags
+	watch func()
+
+	// mu serializes queue updates. It is not held while flags are sent to
+	// HTB; submitting marks the flags being sent so a flag is never
+	// submitted twice concurrently.
+	mu         sync.Mutex
+	submitting map[int]bool
+}
+
+func newFlagQueue(client *htb.Client, state *store.Store, notify func(level, logger string, data interface{}), watch func()) *flagQueue {
+	return &flagQueue{client: client, state: state, notify: notify, watch: watch, submitting: make(map[int]bool)}
+}
+
+// load returns the queued flags; callers hold q.mu
+func (q *flagQueue) load() []queuedFlag {
+	var flags []queuedFlag
+	if _, _, err := q.state.Get(flagQueueKey, &flags); err != nil {
+		log.Printf("Discarding unreadable flag queue: %v", err)
+	}
+	return flags
+}
+
+// list returns the queued flags
+func (q *flagQueue) list() []queuedFlag {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load()
+}
+
+// hasAutoRetry reports whether any queued flag is retried automatically
+func (q *flagQueue) hasAutoRetry() bool {
+	for _, flag := range q.list() {
+		if flag.AutoRetry {
+			return true
+		}
+	}
+	return false
+}
+
+// update applies fn to the queued flags and persists the result; callers
+// hold q.mu
+func (q *flagQueue) update(fn func(flags []queuedFlag) []queuedFlag) error {
+	flags := fn(q.load())
+	if err := q.state.Put(flagQueueKey, flags); err != nil {
+		return fmt.Errorf("failed to save flag queue: %w", err)
+	}
+	return nil
+}
+
+// remove drops a flag from the queue; callers hold q.mu
+func (q *flagQueue) remove(id int) error {
+	return q.update(func(flags []queuedFlag) []queuedFlag {
+		kept := flags[:0]
+		for _, flag := range flags {
+			if flag.ID != id {
+				kept = append(kept, flag)
+			}
+		}
+		return kept
+	})
+}
+
+// submit submits a flag. Unless mode is outageFail the flag is written to
+// the queue first; it stays there when HTB is unreachable, in which case
+// the queued entry is returned instead of a result.
+func (q *flagQueue) submit(ctx context.Context, mode, target string, id int, flag string, difficulty int) (*htb.SubmissionResult, *queuedFlag, error) {
+	if mode == outageFail {
+		result, err := submitFlag(ctx, q.client, target, id, flag, difficulty)
+		return result, nil, err
+	}
+
+	q.mu.Lock()
+	entry := queuedFlag{
+		Target:     target,
+		TargetID:   id,
+		Flag:       flag,
+		Difficulty: difficulty,
+		AutoRetry:  mode == outageRetry,
+		QueuedAt:   time.Now(),
+		Attempts:   1,
+	}
+	err := q.update(func(flags []queuedFlag) []queuedFlag {
+		for _, queued := range flags {
+			entry.ID = max(entry.ID, queued.ID)
+		}
+		entry.ID++
+		return append(flags, entry)
+	})
+	if err != nil {
+		q.mu.Unlock()
+		return nil, nil, err
+	}
+	q.submitting[entry.ID] = true
+	q.mu.Unlock()
+
+	result, err := submitFlag(ctx, q.client, target, id, flag, difficulty)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.submitting, entry.ID)
+
+	if err != nil && isUnreachable(err) {
+		entry.LastError = err.Error()
+		q.setError(entry.ID, entry.LastError)
+		if entry.AutoRetry {
+			q.watch()
+		}
+		return nil, &entry, nil
+	}
+
+	if removeErr := q.remove(entry.ID); removeErr != nil {
+		log.Printf("Failed to remove flag %d from the queue: %v", entry.ID, removeErr)
+	}
+	return result, nil, err
+}
+
+// setError records a failed attempt on a queued flag; callers hold q.mu
+func (q *flagQueue) setError(id int, message string) {
+	err := q.update(func(flags []queuedFlag) []queuedFlag {
+		for i := range flags {
+			if flags[i].ID == id {
+				flags[i].LastError = message
+			}
+		}
+		return flags
+	})
+	if err != nil {
+		log.Printf("Failed to update flag %d in the queue: %v", id, err)
+	}
+}
+
+// retry submits the queued flags selected by match, removing those HTB
+// answered and keeping those it still can't be reached for. The selected
+// flags are snapshotted under q.mu and submitted without it; flags already
+// being submitted are skipped.
+func (q *flagQueue) retry(ctx context.Context, match func(flag queuedFlag) bool) []queuedFlagResult {
+	q.mu.Lock()
+	var selected []queuedFlag
+	for _, flag := range q.load() {
+		if match(flag) && !q.submitting[flag.ID] {
+			q.submitting[flag.ID] = true
+			selected = append(selected, flag)
+		}
+	}
+	q.mu.Unlock()
+
+	results := []queuedFlagResult{}
+	for _, flag := range selected {
+		flag.Attempts++
+		result, err := submitFlag(ctx, q.client, flag.Target, flag.TargetID, flag.Flag, flag.Difficulty)
+		outcome := queuedFlagResult{Flag: flag, Result: result}
+		if err != nil {
+			outcome.Error = err.Error()
+			outcome.Pending = isUnreachable(err)
+		}
+		results = append(results, outcome)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, outcome := range results {
+		var err error
+		if outcome.Pending {
+			err = q.update(func(flags []queuedFlag) []queuedFlag {
+				for i := range flags {
+					if flags[i].ID == outcome.Flag.ID {
+						flags[i].Attempts = outcome.Flag.Attempts
+						flags[i].LastError = outcome.Error
+					}
+				}
+				return flags
+			})
+		} else {
+			err = q.remove(outcome.Flag.ID)
+		}
+		if err != nil {
+			log.Printf("Failed to update flag %d in the queue: %v", outcome.Flag.ID, err)
+		}
+		delete(q.submitting, outcome.Flag.ID)
+	}
+	return results
+}
+
+// discard drops the queued flags selected by match and returns them
+func (q *flagQueue) discard(match func(flag queuedFlag) bool) ([]queuedFlag, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var discarded []queuedFlag
+	err := q.update(func(flags []queuedFlag) []queuedFlag {
+		kept := flags[:0]
+		for _, flag := range flags {
+			if match(flag) {
+				discarded = append(discarded, flag)
+			} else {
+				kept = append(kept, flag)
+			}
+		}
+		return kept
+	})
+	return discarded, err
+}
+
+// Handle is a poller Handler retrying auto-retry flags: a successful poll
+// means HTB is reachable again
+func (q *flagQueue) Handle(ctx context.Context, machine *htb.ActiveMachineInfo) {
+	if !q.hasAutoRetry() {
+		return
+	}
+
+	results := q.retry(ctx, func(flag queuedFlag) bool { return flag.AutoRetry })
+	for _, outcome := range results {
+		if outcome.Pending {
+			continue
+		}
+
+		level := mcp.LogLevelNotice
+		if outcome.Result == nil || !outcome.Result.Success {
+			level = mcp.LogLevelWarning
+		}
+		q.notify(level, "flag_queue", map[string]interface{}{
+			"event":   "queued_flag_submitted",
+			"flag":    outcome.Flag,
+			"result":  outcome.Result,
+			"error":   outcome.Error,
+			"message": queuedFlagMessage(outcome),
+		})
+	}
+}
+
+// queuedFlagMessage summarizes the outcome of a retried flag
+func queuedFlagMessage(outcome queuedFlagResult) string {
+	target := fmt.Sprintf("%s %d", outcome.Flag.Target, outcome.Flag.TargetID)
+	switch {
+	case outcome.Pending:
+		return fmt.Sprintf("Queued flag #%d for %s is still pending: HTB is unreachable", outcome.Flag.ID, target)
+	case outcome.Error != "":
+		return fmt.Sprintf("Queued flag #%d for %s failed: %s", outcome.Flag.ID, target, outcome.Error)
+	case outcome.Result.Success:
+		return fmt.Sprintf("Queued flag #%d for %s was accepted: %s", outcome.Flag.ID, target, outcome.Result.Message)
+	default:
+		return fmt.Sprintf("Queued flag #%d for %s was rejected: %s", outcome.Flag.ID, target, outcome.Result.Message)
+	}
+}
+
+// ListQueuedFlags tool for listing flag submissions queued during outages
+type ListQueuedFlags struct {
+	queue *flagQueue
+}
+
+func NewListQueuedFlags(queue *flagQueue) *ListQueuedFlags {
+	return &ListQueuedFlags{queue: queue}
+}
+
+func (t *ListQueuedFlags) Name() string {
+	return "list_queued_flags"
+}
+
+func (t *ListQueuedFlags) Description() string {
+	return "List flag submissions queued while HTB was unreachable, with their attempts and last error"
+}
+
+func (t *ListQueuedFlags) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ListQueuedFlags) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	flags := t.queue.list()
+	if flags == nil {
+		flags = []queuedFlag{}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"queued": flags,
+		"count":  len(flags),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// RetryQueuedFlags tool for submitting or discarding queued flags
+type RetryQueuedFlags struct {
+	queue *flagQueue
+}
+
+func NewRetryQueuedFlags(queue *flagQueue) *RetryQueuedFlags {
+	return &RetryQueuedFlags{queue: queue}
+}
+
+func (t *RetryQueuedFlags) Name() string {
+	return "retry_queued_flags"
+}
+
+func (t *RetryQueuedFlags) Description() string {
+	return "Submit flags queued while HTB was unreachable, or discard them. Flags HTB still can't be reached for stay queued."
+}
+
+func (t *RetryQueuedFlags) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"id": {
+				Type:        "integer",
+				Description: "Only retry or discard the queued flag with this ID (from list_queued_flags); all queued flags if omitted",
+			},
+			"discard": {
+				Type:        "boolean",
+				Description: "Discard the flags instead of submitting them",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *RetryQueuedFlags) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	match := func(flag queuedFlag) bool { return true }
+	if raw, exists := args["id"]; exists && raw != nil {
+		id, ok := numericID(raw)
+		if !ok {
+			return nil, fmt.Errorf("id must be a queued flag ID")
+		}
+		match = func(flag queuedFlag) bool { return flag.ID == id }
+	}
+
+	var response interface{}
+	if discard, _ := args["discard"].(bool); discard {
+		discarded, err := t.queue.discard(match)
+		if err != nil {
+			return nil, err
+		}
+		if discarded == nil {
+			discarded = []queuedFlag{}
+		}
+		response = map[string]interface{}{
+			"discarded": discarded,
+			"count":     len(discarded),
+		}
+	} else {
+		results := t.queue.retry(ctx, match)
+		messages := make([]string, 0, len(results))
+		for _, outcome := range results {
+			messages = append(messages, queuedFlagMessage(outcome))
+		}
+		response = map[string]interface{}{
+			"results":  results,
+			"messages": messages,
+			"count":    len(results),
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
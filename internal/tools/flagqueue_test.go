@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestFlagQueueRetryDoesNotHoldLockWhileSubmitting(t *testing.T) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.Write([]byte(`{"success":true,"message":"Flag accepted"}`))
+	}))
+	defer api.Close()
+
+	client := htb.NewClient(&config.Config{HTBToken: "header.payload.signature", HTBBaseURL: api.URL, RequestTimeout: 5 * time.Second})
+	q := newFlagQueue(client, store.Open(""), func(level, logger string, data interface{}) {}, func() {})
+
+	q.mu.Lock()
+	err := q.update(func(flags []queuedFlag) []queuedFlag {
+		return append(flags, queuedFlag{ID: 1, Target: flagTargetMachine, TargetID: 7, Flag: "HTB{x}"})
+	})
+	q.mu.Unlock()
+	if err != nil {
+		t.Fatalf("Failed to queue flag: %v", err)
+	}
+
+	done := make(chan []queuedFlagResult)
+	go func() {
+		done <- q.retry(context.Background(), func(flag queuedFlag) bool { return true })
+	}()
+	<-received
+
+	// The queue stays readable while HTB is slow to answer
+	listed := make(chan []queuedFlag)
+	go func() { listed <- q.list() }()
+	select {
+	case flags := <-listed:
+		if len(flags) != 1 {
+			t.Errorf("Expected the flag to stay queued while it is submitted, got %+v", flags)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Listing the queue blocked on a submission")
+	}
+
+	// A concurrent retry skips the flag being submitted
+	if results := q.retry(context.Background(), func(flag queuedFlag) bool { return true }); len(results) != 0 {
+		t.Errorf("Expected the in-flight flag to be skipped, got %+v", results)
+	}
+
+	close(release)
+	results := <-done
+	if len(results) != 1 || results[0].Pending || results[0].Result == nil || !results[0].Result.Success {
+		t.Errorf("Expected the flag to be submitted, got %+v", results)
+	}
+	if flags := q.list(); len(flags) != 0 {
+		t.Errorf("Expected the submitted flag to leave the queue, got %+v", flags)
+	}
+}
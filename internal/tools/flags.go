@@ -0,0 +1,315 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Flag submission target types
+const (
+	flagTargetMachine   = "machine"
+	flagTargetChallenge = "challenge"
+	flagTargetFortress  = "fortress"
+	flagTargetEndgame   = "endgame"
+	flagTargetProlab    = "prolab"
+)
+
+// defaultDifficultyRating is used when no difficulty rating is supplied
+const defaultDifficultyRating = 5
+
+// maxRateLimitRetries bounds retries of a single submission after HTTP 429
+const maxRateLimitRetries = 3
+
+// submitFlag submits a flag to the endpoint matching the target type. Flags
+// rejected by the API are reported as an unsuccessful result; only transport
+// errors, authentication errors and rate limiting are returned as errors.
+func submitFlag(ctx context.Context, client *htb.Client, target string, id int, flag string, difficulty int) (*htb.SubmissionResult, error) {
+	var endpoint string
+	payload := htb.FlagSubmissionRequest{Flag: flag}
+	if difficulty > 0 {
+		payload.Difficulty = strconv.Itoa(difficulty)
+	}
+
+	switch target {
+	case flagTargetMachine:
+		endpoint = "/machine/own"
+		payload.ID = id
+	case flagTargetChallenge:
+		endpoint = "/challenge/own"
+		payload.ChallengeID = strconv.Itoa(id)
+	case flagTargetFortress, flagTargetEndgame, flagTargetProlab:
+		endpoint = fmt.Sprintf("/%s/%d/flag", target, id)
+		payload.Difficulty = ""
+	default:
+		return nil, fmt.Errorf("unsupported target type: %s", target)
+	}
+
+	var result htb.SubmissionResult
+	if err := client.PostJSON(ctx, endpoint, payload, &result); err != nil {
+		var apiErr *htb.HTBAPIError
+		if errors.As(err, &apiErr) && isFlagRejection(apiErr.StatusCode) {
+			return &htb.SubmissionResult{Success: false, Message: apiErr.Message}, nil
+		}
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// isFlagRejection reports whether an HTTP status means the API rejected the flag itself
+func isFlagRejection(statusCode int) bool {
+	return statusCode >= 400 && statusCode < 500 &&
+		statusCode != http.StatusTooManyRequests &&
+		statusCode != http.StatusUnauthorized
+}
+
+// verifiedSubmission is a submission result with the own verification of
+// accepted flags
+type verifiedSubmission struct {
+	*htb.SubmissionResult
+	Verification *ownVerification `json:"verification,omitempty"`
+
+	// Queued is set when HTB was unreachable and the flag was queued
+	Queued *queuedFlag `json:"queued,omitempty"`
+}
+
+// flagSubmissionSchema is the output schema of the flag submission tools
+var flagSubmissionSchema = mcp.SchemaFor(verifiedSubmission{})
+
+// flagSubmissionResponse builds a tool response from a submission result,
+// flagging rejected submissions with isError
+func flagSubmissionResponse(result *htb.SubmissionResult, verification *ownVerification) (*mcp.CallToolResponse, error) {
+	response, err := mcp.CreateStructuredResponse(verifiedSubmission{SubmissionResult: result, Verification: verification})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	response.IsError = !result.Success
+	return response, nil
+}
+
+// queuedSubmissionResponse builds a tool response for a flag queued because
+// HTB was unreachable
+func queuedSubmissionResponse(entry *queuedFlag) (*mcp.CallToolResponse, error) {
+	next := "run retry_queued_flags to submit it once HTB is reachable"
+	if entry.AutoRetry {
+		next = "it will be submitted automatically once HTB is reachable again"
+	}
+
+	result := &htb.SubmissionResult{
+		Message: fmt.Sprintf("HTB is unreachable (%s); the flag is queued as #%d and %s", entry.LastError, entry.ID, next),
+	}
+	response, err := mcp.CreateStructuredResponse(verifiedSubmission{SubmissionResult: result, Queued: entry})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+	return response, nil
+}
+
+// difficultyRating extracts an optional 1-10 difficulty rating from args
+func difficultyRating(args map[string]interface{}) (int, error) {
+	raw, exists := args["difficulty"]
+	if !exists || raw == nil {
+		return defaultDifficultyRating, nil
+	}
+
+	difficulty, ok := raw.(float64)
+	if !ok || difficulty != float64(int(difficulty)) {
+		return 0, fmt.Errorf("difficulty must be an integer between 1 and 10")
+	}
+	if difficulty < 1 || difficulty > 10 {
+		return 0, fmt.Errorf("difficulty must be between 1 and 10, got %d", int(difficulty))
+	}
+
+	return int(difficulty), nil
+}
+
+// isRateLimited reports whether err is an HTB API HTTP 429 response
+func isRateLimited(err error) bool {
+	var apiErr *htb.HTBAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// batchFlagResult represents the outcome of one flag in a batch submission
+type batchFlagResult struct {
+	Index         int    `json:"index"`
+	Type          string `json:"type"`
+	ID            int    `json:"id"`
+	Success       bool   `json:"success"`
+	Message       string `json:"message,omitempty"`
+	PointsAwarded int    `json:"points_awarded,omitempty"`
+	FirstBlood    bool   `json:"first_blood,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// SubmitFlagsBatch tool for submitting several flags sequentially
+type SubmitFlagsBatch struct {
+	client  *htb.Client
+	notes   *notes.Store
+	spacing time.Duration
+}
+
+func NewSubmitFlagsBatch(client *htb.Client, store *notes.Store, rateLimitPerMinute int) *SubmitFlagsBatch {
+	spacing := time.Second
+	if rateLimitPerMinute > 0 {
+		spacing = time.Minute / time.Duration(rateLimitPerMinute)
+	}
+
+	return &SubmitFlagsBatch{client: client, notes: store, spacing: spacing}
+}
+
+func (t *SubmitFlagsBatch) Name() string {
+	return "submit_flags_batch"
+}
+
+func (t *SubmitFlagsBatch) Description() string {
+	return "Submit multiple flags (machines, challenges, fortresses, endgames, Pro Labs) sequentially with rate-limit awareness and return per-flag results"
+}
+
+func (t *SubmitFlagsBatch) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"flags": {
+				Type:        "array",
+				Description: "Flags to submit, in order",
+				Items: &mcp.Property{
+					Type:        "object",
+					Description: "A single flag submission",
+					Properties: map[string]mcp.Property{
+						"type": {
+							Type:        "string",
+							Description: "Target type",
+							Enum:        []string{flagTargetMachine, flagTargetChallenge, flagTargetFortress, flagTargetEndgame, flagTargetProlab},
+						},
+						"id": {
+							Type:        "integer",
+							Description: "The ID of the target",
+						},
+						"flag": {
+							Type:        "string",
+							Description: "The flag to submit",
+						},
+						"difficulty": {
+							Type:        "integer",
+							Description: "Difficulty rating (1-10) for machines and challenges",
+							Default:     defaultDifficultyRating,
+						},
+					},
+					Required: []string{"type", "id", "flag"},
+				},
+			},
+		},
+		Required: []string{"flags"},
+	}
+}
+
+func (t *SubmitFlagsBatch) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	flags, ok := args["flags"].([]interface{})
+	if !ok || len(flags) == 0 {
+		return nil, fmt.Errorf("flags is required")
+	}
+
+	results := make([]batchFlagResult, 0, len(flags))
+	for i, raw := range flags {
+		if i > 0 {
+			if err := sleepContext(ctx, t.spacing); err != nil {
+				return nil, err
+			}
+		}
+
+		result := batchFlagResult{Index: i}
+
+		entry, ok := raw.(map[string]interface{})
+		if !ok {
+			result.Error = "flag entry must be an object"
+			results = append(results, result)
+			continue
+		}
+
+		result.Type, _ = entry["type"].(string)
+		id, idOK := numericID(entry["id"])
+		flag, flagOK := entry["flag"].(string)
+		if result.Type == "" || !idOK || !flagOK {
+			result.Error = "type, id and flag are required"
+			results = append(results, result)
+			continue
+		}
+		result.ID = id
+
+		difficulty, err := difficultyRating(entry)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		submission, err := t.submitWithRetry(ctx, result.Type, result.ID, flag, difficulty*10)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = submission.Success
+			result.Message = submission.Message
+			result.PointsAwarded = submission.PointsAwarded
+			result.FirstBlood = submission.FirstBlood
+		}
+
+		if result.Type == flagTargetMachine {
+			session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+				Kind:      notes.KindFlag,
+				MachineID: result.ID,
+				Text:      fmt.Sprintf("Batch flag submission result: %s%s", result.Message, result.Error),
+			})
+		}
+
+		results = append(results, result)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// submitWithRetry submits a flag, backing off exponentially on HTTP 429
+func (t *SubmitFlagsBatch) submitWithRetry(ctx context.Context, target string, id int, flag string, difficulty int) (*htb.SubmissionResult, error) {
+	backoff := t.spacing * 2
+	for attempt := 0; ; attempt++ {
+		result, err := submitFlag(ctx, t.client, target, id, flag, difficulty)
+		if err == nil || !isRateLimited(err) || attempt >= maxRateLimitRetries {
+			return result, err
+		}
+
+		if err := sleepContext(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ListNotifications tool for reading the user's platform notification inbox
+type ListNotifications struct {
+	client *htb.Client
+}
+
+func NewListNotifications(client *htb.Client) *ListNotifications {
+	return &ListNotifications{client: client}
+}
+
+func (t *ListNotifications) Name() string {
+	return "list_notifications"
+}
+
+func (t *ListNotifications) Description() string {
+	return "List the user's HackTheBox notification inbox (respect received, team invites, content updates) with unread counts per type, so they can be triaged"
+}
+
+func (t *ListNotifications) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"unread_only": {
+				Type:        "boolean",
+				Description: "Only list unread notifications",
+				Default:     true,
+			},
+			"type": {
+				Type:        "string",
+				Description: "Only list notifications of this type (case-insensitive), e.g. respect, team_invite or content_update",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: "Maximum number of notifications to list",
+				Default:     20,
+			},
+		},
+	}
+}
+
+func (t *ListNotifications) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	unreadOnly := true
+	if u, ok := args["unread_only"].(bool); ok {
+		unreadOnly = u
+	}
+	notificationType, _ := args["type"].(string)
+	notificationType = strings.TrimSpace(notificationType)
+	limit := 20
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	// Make API request
+	inbox, err := t.client.GetNotifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	unread := 0
+	unreadByType := make(map[string]int)
+	notifications := []htb.Notification{}
+	for _, n := range inbox {
+		if !n.Read {
+			unread++
+			unreadByType[n.Type]++
+		}
+		if (unreadOnly && n.Read) || (notificationType != "" && !strings.EqualFold(n.Type, notificationType)) {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+
+	matched := len(notifications)
+	if matched > limit {
+		notifications = notifications[:limit]
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"total":          len(inbox),
+		"unread":         unread,
+		"unread_by_type": unreadByType,
+		"matched":        matched,
+		"notifications":  notifications,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// MarkNotificationsRead tool for marking inbox notifications as read
+type MarkNotificationsRead struct {
+	client *htb.Client
+}
+
+func NewMarkNotificationsRead(client *htb.Client) *MarkNotificationsRead {
+	return &MarkNotificationsRead{client: client}
+}
+
+func (t *MarkNotificationsRead) Name() string {
+	return "mark_notifications_read"
+}
+
+func (t *MarkNotificationsRead) Description() string {
+	return "Mark HackTheBox inbox notifications as read, either by ID (from list_notifications) or the whole inbox with all=true"
+}
+
+func (t *MarkNotificationsRead) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"notification_ids": {
+				Type:        "array",
+				Description: "IDs of the notifications to mark as read",
+				Items:       &mcp.Property{Type: "integer"},
+			},
+			"all": {
+				Type:        "boolean",
+				Description: "Mark every notification in the inbox as read",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *MarkNotificationsRead) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	all, _ := args["all"].(bool)
+	raw, _ := args["notification_ids"].([]interface{})
+
+	var ids []int
+	for _, v := range raw {
+		id, ok := numericID(v)
+		if !ok {
+			return nil, fmt.Errorf("notification_ids must be numeric notification IDs")
+		}
+		ids = append(ids, id)
+	}
+	switch {
+	case all && len(ids) > 0:
+		return nil, fmt.Errorf("pass either notification_ids or all, not both")
+	case !all && len(ids) == 0:
+		return nil, fmt.Errorf("notification_ids or all is required")
+	}
+
+	// Make API request
+	var message string
+	var err error
+	if all {
+		message, err = t.client.MarkAllNotificationsRead(ctx)
+	} else {
+		message, err = t.client.MarkNotificationsRead(ctx, ids)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"all":     all,
+		"message": message,
+	}
+	if !all {
+		result["notification_ids"] = ids
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
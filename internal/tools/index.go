@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/search"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+)
+
+// searchIndex keeps a full-text index over the exported machine and
+// challenge catalogs, rebuilding it when an export is stored here or shared
+// by another instance
+type searchIndex struct {
+	state  *store.Store
+	shared cache.Cache
+
+	mu    sync.Mutex
+	index *search.Index
+
+	// builtFrom holds the save time of each catalog the index was built from
+	builtFrom map[string]time.Time
+}
+
+func newSearchIndex(state *store.Store, shared cache.Cache) *searchIndex {
+	return &searchIndex{state: state, shared: shared, builtFrom: make(map[string]time.Time)}
+}
+
+// current returns the index over the fresh catalogs, or nil if there are none
+func (c *searchIndex) current() *search.Index {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fresh := make(map[string]time.Time)
+	for _, kind := range []string{catalogMachines, catalogChallenges} {
+		if savedAt, ok := catalogStamp(c.state, c.shared, kind); ok {
+			fresh[kind] = savedAt
+		}
+	}
+	if c.index != nil && sameTimes(fresh, c.builtFrom) {
+		return c.index
+	}
+
+	var docs []search.Document
+	for kind := range fresh {
+		if cat, ok := loadCatalog(c.state, c.shared, kind); ok {
+			docs = append(docs, catalogDocuments(cat)...)
+		}
+	}
+
+	c.index = nil
+	if len(docs) > 0 {
+		c.index = search.New(docs)
+	}
+	c.builtFrom = fresh
+	return c.index
+}
+
+// complete returns names of a kind completing prefix and the total number of matches
+func (c *searchIndex) complete(kind, prefix string, limit int) ([]string, int) {
+	return c.current().Complete(kind, prefix, limit)
+}
+
+// catalogDocuments converts catalog items to search documents. Categories,
+// operating systems, difficulties and tags are all indexed as tags.
+func catalogDocuments(cat *catalog) []search.Document {
+	docs := make([]search.Document, 0, len(cat.Items))
+	for _, item := range cat.Items {
+		doc := search.Document{
+			Kind:        cat.Kind,
+			Name:        itemString(item, "name"),
+			Description: itemString(item, "description", "synopsis"),
+			Item:        item,
+		}
+		if id, ok := item["id"].(float64); ok {
+			doc.ID = int(id)
+		}
+		for _, keys := range [][]string{catalogFields["os"], catalogFields["category"], catalogFields["difficulty"]} {
+			if tag := itemString(item, keys...); tag != "" {
+				doc.Tags = append(doc.Tags, tag)
+			}
+		}
+		doc.Tags = append(doc.Tags, itemTags(item["tags"])...)
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// itemTags returns tag names from a list of strings or of {"name": ...} objects
+func itemTags(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	var tags []string
+	for _, tag := range list {
+		switch t := tag.(type) {
+		case string:
+			tags = append(tags, t)
+		case map[string]interface{}:
+			if name := itemString(t, "name"); name != "" {
+				tags = append(tags, name)
+			}
+		}
+	}
+	return tags
+}
+
+// sameTimes reports whether two catalog save time sets are equal
+func sameTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for kind, t := range a {
+		if !b[kind].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
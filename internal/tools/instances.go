@@ -0,0 +1,501 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ListActiveInstances tool for aggregating everything running on the account
+type ListActiveInstances struct {
+	client *htb.Client
+}
+
+func NewListActiveInstances(client *htb.Client) *ListActiveInstances {
+	return &ListActiveInstances{client: client}
+}
+
+func (t *ListActiveInstances) Name() string {
+	return "list_active_instances"
+}
+
+func (t *ListActiveInstances) Description() string {
+	return "List everything currently running on the account: active machine, release-arena machine, challenge containers, Pwnbox and VPN/Pro Lab connections"
+}
+
+func (t *ListActiveInstances) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ListActiveInstances) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	result := make(map[string]interface{})
+	errs := make(map[string]string)
+
+	// A failing source should not hide the others, so errors are collected per source
+	if machine, err := t.client.GetActiveMachine(ctx); err != nil {
+		errs["machine"] = err.Error()
+	} else {
+		result["machine"] = machine
+	}
+
+	if arena, err := t.client.GetArenaMachine(ctx); err != nil {
+		errs["release_arena"] = err.Error()
+	} else {
+		result["release_arena"] = arena
+	}
+
+	if challenges, err := t.client.GetWithParsing(ctx, "/challenge/active", "info"); err != nil {
+		errs["challenges"] = err.Error()
+	} else {
+		result["challenges"] = challenges
+	}
+
+	if pwnbox, err := t.client.GetWithParsing(ctx, "/pwnbox/status", "data"); err != nil {
+		errs["pwnbox"] = err.Error()
+	} else {
+		result["pwnbox"] = pwnbox
+	}
+
+	if connections, err := t.client.GetWithParsing(ctx, "/connection/status", ""); err != nil {
+		errs["connections"] = err.Error()
+	} else {
+		result["connections"] = connections
+	}
+
+	if len(errs) > 0 {
+		result["errors"] = errs
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// Instance placement options for machine spawns
+const (
+	instanceAuto      = "auto"
+	instanceDedicated = "dedicated"
+	instanceShared    = "shared"
+)
+
+// SpawnMachineInstance tool for spawning a machine on a dedicated or shared instance
+type SpawnMachineInstance struct {
+	client    *htb.Client
+	machines  *machineResolver
+	notes     *notes.Store
+	watch     spawnWatchFunc
+	vpnRegion string
+}
+
+func NewSpawnMachineInstance(client *htb.Client, machines *machineResolver, store *notes.Store, watch spawnWatchFunc, vpnRegion string) *SpawnMachineInstance {
+	return &SpawnMachineInstance{client: client, machines: machines, notes: store, watch: watch, vpnRegion: vpnRegion}
+}
+
+func (t *SpawnMachineInstance) Name() string {
+	return "spawn_machine_instance"
+}
+
+func (t *SpawnMachineInstance) Description() string {
+	return "Spawn a machine on a VIP+ dedicated instance or a shared server, choosing automatically from the account's subscription. Queued spawns report their queue position and estimated wait, like start_machine"
+}
+
+func (t *SpawnMachineInstance) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine to spawn",
+			},
+			"machine_name": machineNameProperty,
+			"instance": {
+				Type:        "string",
+				Description: "Where to spawn the machine; auto uses a dedicated instance for VIP+ accounts",
+				Enum:        []string{instanceAuto, instanceDedicated, instanceShared},
+				Default:     instanceAuto,
+			},
+			"vpn_region": {
+				Type:        "string",
+				Description: "Preferred VPN region; the lab VPN server is switched to it before spawning if needed. Defaults to PREFERRED_VPN_REGION",
+				Enum:        []string{"EU", "US", "AU", "SG"},
+			},
+			"wait": spawnWaitProperty,
+		},
+	}
+}
+
+func (t *SpawnMachineInstance) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := instanceAuto
+	if i, ok := args["instance"].(string); ok && i != "" {
+		instance = i
+	}
+
+	region := t.vpnRegion
+	if r, ok := args["vpn_region"].(string); ok && r != "" {
+		region = r
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect subscription: %w", err)
+	}
+
+	switch instance {
+	case instanceAuto:
+		instance = instanceShared
+		if user.IsDedicatedVIP {
+			instance = instanceDedicated
+		}
+	case instanceDedicated:
+		if !user.IsDedicatedVIP {
+			return nil, fmt.Errorf("dedicated instances require a VIP+ subscription")
+		}
+	case instanceShared:
+	default:
+		return nil, fmt.Errorf("unsupported instance type: %s", instance)
+	}
+
+	var data interface{}
+	if instance == instanceDedicated {
+		if _, err := ensureVPNRegion(ctx, t.client, region); err != nil {
+			return nil, err
+		}
+
+		payload := htb.MachineActionRequest{MachineID: machineID}
+		data, err = t.client.PostWithParsing(ctx, "/vm/spawn", payload, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to spawn dedicated instance: %w", err)
+		}
+		session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+			Kind:      notes.KindSpawn,
+			MachineID: machineID,
+			Text:      "Machine started on dedicated instance",
+		})
+		trackMachine(ctx, machineID)
+	} else {
+		data, err = spawnMachine(ctx, t.client, session.NotesFrom(ctx, t.notes), machineID, region)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"machine_id": machineID,
+		"instance":   instance,
+		"result":     withReadiness(ctx, t.client, args, machineID, withQueueInfo(data, machineID, t.watch)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ResetMachineInstance tool for resetting the user's machine instance
+type ResetMachineInstance struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewResetMachineInstance(client *htb.Client, machines *machineResolver) *ResetMachineInstance {
+	return &ResetMachineInstance{client: client, machines: machines}
+}
+
+func (t *ResetMachineInstance) Name() string {
+	return "reset_machine_instance"
+}
+
+func (t *ResetMachineInstance) Description() string {
+	return "Reset a machine instance. VIP+ dedicated instances reset immediately; on shared servers this starts a community reset vote"
+}
+
+func (t *ResetMachineInstance) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine to reset",
+			},
+			"machine_name": machineNameProperty,
+		},
+	}
+}
+
+func (t *ResetMachineInstance) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect subscription: %w", err)
+	}
+
+	// Make API request
+	payload := htb.MachineActionRequest{MachineID: machineID}
+	var reset struct {
+		Message string `json:"message"`
+	}
+	if err := t.client.PostJSON(ctx, "/vm/reset", payload, &reset); err != nil {
+		return nil, fmt.Errorf("failed to reset machine: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"machine_id":    machineID,
+		"dedicated":     user.IsDedicatedVIP,
+		"requires_vote": !user.IsDedicatedVIP,
+		"message":       reset.Message,
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// resetVoteTarget returns the machine targeted by args, defaulting to the
+// active machine
+func resetVoteTarget(ctx context.Context, client *htb.Client, machines *machineResolver, args map[string]interface{}) (int, error) {
+	if hasMachineTarget(args) {
+		return machines.resolve(ctx, args)
+	}
+
+	active, err := client.GetActiveMachine(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active machine: %w", err)
+	}
+	if active == nil {
+		return 0, fmt.Errorf("no active machine; pass machine_id or machine_name")
+	}
+	return active.ID, nil
+}
+
+// resetVoteResult describes a machine's pending reset vote
+func resetVoteResult(machineID int, vote *htb.ResetVote) map[string]interface{} {
+	result := map[string]interface{}{
+		"machine_id":   machineID,
+		"vote_pending": vote != nil,
+	}
+	if vote != nil {
+		result["vote"] = vote
+		result["votes_needed"] = max(vote.VotesRequired-vote.Votes, 0)
+	}
+	return result
+}
+
+// VoteMachineReset tool for voting to reset a machine on a shared server
+type VoteMachineReset struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewVoteMachineReset(client *htb.Client, machines *machineResolver) *VoteMachineReset {
+	return &VoteMachineReset{client: client, machines: machines}
+}
+
+func (t *VoteMachineReset) Name() string {
+	return "vote_machine_reset"
+}
+
+func (t *VoteMachineReset) Description() string {
+	return "Vote to reset a machine on a shared free server, starting a reset vote if none is pending. Defaults to the active machine"
+}
+
+func (t *VoteMachineReset) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine to vote on (defaults to the active machine)",
+			},
+			"machine_name": machineNameProperty,
+		},
+	}
+}
+
+func (t *VoteMachineReset) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := resetVoteTarget(ctx, t.client, t.machines, args)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect subscription: %w", err)
+	}
+	if user.IsDedicatedVIP {
+		return nil, fmt.Errorf("dedicated instances reset without a vote; use reset_machine_instance")
+	}
+
+	// Make API request
+	message, err := t.client.VoteMachineReset(ctx, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to vote for machine reset: %w", err)
+	}
+
+	vote, err := t.client.GetResetVote(ctx, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reset vote status: %w", err)
+	}
+
+	result := resetVoteResult(machineID, vote)
+	result["message"] = message
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetResetVotes tool for showing the pending reset vote on a machine
+type GetResetVotes struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewGetResetVotes(client *htb.Client, machines *machineResolver) *GetResetVotes {
+	return &GetResetVotes{client: client, machines: machines}
+}
+
+func (t *GetResetVotes) Name() string {
+	return "get_reset_votes"
+}
+
+func (t *GetResetVotes) Description() string {
+	return "Show the pending reset vote on a machine's shared server, with the votes still needed. Defaults to the active machine"
+}
+
+func (t *GetResetVotes) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine (defaults to the active machine)",
+			},
+			"machine_name": machineNameProperty,
+		},
+	}
+}
+
+func (t *GetResetVotes) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := resetVoteTarget(ctx, t.client, t.machines, args)
+	if err != nil {
+		return nil, err
+	}
+
+	vote, err := t.client.GetResetVote(ctx, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reset vote status: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(resetVoteResult(machineID, vote))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// pwnboxLowQuotaRatio is the remaining fraction of quota considered near the cap
+const pwnboxLowQuotaRatio = 0.1
+
+// GetPwnboxQuota tool for checking monthly Pwnbox usage
+type GetPwnboxQuota struct {
+	client *htb.Client
+}
+
+func NewGetPwnboxQuota(client *htb.Client) *GetPwnboxQuota {
+	return &GetPwnboxQuota{client: client}
+}
+
+func (t *GetPwnboxQuota) Name() string {
+	return "get_pwnbox_quota"
+}
+
+func (t *GetPwnboxQuota) Description() string {
+	return "Get monthly Pwnbox hours used and remaining for the account's subscription"
+}
+
+func (t *GetPwnboxQuota) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetPwnboxQuota) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var usage htb.PwnboxUsageResponse
+	if err := t.client.GetJSON(ctx, "/pwnbox/usage", &usage); err != nil {
+		return nil, fmt.Errorf("failed to get Pwnbox usage: %w", err)
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"subscription": user.Subscription,
+		"hours_used":   float64(usage.Data.Used),
+		"unlimited":    usage.Data.Unlimited,
+		"resets_at":    usage.Data.ResetsAt,
+	}
+
+	if !usage.Data.Unlimited {
+		remaining := float64(usage.Data.Limit - usage.Data.Used)
+		if remaining < 0 {
+			remaining = 0
+		}
+		result["hours_limit"] = float64(usage.Data.Limit)
+		result["hours_remaining"] = remaining
+		result["near_cap"] = usage.Data.Limit > 0 && remaining <= float64(usage.Data.Limit)*pwnboxLowQuotaRatio
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
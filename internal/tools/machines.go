@@ -3,7 +3,15 @@ package tools
 import (
 	"context"
 	"fmt"
-
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
 	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
 )
@@ -11,10 +19,12 @@ import (
 // ListMachines tool for listing HTB machines
 type ListMachines struct {
 	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
 }
 
-func NewListMachines(client *htb.Client) *ListMachines {
-	return &ListMachines{client: client}
+func NewListMachines(client *htb.Client, state *store.Store, shared cache.Cache) *ListMachines {
+	return &ListMachines{client: client, state: state, shared: shared}
 }
 
 func (t *ListMachines) Name() string {
@@ -22,13 +32,13 @@ func (t *ListMachines) Name() string {
 }
 
 func (t *ListMachines) Description() string {
-	return "Get a list of HackTheBox machines with optional filtering by status, difficulty, and OS"
+	return "Get a list of HackTheBox machines with optional filtering by status, difficulty, and OS. Each machine includes a perceived_difficulty score (1-10) from community ratings when available"
 }
 
 func (t *ListMachines) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
-		Properties: map[string]mcp.Property{
+		Properties: withCatalogProperties(map[string]mcp.Property{
 			"status": {
 				Type:        "string",
 				Description: "Filter by machine status",
@@ -47,19 +57,53 @@ func (t *ListMachines) Schema() mcp.ToolSchema {
 			},
 			"page": {
 				Type:        "integer",
-				Description: "Page number for pagination",
+				Description: "HTB listing page to start from; later chunks continue with cursor",
 				Default:     1,
 			},
 			"per_page": {
 				Type:        "integer",
-				Description: "Number of machines per page",
+				Description: "Number of machines fetched per HTB API request; pages are aggregated up to chunk_size",
 				Default:     20,
 			},
-		},
+			"cursor": {
+				Type:        "string",
+				Description: "Continuation cursor from a previous chunked response",
+			},
+			"chunk_size": {
+				Type:        "integer",
+				Description: "Maximum number of machines returned per response",
+				Default:     defaultChunkSize,
+			},
+		}),
 	}
 }
 
+// machineChunk is the structured content of list_machines
+type machineChunk struct {
+	Items []htb.Machine `json:"items"`
+	chunkInfo
+}
+
+func (t *ListMachines) OutputSchema() mcp.ToolSchema {
+	return mcp.SchemaFor(machineChunk{})
+}
+
 func (t *ListMachines) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	result, err := t.list(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return typedChunk(result, &machineChunk{})
+}
+
+// list returns the requested chunk of machines
+func (t *ListMachines) list(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	// Serve from the exported catalog when there is a fresh one
+	if items, notice, ok := catalogListing(t.state, t.shared, catalogMachines, "os", args); ok {
+		htb.AddPerceivedDifficulty(items)
+		return chunkedResponse(items, notice, args)
+	}
+
 	// Extract parameters
 	status := "active"
 	if s, ok := args["status"].(string); ok {
@@ -67,7 +111,7 @@ func (t *ListMachines) Execute(ctx context.Context, args map[string]interface{})
 	}
 
 	perPage := 20
-	if pp, ok := args["per_page"].(float64); ok {
+	if pp, ok := args["per_page"].(float64); ok && pp > 0 {
 		perPage = int(pp)
 	}
 
@@ -79,30 +123,32 @@ func (t *ListMachines) Execute(ctx context.Context, args map[string]interface{})
 		endpoint = fmt.Sprintf("/machine/paginated/?per_page=%d", perPage)
 	}
 
-	// Make API request
-	data, err := t.client.GetWithParsing(ctx, endpoint, "data")
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch machines: %w", err)
-	}
-
-	// Create JSON content
-	content, err := mcp.CreateJSONContent(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	// Make API requests page by page until the chunk is full, falling back
+	// to the cached listing when offline
+	fetch := func(ctx context.Context, page int) ([]interface{}, string, error) {
+		data, notice, err := fetchListing(ctx, t.client, t.state, t.shared, fmt.Sprintf("%s&page=%d", endpoint, page), "data")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to fetch machines: %w", err)
+		}
+		items, _ := data.([]interface{})
+		htb.AddPerceivedDifficulty(items)
+		return items, notice, nil
 	}
 
-	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
-	}, nil
+	return chunkedListing(ctx, fetch, perPage, args, 0)
 }
 
 // StartMachine tool for starting a HTB machine
 type StartMachine struct {
-	client *htb.Client
+	client    *htb.Client
+	machines  *machineResolver
+	notes     *notes.Store
+	watch     spawnWatchFunc
+	vpnRegion string
 }
 
-func NewStartMachine(client *htb.Client) *StartMachine {
-	return &StartMachine{client: client}
+func NewStartMachine(client *htb.Client, machines *machineResolver, store *notes.Store, watch spawnWatchFunc, vpnRegion string) *StartMachine {
+	return &StartMachine{client: client, machines: machines, notes: store, watch: watch, vpnRegion: vpnRegion}
 }
 
 func (t *StartMachine) Name() string {
@@ -110,7 +156,7 @@ func (t *StartMachine) Name() string {
 }
 
 func (t *StartMachine) Description() string {
-	return "Start a HackTheBox machine by ID and get connection details"
+	return "Start a HackTheBox machine by ID or name and get connection details"
 }
 
 func (t *StartMachine) Schema() mcp.ToolSchema {
@@ -121,50 +167,210 @@ func (t *StartMachine) Schema() mcp.ToolSchema {
 				Type:        "integer",
 				Description: "The ID of the machine to start",
 			},
+			"machine_name": machineNameProperty,
+			"vpn_region": {
+				Type:        "string",
+				Description: "Preferred VPN region; the lab VPN server is switched to it before spawning if needed. Defaults to PREFERRED_VPN_REGION",
+				Enum:        []string{"EU", "US", "AU", "SG"},
+			},
+			"wait": spawnWaitProperty,
 		},
-		Required: []string{"machine_id"},
 	}
 }
 
 func (t *StartMachine) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	machineID, ok := args["machine_id"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("machine_id is required")
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	region := t.vpnRegion
+	if r, ok := args["vpn_region"].(string); ok && r != "" {
+		region = r
+	}
+
+	data, err := spawnMachine(ctx, t.client, session.NotesFrom(ctx, t.notes), machineID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(withReadiness(ctx, t.client, args, machineID, withQueueInfo(data, machineID, t.watch)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content, machineLink(machineID)},
+	}, nil
+}
+
+// machineLink links to the machine's htb://machines/{id} resource
+func machineLink(machineID int) mcp.Content {
+	return mcp.CreateResourceLinkContent(fmt.Sprintf("htb://machines/%d", machineID), fmt.Sprintf("Machine %d profile", machineID), "application/json")
+}
+
+// spawnMachine starts a machine, switching to the preferred VPN region first
+// if one is given, and records the spawn in the notes store
+func spawnMachine(ctx context.Context, client *htb.Client, store *notes.Store, machineID int, vpnRegion string) (interface{}, error) {
+	switched, err := ensureVPNRegion(ctx, client, vpnRegion)
+	if err != nil {
+		return nil, err
+	}
+	if switched != nil {
+		store.Add(notes.Entry{
+			Kind:      notes.KindSpawn,
+			MachineID: machineID,
+			Text:      fmt.Sprintf("Switched VPN server to %s before spawning", switched.FriendlyName),
+		})
 	}
 
 	// Build request payload
 	payload := htb.MachineActionRequest{
-		MachineID: int(machineID),
+		MachineID: machineID,
 	}
 
 	// Determine the correct endpoint based on machine type
 	// For now, we'll use the standard machine endpoint
-	endpoint := fmt.Sprintf("/machine/play/%d", int(machineID))
+	endpoint := fmt.Sprintf("/machine/play/%d", machineID)
 
 	// Make API request
-	data, err := t.client.PostWithParsing(ctx, endpoint, payload, "")
+	data, err := client.PostWithParsing(ctx, endpoint, payload, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to start machine: %w", err)
 	}
 
-	// Create JSON content
-	content, err := mcp.CreateJSONContent(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	store.Add(notes.Entry{
+		Kind:      notes.KindSpawn,
+		MachineID: machineID,
+		Text:      "Machine started",
+	})
+	trackMachine(ctx, machineID)
+
+	return data, nil
+}
+
+// trackMachine records machineID as the machine the client of the session
+// carried by ctx works on, so background notifications about it go there
+func trackMachine(ctx context.Context, machineID int) {
+	if s, ok := session.FromContext(ctx); ok {
+		s.SetMachine(machineID)
 	}
+}
 
-	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
-	}, nil
+// spawnWatchFunc watches a queued spawn and notifies the client once the
+// machine becomes available
+type spawnWatchFunc func(machineID int)
+
+// withQueueInfo surfaces the queue position and estimated wait of a queued
+// spawn and starts watching for the machine to become available. Spawns that
+// were not queued are returned unchanged.
+func withQueueInfo(data interface{}, machineID int, watch spawnWatchFunc) interface{} {
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+
+	position, queued := numericID(obj["queue_position"])
+	if !queued {
+		position, queued = numericID(obj["position"])
+	}
+	if !queued || position <= 0 {
+		return data
+	}
+
+	if watch != nil {
+		watch(machineID)
+	}
+
+	result := map[string]interface{}{
+		"queued":         true,
+		"queue_position": position,
+		"result":         data,
+		"message":        fmt.Sprintf("Spawn queued at position %d; a notification is sent when the machine becomes available", position),
+	}
+	if wait, ok := numericID(obj["estimated_wait"]); ok {
+		result["estimated_wait_minutes"] = (wait + 59) / 60
+	}
+	return result
+}
+
+// Polling of a spawned machine until it has an IP address
+const (
+	spawnReadyTimeout  = 3 * time.Minute
+	spawnReadyInterval = 5 * time.Second
+)
+
+// spawnWaitProperty is the wait argument of tools that spawn machines
+var spawnWaitProperty = mcp.Property{
+	Type:        "boolean",
+	Description: "Wait until the machine has an IP address before returning; a request with a progressToken receives progress notifications while waiting",
+	Default:     false,
+}
+
+// withReadiness waits for a spawned machine to get an IP address if asked to,
+// adding the outcome to the spawn result. Queued spawns are not waited for.
+func withReadiness(ctx context.Context, client *htb.Client, args map[string]interface{}, machineID int, result interface{}) interface{} {
+	wait, _ := args["wait"].(bool)
+	if obj, ok := result.(map[string]interface{}); !wait || (ok && obj["queued"] == true) {
+		return result
+	}
+
+	ready := map[string]interface{}{"result": result}
+	active, err := waitForMachineIP(ctx, client, machineID)
+	switch {
+	case err != nil:
+		ready["ready"] = false
+		ready["message"] = fmt.Sprintf("Stopped waiting for the machine: %v; check again with get_machine_ip", err)
+	case active == nil:
+		ready["ready"] = false
+		ready["message"] = fmt.Sprintf("The machine did not get an IP address within %s; check again with get_machine_ip", spawnReadyTimeout)
+	default:
+		ready["ready"] = true
+		ready["name"] = active.Name
+		ready["ip"] = active.IP
+	}
+	return ready
+}
+
+// waitForMachineIP polls the active machine until machineID has an IP
+// address, reporting progress while it waits. It returns nil if the machine
+// is not ready within spawnReadyTimeout.
+func waitForMachineIP(ctx context.Context, client *htb.Client, machineID int) (*htb.ActiveMachineInfo, error) {
+	progress := progressFrom(ctx)
+	attempts := int(spawnReadyTimeout / spawnReadyInterval)
+	total := float64(attempts + 1)
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		active, err := client.GetActiveMachine(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get active machine: %w", err)
+		}
+		if active != nil && active.ID == machineID && active.IP != "" && !active.IsSpawning {
+			progress.report(total, total, fmt.Sprintf("%s is up at %s", active.Name, active.IP))
+			return active, nil
+		}
+
+		elapsed := time.Duration(attempt-1) * spawnReadyInterval
+		progress.report(float64(attempt), total, fmt.Sprintf("Waiting for machine %d to get an IP address (%s elapsed)", machineID, elapsed))
+
+		if attempt < attempts {
+			if err := sleepContext(ctx, spawnReadyInterval); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, nil
 }
 
 // GetMachineIP tool for getting machine IP address
 type GetMachineIP struct {
-	client *htb.Client
+	client   *htb.Client
+	machines *machineResolver
 }
 
-func NewGetMachineIP(client *htb.Client) *GetMachineIP {
-	return &GetMachineIP{client: client}
+func NewGetMachineIP(client *htb.Client, machines *machineResolver) *GetMachineIP {
+	return &GetMachineIP{client: client, machines: machines}
 }
 
 func (t *GetMachineIP) Name() string {
@@ -172,7 +378,7 @@ func (t *GetMachineIP) Name() string {
 }
 
 func (t *GetMachineIP) Description() string {
-	return "Get the IP address of the currently active machine"
+	return "Get the IP address of a machine by ID or name, or of the currently active machine (including release-arena and Starting Point instances)"
 }
 
 func (t *GetMachineIP) Schema() mcp.ToolSchema {
@@ -183,42 +389,142 @@ func (t *GetMachineIP) Schema() mcp.ToolSchema {
 				Type:        "integer",
 				Description: "Optional machine ID. If not provided, gets the active machine IP",
 			},
+			"machine_name": machineNameProperty,
 		},
 	}
 }
 
+// machineIP is the result of get_machine_ip
+type machineIP struct {
+	MachineID   int    `json:"machine_id"`
+	MachineName string `json:"machine_name"`
+	IP          string `json:"ip"`
+	Source      string `json:"source"`
+	Type        string `json:"type,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+func (t *GetMachineIP) OutputSchema() mcp.ToolSchema {
+	return mcp.SchemaFor(machineIP{})
+}
+
 func (t *GetMachineIP) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	// Get active machine information
-	data, err := t.client.GetWithParsing(ctx, "/machine/active", "info")
+	var result *machineIP
+	var err error
+
+	if hasMachineTarget(args) {
+		machineID, resolveErr := t.machines.resolve(ctx, args)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		result, err = t.lookupByID(ctx, machineID)
+	} else {
+		result, err = t.lookupActive(ctx)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active machine: %w", err)
+		return nil, err
 	}
 
-	if data == nil {
+	if result == nil {
 		content := mcp.CreateTextContent("No machine is currently active")
 		return &mcp.CallToolResponse{
-			Content: []mcp.Content{content},
+			Content:           []mcp.Content{content},
+			StructuredContent: &machineIP{Source: "none", Message: "No machine is currently active"},
 		}, nil
 	}
 
 	// Create JSON content
-	content, err := mcp.CreateJSONContent(data)
+	content, err := mcp.CreateJSONContent(result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create JSON content: %w", err)
 	}
 
 	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
+		Content:           []mcp.Content{content},
+		StructuredContent: result,
 	}, nil
 }
 
+// lookupActive returns the IP of the active lab or release-arena machine
+func (t *GetMachineIP) lookupActive(ctx context.Context) (*machineIP, error) {
+	active, err := t.client.GetActiveMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active machine: %w", err)
+	}
+	if active != nil {
+		return machineIPResult(active, "active"), nil
+	}
+
+	arena, err := t.client.GetArenaMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release arena machine: %w", err)
+	}
+	if arena != nil {
+		return machineIPResult(arena, "release_arena"), nil
+	}
+
+	return nil, nil
+}
+
+// lookupByID returns the IP of a specific machine, preferring running instances
+func (t *GetMachineIP) lookupByID(ctx context.Context, machineID int) (*machineIP, error) {
+	if active, err := t.client.GetActiveMachine(ctx); err == nil && active != nil && active.ID == machineID {
+		return machineIPResult(active, "active"), nil
+	}
+
+	if arena, err := t.client.GetArenaMachine(ctx); err == nil && arena != nil && arena.ID == machineID {
+		return machineIPResult(arena, "release_arena"), nil
+	}
+
+	profile, err := t.client.GetMachineProfile(ctx, strconv.Itoa(machineID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine %d: %w", machineID, err)
+	}
+
+	result := &machineIP{
+		MachineID:   profile.ID,
+		MachineName: profile.Name,
+		IP:          profile.IP,
+		Source:      "profile",
+	}
+	if profile.IP == "" {
+		result.Message = "Machine has no IP assigned; it is probably not spawned"
+	}
+
+	return result, nil
+}
+
+// machineIPResult builds the get_machine_ip result for a running instance
+func machineIPResult(machine *htb.ActiveMachineInfo, source string) *machineIP {
+	result := &machineIP{
+		MachineID:   machine.ID,
+		MachineName: machine.Name,
+		IP:          machine.IP,
+		Type:        machine.Type,
+		Source:      source,
+		ExpiresAt:   machine.ExpiresAt,
+	}
+	if machine.Avatar != "" {
+		result.AvatarURL = htb.AssetURL(machine.Avatar)
+	}
+	if machine.IsSpawning {
+		result.Message = "Machine is still spawning; the IP may not be assigned yet"
+	}
+	return result
+}
+
 // SubmitUserFlag tool for submitting user flags
 type SubmitUserFlag struct {
-	client *htb.Client
+	client   *htb.Client
+	machines *machineResolver
+	notes    *notes.Store
+	queue    *flagQueue
 }
 
-func NewSubmitUserFlag(client *htb.Client) *SubmitUserFlag {
-	return &SubmitUserFlag{client: client}
+func NewSubmitUserFlag(client *htb.Client, machines *machineResolver, store *notes.Store, queue *flagQueue) *SubmitUserFlag {
+	return &SubmitUserFlag{client: client, machines: machines, notes: store, queue: queue}
 }
 
 func (t *SubmitUserFlag) Name() string {
@@ -233,23 +539,26 @@ func (t *SubmitUserFlag) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
 		Properties: map[string]mcp.Property{
-			"machine_id": {
-				Type:        "integer",
-				Description: "The ID of the machine",
-			},
+			"machine_id":   machineIDProperty,
+			"machine_name": machineNameProperty,
 			"flag": {
 				Type:        "string",
 				Description: "The user flag to submit",
 			},
+			"on_outage": onOutageProperty,
 		},
-		Required: []string{"machine_id", "flag"},
+		Required: []string{"flag"},
 	}
 }
 
+func (t *SubmitUserFlag) OutputSchema() mcp.ToolSchema {
+	return flagSubmissionSchema
+}
+
 func (t *SubmitUserFlag) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	machineID, ok := args["machine_id"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("machine_id is required")
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
 	}
 
 	flag, ok := args["flag"].(string)
@@ -257,34 +566,46 @@ func (t *SubmitUserFlag) Execute(ctx context.Context, args map[string]interface{
 		return nil, fmt.Errorf("flag is required")
 	}
 
-	// Build request payload
-	payload := htb.FlagSubmissionRequest{
-		ID:   int(machineID),
-		Flag: flag,
+	mode, err := outageMode(args)
+	if err != nil {
+		return nil, err
 	}
 
+	verifier := newOwnVerifier(ctx, t.client, flagTargetMachine, machineID, ownUser)
+
 	// Make API request
-	data, err := t.client.PostWithParsing(ctx, "/machine/own", payload, "message")
+	result, queued, err := t.queue.submit(ctx, mode, flagTargetMachine, machineID, flag, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to submit user flag: %w", err)
 	}
+	if queued != nil {
+		session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+			Kind:      notes.KindFlag,
+			MachineID: machineID,
+			Text:      fmt.Sprintf("User flag queued as #%d while HTB is unreachable", queued.ID),
+		})
+		return queuedSubmissionResponse(queued)
+	}
 
-	// Create text content with result
-	message := fmt.Sprintf("User flag submission result: %v", data)
-	content := mcp.CreateTextContent(message)
+	session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+		Kind:      notes.KindFlag,
+		MachineID: machineID,
+		Text:      fmt.Sprintf("User flag submission result: %s", result.Message),
+	})
 
-	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
-	}, nil
+	return flagSubmissionResponse(result, verifier.verify(ctx, result))
 }
 
 // SubmitRootFlag tool for submitting root flags
 type SubmitRootFlag struct {
-	client *htb.Client
+	client   *htb.Client
+	machines *machineResolver
+	notes    *notes.Store
+	queue    *flagQueue
 }
 
-func NewSubmitRootFlag(client *htb.Client) *SubmitRootFlag {
-	return &SubmitRootFlag{client: client}
+func NewSubmitRootFlag(client *htb.Client, machines *machineResolver, store *notes.Store, queue *flagQueue) *SubmitRootFlag {
+	return &SubmitRootFlag{client: client, machines: machines, notes: store, queue: queue}
 }
 
 func (t *SubmitRootFlag) Name() string {
@@ -299,23 +620,26 @@ func (t *SubmitRootFlag) Schema() mcp.ToolSchema {
 	return mcp.ToolSchema{
 		Type: "object",
 		Properties: map[string]mcp.Property{
-			"machine_id": {
-				Type:        "integer",
-				Description: "The ID of the machine",
-			},
+			"machine_id":   machineIDProperty,
+			"machine_name": machineNameProperty,
 			"flag": {
 				Type:        "string",
 				Description: "The root flag to submit",
 			},
+			"on_outage": onOutageProperty,
 		},
-		Required: []string{"machine_id", "flag"},
+		Required: []string{"flag"},
 	}
 }
 
+func (t *SubmitRootFlag) OutputSchema() mcp.ToolSchema {
+	return flagSubmissionSchema
+}
+
 func (t *SubmitRootFlag) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
-	machineID, ok := args["machine_id"].(float64)
-	if !ok {
-		return nil, fmt.Errorf("machine_id is required")
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
 	}
 
 	flag, ok := args["flag"].(string)
@@ -323,23 +647,237 @@ func (t *SubmitRootFlag) Execute(ctx context.Context, args map[string]interface{
 		return nil, fmt.Errorf("flag is required")
 	}
 
-	// Build request payload
-	payload := htb.FlagSubmissionRequest{
-		ID:   int(machineID),
-		Flag: flag,
+	mode, err := outageMode(args)
+	if err != nil {
+		return nil, err
 	}
 
+	verifier := newOwnVerifier(ctx, t.client, flagTargetMachine, machineID, ownRoot)
+
 	// Make API request to the same endpoint (HTB API handles flag type detection)
-	data, err := t.client.PostWithParsing(ctx, "/machine/own", payload, "message")
+	result, queued, err := t.queue.submit(ctx, mode, flagTargetMachine, machineID, flag, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to submit root flag: %w", err)
 	}
+	if queued != nil {
+		session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+			Kind:      notes.KindFlag,
+			MachineID: machineID,
+			Text:      fmt.Sprintf("Root flag queued as #%d while HTB is unreachable", queued.ID),
+		})
+		return queuedSubmissionResponse(queued)
+	}
+
+	session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+		Kind:      notes.KindFlag,
+		MachineID: machineID,
+		Text:      fmt.Sprintf("Root flag submission result: %s", result.Message),
+	})
+
+	return flagSubmissionResponse(result, verifier.verify(ctx, result))
+}
+
+// GetTimeRemaining tool for checking how long the active machine has left
+type GetTimeRemaining struct {
+	client *htb.Client
+}
+
+func NewGetTimeRemaining(client *htb.Client) *GetTimeRemaining {
+	return &GetTimeRemaining{client: client}
+}
+
+func (t *GetTimeRemaining) Name() string {
+	return "get_time_remaining"
+}
+
+func (t *GetTimeRemaining) Description() string {
+	return "Get the time remaining before the currently active machine expires"
+}
+
+func (t *GetTimeRemaining) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
 
-	// Create text content with result
-	message := fmt.Sprintf("Root flag submission result: %v", data)
-	content := mcp.CreateTextContent(message)
+func (t *GetTimeRemaining) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machine, err := t.client.GetActiveMachine(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active machine: %w", err)
+	}
+
+	if machine == nil {
+		content := mcp.CreateTextContent("No machine is currently active")
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{content},
+		}, nil
+	}
+
+	result := map[string]interface{}{
+		"machine_id":   machine.ID,
+		"machine_name": machine.Name,
+		"expires_at":   machine.ExpiresAt,
+	}
+
+	if expires, err := machine.ExpiresTime(); err == nil {
+		remaining := time.Until(expires)
+		if remaining < 0 {
+			remaining = 0
+		}
+		result["remaining_seconds"] = int(remaining.Seconds())
+		result["remaining"] = remaining.Round(time.Second).String()
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
 
 	return &mcp.CallToolResponse{
 		Content: []mcp.Content{content},
 	}, nil
 }
+
+// SubmitMachineFeedback tool for submitting post-own difficulty feedback
+type SubmitMachineFeedback struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewSubmitMachineFeedback(client *htb.Client, machines *machineResolver) *SubmitMachineFeedback {
+	return &SubmitMachineFeedback{client: client, machines: machines}
+}
+
+func (t *SubmitMachineFeedback) Name() string {
+	return "submit_machine_feedback"
+}
+
+func (t *SubmitMachineFeedback) Description() string {
+	return "Submit the post-own perceived difficulty feedback for a machine (1 = Piece of Cake ... 10 = Brainfuck)"
+}
+
+func (t *SubmitMachineFeedback) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the owned machine",
+			},
+			"machine_name": machineNameProperty,
+			"difficulty": {
+				Type:        "integer",
+				Description: "Perceived difficulty rating (1-10)",
+			},
+		},
+		Required: []string{"difficulty"},
+	}
+}
+
+func (t *SubmitMachineFeedback) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := args["difficulty"]; !ok {
+		return nil, fmt.Errorf("difficulty is required")
+	}
+	difficulty, err := difficultyRating(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build request payload (HTB API expects difficulty * 10)
+	payload := htb.FlagSubmissionRequest{
+		ID:         machineID,
+		Difficulty: strconv.Itoa(difficulty * 10),
+	}
+
+	// Make API request
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := t.client.PostJSON(ctx, "/machine/difficulty", payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to submit machine feedback: %w", err)
+	}
+
+	content := mcp.CreateTextContent(fmt.Sprintf("Difficulty feedback submitted for machine %d: %s", machineID, result.Message))
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetMachineAvatar tool for fetching a machine's artwork
+type GetMachineAvatar struct {
+	client   *htb.Client
+	machines *machineResolver
+}
+
+func NewGetMachineAvatar(client *htb.Client, machines *machineResolver) *GetMachineAvatar {
+	return &GetMachineAvatar{client: client, machines: machines}
+}
+
+func (t *GetMachineAvatar) Name() string {
+	return "get_machine_avatar"
+}
+
+func (t *GetMachineAvatar) Description() string {
+	return "Fetch a HackTheBox machine's avatar artwork as image content so rich clients can render the target"
+}
+
+func (t *GetMachineAvatar) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id":   machineIDProperty,
+			"machine_name": machineNameProperty,
+		},
+	}
+}
+
+func (t *GetMachineAvatar) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := t.client.GetMachineProfile(ctx, strconv.Itoa(machineID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine profile: %w", err)
+	}
+	if profile.AvatarURL == "" {
+		return nil, fmt.Errorf("machine %s has no avatar", profile.Name)
+	}
+
+	image, err := downloadImage(ctx, t.client, profile.AvatarURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download machine avatar: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{
+			mcp.CreateTextContent(fmt.Sprintf("Avatar of %s (%s, %s): %s", profile.Name, profile.OS, profile.DifficultyText, profile.AvatarURL)),
+			image,
+		},
+	}, nil
+}
+
+// downloadImage fetches an HTB asset as image content. Assets are public, so
+// no token is sent; the MIME type is sniffed when the server doesn't send an
+// image type, and anything that is not an image is an error.
+func downloadImage(ctx context.Context, client *htb.Client, assetURL string) (mcp.Content, error) {
+	data, mimeType, err := client.Download(ctx, assetURL)
+	if err != nil {
+		return mcp.Content{}, err
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return mcp.Content{}, fmt.Errorf("%s is not an image (%s)", assetURL, mimeType)
+	}
+	return mcp.CreateImageContent(mimeType, data), nil
+}
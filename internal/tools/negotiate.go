@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// negotiateContent adapts a tool result to the session's client. Clients that
+// support structured content also get JSON results as structuredContent;
+// older clients get resource links as plain text and no structured content.
+func negotiateContent(ctx context.Context, result *mcp.CallToolResponse) *mcp.CallToolResponse {
+	if result == nil {
+		return result
+	}
+
+	if s, ok := session.FromContext(ctx); ok && s.SupportsStructuredContent() {
+		if result.StructuredContent == nil {
+			result.StructuredContent = structuredContent(result.Content)
+		}
+		return result
+	}
+
+	result.StructuredContent = nil
+	for i, content := range result.Content {
+		if content.Type == "resource_link" {
+			result.Content[i] = mcp.CreateTextContent(fmt.Sprintf("Resource %s: %s", content.Name, content.URI))
+		}
+	}
+	return result
+}
+
+// NegotiateTools adapts tool definitions to the session's client, dropping
+// output schemas for clients that don't support structured content
+func NegotiateTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	if s, ok := session.FromContext(ctx); ok && s.SupportsStructuredContent() {
+		return tools
+	}
+
+	for i := range tools {
+		tools[i].OutputSchema = nil
+	}
+	return tools
+}
+
+// structuredContent returns the JSON object of a result made of a single
+// JSON text content, or nil. Structured content must be an object, so
+// arrays and scalars are left as text only.
+func structuredContent(contents []mcp.Content) interface{} {
+	var object json.RawMessage
+	for _, content := range contents {
+		if content.Type != "text" || content.MimeType != "application/json" {
+			continue
+		}
+		if object != nil || !strings.HasPrefix(strings.TrimSpace(content.Text), "{") || !json.Valid([]byte(content.Text)) {
+			return nil
+		}
+		object = json.RawMessage(content.Text)
+	}
+	if object == nil {
+		return nil
+	}
+	return object
+}
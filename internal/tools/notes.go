@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// AddNote tool for recording a note against a machine
+type AddNote struct {
+	notes *notes.Store
+}
+
+func NewAddNote(store *notes.Store) *AddNote {
+	return &AddNote{notes: store}
+}
+
+func (t *AddNote) Name() string {
+	return "add_note"
+}
+
+func (t *AddNote) Description() string {
+	return "Record a note or finding for a HackTheBox machine so it can later be exported"
+}
+
+func (t *AddNote) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine the note belongs to",
+			},
+			"machine_name": {
+				Type:        "string",
+				Description: "Optional machine name used for the exported file",
+			},
+			"note": {
+				Type:        "string",
+				Description: "The note or finding text (Markdown supported)",
+			},
+		},
+		Required: []string{"machine_id", "note"},
+	}
+}
+
+func (t *AddNote) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, ok := args["machine_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("machine_id is required")
+	}
+
+	note, ok := args["note"].(string)
+	if !ok || strings.TrimSpace(note) == "" {
+		return nil, fmt.Errorf("note is required")
+	}
+
+	machineName, _ := args["machine_name"].(string)
+
+	session.NotesFrom(ctx, t.notes).Add(notes.Entry{
+		Kind:        notes.KindNote,
+		MachineID:   int(machineID),
+		MachineName: machineName,
+		Text:        note,
+	})
+
+	content := mcp.CreateTextContent(fmt.Sprintf("Note recorded for machine %d", int(machineID)))
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ExportNotes tool for writing notes and session data to a Markdown vault
+type ExportNotes struct {
+	notes      *notes.Store
+	defaultDir string
+	roots      rootsFunc
+}
+
+func NewExportNotes(store *notes.Store, defaultDir string, roots rootsFunc) *ExportNotes {
+	return &ExportNotes{notes: store, defaultDir: defaultDir, roots: roots}
+}
+
+func (t *ExportNotes) Name() string {
+	return "export_notes"
+}
+
+func (t *ExportNotes) Description() string {
+	return "Export accumulated notes and session data as Markdown files (one per machine, with frontmatter) for Obsidian or a reporting repository"
+}
+
+func (t *ExportNotes) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"directory": {
+				Type:        "string",
+				Description: "Target directory. Defaults to the configured NOTES_DIR, or htb-notes in the client's first workspace root. When the client declares roots, the directory must lie inside one; otherwise it must lie inside NOTES_DIR",
+			},
+		},
+	}
+}
+
+func (t *ExportNotes) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	requested, _ := args["directory"].(string)
+	dir, err := workspaceDir(ctx, t.roots, requested, t.defaultDir, "htb-notes")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, doc := range session.NotesFrom(ctx, t.notes).Documents() {
+		name := fmt.Sprintf("machine-%d", doc.MachineID)
+		if doc.MachineName != "" {
+			name = fmt.Sprintf("%s-%d", safeFileName(doc.MachineName), doc.MachineID)
+		}
+		path, err := writeWorkspaceFile(dir, name+".md", []byte(doc.Markdown), 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export notes: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		content := mcp.CreateTextContent("No notes or session data to export")
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{content},
+		}, nil
+	}
+
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"directory": dir,
+		"files":     paths,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
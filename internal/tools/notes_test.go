@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+)
+
+func TestExportNotesRefusesSymlink(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	target := filepath.Join(outside, "authorized_keys")
+	if err := os.Symlink(target, filepath.Join(dir, "lame-1.md")); err != nil {
+		t.Skipf("Symlinks unsupported: %v", err)
+	}
+
+	store := notes.NewStore()
+	store.Add(notes.Entry{Kind: notes.KindNote, MachineID: 1, MachineName: "Lame", Text: "foothold via SMB"})
+	tool := NewExportNotes(store, dir, noRoots)
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{}); err == nil {
+		t.Error("Expected exporting through a planted symlink to be refused")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the directory, got %v", err)
+	}
+}
+
+func TestExportNotesFileNames(t *testing.T) {
+	dir := t.TempDir()
+	store := notes.NewStore()
+	store.Add(notes.Entry{Kind: notes.KindNote, MachineID: 1, MachineName: "../Lame", Text: "foothold via SMB"})
+	store.Add(notes.Entry{Kind: notes.KindSpawn, MachineID: 2, Text: "Machine started"})
+
+	if _, err := NewExportNotes(store, dir, noRoots).Execute(context.Background(), map[string]interface{}{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	for _, name := range []string{"..-lame-1.md", "machine-2.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s in the export directory: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// fetchListing fetches a listing field from the HTB API. Successful results
+// are cached in the shared cache, so instances serving the same account reuse
+// them, and in the persistent store. When the API is unreachable the last
+// stored listing is returned along with a stale notice instead of failing.
+func fetchListing(ctx context.Context, client *htb.Client, state *store.Store, shared cache.Cache, endpoint, field string) (interface{}, string, error) {
+	// Listings are cached as JSON so callers may modify what they get back
+	if cached, ok := shared.Get(listingKey(endpoint)); ok {
+		var data interface{}
+		if err := cache.Decode(cached, &data); err == nil {
+			htb.TraceFrom(ctx).CacheHit()
+			return data, "", nil
+		}
+	}
+
+	var result map[string]json.RawMessage
+	err := client.GetJSON(ctx, endpoint, &result)
+	if err == nil {
+		var data interface{}
+		if raw := result[field]; raw != nil {
+			if err := json.Unmarshal(raw, &data); err != nil {
+				return nil, "", fmt.Errorf("failed to parse %s: %w", field, err)
+			}
+			shared.Set(listingKey(endpoint), raw)
+		}
+		if err := state.Put(listingKey(endpoint), data); err != nil {
+			log.Printf("Failed to cache listing %s: %v", endpoint, err)
+		}
+		return data, "", nil
+	}
+
+	if !isUnreachable(err) {
+		return nil, "", err
+	}
+
+	var cached interface{}
+	savedAt, ok, cacheErr := state.Get(listingKey(endpoint), &cached)
+	if cacheErr != nil || !ok {
+		return nil, "", err
+	}
+
+	notice := fmt.Sprintf("HTB API unreachable (%v); showing cached data, stale as of %s", err, savedAt.Format(time.RFC3339))
+	return cached, notice, nil
+}
+
+// listingKey returns the cache and persistent store key for a listing
+func listingKey(endpoint string) string {
+	return "listing:" + endpoint
+}
+
+// isUnreachable reports whether err indicates the HTB API could not be reached
+// (network failure, timeout, maintenance or server-side outage) rather than a
+// client error
+func isUnreachable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var maintErr *htb.MaintenanceError
+	if errors.As(err, &maintErr) {
+		return true
+	}
+
+	var apiErr *htb.HTBAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// maintenanceResponse returns a friendly tool result for err if HTB is under
+// maintenance, with guidance on when to retry
+func maintenanceResponse(err error) (*mcp.CallToolResponse, bool) {
+	var maintErr *htb.MaintenanceError
+	if !errors.As(err, &maintErr) {
+		return nil, false
+	}
+
+	retry := "Maintenance usually lasts a few minutes to an hour; wait and retry the request later."
+	if maintErr.RetryAfter != nil {
+		wait := time.Until(*maintErr.RetryAfter).Round(time.Second)
+		if wait < 0 {
+			wait = 0
+		}
+		retry = fmt.Sprintf("HTB asked to retry after %s (in about %s).", maintErr.RetryAfter.Format(time.RFC3339), wait)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{
+			mcp.CreateTextContent(fmt.Sprintf("HTB is under maintenance, so the request could not be completed. %s Cached listings remain available through list_machines and list_challenges.", retry)),
+		},
+		IsError: true,
+	}, true
+}
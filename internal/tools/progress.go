@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+type progressTokenKey struct{}
+
+type progressReporterKey struct{}
+
+// WithProgressToken returns a context carrying the progressToken a client sent
+// with a tool call, so long-running tools can report progress on it
+func WithProgressToken(ctx context.Context, token interface{}) context.Context {
+	if token == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressTokenKey{}, token)
+}
+
+// progressReporter sends notifications/progress for a single tool call
+type progressReporter struct {
+	notifier Notifier
+	token    interface{}
+
+	mu   sync.Mutex
+	last float64
+}
+
+// withProgress attaches a progress reporter to ctx if the tool call carries a
+// progress token and notifications can be sent
+func (r *Registry) withProgress(ctx context.Context) context.Context {
+	token := ctx.Value(progressTokenKey{})
+	notifier := r.currentNotifier()
+	if token == nil || notifier == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressReporterKey{}, &progressReporter{notifier: notifier, token: token})
+}
+
+// progressFrom returns the progress reporter of a tool call, or nil if the
+// client did not ask for progress. A nil reporter ignores reports.
+func progressFrom(ctx context.Context) *progressReporter {
+	p, _ := ctx.Value(progressReporterKey{}).(*progressReporter)
+	return p
+}
+
+// report sends a progress notification. Clients expect progress to increase,
+// so reports that do not are dropped; total is omitted when zero.
+func (p *progressReporter) report(progress, total float64, message string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	if progress <= p.last {
+		p.mu.Unlock()
+		return
+	}
+	p.last = progress
+	p.mu.Unlock()
+
+	params := mcp.ProgressNotification{
+		ProgressToken: p.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	}
+	if err := p.notifier.Notify(mcp.MethodNotificationProgress, params); err != nil {
+		log.Printf("Failed to send progress notification: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// leaderboardEndpoints maps leaderboard types to their HTB ranking endpoints
+var leaderboardEndpoints = map[string]string{
+	"users":        "/rankings/users",
+	"teams":        "/rankings/teams",
+	"countries":    "/rankings/countries",
+	"universities": "/rankings/universities",
+}
+
+// GetLeaderboard tool for reading the HTB Hall of Fame rankings
+type GetLeaderboard struct {
+	client *htb.Client
+	state  *store.Store
+	shared cache.Cache
+}
+
+func NewGetLeaderboard(client *htb.Client, state *store.Store, shared cache.Cache) *GetLeaderboard {
+	return &GetLeaderboard{client: client, state: state, shared: shared}
+}
+
+func (t *GetLeaderboard) Name() string {
+	return "get_leaderboard"
+}
+
+func (t *GetLeaderboard) Description() string {
+	return "Get the HackTheBox Hall of Fame leaderboard of users, teams, countries or universities, returned in chunks with a continuation cursor"
+}
+
+func (t *GetLeaderboard) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"type": {
+				Type:        "string",
+				Description: "Leaderboard to get",
+				Enum:        []string{"users", "teams", "countries", "universities"},
+				Default:     "users",
+			},
+			"cursor": {
+				Type:        "string",
+				Description: "Continuation cursor from a previous chunked response",
+			},
+			"chunk_size": {
+				Type:        "integer",
+				Description: "Maximum number of entries returned per response",
+				Default:     defaultChunkSize,
+			},
+		},
+	}
+}
+
+func (t *GetLeaderboard) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	leaderboard := "users"
+	if l, ok := args["type"].(string); ok && l != "" {
+		leaderboard = l
+	}
+	endpoint, ok := leaderboardEndpoints[leaderboard]
+	if !ok {
+		return nil, fmt.Errorf("invalid type %q: must be users, teams, countries or universities", leaderboard)
+	}
+
+	// Make API request, falling back to the cached leaderboard when offline
+	data, notice, err := fetchListing(ctx, t.client, t.state, t.shared, endpoint, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s leaderboard: %w", leaderboard, err)
+	}
+
+	return chunkedResponse(data, notice, args)
+}
@@ -2,16 +2,85 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/logging"
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/redact"
+	"github.com/NoASLR/htb-mcp-server/internal/scheduler"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
 	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
 )
 
-// Registry manages all available MCP tools
+// Registry manages all available MCP tools. Tools, filters and the client
+// hooks may be set while tool calls are running, so they are only accessed
+// under mu.
 type Registry struct {
-	tools     map[string]Tool
-	htbClient *htb.Client
+	mu    sync.RWMutex
+	tools map[string]Tool
+
+	config     *config.Config
+	htbClient  *htb.Client
+	shared     *Shared
+	cache      cache.Cache
+	notes      *notes.Store
+	machines   *machineResolver
+	challenges *challengeResolver
+	state      *store.Store
+	index      *searchIndex
+	plans      *subscriptionCatalog
+	scheduler  *scheduler.Scheduler
+	flags      *flagQueue
+	notifier   Notifier
+	sampler    Sampler
+	rooter     Rooter
+	elicitor   Elicitor
+	logger     *logging.Logger
+
+	// Post-processing applied to every tool result, in order
+	filters  []OutputFilter
+	redactor *redact.Redactor
+
+	// Context of the client's connection, set by Start
+	ctx context.Context
+
+	// Session of the connected client, if any
+	session *session.Session
+
+	// Removes the schema drift callback from the shared HTB client
+	unsubscribeDrift func()
+}
+
+// OutputFilter post-processes a tool result before it reaches the client
+type OutputFilter func(resp *mcp.CallToolResponse) *mcp.CallToolResponse
+
+// Notifier delivers server-initiated notifications to the connected client
+type Notifier interface {
+	Notify(method string, params interface{}) error
+}
+
+// Sampler requests LLM completions from the connected client
+type Sampler interface {
+	CreateMessage(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResponse, error)
+}
+
+// Rooter lists the workspace roots declared by the connected client
+type Rooter interface {
+	ListRoots(ctx context.Context) ([]mcp.Root, error)
+}
+
+// Elicitor asks the connected client to collect input from the user
+type Elicitor interface {
+	Elicit(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResponse, error)
 }
 
 // Tool interface that all HTB tools must implement
@@ -22,65 +91,468 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error)
 }
 
-// NewRegistry creates a new tool registry
-func NewRegistry(htbClient *htb.Client) *Registry {
+// AnnotatedTool is implemented by tools that provide behavior hints to clients
+type AnnotatedTool interface {
+	Annotations() *mcp.ToolAnnotations
+}
+
+// StructuredTool is implemented by tools whose results always carry
+// structuredContent matching OutputSchema
+type StructuredTool interface {
+	OutputSchema() mcp.ToolSchema
+}
+
+// processStart is when the server process started, shared by every registry
+var processStart = time.Now()
+
+// NewRegistry creates a new tool registry using the state, cache and
+// background workers of shared. Listings, name lookups, exported catalogs and
+// the detected subscription are cached in the shared cache, which instances
+// serving the same account may also share.
+func NewRegistry(cfg *config.Config, htbClient *htb.Client, shared *Shared) *Registry {
+	c := shared.Cache()
 	registry := &Registry{
-		tools:     make(map[string]Tool),
-		htbClient: htbClient,
+		tools:      make(map[string]Tool),
+		config:     cfg,
+		htbClient:  htbClient,
+		shared:     shared,
+		cache:      c,
+		notes:      shared.notes,
+		machines:   newMachineResolver(htbClient, c),
+		challenges: newChallengeResolver(htbClient, c),
+		state:      shared.state,
+		scheduler:  shared.scheduler,
+		flags:      shared.flags,
 	}
+	registry.index = newSearchIndex(registry.state, c)
+	registry.plans = newSubscriptionCatalog(htbClient, c, registry.challenges, registry.toolsChanged)
+
+	// Report HTB API changes that would otherwise decode as empty data
+	registry.unsubscribeDrift = htbClient.OnSchemaDrift(func(drift htb.SchemaDrift) {
+		registry.notify(mcp.LogLevelWarning, "schema", drift)
+	})
+
+	// Show timestamps in the configured time zone
+	if cfg.TimeZone != nil {
+		registry.AddOutputFilter(timestampFilter(cfg.TimeZone))
+	}
+
+	// Redact sensitive values from tool results and notifications
+	if registry.redactor = redact.New(cfg.Redact, cfg.HTBToken, cfg.AcademyToken); registry.redactor != nil {
+		registry.AddOutputFilter(registry.redactor.Response)
+	}
+
+	// Send log messages to the client at the configured level until it picks one
+	level, ok := logging.ParseLevel(cfg.LogLevel)
+	if !ok {
+		level = mcp.LogLevelInfo
+	}
+	registry.logger = logging.New(level, registry.redactor)
 
 	// Register all available tools
 	registry.registerTools()
 
+	// Register tools provided by extension executables
+	registry.registerExtensions()
+
+	// Receive notifications from the shared background work
+	shared.attach(registry)
+
 	return registry
 }
 
+// academyClient returns an HTB Academy API client, or nil if no Academy token is configured
+func (r *Registry) academyClient() *htb.Client {
+	if r.config.AcademyToken == "" {
+		return nil
+	}
+	return htb.NewAcademyClient(r.config)
+}
+
+// statePath returns the persistent state file inside dir, or "" to keep state in memory
+func statePath(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "state.json")
+}
+
 // registerTools registers all available HTB tools
 func (r *Registry) registerTools() {
 	// Challenge management tools
-	r.RegisterTool(NewListChallenges(r.htbClient))
-	r.RegisterTool(NewStartChallenge(r.htbClient))
-	r.RegisterTool(NewSubmitChallengeFlag(r.htbClient))
+	r.RegisterTool(NewListChallenges(r.htbClient, r.state, r.cache))
+	r.RegisterTool(NewStartChallenge(r.htbClient, r.challenges))
+	r.RegisterTool(NewSubmitChallengeFlag(r.htbClient, r.challenges, r.flags))
+	r.RegisterTool(NewGetChallengeWriteup(r.htbClient, r.challenges))
+	r.RegisterTool(NewDownloadChallengeFiles(r.htbClient, r.challenges, r.config.DownloadDir, r.roots))
+	r.RegisterTool(NewGetChallengeStats(r.htbClient, r.challenges))
+
+	// Favorites tools
+	r.RegisterTool(NewFavoriteContent(r.htbClient, r.machines, r.challenges))
+	r.RegisterTool(NewUnfavoriteContent(r.htbClient, r.machines, r.challenges))
+	r.RegisterTool(NewListFavorites(r.htbClient))
 
 	// Machine management tools
-	r.RegisterTool(NewListMachines(r.htbClient))
-	r.RegisterTool(NewStartMachine(r.htbClient))
-	r.RegisterTool(NewGetMachineIP(r.htbClient))
-	r.RegisterTool(NewSubmitUserFlag(r.htbClient))
-	r.RegisterTool(NewSubmitRootFlag(r.htbClient))
+	r.RegisterTool(NewListMachines(r.htbClient, r.state, r.cache))
+	r.RegisterTool(NewStartMachine(r.htbClient, r.machines, r.notes, r.shared.watchSpawn, r.config.PreferredVPNRegion))
+	r.RegisterTool(NewGetMachineIP(r.htbClient, r.machines))
+	r.RegisterTool(NewGetMachineAvatar(r.htbClient, r.machines))
+	r.RegisterTool(NewGetMyLabIP(r.htbClient))
+	r.RegisterTool(NewDownloadVPNConfig(r.htbClient, r.config.DownloadDir, r.roots))
+	r.RegisterTool(NewGetAttackContext(r.htbClient, r.machines))
+	r.RegisterTool(NewSubmitUserFlag(r.htbClient, r.machines, r.notes, r.flags))
+	r.RegisterTool(NewSubmitRootFlag(r.htbClient, r.machines, r.notes, r.flags))
+	r.RegisterTool(NewSubmitMachineFeedback(r.htbClient, r.machines))
+	r.RegisterTool(NewDigestMachineReviews(r.htbClient, r.machines, r.sample))
+	r.RegisterTool(NewPlanRecon(r.htbClient, r.machines, r.sample))
+	r.RegisterTool(NewGetTimeRemaining(r.htbClient))
+	r.RegisterTool(NewListActiveInstances(r.htbClient))
+	r.RegisterTool(NewSpawnMachineInstance(r.htbClient, r.machines, r.notes, r.shared.watchSpawn, r.config.PreferredVPNRegion))
+	r.RegisterTool(NewResetMachineInstance(r.htbClient, r.machines))
+	r.RegisterTool(NewVoteMachineReset(r.htbClient, r.machines))
+	r.RegisterTool(NewGetResetVotes(r.htbClient, r.machines))
+	r.RegisterTool(NewGetPwnboxQuota(r.htbClient))
+	r.RegisterTool(NewCleanupSession(r.htbClient, r.notes))
+	r.RegisterTool(NewSubmitFlagsBatch(r.htbClient, r.notes, r.config.RateLimitPerMinute))
+	r.RegisterTool(NewListQueuedFlags(r.flags))
+	r.RegisterTool(NewRetryQueuedFlags(r.flags))
+
+	// Scheduling tools
+	r.RegisterTool(NewScheduleMachineSpawn(r.htbClient, r.machines, r.notes, r.scheduler, r.shared.notify, r.config.PreferredVPNRegion, r.config.TimeZone))
+	r.RegisterTool(NewListScheduledSpawns(r.scheduler))
+	r.RegisterTool(NewCancelScheduledSpawn(r.scheduler))
+
+	// Track tools
+	r.RegisterTool(NewListTracks(r.htbClient))
+	r.RegisterTool(NewGetTrackProgress(r.htbClient))
+	r.RegisterTool(NewListTrackItems(r.htbClient))
+	r.RegisterTool(NewEnrollInTrack(r.htbClient))
+
+	// Battlegrounds tools
+	r.RegisterTool(NewGetBattlegroundsStatus(r.htbClient))
+	r.RegisterTool(NewGetBattlegroundsHistory(r.htbClient))
 
 	// User management tools
+	r.RegisterTool(NewGetDashboard(r.htbClient))
 	r.RegisterTool(NewGetUserProfile(r.htbClient))
 	r.RegisterTool(NewGetUserProgress(r.htbClient))
+	r.RegisterTool(NewGetOwnershipPercentage(r.htbClient))
+	r.RegisterTool(NewGetRankHistory(r.htbClient))
+	r.RegisterTool(NewGetLeaderboard(r.htbClient, r.state, r.cache))
+	r.RegisterTool(NewSummarizeActivity(r.htbClient, r.sample))
+	r.RegisterTool(NewGetUserAvatar(r.htbClient))
+	r.RegisterTool(NewGetSeasonTierProgress(r.htbClient))
+	r.RegisterTool(NewGetSeasonResetOutlook(r.htbClient))
+	r.RegisterTool(NewGetSolveAnalytics(r.htbClient, r.notes))
+	r.RegisterTool(NewGetWeeklyDigest(r.htbClient, r.config.DigestInterests))
+	r.RegisterTool(NewListCreatorContent(r.htbClient))
+	r.RegisterTool(NewListNotifications(r.htbClient))
+	r.RegisterTool(NewMarkNotificationsRead(r.htbClient))
+
+	// Team management tools
+	r.RegisterTool(NewInviteTeamMember(r.htbClient))
+	r.RegisterTool(NewRemoveTeamMember(r.htbClient))
+	r.RegisterTool(NewListTeamJoinRequests(r.htbClient))
+	r.RegisterTool(NewProcessTeamJoinRequest(r.htbClient))
+	r.RegisterTool(NewEditTeamMotto(r.htbClient))
+
+	// Academy tools
+	r.RegisterTool(NewGetCertificationStatus(r.academyClient()))
+
+	// Content creator tools
+	r.RegisterTool(NewListMySubmissions(r.htbClient))
+	r.RegisterTool(NewGetSubmissionStatus(r.htbClient))
+	r.RegisterTool(NewGetSubmissionFeedback(r.htbClient))
 
 	// Search and utility tools
-	r.RegisterTool(NewSearchContent(r.htbClient))
-	r.RegisterTool(NewGetServerStatus(r.htbClient))
+	r.RegisterTool(NewSearchContent(r.htbClient, r.index))
+	r.RegisterTool(NewGetServerStatus(r.htbClient, r.Uptime))
+	r.RegisterTool(NewGetRateLimitStatus(r.htbClient))
+	r.RegisterTool(NewGetPlatformUpdates(r.htbClient))
+	r.RegisterTool(NewGetHTBStatus(r.htbClient))
+	r.RegisterTool(NewGetNewContent(r.htbClient, r.state))
+	r.RegisterTool(NewGetRetirementSchedule(r.htbClient))
+	r.RegisterTool(NewExportAllMachines(r.htbClient, r.state, r.cache))
+	r.RegisterTool(NewExportAllChallenges(r.htbClient, r.state, r.cache))
+
+	// Notes tools
+	r.RegisterTool(NewAddNote(r.notes))
+	r.RegisterTool(NewExportNotes(r.notes, r.config.NotesDir, r.roots))
+}
+
+// registerExtensions registers the tools of the configured extension
+// executables, described once for every registry sharing the same state.
+// Extensions that fail to load are skipped, and built-in tools take
+// precedence over extension tools with the same name.
+func (r *Registry) registerExtensions() {
+	extTools, errs := r.shared.extensionTools()
+	for _, err := range errs {
+		r.logger.Warnf("extensions", "Skipping extension: %v", err)
+	}
+
+	for _, tool := range extTools {
+		if _, exists := r.GetTool(tool.Name()); exists {
+			r.logger.Warnf("extensions", "Skipping extension tool %s from %s: name already registered", tool.Name(), tool.Path())
+			continue
+		}
+		r.RegisterTool(tool)
+	}
+}
+
+// Start starts the shared background work, if no other registry has, and
+// runs the registry's own background work under ctx
+func (r *Registry) Start(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	r.shared.Start(ctx)
+}
+
+// backgroundContext returns the context of the client's connection
+func (r *Registry) backgroundContext() context.Context {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// SetNotifier sets the notifier used for server-initiated notifications
+func (r *Registry) SetNotifier(notifier Notifier) {
+	r.mu.Lock()
+	r.notifier = notifier
+	r.mu.Unlock()
+	r.logger.SetNotifier(notifier)
+}
+
+// currentNotifier returns the notifier, or nil if none is set
+func (r *Registry) currentNotifier() Notifier {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.notifier
+}
+
+// Logger returns the logger forwarding log messages to the client
+func (r *Registry) Logger() *logging.Logger {
+	return r.logger
+}
+
+// Redactor returns the redactor applied to output sent to the client, or nil
+// if redaction is disabled
+func (r *Registry) Redactor() *redact.Redactor {
+	return r.redactor
+}
+
+// SetSession sets the session of the connected client, whose machine
+// receives background notifications meant for it
+func (r *Registry) SetSession(s *session.Session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.session = s
+}
+
+// workingOn reports whether the connected client spawned machineID
+func (r *Registry) workingOn(machineID int) bool {
+	r.mu.RLock()
+	s := r.session
+	r.mu.RUnlock()
+
+	if s == nil {
+		return false
+	}
+	id, ok := s.Machine()
+	return ok && id == machineID
+}
+
+// SetSampler sets the client used for sampling requests
+func (r *Registry) SetSampler(sampler Sampler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sampler = sampler
+}
+
+// sample requests an LLM completion from the client if a sampler is set
+func (r *Registry) sample(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResponse, error) {
+	r.mu.RLock()
+	sampler := r.sampler
+	r.mu.RUnlock()
+
+	if sampler == nil {
+		return nil, fmt.Errorf("sampling is not available")
+	}
+	if s, ok := session.FromContext(ctx); ok && !s.Supports(mcp.FeatureSampling) {
+		return nil, fmt.Errorf("sampling is not supported by %s", clientName(s))
+	}
+	return sampler.CreateMessage(ctx, req)
+}
+
+// SetRooter sets the client asked for workspace roots
+func (r *Registry) SetRooter(rooter Rooter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rooter = rooter
+}
+
+// roots lists the client's workspace roots, or none if no rooter is set
+func (r *Registry) roots(ctx context.Context) ([]mcp.Root, error) {
+	r.mu.RLock()
+	rooter := r.rooter
+	r.mu.RUnlock()
+
+	if rooter == nil {
+		return nil, nil
+	}
+	return rooter.ListRoots(ctx)
+}
+
+// SetElicitor sets the client asked to confirm tool calls
+func (r *Registry) SetElicitor(elicitor Elicitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.elicitor = elicitor
+}
+
+// clientName describes the client of a session for error messages
+func clientName(s *session.Session) string {
+	if client, ok := s.Client(); ok && client.Info.Name != "" {
+		return fmt.Sprintf("client %s", client.Info.Name)
+	}
+	return "the connected client"
+}
+
+// notify sends a log message notification to the client if a notifier is
+// set and the client's log level allows it
+func (r *Registry) notify(level, logger string, data interface{}) {
+	r.logger.Notify(level, logger, data)
+}
+
+// toolsChanged tells the client the tool list changed, e.g. when tools are
+// annotated for the detected subscription
+func (r *Registry) toolsChanged() {
+	notifier := r.currentNotifier()
+	if notifier == nil {
+		return
+	}
+	if err := notifier.Notify(mcp.MethodNotificationToolsChanged, struct{}{}); err != nil {
+		r.logger.Errorf("server", "Failed to send tool list change: %v", err)
+	}
+}
+
+// DetectSubscription detects the account's subscription in the background,
+// so tools it doesn't include are annotated in tools/list. The client is
+// sent notifications/tools/list_changed if the annotations change.
+func (r *Registry) DetectSubscription() {
+	go func() {
+		ctx, cancel := context.WithTimeout(r.backgroundContext(), r.config.RequestTimeout)
+		defer cancel()
+		if _, err := r.plans.current(ctx); err != nil {
+			r.logger.Warnf("server", "Failed to detect subscription: %v", err)
+		}
+	}()
+}
+
+// resourceUpdated tells the client a resource changed; the notifier only
+// forwards it to clients subscribed to uri
+func (r *Registry) resourceUpdated(uri string) {
+	notifier := r.currentNotifier()
+	if notifier == nil {
+		return
+	}
+	if err := notifier.Notify(mcp.MethodNotificationResourceUpdated, mcp.ResourceUpdatedNotification{URI: uri}); err != nil {
+		r.logger.Errorf("server", "Failed to send resource update for %s: %v", uri, err)
+	}
+}
+
+// Content kinds whose names can be completed
+const (
+	CompleteMachines   = catalogMachines
+	CompleteChallenges = catalogChallenges
+)
+
+// Complete suggests names of a content kind starting with prefix from the
+// local content index, without calling the HTB API. There are no suggestions
+// until the kind has been exported.
+func (r *Registry) Complete(kind, prefix string) mcp.Completion {
+	names, total := r.index.complete(kind, prefix, mcp.MaxCompletionValues)
+	if names == nil {
+		names = []string{}
+	}
+	return mcp.Completion{Values: names, Total: total, HasMore: total > len(names)}
+}
+
+// Notes returns the notes store used for work not tied to an MCP session,
+// such as background polling
+func (r *Registry) Notes() *notes.Store {
+	return r.notes
+}
+
+// Close detaches the registry from the shared background work, which keeps
+// running for other registries until the Shared is closed
+func (r *Registry) Close() {
+	r.shared.detach(r)
+	r.unsubscribeDrift()
 }
 
 // RegisterTool registers a new tool
 func (r *Registry) RegisterTool(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.tools[tool.Name()] = tool
 }
 
 // GetTool returns a tool by name
 func (r *Registry) GetTool(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	tool, exists := r.tools[name]
 	return tool, exists
 }
 
-// GetTools returns all registered tools in MCP format
+// registeredTools returns a snapshot of the registered tools
+func (r *Registry) registeredTools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tools := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// Uptime returns how long the server process has been running. Over HTTP
+// each session has its own registry, so this is not the registry's age.
+func (r *Registry) Uptime() time.Duration {
+	return time.Since(processStart)
+}
+
+// GetTools returns all registered tools in MCP format, sorted by name
 func (r *Registry) GetTools() []mcp.Tool {
 	var tools []mcp.Tool
 
-	for _, tool := range r.tools {
-		tools = append(tools, mcp.Tool{
+	for _, tool := range r.registeredTools() {
+		t := mcp.Tool{
 			Name:        tool.Name(),
 			Description: tool.Description(),
 			InputSchema: tool.Schema(),
-		})
+		}
+		if req, level, unavailable := r.plans.unavailable(t.Name); unavailable {
+			t.Description = fmt.Sprintf("[Requires %s; this account is %s] %s", req.level, level, t.Description)
+		}
+		if annotated, ok := tool.(AnnotatedTool); ok {
+			t.Annotations = annotated.Annotations()
+		}
+		if structured, ok := tool.(StructuredTool); ok {
+			schema := structured.OutputSchema()
+			t.OutputSchema = &schema
+		}
+		tools = append(tools, t)
 	}
 
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+
 	return tools
 }
 
@@ -91,14 +563,99 @@ func (r *Registry) ExecuteTool(ctx context.Context, name string, args map[string
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
 
-	return tool.Execute(ctx, args)
+	if sess, ok := session.FromContext(ctx); ok {
+		if !sess.Limiter.Allow() {
+			return nil, fmt.Errorf("rate limit exceeded for this session: at most %d tool calls per minute", r.config.RateLimitPerMinute)
+		}
+		if !sess.StartCall() {
+			return nil, fmt.Errorf("too many tool calls in progress for this session: at most %d at once", sess.MaxInFlightCalls)
+		}
+		defer sess.EndCall()
+	}
+
+	if err := r.plans.check(ctx, name, args); err != nil {
+		return nil, err
+	}
+
+	if err := r.confirm(ctx, name, args); err != nil {
+		return nil, err
+	}
+
+	var trace *htb.Trace
+	if r.config.ExecutionMeta {
+		ctx, trace = htb.WithTrace(ctx)
+	}
+	start := time.Now()
+
+	result, err := tool.Execute(r.withProgress(ctx), args)
+	if err != nil {
+		if maintenance, ok := maintenanceResponse(err); ok {
+			return withExecutionMeta(maintenance, trace, start), nil
+		}
+		return nil, r.redactor.Error(r.plans.explainForbidden(ctx, err))
+	}
+
+	r.mu.RLock()
+	filters := r.filters
+	r.mu.RUnlock()
+	for _, filter := range filters {
+		result = filter(result)
+	}
+	result = withExecutionMeta(negotiateContent(ctx, result), trace, start)
+	if err := r.checkResultSize(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkResultSize refuses results whose encoding exceeds MaxResultBytes, so
+// one call can't flood the client's context or the transport
+func (r *Registry) checkResultSize(result *mcp.CallToolResponse) error {
+	if r.config.MaxResultBytes <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	if len(data) > r.config.MaxResultBytes {
+		return fmt.Errorf("result of %d bytes exceeds the %d byte limit for tool results; narrow the request with filters, a smaller limit or chunk_size", len(data), r.config.MaxResultBytes)
+	}
+	return nil
+}
+
+// withExecutionMeta adds the HTB requests recorded by trace and the call's
+// duration to the result's _meta, so clients can see why a call was slow.
+// Results are returned unchanged when not tracing.
+func withExecutionMeta(result *mcp.CallToolResponse, trace *htb.Trace, start time.Time) *mcp.CallToolResponse {
+	if trace == nil {
+		return result
+	}
+
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	result.Meta["execution"] = struct {
+		DurationMS int64 `json:"duration_ms"`
+		htb.TraceSummary
+	}{time.Since(start).Milliseconds(), trace.Summary()}
+	return result
+}
+
+// AddOutputFilter appends a post-processing stage applied to every tool result
+func (r *Registry) AddOutputFilter(filter OutputFilter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Copy so calls already iterating the old slice are unaffected
+	r.filters = append(r.filters[:len(r.filters):len(r.filters)], filter)
 }
 
 // ListToolNames returns a list of all registered tool names
 func (r *Registry) ListToolNames() []string {
 	var names []string
-	for name := range r.tools {
-		names = append(names, name)
+	for _, tool := range r.registeredTools() {
+		names = append(names, tool.Name())
 	}
 	return names
 }
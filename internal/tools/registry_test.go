@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/extensions"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestUptimeIsProcessWide(t *testing.T) {
+	cfg := config.Default()
+	cfg.HTBToken = "header.payload.signature"
+	client := htb.NewClient(cfg)
+	shared := NewShared(cfg, client, cache.New(cfg.CacheTTL))
+	defer shared.Close()
+
+	first := NewRegistry(cfg, client, shared)
+	defer first.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	// A registry created later, like that of a new HTTP session, reports
+	// the same process uptime rather than its own age
+	second := NewRegistry(cfg, client, shared)
+	defer second.Close()
+	if uptime := second.Uptime(); uptime < 20*time.Millisecond || uptime < first.Uptime()-time.Second {
+		t.Errorf("Expected the process uptime, got %v", uptime)
+	}
+}
+
+func TestExtensionsDescribedOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell script extensions need a Unix shell")
+	}
+	dir := t.TempDir()
+	described := filepath.Join(dir, "described")
+	path := filepath.Join(dir, "extension")
+	script := "#!/bin/sh\necho run >> " + described + "\necho '[{\"name\":\"whois\"},{\"name\":\"list_machines\"}]'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write extension: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.HTBToken = "header.payload.signature"
+	cfg.Extensions = []string{path, filepath.Join(dir, "missing")}
+	client := htb.NewClient(cfg)
+	shared := NewShared(cfg, client, cache.New(cfg.CacheTTL))
+	defer shared.Close()
+
+	// Every session's registry gets the tools of one --describe run
+	for i := 0; i < 3; i++ {
+		registry := NewRegistry(cfg, client, shared)
+		defer registry.Close()
+		if _, ok := registry.GetTool("whois"); !ok {
+			t.Errorf("Registry %d: expected the extension tool", i+1)
+		}
+		if tool, _ := registry.GetTool("list_machines"); tool == nil || tool.Name() != "list_machines" {
+			t.Errorf("Registry %d: expected list_machines, got %v", i+1, tool)
+		} else if _, ok := tool.(*extensions.Tool); ok {
+			t.Errorf("Registry %d: expected the built-in list_machines to take precedence", i+1)
+		}
+	}
+	if data, err := os.ReadFile(described); err != nil || strings.Count(string(data), "run") != 1 {
+		t.Errorf("Expected the extension to be described once, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// nameIndexTTL is how long a listing-based name index, or a resolved name,
+// is cached
+const nameIndexTTL = time.Hour
+
+// Cache keys of the machine and challenge name indexes
+const (
+	machineIndexKey   = "index:machines"
+	challengeIndexKey = "index:challenges"
+)
+
+// machineIndexMaxPages bounds how many listing pages are fetched per machine status
+const machineIndexMaxPages = 20
+
+// maxSuggestions is the number of "did you mean" candidates offered
+const maxSuggestions = 3
+
+// namedItem is a piece of HTB content identified by ID and name
+type namedItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// machineResolver resolves machine IDs and names to validated machine IDs,
+// caching successful lookups and suggesting close matches for unknown names.
+// Lookups and the name index are cached in the shared cache, so instances
+// serving the same account reuse them.
+type machineResolver struct {
+	client *htb.Client
+	shared cache.Cache
+
+	// index is the last fetched name index, used when the cached one has
+	// expired and the listing can't be fetched
+	mu    sync.Mutex
+	index []namedItem
+}
+
+func newMachineResolver(client *htb.Client, shared cache.Cache) *machineResolver {
+	return &machineResolver{client: client, shared: shared}
+}
+
+// resolvedMachineKey returns the cache key of a resolved machine ID or name
+func resolvedMachineKey(target string) string {
+	return "resolve:machine:" + strings.ToLower(target)
+}
+
+// machineIDProperty is the schema property for targeting a machine by ID
+var machineIDProperty = mcp.Property{
+	Type:        "integer",
+	Description: "The ID of the machine",
+}
+
+// machineNameProperty is the schema property for targeting a machine by name
+var machineNameProperty = mcp.Property{
+	Type:        "string",
+	Description: "Alternative to machine_id: the machine's name (case-insensitive)",
+}
+
+// hasMachineTarget reports whether args identify a machine by ID or name
+func hasMachineTarget(args map[string]interface{}) bool {
+	return machineTarget(args) != ""
+}
+
+// machineTarget returns the machine ID or name given in args, or ""
+func machineTarget(args map[string]interface{}) string {
+	switch id := args["machine_id"].(type) {
+	case float64:
+		return strconv.Itoa(int(id))
+	case string:
+		if id = strings.TrimSpace(id); id != "" {
+			return id
+		}
+	}
+
+	if name, ok := args["machine_name"].(string); ok {
+		return strings.TrimSpace(name)
+	}
+	return ""
+}
+
+// resolve returns the ID of the machine identified by args. Unknown machines
+// are reported with suggestions instead of being passed on to the HTB API.
+func (m *machineResolver) resolve(ctx context.Context, args map[string]interface{}) (int, error) {
+	target := machineTarget(args)
+	if target == "" {
+		return 0, fmt.Errorf("machine_id or machine_name is required")
+	}
+
+	var item namedItem
+	if cached, ok := m.shared.Get(resolvedMachineKey(target)); ok && cache.Decode(cached, &item) == nil {
+		htb.TraceFrom(ctx).CacheHit()
+		return item.ID, nil
+	}
+
+	profile, err := m.client.GetMachineProfile(ctx, target)
+	if err != nil && !isNotFound(err) {
+		return 0, fmt.Errorf("failed to look up machine %s: %w", target, err)
+	}
+	if err != nil || profile == nil || profile.ID == 0 {
+		return 0, fmt.Errorf("machine %q not found%s", target, didYouMean(suggest(target, m.names(ctx))))
+	}
+
+	item = namedItem{ID: profile.ID, Name: profile.Name}
+	for _, key := range []string{target, strconv.Itoa(item.ID), item.Name} {
+		m.shared.SetTTL(resolvedMachineKey(key), item, nameIndexTTL)
+	}
+
+	return item.ID, nil
+}
+
+// names returns the names of all listed machines, refreshing the index when
+// stale. Listing failures yield an empty or stale index rather than an error.
+// The listing is fetched without holding the lock, so resolves served from
+// the cache never wait on it; concurrent refreshes may both fetch.
+func (m *machineResolver) names(ctx context.Context) []string {
+	var index []namedItem
+	if cached, ok := m.shared.Get(machineIndexKey); !ok || cache.Decode(cached, &index) != nil {
+		var fetched []namedItem
+		fetched = append(fetched, fetchNamedItems(ctx, m.client, "/machine/paginated/?per_page=100")...)
+		fetched = append(fetched, fetchNamedItems(ctx, m.client, "/machine/list/retired/paginated/?per_page=100")...)
+
+		m.mu.Lock()
+		if len(fetched) > 0 {
+			m.shared.SetTTL(machineIndexKey, fetched, nameIndexTTL)
+			m.index = fetched
+		}
+		index = m.index
+		m.mu.Unlock()
+	}
+
+	names := make([]string, 0, len(index))
+	for _, item := range index {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// fetchNamedItems fetches every page of a paginated listing
+func fetchNamedItems(ctx context.Context, client *htb.Client, endpoint string) []namedItem {
+	var items []namedItem
+	for page := 1; page <= machineIndexMaxPages; page++ {
+		var result struct {
+			Data []namedItem `json:"data"`
+			Meta struct {
+				LastPage int `json:"last_page"`
+			} `json:"meta"`
+		}
+		if err := client.GetJSON(ctx, fmt.Sprintf("%s&page=%d", endpoint, page), &result); err != nil {
+			break
+		}
+
+		items = append(items, result.Data...)
+		if len(result.Data) == 0 || page >= result.Meta.LastPage {
+			break
+		}
+	}
+	return items
+}
+
+// challengeResolver resolves challenge IDs, given as numbers or numeric
+// strings, and challenge names to numeric challenge IDs. The name index is
+// cached in the shared cache like the machine index.
+type challengeResolver struct {
+	client *htb.Client
+	shared cache.Cache
+
+	// index is the last fetched name index, used when the cached one has
+	// expired and the listings can't be fetched
+	mu    sync.Mutex
+	index []namedItem
+}
+
+func newChallengeResolver(client *htb.Client, shared cache.Cache) *challengeResolver {
+	return &challengeResolver{client: client, shared: shared}
+}
+
+// challengeIDProperty and challengeNameProperty are the schema properties for
+// targeting a challenge
+var (
+	challengeIDProperty = mcp.Property{
+		Type:        "integer",
+		Description: "The ID of the challenge (numeric strings are also accepted)",
+	}
+	challengeNameProperty = mcp.Property{
+		Type:        "string",
+		Description: "Alternative to challenge_id: the challenge's name (case-insensitive)",
+	}
+)
+
+// resolve returns the ID of the challenge identified by args
+func (c *challengeResolver) resolve(ctx context.Context, args map[string]interface{}) (int, error) {
+	var target string
+	switch id := args["challenge_id"].(type) {
+	case float64:
+		return int(id), nil
+	case string:
+		target = strings.TrimSpace(id)
+	}
+	if target == "" {
+		if name, ok := args["challenge_name"].(string); ok {
+			target = strings.TrimSpace(name)
+		}
+	}
+	if target == "" {
+		return 0, fmt.Errorf("challenge_id or challenge_name is required")
+	}
+
+	if id, ok := numericID(target); ok {
+		return id, nil
+	}
+
+	index := c.items(ctx)
+	names := make([]string, 0, len(index))
+	for _, item := range index {
+		if strings.EqualFold(item.Name, target) {
+			return item.ID, nil
+		}
+		names = append(names, item.Name)
+	}
+
+	return 0, fmt.Errorf("challenge %q not found%s", target, didYouMean(suggest(target, names)))
+}
+
+// items returns all active and retired challenges, refreshing the index when
+// stale. Listing failures yield an empty or stale index rather than an error.
+// As with machines, the listing is fetched without holding the lock.
+func (c *challengeResolver) items(ctx context.Context) []namedItem {
+	var index []namedItem
+	if cached, ok := c.shared.Get(challengeIndexKey); ok && cache.Decode(cached, &index) == nil {
+		htb.TraceFrom(ctx).CacheHit()
+		return index
+	}
+
+	var fetched []namedItem
+	for _, endpoint := range []string{"/challenge/list", "/challenge/list/retired"} {
+		var result struct {
+			Challenges []namedItem `json:"challenges"`
+		}
+		if err := c.client.GetJSON(ctx, endpoint, &result); err == nil {
+			fetched = append(fetched, result.Challenges...)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(fetched) > 0 {
+		c.shared.SetTTL(challengeIndexKey, fetched, nameIndexTTL)
+		c.index = fetched
+	}
+	return c.index
+}
+
+// numericID returns v as an integer ID if it is a number or a numeric string
+func numericID(v interface{}) (int, bool) {
+	switch id := v.(type) {
+	case float64:
+		return int(id), true
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(id))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// isNotFound reports whether err is an HTB API 404 response
+func isNotFound(err error) bool {
+	var apiErr *htb.HTBAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// suggest returns up to maxSuggestions candidates closest to target, ignoring
+// those too different to be a plausible typo
+func suggest(target string, candidates []string) []string {
+	target = strings.ToLower(target)
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	var matches []scored
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		if candidate == "" || seen[lower] {
+			continue
+		}
+		seen[lower] = true
+
+		distance := levenshtein(target, lower)
+		if strings.Contains(lower, target) || strings.Contains(target, lower) {
+			distance = min(distance, 1)
+		}
+		if distance <= max(2, len(target)/3) {
+			matches = append(matches, scored{name: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// didYouMean formats suggestions for appending to a not-found error
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %s?", strings.Join(suggestions, ", "))
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
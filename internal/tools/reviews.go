@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// samplingTimeout bounds how long a tool waits for the client to complete a sampling request
+const samplingTimeout = 2 * time.Minute
+
+// defaultDigestMaxTokens is the default sampling budget for review digests
+const defaultDigestMaxTokens = 200
+
+// sampleFunc requests an LLM completion from the connected client
+type sampleFunc func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResponse, error)
+
+// DigestMachineReviews tool for summarizing community reviews of a machine
+type DigestMachineReviews struct {
+	client   *htb.Client
+	machines *machineResolver
+	sample   sampleFunc
+}
+
+func NewDigestMachineReviews(client *htb.Client, machines *machineResolver, sample sampleFunc) *DigestMachineReviews {
+	return &DigestMachineReviews{client: client, machines: machines, sample: sample}
+}
+
+func (t *DigestMachineReviews) Name() string {
+	return "digest_machine_reviews"
+}
+
+func (t *DigestMachineReviews) Description() string {
+	return "Summarize all community reviews of a HackTheBox machine into a short consensus digest using client-side sampling, falling back to the raw reviews when sampling is unavailable"
+}
+
+func (t *DigestMachineReviews) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id":   machineIDProperty,
+			"machine_name": machineNameProperty,
+			"max_tokens": {
+				Type:        "integer",
+				Description: "Maximum tokens for the digest",
+				Default:     defaultDigestMaxTokens,
+			},
+		},
+	}
+}
+
+func (t *DigestMachineReviews) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := maxTokensArg(args, defaultDigestMaxTokens)
+
+	// Make API request
+	reviews, err := t.client.GetMachineReviews(ctx, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine reviews: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"machine_id":    machineID,
+		"review_count":  len(reviews),
+		"average_stars": averageStars(reviews),
+	}
+
+	if len(reviews) == 0 {
+		result["digest"] = "No reviews have been posted for this machine yet"
+	} else {
+		digest, model, err := t.digest(ctx, reviews, maxTokens)
+		if err != nil {
+			result["digest_unavailable"] = err.Error()
+			result["reviews"] = reviews
+		} else {
+			result["digest"] = digest
+			result["model"] = model
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// digest asks the client to summarize the reviews and returns the summary and model used
+func (t *DigestMachineReviews) digest(ctx context.Context, reviews []htb.MachineReview, maxTokens int) (string, string, error) {
+	var b strings.Builder
+	for _, review := range reviews {
+		fmt.Fprintf(&b, "- %.1f stars: %s. %s\n", float64(review.Stars), review.Headline, review.Review)
+	}
+
+	return sampleText(ctx, t.sample,
+		"You summarize HackTheBox machine reviews. Reply with one or two short sentences capturing the consensus on stability, realism and difficulty of each stage (e.g. \"stable box, CTF-y privesc\"). Do not include spoilers.",
+		"Summarize these reviews:\n\n"+b.String(),
+		maxTokens)
+}
+
+// averageStars returns the mean star rating of the reviews
+func averageStars(reviews []htb.MachineReview) float64 {
+	if len(reviews) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, review := range reviews {
+		total += float64(review.Stars)
+	}
+	return total / float64(len(reviews))
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/scheduler"
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// taskKindSpawn identifies scheduled machine spawns
+const taskKindSpawn = "machine_spawn"
+
+// scheduledSpawnTimeout bounds how long a scheduled spawn may take
+const scheduledSpawnTimeout = 2 * time.Minute
+
+// notifyFunc sends a log message notification to the connected client
+type notifyFunc func(level, logger string, data interface{})
+
+// ScheduleMachineSpawn tool for spawning a machine at a later time
+type ScheduleMachineSpawn struct {
+	client    *htb.Client
+	machines  *machineResolver
+	notes     *notes.Store
+	scheduler *scheduler.Scheduler
+	notify    notifyFunc
+	vpnRegion string
+	timeZone  *time.Location
+}
+
+func NewScheduleMachineSpawn(client *htb.Client, machines *machineResolver, store *notes.Store, sched *scheduler.Scheduler, notify notifyFunc, vpnRegion string, timeZone *time.Location) *ScheduleMachineSpawn {
+	return &ScheduleMachineSpawn{client: client, machines: machines, notes: store, scheduler: sched, notify: notify, vpnRegion: vpnRegion, timeZone: timeZone}
+}
+
+func (t *ScheduleMachineSpawn) Name() string {
+	return "schedule_machine_spawn"
+}
+
+func (t *ScheduleMachineSpawn) Description() string {
+	return "Schedule a HackTheBox machine to be spawned at a given time (e.g. the weekly release time). A notification is sent when the spawn runs"
+}
+
+func (t *ScheduleMachineSpawn) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"machine_id": {
+				Type:        "integer",
+				Description: "The ID of the machine to spawn",
+			},
+			"machine_name": machineNameProperty,
+			"run_at": {
+				Type:        "string",
+				Description: "When to spawn the machine: an RFC3339 timestamp, a date and time without offset (e.g. 2025-01-04 20:00) in the configured TIMEZONE, or \"next_release\" for the next weekly release (Saturday 19:00 UTC)",
+			},
+			"delay_minutes": {
+				Type:        "integer",
+				Description: "Alternative to run_at: spawn the machine after this many minutes",
+			},
+		},
+	}
+}
+
+func (t *ScheduleMachineSpawn) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	machineID, err := t.machines.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var runAt time.Time
+	if ra, ok := args["run_at"].(string); ok && ra != "" {
+		if ra == "next_release" {
+			runAt = scheduler.NextRelease(time.Now())
+		} else {
+			parsed, err := parseScheduleTime(ra, t.timeZone)
+			if err != nil {
+				return nil, fmt.Errorf("run_at must be an RFC3339 timestamp, a date and time, or \"next_release\": %w", err)
+			}
+			runAt = parsed
+		}
+	} else if delay, ok := args["delay_minutes"].(float64); ok {
+		runAt = time.Now().Add(time.Duration(delay) * time.Minute)
+	} else {
+		return nil, fmt.Errorf("either run_at or delay_minutes is required")
+	}
+
+	if !runAt.After(time.Now()) {
+		return nil, fmt.Errorf("scheduled time %s is in the past", runAt.Format(time.RFC3339))
+	}
+
+	description := fmt.Sprintf("Spawn machine %d", machineID)
+	store := session.NotesFrom(ctx, t.notes)
+	sess, _ := session.FromContext(ctx)
+	task, err := t.scheduler.Schedule(taskKindSpawn, description, runAt, func() {
+		t.run(machineID, store, sess)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule spawn: %w", err)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// run performs a scheduled spawn, recording it in the scheduling session's
+// notes and tracking the machine for that session, and reports the outcome
+// to the client
+func (t *ScheduleMachineSpawn) run(machineID int, store *notes.Store, sess *session.Session) {
+	ctx, cancel := context.WithTimeout(context.Background(), scheduledSpawnTimeout)
+	defer cancel()
+	if sess != nil {
+		ctx = session.NewContext(ctx, sess)
+	}
+
+	data, err := spawnMachine(ctx, t.client, store, machineID, t.vpnRegion)
+	if err != nil {
+		log.Printf("Scheduled spawn of machine %d failed: %v", machineID, err)
+		t.notify(mcp.LogLevelError, "scheduler", map[string]interface{}{
+			"event":      "scheduled_spawn_failed",
+			"machine_id": machineID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	log.Printf("Scheduled spawn of machine %d executed", machineID)
+	t.notify(mcp.LogLevelInfo, "scheduler", map[string]interface{}{
+		"event":      "scheduled_spawn_executed",
+		"machine_id": machineID,
+		"result":     data,
+	})
+}
+
+// ListScheduledSpawns tool for listing pending scheduled spawns
+type ListScheduledSpawns struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewListScheduledSpawns(sched *scheduler.Scheduler) *ListScheduledSpawns {
+	return &ListScheduledSpawns{scheduler: sched}
+}
+
+func (t *ListScheduledSpawns) Name() string {
+	return "list_scheduled_spawns"
+}
+
+func (t *ListScheduledSpawns) Description() string {
+	return "List pending scheduled machine spawns"
+}
+
+func (t *ListScheduledSpawns) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ListScheduledSpawns) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var spawns []scheduler.Task
+	for _, task := range t.scheduler.Pending() {
+		if task.Kind == taskKindSpawn {
+			spawns = append(spawns, task)
+		}
+	}
+
+	if len(spawns) == 0 {
+		content := mcp.CreateTextContent("No scheduled spawns pending")
+		return &mcp.CallToolResponse{
+			Content: []mcp.Content{content},
+		}, nil
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(spawns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// CancelScheduledSpawn tool for cancelling a pending scheduled spawn
+type CancelScheduledSpawn struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewCancelScheduledSpawn(sched *scheduler.Scheduler) *CancelScheduledSpawn {
+	return &CancelScheduledSpawn{scheduler: sched}
+}
+
+func (t *CancelScheduledSpawn) Name() string {
+	return "cancel_scheduled_spawn"
+}
+
+func (t *CancelScheduledSpawn) Description() string {
+	return "Cancel a pending scheduled machine spawn by schedule ID"
+}
+
+func (t *CancelScheduledSpawn) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"schedule_id": {
+				Type:        "integer",
+				Description: "The ID of the scheduled spawn to cancel",
+			},
+		},
+		Required: []string{"schedule_id"},
+	}
+}
+
+func (t *CancelScheduledSpawn) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	scheduleID, ok := args["schedule_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("schedule_id is required")
+	}
+
+	// Other scheduled work, such as the weekly digest, isn't a spawn to cancel
+	if !t.pendingSpawn(int(scheduleID)) {
+		return nil, fmt.Errorf("failed to cancel scheduled spawn: no pending spawn with id %d", int(scheduleID))
+	}
+	if err := t.scheduler.Cancel(int(scheduleID)); err != nil {
+		return nil, fmt.Errorf("failed to cancel scheduled spawn: %w", err)
+	}
+
+	content := mcp.CreateTextContent(fmt.Sprintf("Scheduled spawn %d cancelled", int(scheduleID)))
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// pendingSpawn reports whether id names a pending scheduled spawn
+func (t *CancelScheduledSpawn) pendingSpawn(id int) bool {
+	for _, task := range t.scheduler.Pending() {
+		if task.ID == id && task.Kind == taskKindSpawn {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/scheduler"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// scheduleTools creates the scheduling tools against a stub HTB API serving
+// machine 7, returning the spawns it receives and notifications sent
+func scheduleTools(t *testing.T, sched *scheduler.Scheduler, store *notes.Store) (*ScheduleMachineSpawn, chan string, chan map[string]interface{}) {
+	t.Helper()
+
+	spawned := make(chan string, 4)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		case "/machine/play/7":
+			spawned <- r.Method
+			w.Write([]byte(`{"success":true,"message":"Machine deployed"}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	client := htb.NewClient(&config.Config{HTBToken: "header.payload.signature", HTBBaseURL: api.URL, RequestTimeout: 5 * time.Second})
+	notified := make(chan map[string]interface{}, 4)
+	notify := func(level, logger string, data interface{}) {
+		notified <- data.(map[string]interface{})
+	}
+	return NewScheduleMachineSpawn(client, newMachineResolver(client, cache.New(time.Minute)), store, sched, notify, "", nil), spawned, notified
+}
+
+func TestScheduledSpawnRuns(t *testing.T) {
+	sched := scheduler.New()
+	defer sched.Stop()
+	store := notes.NewStore()
+	tool, spawned, notified := scheduleTools(t, sched, store)
+
+	runAt := time.Now().Add(100 * time.Millisecond).Format(time.RFC3339Nano)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"machine_id": float64(7), "run_at": runAt}); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+
+	select {
+	case method := <-spawned:
+		if method != http.MethodPost {
+			t.Errorf("Expected the machine to be spawned with POST, got %s", method)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Scheduled spawn never ran")
+	}
+	select {
+	case data := <-notified:
+		if data["event"] != "scheduled_spawn_executed" || data["machine_id"] != 7 {
+			t.Errorf("Expected a spawn notification for machine 7, got %+v", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No notification for the scheduled spawn")
+	}
+	if entries := store.Entries(); len(entries) != 1 || entries[0].Kind != notes.KindSpawn || entries[0].MachineID != 7 {
+		t.Errorf("Expected the spawn in the notes, got %+v", entries)
+	}
+}
+
+func TestScheduleMachineSpawnRejectsPastTimes(t *testing.T) {
+	sched := scheduler.New()
+	defer sched.Stop()
+	tool, _, _ := scheduleTools(t, sched, notes.NewStore())
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"machine_id": float64(7), "run_at": past}); err == nil || !strings.Contains(err.Error(), "in the past") {
+		t.Errorf("Expected a past time to be refused, got %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"machine_id": float64(7)}); err == nil {
+		t.Error("Expected a spawn without run_at or delay_minutes to be refused")
+	}
+	if pending := sched.Pending(); len(pending) != 0 {
+		t.Errorf("Expected nothing scheduled, got %+v", pending)
+	}
+}
+
+func TestListAndCancelScheduledSpawns(t *testing.T) {
+	ctx := context.Background()
+	sched := scheduler.New()
+	defer sched.Stop()
+	schedule, _, _ := scheduleTools(t, sched, notes.NewStore())
+	list, cancel := NewListScheduledSpawns(sched), NewCancelScheduledSpawn(sched)
+
+	result, err := list.Execute(ctx, map[string]interface{}{})
+	if err != nil || result.Content[0].Text != "No scheduled spawns pending" {
+		t.Fatalf("Expected no spawns, got %+v, %v", result, err)
+	}
+
+	// Other scheduled work is neither listed nor cancellable as a spawn
+	digest, err := sched.Schedule(taskKindDigest, "Weekly practice digest", time.Now().Add(time.Hour), func() {})
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	for _, delay := range []float64{120, 60} {
+		if _, err := schedule.Execute(ctx, map[string]interface{}{"machine_id": float64(7), "delay_minutes": delay}); err != nil {
+			t.Fatalf("Schedule failed: %v", err)
+		}
+	}
+
+	var spawns []scheduler.Task
+	result, err = list.Execute(ctx, map[string]interface{}{})
+	if err != nil || json.Unmarshal([]byte(result.Content[0].Text), &spawns) != nil {
+		t.Fatalf("Failed to list spawns: %+v, %v", result, err)
+	}
+	if len(spawns) != 2 || spawns[0].ID != 3 || spawns[1].ID != 2 || spawns[0].Description != "Spawn machine 7" {
+		t.Fatalf("Expected both spawns soonest first, got %+v", spawns)
+	}
+
+	if _, err := cancel.Execute(ctx, map[string]interface{}{"schedule_id": float64(digest.ID)}); err == nil {
+		t.Error("Expected cancelling the digest as a spawn to be refused")
+	}
+	if _, err := cancel.Execute(ctx, map[string]interface{}{"schedule_id": float64(42)}); err == nil {
+		t.Error("Expected cancelling an unknown spawn to fail")
+	}
+	if _, err := cancel.Execute(ctx, map[string]interface{}{}); err == nil {
+		t.Error("Expected schedule_id to be required")
+	}
+
+	result, err = cancel.Execute(ctx, map[string]interface{}{"schedule_id": float64(3)})
+	if err != nil || result.Content[0].Text != "Scheduled spawn 3 cancelled" {
+		t.Fatalf("Cancel failed: %+v, %v", result, err)
+	}
+	pending := sched.Pending()
+	if len(pending) != 2 || pending[0].ID != digest.ID || pending[1].ID != 2 {
+		t.Errorf("Expected the digest and the other spawn to stay pending, got %+v", pending)
+	}
+
+	if _, err := cancel.Execute(ctx, map[string]interface{}{"schedule_id": float64(3)}); err == nil {
+		t.Error("Expected cancelling twice to fail")
+	}
+}
@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/NoASLR/htb-mcp-server/internal/session"
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
 	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
 )
 
+// searchResultLimit bounds the results per content type of an indexed search
+const searchResultLimit = 50
+
 // SearchContent tool for searching across HTB platform
 type SearchContent struct {
 	client *htb.Client
+	index  *searchIndex
 }
 
-func NewSearchContent(client *htb.Client) *SearchContent {
-	return &SearchContent{client: client}
+func NewSearchContent(client *htb.Client, index *searchIndex) *SearchContent {
+	return &SearchContent{client: client, index: index}
 }
 
 func (t *SearchContent) Name() string {
@@ -56,6 +61,11 @@ func (t *SearchContent) Execute(ctx context.Context, args map[string]interface{}
 		searchType = st
 	}
 
+	// Machine and challenge searches are answered from the local index
+	if resp, err := t.fromIndex(query, searchType, args); resp != nil || err != nil {
+		return resp, err
+	}
+
 	// Build search endpoint URL
 	endpoint := fmt.Sprintf("/search/fetch?query=%s", query)
 
@@ -91,16 +101,48 @@ func (t *SearchContent) Execute(ctx context.Context, args map[string]interface{}
 	}, nil
 }
 
+// fromIndex answers a search from the full-text index over exported
+// content, or returns nil if the index does not cover the searched types
+func (t *SearchContent) fromIndex(query, searchType string, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	kinds := []string{searchType}
+	notice := "Served from the local content index"
+	switch searchType {
+	case "users":
+		return nil, nil
+	case "all":
+		kinds = []string{catalogMachines, catalogChallenges}
+		notice += "; users are not indexed, search with type=users to include them"
+	}
+
+	index := t.index.current()
+	for _, kind := range kinds {
+		if index.Count(kind) == 0 {
+			return nil, nil
+		}
+	}
+
+	data := make(map[string]interface{})
+	for _, kind := range kinds {
+		items := make([]interface{}, 0)
+		for _, result := range index.Search(query, kind, searchResultLimit) {
+			items = append(items, result.Item)
+		}
+		data[kind] = items
+	}
+
+	return chunkedResponse(data, notice, args)
+}
+
 // GetServerStatus tool for server health and status information
 type GetServerStatus struct {
-	client    *htb.Client
-	startTime time.Time
+	client *htb.Client
+	uptime func() time.Duration
 }
 
-func NewGetServerStatus(client *htb.Client) *GetServerStatus {
+func NewGetServerStatus(client *htb.Client, uptime func() time.Duration) *GetServerStatus {
 	return &GetServerStatus{
-		client:    client,
-		startTime: time.Now(),
+		client: client,
+		uptime: uptime,
 	}
 }
 
@@ -109,7 +151,7 @@ func (t *GetServerStatus) Name() string {
 }
 
 func (t *GetServerStatus) Description() string {
-	return "Get MCP server health status and HTB API connectivity information"
+	return "Get MCP server health status, HTB API connectivity and any detected HTB API schema drift"
 }
 
 func (t *GetServerStatus) Schema() mcp.ToolSchema {
@@ -126,16 +168,22 @@ func (t *GetServerStatus) Execute(ctx context.Context, args map[string]interface
 		htbStatus = fmt.Sprintf("unhealthy: %v", err)
 	}
 
-	// Calculate uptime
-	uptime := time.Since(t.startTime)
-
 	// Build status response
 	status := htb.ServerStatus{
 		Status:       "running",
 		Version:      "1.0.0",
 		HTBAPIStatus: htbStatus,
-		Uptime:       uptime.String(),
+		Uptime:       t.uptime().String(),
 		Timestamp:    time.Now(),
+		SchemaDrift:  t.client.SchemaDrift(),
+	}
+	if sess, ok := session.FromContext(ctx); ok {
+		if client, ok := sess.Client(); ok {
+			status.Client = client
+		}
+		if lastPing, ok := sess.LastPing(); ok {
+			status.LastPing = &lastPing
+		}
 	}
 
 	// Create JSON content
@@ -148,3 +196,179 @@ func (t *GetServerStatus) Execute(ctx context.Context, args map[string]interface
 		Content: []mcp.Content{content},
 	}, nil
 }
+
+// GetRateLimitStatus tool for reporting the remaining request budget
+type GetRateLimitStatus struct {
+	client *htb.Client
+}
+
+func NewGetRateLimitStatus(client *htb.Client) *GetRateLimitStatus {
+	return &GetRateLimitStatus{client: client}
+}
+
+func (t *GetRateLimitStatus) Name() string {
+	return "get_rate_limit_status"
+}
+
+func (t *GetRateLimitStatus) Description() string {
+	return "Get the remaining tool-call budget for this session, recent HTB API rate limiting (429 responses) and projected reset times, for pacing bulk operations"
+}
+
+func (t *GetRateLimitStatus) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetRateLimitStatus) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	now := time.Now()
+
+	// The session limiter counts this call too, so remaining excludes it
+	budget := map[string]interface{}{"limited": false}
+	if sess, ok := session.FromContext(ctx); ok && sess.Limiter != nil {
+		budget = map[string]interface{}{
+			"limited":          true,
+			"limit_per_minute": sess.Limiter.Capacity(),
+			"remaining":        sess.Limiter.Remaining(),
+			"full_at":          now.Add(sess.Limiter.FullIn()),
+		}
+	}
+
+	htbStatus := t.client.RateLimitStatus()
+	throttled := htbStatus.RetryAfter != nil
+	if htbStatus.Remaining != nil && *htbStatus.Remaining == 0 && htbStatus.ResetAt != nil && htbStatus.ResetAt.After(now) {
+		throttled = true
+	}
+
+	result := map[string]interface{}{
+		"session":   budget,
+		"htb_api":   htbStatus,
+		"throttled": throttled,
+		"timestamp": now,
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetPlatformUpdates tool for reading HTB's changelog/news feed
+type GetPlatformUpdates struct {
+	client *htb.Client
+}
+
+func NewGetPlatformUpdates(client *htb.Client) *GetPlatformUpdates {
+	return &GetPlatformUpdates{client: client}
+}
+
+func (t *GetPlatformUpdates) Name() string {
+	return "get_platform_updates"
+}
+
+func (t *GetPlatformUpdates) Description() string {
+	return "Get recent HackTheBox platform changelog and news items"
+}
+
+func (t *GetPlatformUpdates) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"limit": {
+				Type:        "integer",
+				Description: "Maximum number of items to return",
+				Default:     10,
+			},
+		},
+	}
+}
+
+func (t *GetPlatformUpdates) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, "/changelogs", "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform updates: %w", err)
+	}
+
+	if items, ok := data.([]interface{}); ok && len(items) > limit {
+		data = items[:limit]
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetHTBStatus tool for checking HTB infrastructure health
+type GetHTBStatus struct {
+	client *htb.Client
+}
+
+func NewGetHTBStatus(client *htb.Client) *GetHTBStatus {
+	return &GetHTBStatus{client: client}
+}
+
+func (t *GetHTBStatus) Name() string {
+	return "get_htb_status"
+}
+
+func (t *GetHTBStatus) Description() string {
+	return "Get HackTheBox infrastructure status (labs, VPN, website) and ongoing incidents from the public status page"
+}
+
+func (t *GetHTBStatus) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetHTBStatus) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	summary, err := t.client.GetStatusPage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HTB status page: %w", err)
+	}
+
+	var degraded []htb.StatusComponent
+	for _, component := range summary.Components {
+		if component.Status != "operational" {
+			degraded = append(degraded, component)
+		}
+	}
+
+	result := map[string]interface{}{
+		"overall":             summary.Status.Description,
+		"indicator":           summary.Status.Indicator,
+		"all_operational":     len(degraded) == 0 && len(summary.Incidents) == 0,
+		"degraded_components": degraded,
+		"incidents":           summary.Incidents,
+		"components":          summary.Components,
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
@@ -0,0 +1,314 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// seasonTiers lists the season reward tiers from lowest to highest
+var seasonTiers = []string{"Bronze", "Silver", "Gold", "Platinum", "Ruby", "Holo"}
+
+// GetSeasonTierProgress tool for showing progress towards the next season reward tier
+type GetSeasonTierProgress struct {
+	client *htb.Client
+}
+
+func NewGetSeasonTierProgress(client *htb.Client) *GetSeasonTierProgress {
+	return &GetSeasonTierProgress{client: client}
+}
+
+func (t *GetSeasonTierProgress) Name() string {
+	return "get_season_tier_progress"
+}
+
+func (t *GetSeasonTierProgress) Description() string {
+	return "Show your current season tier (Bronze to Holo), flags needed for the next tier and weeks remaining in the season"
+}
+
+func (t *GetSeasonTierProgress) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"season_id": {
+				Type:        "integer",
+				Description: "Season ID (defaults to the active season)",
+			},
+		},
+	}
+}
+
+func (t *GetSeasonTierProgress) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	seasons, err := t.client.GetSeasons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons: %w", err)
+	}
+
+	season := selectSeason(seasons, args)
+	if season == nil {
+		return nil, fmt.Errorf("season not found")
+	}
+
+	rank, err := t.client.GetSeasonRank(ctx, season.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season rank: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"season_id":   season.ID,
+		"season_name": season.Name,
+		"active":      season.Active,
+	}
+
+	if end, err := htb.ParseTime(season.EndDate); err == nil {
+		result["ends_at"] = end.Format(time.RFC3339)
+		remaining := time.Until(end)
+		if remaining < 0 {
+			remaining = 0
+		}
+		result["weeks_remaining"] = math.Round(remaining.Hours()/(24*7)*10) / 10
+	}
+
+	if rank == nil {
+		result["tier"] = "Unranked"
+		result["message"] = "You have no season points yet; own a seasonal machine flag to enter the Bronze tier"
+	} else {
+		result["tier"] = rank.League
+		result["rank"] = rank.Rank
+		result["total_ranked"] = rank.TotalRanks
+		result["season_points"] = float64(rank.TotalSeasonPoints)
+
+		if next := nextSeasonTier(rank.League); next != "" {
+			result["next_tier"] = next
+			result["flags_obtained"] = rank.FlagsToNextRank.Obtained
+			result["flags_required"] = rank.FlagsToNextRank.Total
+			needed := rank.FlagsToNextRank.Total - rank.FlagsToNextRank.Obtained
+			if needed < 0 {
+				needed = 0
+			}
+			result["flags_to_next_tier"] = needed
+		} else if strings.EqualFold(rank.League, seasonTiers[len(seasonTiers)-1]) {
+			result["message"] = "You are in the highest tier"
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// selectSeason returns the season matching season_id, or the active season
+func selectSeason(seasons []htb.Season, args map[string]interface{}) *htb.Season {
+	id, hasID := args["season_id"].(float64)
+	for i := range seasons {
+		if hasID && seasons[i].ID == int(id) {
+			return &seasons[i]
+		}
+		if !hasID && seasons[i].Active {
+			return &seasons[i]
+		}
+	}
+	return nil
+}
+
+// nextSeasonTier returns the tier above league, or "" if league is the highest or unknown
+func nextSeasonTier(league string) string {
+	for i, tier := range seasonTiers {
+		if strings.EqualFold(tier, league) && i+1 < len(seasonTiers) {
+			return seasonTiers[i+1]
+		}
+	}
+	return ""
+}
+
+// seasonEndingSoon is how close to its end a season is reported as ending soon
+const seasonEndingSoon = 14 * 24 * time.Hour
+
+// Season phases reported by get_season_reset_outlook
+const (
+	phaseInSeason   = "in_season"
+	phaseEndingSoon = "ending_soon"
+	phaseOffSeason  = "off_season"
+)
+
+// seasonResets and seasonCarriesOver describe what a season reset affects
+var (
+	seasonResets = []string{
+		"Season points and season rank start from zero in the next season",
+		"The league tier (Bronze to Holo) starts over; the tier reached is kept as the ended season's reward",
+	}
+	seasonCarriesOver = []string{
+		"Global points, global rank and ownership percentage",
+		"Owns of seasonal machines, which join the main lab after the season",
+		"Rewards, badges and certificates earned in past seasons",
+	}
+)
+
+// seasonWindow is a season with its parsed start and end
+type seasonWindow struct {
+	season     htb.Season
+	start, end time.Time
+}
+
+// seasonOutlook locates now among seasons: the season running now, the
+// latest ended one and the next one to start. Seasons without parseable
+// dates are skipped.
+func seasonOutlook(seasons []htb.Season, now time.Time) (current, previous, next *seasonWindow) {
+	for _, season := range seasons {
+		start, startErr := htb.ParseTime(season.StartDate)
+		end, endErr := htb.ParseTime(season.EndDate)
+		if startErr != nil || endErr != nil {
+			continue
+		}
+		w := &seasonWindow{season: season, start: start, end: end}
+
+		switch {
+		case !now.Before(start) && now.Before(end):
+			current = w
+		case !now.Before(end):
+			if previous == nil || end.After(previous.end) {
+				previous = w
+			}
+		default:
+			if next == nil || start.Before(next.start) {
+				next = w
+			}
+		}
+	}
+	return current, previous, next
+}
+
+// inDays returns d in days, rounded to one decimal
+func inDays(d time.Duration) float64 {
+	return math.Round(d.Hours()/24*10) / 10
+}
+
+// GetSeasonResetOutlook tool for explaining upcoming season resets
+type GetSeasonResetOutlook struct {
+	client *htb.Client
+}
+
+func NewGetSeasonResetOutlook(client *htb.Client) *GetSeasonResetOutlook {
+	return &GetSeasonResetOutlook{client: client}
+}
+
+func (t *GetSeasonResetOutlook) Name() string {
+	return "get_season_reset_outlook"
+}
+
+func (t *GetSeasonResetOutlook) Description() string {
+	return "Explain the upcoming season reset: when the current season ends and the next starts, what resets and what carries over, and the flags per week needed to reach the next tier before the end, to plan an end-of-season push"
+}
+
+func (t *GetSeasonResetOutlook) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetSeasonResetOutlook) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	seasons, err := t.client.GetSeasons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons: %w", err)
+	}
+
+	now := time.Now()
+	current, previous, next := seasonOutlook(seasons, now)
+
+	result := map[string]interface{}{
+		"checked_at":         now.UTC().Format(time.RFC3339),
+		"resets":             seasonResets,
+		"carries_over":       seasonCarriesOver,
+		"rank_recalculation": "Global rank follows ownership of active content, so it shifts as machines retire and release; see get_retirement_schedule",
+	}
+
+	if next != nil {
+		result["next_season"] = map[string]interface{}{
+			"season_id":   next.season.ID,
+			"season_name": next.season.Name,
+			"starts_at":   next.start.UTC().Format(time.RFC3339),
+			"days_until":  inDays(next.start.Sub(now)),
+		}
+	}
+
+	if current == nil {
+		result["phase"] = phaseOffSeason
+		if previous != nil {
+			result["previous_season"] = map[string]interface{}{
+				"season_id":   previous.season.ID,
+				"season_name": previous.season.Name,
+				"ended_at":    previous.end.UTC().Format(time.RFC3339),
+			}
+		}
+		result["message"] = "No season is running; season points and tiers reset when the next season starts"
+		if next == nil {
+			result["message"] = "No season is running and none has been announced yet"
+		}
+	} else {
+		remaining := current.end.Sub(now)
+		result["phase"] = phaseInSeason
+		if remaining <= seasonEndingSoon {
+			result["phase"] = phaseEndingSoon
+		}
+		result["season_id"] = current.season.ID
+		result["season_name"] = current.season.Name
+		result["ends_at"] = current.end.UTC().Format(time.RFC3339)
+		result["days_remaining"] = inDays(remaining)
+		if next != nil {
+			result["off_season_days"] = inDays(next.start.Sub(current.end))
+		}
+
+		// Standing is best effort; the reset dates are useful without it
+		rank, err := t.client.GetSeasonRank(ctx, current.season.ID)
+		switch {
+		case err != nil:
+			result["standing_unavailable"] = err.Error()
+		case rank == nil:
+			result["tier"] = "Unranked"
+			result["message"] = fmt.Sprintf("%s ends in %.1f days; own a seasonal machine flag before then to earn a tier", current.season.Name, inDays(remaining))
+		default:
+			result["tier"] = rank.League
+			result["rank"] = rank.Rank
+			result["season_points"] = float64(rank.TotalSeasonPoints)
+			result["message"] = fmt.Sprintf("%s ends in %.1f days; your %s tier is kept as this season's reward", current.season.Name, inDays(remaining), rank.League)
+
+			if nextTier := nextSeasonTier(rank.League); nextTier != "" {
+				needed := rank.FlagsToNextRank.Total - rank.FlagsToNextRank.Obtained
+				if needed < 0 {
+					needed = 0
+				}
+				result["next_tier"] = nextTier
+				result["flags_to_next_tier"] = needed
+
+				weeks := remaining.Hours() / (24 * 7)
+				if needed > 0 && weeks > 0 {
+					perWeek := math.Round(float64(needed)/math.Max(weeks, 1)*10) / 10
+					result["flags_per_week_needed"] = perWeek
+					result["message"] = fmt.Sprintf("%s ends in %.1f days; %d more flags reach %s before the reset (about %.1f per week)", current.season.Name, inDays(remaining), needed, nextTier, perWeek)
+				}
+			}
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
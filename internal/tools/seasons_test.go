@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+func TestSeasonOutlook(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(days int) string { return now.Add(time.Duration(days) * 24 * time.Hour).Format(time.RFC3339) }
+	seasons := []htb.Season{
+		{ID: 5, Name: "Season 5", StartDate: at(-400), EndDate: at(-300)},
+		{ID: 6, Name: "Season 6", StartDate: at(-200), EndDate: at(-100)},
+		{ID: 7, Name: "Season 7", Active: true, StartDate: at(-80), EndDate: at(10)},
+		{ID: 8, Name: "Season 8", StartDate: at(17), EndDate: at(110)},
+		{ID: 9, Name: "Season 9", StartDate: at(120), EndDate: at(200)},
+		{ID: 10, Name: "Undated"},
+	}
+
+	current, previous, next := seasonOutlook(seasons, now)
+	if current == nil || current.season.ID != 7 {
+		t.Fatalf("Expected Season 7 to be running, got %+v", current)
+	}
+	if previous == nil || previous.season.ID != 6 {
+		t.Errorf("Expected Season 6 as the latest ended season, got %+v", previous)
+	}
+	if next == nil || next.season.ID != 8 {
+		t.Fatalf("Expected Season 8 as the next season, got %+v", next)
+	}
+	if days := inDays(next.start.Sub(current.end)); days != 7 {
+		t.Errorf("Expected a week off-season, got %v days", days)
+	}
+
+	// Between seasons nothing is running
+	current, previous, next = seasonOutlook(seasons, now.Add(12*24*time.Hour))
+	if current != nil || previous == nil || previous.season.ID != 7 || next == nil || next.season.ID != 8 {
+		t.Errorf("Expected an off-season between Season 7 and 8, got %+v, %+v, %+v", current, previous, next)
+	}
+}
+
+func TestNextSeasonTier(t *testing.T) {
+	tests := map[string]string{
+		"Silver":  "Gold",
+		"silver":  "Gold",
+		"Holo":    "",
+		"Unknown": "",
+	}
+	for league, want := range tests {
+		if got := nextSeasonTier(league); got != want {
+			t.Errorf("nextSeasonTier(%q) = %q, want %q", league, got, want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/internal/extensions"
+	"github.com/NoASLR/htb-mcp-server/internal/notes"
+	"github.com/NoASLR/htb-mcp-server/internal/poller"
+	"github.com/NoASLR/htb-mcp-server/internal/resources"
+	"github.com/NoASLR/htb-mcp-server/internal/scheduler"
+	"github.com/NoASLR/htb-mcp-server/internal/store"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Shared holds the state and background work of one HTB account, shared by
+// every registry serving it, such as those of the HTTP transport's
+// sessions. Background notifications go to every attached registry.
+type Shared struct {
+	config    *config.Config
+	htbClient *htb.Client
+	cache     cache.Cache
+	notes     *notes.Store
+	state     *store.Store
+	scheduler *scheduler.Scheduler
+	poller    *poller.Poller
+
+	// Queued spawns watched by the poller
+	spawns       *poller.SpawnWatcher
+	spawnHandler sync.Once
+
+	// Flags queued during outages, retried by the poller
+	flags        *flagQueue
+	flagsHandler sync.Once
+
+	// Tools of the configured extensions, described once for every registry,
+	// and the extensions that failed to describe themselves
+	extensionsOnce sync.Once
+	extTools       []*extensions.Tool
+	extErrs        []error
+
+	// Registries receiving background notifications, and the context
+	// background work runs under once started
+	mu         sync.RWMutex
+	registries map[*Registry]struct{}
+	ctx        context.Context
+	start      sync.Once
+}
+
+// NewShared creates the state and background workers of the account served
+// by htbClient, caching in c. Nothing runs until Start is called.
+func NewShared(cfg *config.Config, htbClient *htb.Client, c cache.Cache) *Shared {
+	s := &Shared{
+		config:     cfg,
+		htbClient:  htbClient,
+		cache:      c,
+		notes:      notes.NewStore(),
+		state:      store.Open(statePath(cfg.StateDir)),
+		scheduler:  scheduler.New(),
+		poller:     poller.New(htbClient, cfg.PollInterval),
+		registries: make(map[*Registry]struct{}),
+	}
+	s.spawns = poller.NewSpawnWatcher(s.notifyMachine)
+	s.flags = newFlagQueue(htbClient, s.state, s.notify, s.watchFlags)
+	s.registerPollerHandlers()
+	return s
+}
+
+// Cache returns the cache shared by every registry
+func (s *Shared) Cache() cache.Cache {
+	return s.cache
+}
+
+// Notes returns the notes store of work not tied to a session, which
+// session stores are layered on
+func (s *Shared) Notes() *notes.Store {
+	return s.notes
+}
+
+// extensionTools runs each configured extension with --describe on first
+// use and returns the tools they provide, along with the errors of those
+// that failed, so sessions share one tool list
+func (s *Shared) extensionTools() ([]*extensions.Tool, []error) {
+	s.extensionsOnce.Do(func() {
+		for _, path := range s.config.Extensions {
+			tools, err := extensions.Discover(context.Background(), path, s.config.ExtensionTimeout)
+			if err != nil {
+				s.extErrs = append(s.extErrs, err)
+				continue
+			}
+			s.extTools = append(s.extTools, tools...)
+		}
+	})
+	return s.extTools, s.extErrs
+}
+
+// registerPollerHandlers registers the opt-in active machine poller handlers
+func (s *Shared) registerPollerHandlers() {
+	if s.config.KeepaliveEnabled {
+		keepalive := poller.NewKeepalive(s.htbClient, s.notes, s.config.KeepaliveThreshold)
+		s.poller.AddHandler(keepalive.Handle)
+	}
+
+	if len(s.config.ExpiryWarnings) > 0 {
+		warner := poller.NewExpiryWarner(s.config.ExpiryWarnings, s.notifyMachine)
+		s.poller.AddHandler(warner.Handle)
+	}
+
+	if s.config.IPChangeWatch {
+		watcher := poller.NewIPWatcher(s.notifyMachine, func() {
+			s.resourceUpdated(resources.ActiveMachineURI)
+		})
+		s.poller.AddHandler(watcher.Handle)
+	}
+}
+
+// Start starts the background work under ctx. Only the first call has any
+// effect, so every registry may call it.
+func (s *Shared) Start(ctx context.Context) {
+	s.start.Do(func() {
+		s.mu.Lock()
+		s.ctx = ctx
+		s.mu.Unlock()
+
+		if s.poller.HasHandlers() {
+			s.poller.Start(ctx)
+		}
+
+		if s.config.DigestEnabled {
+			s.scheduleDigest()
+		}
+
+		// Resume automatic retries of flags queued before a restart
+		if s.flags.hasAutoRetry() {
+			s.watchFlags()
+		}
+	})
+}
+
+// Close stops the background work and closes the cache
+func (s *Shared) Close() {
+	s.scheduler.Stop()
+	s.poller.Stop()
+	if closer, ok := s.cache.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// attach sends background notifications to r until it is detached
+func (s *Shared) attach(r *Registry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registries[r] = struct{}{}
+}
+
+// detach stops sending background notifications to r
+func (s *Shared) detach(r *Registry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.registries, r)
+}
+
+// attached returns a snapshot of the attached registries
+func (s *Shared) attached() []*Registry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	registries := make([]*Registry, 0, len(s.registries))
+	for r := range s.registries {
+		registries = append(registries, r)
+	}
+	return registries
+}
+
+// notify sends a log message notification to the client of every attached
+// registry whose log level allows it
+func (s *Shared) notify(level, logger string, data interface{}) {
+	for _, r := range s.attached() {
+		r.notify(level, logger, data)
+	}
+}
+
+// notifyMachine sends a notification about a machine to the clients that
+// spawned it, or to every client if none of them did
+func (s *Shared) notifyMachine(machineID int, level, logger string, data interface{}) {
+	registries := s.attached()
+	var working []*Registry
+	for _, r := range registries {
+		if r.workingOn(machineID) {
+			working = append(working, r)
+		}
+	}
+	if len(working) > 0 {
+		registries = working
+	}
+
+	for _, r := range registries {
+		r.notify(level, logger, data)
+	}
+}
+
+// errorf writes an error to the process log and sends it to every client
+func (s *Shared) errorf(logger, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	s.notify(mcp.LogLevelError, logger, msg)
+}
+
+// resourceUpdated tells every subscribed client a resource changed
+func (s *Shared) resourceUpdated(uri string) {
+	for _, r := range s.attached() {
+		r.resourceUpdated(uri)
+	}
+}
+
+// backgroundContext returns the context background work runs under
+func (s *Shared) backgroundContext() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// scheduleDigest schedules the next weekly digest, which reschedules itself after running
+func (s *Shared) scheduleDigest() {
+	runAt := scheduler.NextWeekly(time.Now(), digestWeekday, digestHour)
+	if _, err := s.scheduler.Schedule(taskKindDigest, "Weekly practice digest", runAt, s.runDigest); err != nil {
+		s.errorf("digest", "Failed to schedule weekly digest: %v", err)
+	}
+}
+
+// runDigest compiles the weekly digest and delivers it as a notification and,
+// if configured, to the digest webhook
+func (s *Shared) runDigest() {
+	defer s.scheduleDigest()
+
+	ctx, cancel := context.WithTimeout(context.Background(), digestTimeout)
+	defer cancel()
+
+	digest, err := compileDigest(ctx, s.htbClient, s.config.DigestInterests, time.Now())
+	if err != nil {
+		s.errorf("digest", "Weekly digest failed: %v", err)
+		return
+	}
+
+	s.notify(mcp.LogLevelInfo, "digest", map[string]interface{}{
+		"event":  "weekly_digest",
+		"digest": digest,
+	})
+
+	if s.config.DigestWebhookURL != "" {
+		if err := postWebhook(ctx, s.config.DigestWebhookURL, digest, s.config.RequestTimeout); err != nil {
+			s.errorf("digest", "Failed to deliver weekly digest webhook: %v", err)
+		}
+	}
+}
+
+// watchSpawn watches a queued spawn, starting the poller if needed so the
+// clients are notified once the machine becomes available
+func (s *Shared) watchSpawn(machineID int) {
+	s.spawnHandler.Do(func() {
+		s.poller.AddHandler(s.spawns.Handle)
+	})
+	s.spawns.Watch(machineID)
+	s.poller.Start(s.backgroundContext())
+}
+
+// watchFlags retries auto-retry queued flags after every successful poll,
+// starting the poller if needed
+func (s *Shared) watchFlags() {
+	s.flagsHandler.Do(func() {
+		s.poller.AddHandler(s.flags.Handle)
+	})
+	s.poller.Start(s.backgroundContext())
+}
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/cache"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// subscriptionLevel is an HTB Labs subscription, lowest first
+type subscriptionLevel int
+
+const (
+	subscriptionFree subscriptionLevel = iota
+	subscriptionVIP
+	subscriptionVIPPlus
+)
+
+func (l subscriptionLevel) String() string {
+	switch l {
+	case subscriptionVIP:
+		return "VIP"
+	case subscriptionVIPPlus:
+		return "VIP+"
+	default:
+		return "Free"
+	}
+}
+
+// subscriptionOf returns the subscription level of an account
+func subscriptionOf(user *htb.User) subscriptionLevel {
+	switch {
+	case user.IsDedicatedVIP:
+		return subscriptionVIPPlus
+	case user.CanAccessVIP:
+		return subscriptionVIP
+	default:
+		return subscriptionFree
+	}
+}
+
+// subscriptionTTL is how long a detected subscription level is trusted
+const subscriptionTTL = 30 * time.Minute
+
+// subscriptionKey is the cache key of the detected subscription level
+const subscriptionKey = "subscription"
+
+// toolRequirement is the subscription level a tool needs
+type toolRequirement struct {
+	level subscriptionLevel
+
+	// feature names what needs the subscription, for error messages
+	feature string
+
+	// applies reports whether a call needs the subscription; nil means
+	// every call does
+	applies func(args map[string]interface{}) bool
+
+	// retired is the content type, contentMachine or contentChallenge, whose
+	// retired entries need the subscription; calls for active content are
+	// allowed. Empty means the content doesn't matter.
+	retired string
+}
+
+// Content types checked for retirement by toolRequirement.retired
+const (
+	contentMachine   = "machine"
+	contentChallenge = "challenge"
+)
+
+// toolRequirements maps tools to the subscriptions they need, highest first.
+// Tools not listed work on any account; limits that can't be checked up
+// front, such as Pwnbox hours beyond the free quota, are explained when HTB
+// answers with a 403.
+var toolRequirements = map[string][]toolRequirement{
+	"get_challenge_writeup": {
+		{level: subscriptionVIP, feature: "official challenge writeups"},
+	},
+	"spawn_machine_instance": {
+		{
+			level:   subscriptionVIPPlus,
+			feature: "dedicated instances",
+			applies: func(args map[string]interface{}) bool {
+				return args["instance"] == instanceDedicated
+			},
+		},
+		{level: subscriptionVIP, feature: "retired machines", retired: contentMachine},
+	},
+	"start_machine": {
+		{level: subscriptionVIP, feature: "retired machines", retired: contentMachine},
+	},
+	"schedule_machine_spawn": {
+		{level: subscriptionVIP, feature: "retired machines", retired: contentMachine},
+	},
+	"start_challenge": {
+		{level: subscriptionVIP, feature: "retired challenges", retired: contentChallenge},
+	},
+	"download_challenge_files": {
+		{level: subscriptionVIP, feature: "retired challenges", retired: contentChallenge},
+	},
+}
+
+// SubscriptionError is returned for tool calls the account's subscription
+// does not allow
+type SubscriptionError struct {
+	Feature  string
+	Required string
+	Current  string
+}
+
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("%s require a %s subscription, but this account is %s; upgrade the subscription or pick content available to %s accounts",
+		e.Feature, e.Required, e.Current, e.Current)
+}
+
+// subscriptionCatalog detects the account's subscription level and checks
+// tool calls against toolRequirements. The level is cached in the shared
+// cache, so instances serving the same account detect it once.
+type subscriptionCatalog struct {
+	client     *htb.Client
+	shared     cache.Cache
+	challenges *challengeResolver
+
+	// onChange is called when a detected subscription changes which tools
+	// are annotated as unavailable in tools/list
+	onChange func()
+
+	// listed is the unavailableTools of the level this catalog last saw
+	mu     sync.Mutex
+	listed string
+}
+
+func newSubscriptionCatalog(client *htb.Client, shared cache.Cache, challenges *challengeResolver, onChange func()) *subscriptionCatalog {
+	return &subscriptionCatalog{client: client, shared: shared, challenges: challenges, onChange: onChange}
+}
+
+// current returns the account's subscription level, detecting it from the
+// user info if unknown or stale
+func (c *subscriptionCatalog) current(ctx context.Context) (subscriptionLevel, error) {
+	level, ok := c.known()
+	if ok {
+		htb.TraceFrom(ctx).CacheHit()
+	} else {
+		user, err := c.client.GetUserInfo(ctx)
+		if err != nil {
+			return subscriptionFree, fmt.Errorf("failed to detect subscription: %w", err)
+		}
+		level = subscriptionOf(user)
+		c.shared.SetTTL(subscriptionKey, level, subscriptionTTL)
+	}
+
+	c.announce(level)
+	return level, nil
+}
+
+// announce calls onChange if level changes which tools this catalog last
+// found unavailable
+func (c *subscriptionCatalog) announce(level subscriptionLevel) {
+	c.mu.Lock()
+	listed := unavailableTools(level)
+	changed := listed != c.listed
+	c.listed = listed
+	c.mu.Unlock()
+
+	if changed && c.onChange != nil {
+		c.onChange()
+	}
+}
+
+// known returns the detected subscription level without calling the API
+func (c *subscriptionCatalog) known() (subscriptionLevel, bool) {
+	cached, ok := c.shared.Get(subscriptionKey)
+	if !ok {
+		return subscriptionFree, false
+	}
+	var level subscriptionLevel
+	if err := cache.Decode(cached, &level); err != nil {
+		return subscriptionFree, false
+	}
+	return level, true
+}
+
+// check returns a SubscriptionError if a tool call needs a higher
+// subscription. Calls are allowed when the subscription or the content can't
+// be looked up, leaving HTB to decide.
+func (c *subscriptionCatalog) check(ctx context.Context, tool string, args map[string]interface{}) error {
+	for _, req := range toolRequirements[tool] {
+		if req.applies != nil && !req.applies(args) {
+			continue
+		}
+
+		level, err := c.current(ctx)
+		if err != nil || level >= req.level {
+			continue
+		}
+
+		// Only look the content up for accounts lacking the subscription
+		if req.retired != "" && !c.isRetired(ctx, req.retired, args) {
+			continue
+		}
+		return &SubscriptionError{Feature: req.feature, Required: req.level.String(), Current: level.String()}
+	}
+	return nil
+}
+
+// isRetired reports whether the machine or challenge identified by args is
+// retired, or false if it can't be looked up
+func (c *subscriptionCatalog) isRetired(ctx context.Context, content string, args map[string]interface{}) bool {
+	switch content {
+	case contentMachine:
+		target := machineTarget(args)
+		if target == "" {
+			return false
+		}
+		profile, err := c.client.GetMachineProfile(ctx, target)
+		return err == nil && profile.Retired
+	case contentChallenge:
+		id, err := c.challenges.resolve(ctx, args)
+		if err != nil {
+			return false
+		}
+		info, err := c.client.GetChallengeInfo(ctx, id)
+		return err == nil && info.Retired
+	}
+	return false
+}
+
+// unconditional returns the requirement every call of a tool needs, if any
+func unconditional(tool string) (toolRequirement, bool) {
+	for _, req := range toolRequirements[tool] {
+		if req.applies == nil && req.retired == "" {
+			return req, true
+		}
+	}
+	return toolRequirement{}, false
+}
+
+// unavailable reports whether the account is known to lack the subscription
+// every call of a tool needs, returning the requirement
+func (c *subscriptionCatalog) unavailable(tool string) (toolRequirement, subscriptionLevel, bool) {
+	req, ok := unconditional(tool)
+	if !ok {
+		return req, subscriptionFree, false
+	}
+	level, known := c.known()
+	return req, level, known && level < req.level
+}
+
+// unavailableTools lists the tools annotated as unavailable for a level
+func unavailableTools(level subscriptionLevel) string {
+	var names []string
+	for tool := range toolRequirements {
+		if req, ok := unconditional(tool); ok && level < req.level {
+			names = append(names, tool)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// explainForbidden turns an HTB 403 into an actionable error naming the
+// account's subscription, since HTB answers requests for VIP content from
+// free accounts with a bare 403. Other errors are returned unchanged.
+func (c *subscriptionCatalog) explainForbidden(ctx context.Context, err error) error {
+	var apiErr *htb.HTBAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		return err
+	}
+
+	level, detectErr := c.current(ctx)
+	if detectErr != nil || level == subscriptionVIPPlus {
+		return err
+	}
+	return fmt.Errorf("%w (HTB refused access; this account is %s, and retired machines and challenges, writeups, Pwnbox beyond the free quota and other VIP content require a VIP or VIP+ subscription)", err, level)
+}
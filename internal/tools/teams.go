@@ -0,0 +1,340 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Team join request actions
+const (
+	joinRequestAccept = "accept"
+	joinRequestReject = "reject"
+)
+
+// boolPtr returns a pointer to b, for optional annotation hints
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// myTeamID returns the ID of the authenticated user's team
+func myTeamID(ctx context.Context, client *htb.Client) (int, error) {
+	user, err := client.GetUserInfo(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	if user.Team == nil || user.Team.ID == 0 {
+		return 0, fmt.Errorf("you are not a member of a team")
+	}
+
+	return user.Team.ID, nil
+}
+
+// teamActionResult is the response of a team management mutation
+type teamActionResult struct {
+	Message string `json:"message"`
+}
+
+// teamActionResponse wraps a raw team management API response as tool output
+func teamActionResponse(data interface{}) (*mcp.CallToolResponse, error) {
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// InviteTeamMember tool for inviting a user to the captain's team
+type InviteTeamMember struct {
+	client *htb.Client
+}
+
+func NewInviteTeamMember(client *htb.Client) *InviteTeamMember {
+	return &InviteTeamMember{client: client}
+}
+
+func (t *InviteTeamMember) Name() string {
+	return "invite_team_member"
+}
+
+func (t *InviteTeamMember) Description() string {
+	return "Invite a user to your HackTheBox team (team captains only)"
+}
+
+func (t *InviteTeamMember) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "The ID of the user to invite",
+			},
+		},
+		Required: []string{"user_id"},
+	}
+}
+
+func (t *InviteTeamMember) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(false),
+		IdempotentHint:  boolPtr(true),
+	}
+}
+
+func (t *InviteTeamMember) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	userID, ok := args["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	teamID, err := myTeamID(ctx, t.client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make API request
+	var result teamActionResult
+	err = t.client.PostJSON(ctx, fmt.Sprintf("/team/invite/%d", teamID), map[string]interface{}{
+		"user_id": int(userID),
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invite user: %w", err)
+	}
+
+	return teamActionResponse(result)
+}
+
+// RemoveTeamMember tool for removing a member from the captain's team
+type RemoveTeamMember struct {
+	client *htb.Client
+}
+
+func NewRemoveTeamMember(client *htb.Client) *RemoveTeamMember {
+	return &RemoveTeamMember{client: client}
+}
+
+func (t *RemoveTeamMember) Name() string {
+	return "remove_team_member"
+}
+
+func (t *RemoveTeamMember) Description() string {
+	return "Remove a member from your HackTheBox team (team captains only; the user must be re-invited to rejoin)"
+}
+
+func (t *RemoveTeamMember) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "The ID of the member to remove",
+			},
+		},
+		Required: []string{"user_id"},
+	}
+}
+
+func (t *RemoveTeamMember) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(true),
+	}
+}
+
+func (t *RemoveTeamMember) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	userID, ok := args["user_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	teamID, err := myTeamID(ctx, t.client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make API request
+	var result teamActionResult
+	if err := t.client.PostJSON(ctx, fmt.Sprintf("/team/kick/%d/%d", teamID, int(userID)), nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	return teamActionResponse(result)
+}
+
+// ListTeamJoinRequests tool for listing pending requests to join the captain's team
+type ListTeamJoinRequests struct {
+	client *htb.Client
+}
+
+func NewListTeamJoinRequests(client *htb.Client) *ListTeamJoinRequests {
+	return &ListTeamJoinRequests{client: client}
+}
+
+func (t *ListTeamJoinRequests) Name() string {
+	return "list_team_join_requests"
+}
+
+func (t *ListTeamJoinRequests) Description() string {
+	return "List pending requests to join your HackTheBox team (team captains only)"
+}
+
+func (t *ListTeamJoinRequests) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *ListTeamJoinRequests) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint: boolPtr(true),
+	}
+}
+
+func (t *ListTeamJoinRequests) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	teamID, err := myTeamID(ctx, t.client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make API request
+	data, err := t.client.GetWithParsing(ctx, fmt.Sprintf("/team/invitations/%d", teamID), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list join requests: %w", err)
+	}
+
+	return teamActionResponse(data)
+}
+
+// ProcessTeamJoinRequest tool for accepting or rejecting a request to join the captain's team
+type ProcessTeamJoinRequest struct {
+	client *htb.Client
+}
+
+func NewProcessTeamJoinRequest(client *htb.Client) *ProcessTeamJoinRequest {
+	return &ProcessTeamJoinRequest{client: client}
+}
+
+func (t *ProcessTeamJoinRequest) Name() string {
+	return "process_team_join_request"
+}
+
+func (t *ProcessTeamJoinRequest) Description() string {
+	return "Accept or reject a pending request to join your HackTheBox team (team captains only)"
+}
+
+func (t *ProcessTeamJoinRequest) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"request_id": {
+				Type:        "integer",
+				Description: "The ID of the join request (from list_team_join_requests)",
+			},
+			"action": {
+				Type:        "string",
+				Description: "Whether to accept or reject the request",
+				Enum:        []string{joinRequestAccept, joinRequestReject},
+			},
+		},
+		Required: []string{"request_id", "action"},
+	}
+}
+
+func (t *ProcessTeamJoinRequest) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(false),
+	}
+}
+
+func (t *ProcessTeamJoinRequest) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	requestID, ok := args["request_id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("request_id is required")
+	}
+
+	action, ok := args["action"].(string)
+	if !ok || (action != joinRequestAccept && action != joinRequestReject) {
+		return nil, fmt.Errorf("action must be %q or %q", joinRequestAccept, joinRequestReject)
+	}
+
+	// Make API request
+	var result teamActionResult
+	if err := t.client.PostJSON(ctx, fmt.Sprintf("/team/invite/%s/%d", action, int(requestID)), nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to %s join request: %w", action, err)
+	}
+
+	return teamActionResponse(result)
+}
+
+// EditTeamMotto tool for changing the captain's team motto
+type EditTeamMotto struct {
+	client *htb.Client
+}
+
+func NewEditTeamMotto(client *htb.Client) *EditTeamMotto {
+	return &EditTeamMotto{client: client}
+}
+
+func (t *EditTeamMotto) Name() string {
+	return "edit_team_motto"
+}
+
+func (t *EditTeamMotto) Description() string {
+	return "Change the motto of your HackTheBox team (team captains only)"
+}
+
+func (t *EditTeamMotto) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"motto": {
+				Type:        "string",
+				Description: "The new team motto",
+			},
+		},
+		Required: []string{"motto"},
+	}
+}
+
+func (t *EditTeamMotto) Annotations() *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(true),
+	}
+}
+
+func (t *EditTeamMotto) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	motto, ok := args["motto"].(string)
+	if !ok {
+		return nil, fmt.Errorf("motto is required")
+	}
+
+	teamID, err := myTeamID(ctx, t.client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make API request
+	var result teamActionResult
+	err = t.client.PostJSON(ctx, fmt.Sprintf("/team/edit/%d", teamID), map[string]interface{}{
+		"motto": motto,
+	}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit team motto: %w", err)
+	}
+
+	return teamActionResponse(result)
+}
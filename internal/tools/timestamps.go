@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// jsonTimestamp matches JSON string values holding a date and time, with or
+// without fractional seconds and offset, in the formats HTB returns
+var jsonTimestamp = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?"`)
+
+// timestampFilter converts timestamps in JSON tool results to loc, formatted
+// as RFC3339 with an explicit offset. HTB timestamps without an offset are UTC.
+// The JSON text is rewritten in place, keeping field order and formatting.
+func timestampFilter(loc *time.Location) OutputFilter {
+	return func(resp *mcp.CallToolResponse) *mcp.CallToolResponse {
+		if resp == nil {
+			return resp
+		}
+
+		rewrite := func(text string) string {
+			return jsonTimestamp.ReplaceAllStringFunc(text, func(quoted string) string {
+				t, err := htb.ParseTime(strings.Trim(quoted, `"`))
+				if err != nil {
+					return quoted
+				}
+				return `"` + t.In(loc).Format(time.RFC3339) + `"`
+			})
+		}
+
+		for i := range resp.Content {
+			content := &resp.Content[i]
+			if content.Type != "text" || content.MimeType != "application/json" {
+				continue
+			}
+			content.Text = rewrite(content.Text)
+		}
+		resp.RewriteStructuredContent(rewrite)
+		return resp
+	}
+}
+
+// parseScheduleTime parses a scheduled time given as RFC3339, or as a date
+// and time without an offset read in loc
+func parseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if local, localErr := time.ParseInLocation(layout, value, loc); localErr == nil {
+			return local, nil
+		}
+	}
+	return time.Time{}, err
+}
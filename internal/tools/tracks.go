@@ -0,0 +1,457 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+var trackIDProperty = mcp.Property{
+	Type:        "integer",
+	Description: "The ID of the track",
+}
+
+var trackNameProperty = mcp.Property{
+	Type:        "string",
+	Description: "The name of the track, as an alternative to track_id",
+}
+
+// resolveTrack returns the track ID from a track_id or track_name argument
+func resolveTrack(ctx context.Context, client *htb.Client, args map[string]interface{}) (int, error) {
+	if raw, exists := args["track_id"]; exists && raw != nil {
+		id, ok := numericID(raw)
+		if !ok {
+			return 0, fmt.Errorf("track_id must be a numeric track ID")
+		}
+		return id, nil
+	}
+
+	name, _ := args["track_name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("track_id or track_name is required")
+	}
+
+	tracks, err := client.GetTracks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	var partial []htb.Track
+	for _, track := range tracks {
+		if strings.EqualFold(track.Name, name) {
+			return track.ID, nil
+		}
+		if strings.Contains(strings.ToLower(track.Name), strings.ToLower(name)) {
+			partial = append(partial, track)
+		}
+	}
+
+	switch len(partial) {
+	case 0:
+		return 0, fmt.Errorf("track %q not found; use list_tracks to see the available tracks", name)
+	case 1:
+		return partial[0].ID, nil
+	default:
+		names := make([]string, len(partial))
+		for i, track := range partial {
+			names[i] = track.Name
+		}
+		return 0, fmt.Errorf("track %q is ambiguous: %s", name, strings.Join(names, ", "))
+	}
+}
+
+// loadTrack resolves and fetches a track, also marking items complete that
+// the user's recent owns show as done, so machines rooted and challenges
+// solved outside the track count towards it. Owns are best effort; if they
+// can't be fetched, the track's own completion is used.
+func loadTrack(ctx context.Context, client *htb.Client, args map[string]interface{}) (*htb.Track, error) {
+	trackID, err := resolveTrack(ctx, client, args)
+	if err != nil {
+		return nil, err
+	}
+
+	track, err := client.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	owned, err := ownedItems(ctx, client)
+	if err != nil {
+		return track, nil
+	}
+	for i, item := range track.Items {
+		if owned[trackItemKey(item.Type, item.ID)] {
+			track.Items[i].Complete = true
+		}
+	}
+	return track, nil
+}
+
+// ownedItems returns the machines the user has rooted and the challenges
+// they have solved, keyed by trackItemKey
+func ownedItems(ctx context.Context, client *htb.Client) (map[string]bool, error) {
+	user, err := client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := client.GetUserActivity(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]bool, len(activity))
+	for _, own := range activity {
+		switch {
+		case own.ObjectType == htb.TrackItemMachine && own.Type == "root":
+			owned[trackItemKey(htb.TrackItemMachine, own.ID)] = true
+		case own.ObjectType == htb.TrackItemChallenge:
+			owned[trackItemKey(htb.TrackItemChallenge, own.ID)] = true
+		}
+	}
+	return owned, nil
+}
+
+func trackItemKey(itemType string, id int) string {
+	return fmt.Sprintf("%s:%d", itemType, id)
+}
+
+// difficultyScale orders difficulties from easiest to hardest
+var difficultyScale = []htb.DifficultyLevel{
+	htb.DifficultyVeryEasy,
+	htb.DifficultyEasy,
+	htb.DifficultyMedium,
+	htb.DifficultyHard,
+	htb.DifficultyInsane,
+}
+
+// trackProgress summarizes the completion of a track's items
+type trackProgress struct {
+	Completed int     `json:"completed"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+
+	Machines   trackCount `json:"machines"`
+	Challenges trackCount `json:"challenges"`
+
+	Next *htb.TrackItem `json:"next,omitempty"`
+
+	// Remaining lists the items not completed, and RemainingByDifficulty
+	// counts them per difficulty
+	Remaining             []htb.TrackItem `json:"remaining"`
+	RemainingByDifficulty map[string]int  `json:"remaining_by_difficulty,omitempty"`
+
+	// EstimatedDifficulty is the median difficulty of the remaining items
+	EstimatedDifficulty htb.DifficultyLevel `json:"estimated_difficulty,omitempty"`
+}
+
+// trackCount counts the completed items of one type
+type trackCount struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// progressOf computes a track's progress; the next item is the first one
+// not completed, in track order
+func progressOf(track *htb.Track) trackProgress {
+	progress := trackProgress{Remaining: []htb.TrackItem{}}
+	var ranks []int
+	for i, item := range track.Items {
+		count := &progress.Challenges
+		if item.Type == htb.TrackItemMachine {
+			count = &progress.Machines
+		}
+
+		count.Total++
+		progress.Total++
+		if item.Complete {
+			count.Completed++
+			progress.Completed++
+			continue
+		}
+
+		if progress.Next == nil {
+			progress.Next = &track.Items[i]
+		}
+		progress.Remaining = append(progress.Remaining, item)
+		if item.Difficulty == "" {
+			continue
+		}
+		if progress.RemainingByDifficulty == nil {
+			progress.RemainingByDifficulty = make(map[string]int)
+		}
+		progress.RemainingByDifficulty[string(item.Difficulty)]++
+		for rank, level := range difficultyScale {
+			if level == item.Difficulty {
+				ranks = append(ranks, rank)
+			}
+		}
+	}
+
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Completed*1000/progress.Total) / 10
+	}
+	if len(ranks) > 0 {
+		sort.Ints(ranks)
+		progress.EstimatedDifficulty = difficultyScale[ranks[len(ranks)/2]]
+	}
+	return progress
+}
+
+// ListTracks tool for listing HTB tracks
+type ListTracks struct {
+	client *htb.Client
+}
+
+func NewListTracks(client *htb.Client) *ListTracks {
+	return &ListTracks{client: client}
+}
+
+func (t *ListTracks) Name() string {
+	return "list_tracks"
+}
+
+func (t *ListTracks) Description() string {
+	return "List HackTheBox Tracks, curated learning paths of machines and challenges, with optional filtering by difficulty and enrollment"
+}
+
+func (t *ListTracks) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"difficulty": {
+				Type:        "string",
+				Description: "Only list tracks of this difficulty",
+			},
+			"enrolled": {
+				Type:        "boolean",
+				Description: "Only list tracks you are enrolled in",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *ListTracks) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	tracks, err := t.client.GetTracks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	difficulty, _ := args["difficulty"].(string)
+	enrolledOnly, _ := args["enrolled"].(bool)
+
+	filtered := make([]htb.Track, 0, len(tracks))
+	for _, track := range tracks {
+		if difficulty != "" && !strings.EqualFold(string(track.Difficulty), string(htb.NormalizeDifficulty(difficulty))) {
+			continue
+		}
+		if enrolledOnly && !track.Enrolled {
+			continue
+		}
+		filtered = append(filtered, track)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"tracks": filtered,
+		"count":  len(filtered),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetTrackProgress tool for showing progress through a track
+type GetTrackProgress struct {
+	client *htb.Client
+}
+
+func NewGetTrackProgress(client *htb.Client) *GetTrackProgress {
+	return &GetTrackProgress{client: client}
+}
+
+func (t *GetTrackProgress) Name() string {
+	return "get_track_progress"
+}
+
+func (t *GetTrackProgress) Description() string {
+	return "Show your progress through a HackTheBox Track, combining the track with your owns: completed machines and challenges, percentage done, the remaining items with their estimated difficulty and the next item to tackle"
+}
+
+func (t *GetTrackProgress) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"track_id":   trackIDProperty,
+			"track_name": trackNameProperty,
+		},
+	}
+}
+
+func (t *GetTrackProgress) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	track, err := loadTrack(ctx, t.client, args)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"track_id":   track.ID,
+		"track_name": track.Name,
+		"enrolled":   track.Enrolled,
+		"progress":   progressOf(track),
+	}
+	if !track.Enrolled {
+		result["message"] = "You are not enrolled in this track; use enroll_in_track to follow it"
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ListTrackItems tool for listing the machines and challenges of a track
+type ListTrackItems struct {
+	client *htb.Client
+}
+
+func NewListTrackItems(client *htb.Client) *ListTrackItems {
+	return &ListTrackItems{client: client}
+}
+
+func (t *ListTrackItems) Name() string {
+	return "list_track_items"
+}
+
+func (t *ListTrackItems) Description() string {
+	return "List the machines and challenges belonging to a HackTheBox Track, in track order, with your completion of each"
+}
+
+func (t *ListTrackItems) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"track_id":   trackIDProperty,
+			"track_name": trackNameProperty,
+			"type": {
+				Type:        "string",
+				Description: "Only list machines or challenges",
+				Enum:        []string{htb.TrackItemMachine, htb.TrackItemChallenge},
+			},
+			"incomplete": {
+				Type:        "boolean",
+				Description: "Only list items you have not completed",
+				Default:     false,
+			},
+		},
+	}
+}
+
+func (t *ListTrackItems) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	itemType, _ := args["type"].(string)
+	if itemType != "" && itemType != htb.TrackItemMachine && itemType != htb.TrackItemChallenge {
+		return nil, fmt.Errorf("type must be machine or challenge, got %q", itemType)
+	}
+	incomplete, _ := args["incomplete"].(bool)
+
+	track, err := loadTrack(ctx, t.client, args)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]htb.TrackItem, 0, len(track.Items))
+	for _, item := range track.Items {
+		if (itemType != "" && item.Type != itemType) || (incomplete && item.Complete) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"track_id":   track.ID,
+		"track_name": track.Name,
+		"items":      items,
+		"count":      len(items),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// EnrollInTrack tool for enrolling in a track
+type EnrollInTrack struct {
+	client *htb.Client
+}
+
+func NewEnrollInTrack(client *htb.Client) *EnrollInTrack {
+	return &EnrollInTrack{client: client}
+}
+
+func (t *EnrollInTrack) Name() string {
+	return "enroll_in_track"
+}
+
+func (t *EnrollInTrack) Description() string {
+	return "Enroll in a HackTheBox Track and report how much of it your existing owns already cover"
+}
+
+func (t *EnrollInTrack) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"track_id":   trackIDProperty,
+			"track_name": trackNameProperty,
+		},
+	}
+}
+
+func (t *EnrollInTrack) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	track, err := loadTrack(ctx, t.client, args)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Already enrolled in %s", track.Name)
+	if !track.Enrolled {
+		if message, err = t.client.EnrollTrack(ctx, track.ID); err != nil {
+			return nil, fmt.Errorf("failed to enroll in track: %w", err)
+		}
+		if message == "" {
+			message = fmt.Sprintf("Enrolled in %s", track.Name)
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"track_id":   track.ID,
+		"track_name": track.Name,
+		"message":    message,
+		"progress":   progressOf(track),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
@@ -2,7 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/NoASLR/htb-mcp-server/pkg/htb"
 	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
@@ -32,12 +36,32 @@ func (t *GetUserProfile) Schema() mcp.ToolSchema {
 	}
 }
 
+func (t *GetUserProfile) OutputSchema() mcp.ToolSchema {
+	return mcp.SchemaFor(htb.User{})
+}
+
 func (t *GetUserProfile) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
 	// Make API request to get user info
-	data, err := t.client.GetWithParsing(ctx, "/user/info", "info")
-	if err != nil {
+	var response struct {
+		Info json.RawMessage `json:"info"`
+	}
+	if err := t.client.GetJSON(ctx, "/user/info", &response); err != nil {
 		return nil, fmt.Errorf("failed to get user profile: %w", err)
 	}
+	if len(response.Info) == 0 || string(response.Info) == "null" {
+		return nil, fmt.Errorf("failed to get user profile: no info in the response")
+	}
+
+	// The text keeps every field HTB returns; structured content is the
+	// typed profile described by the output schema
+	var data interface{}
+	var user htb.User
+	if err := json.Unmarshal(response.Info, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode user profile: %w", err)
+	}
+	if err := json.Unmarshal(response.Info, &user); err != nil {
+		return nil, fmt.Errorf("failed to decode user profile: %w", err)
+	}
 
 	// Create JSON content
 	content, err := mcp.CreateJSONContent(data)
@@ -46,7 +70,8 @@ func (t *GetUserProfile) Execute(ctx context.Context, args map[string]interface{
 	}
 
 	return &mcp.CallToolResponse{
-		Content: []mcp.Content{content},
+		Content:           []mcp.Content{content},
+		StructuredContent: &user,
 	}, nil
 }
 
@@ -92,32 +117,365 @@ func (t *GetUserProgress) Execute(ctx context.Context, args map[string]interface
 		progressType = pt
 	}
 
-	var endpoint string
-	var dataField string
+	limit := 50
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
 
+	var data interface{}
 	switch progressType {
 	case "machines":
-		// Get user's machine progress
-		endpoint = "/user/info"
-		dataField = "info"
+		// Get user's machine progress by operating system
+		progress, err := t.client.GetMachineProgress(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get machine progress: %w", err)
+		}
+		data = map[string]interface{}{
+			"owned":      progress.MachineOwns.Solved,
+			"total":      progress.MachineOwns.Total,
+			"percentage": progress.MachineOwns.Percentage,
+			"by_os":      progress.OperatingSystems,
+		}
 	case "challenges":
-		// Get user's challenge progress
-		endpoint = "/user/info"
-		dataField = "info"
+		// Get user's challenge progress by category
+		progress, err := t.client.GetChallengeProgress(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get challenge progress: %w", err)
+		}
+		data = map[string]interface{}{
+			"solved":      progress.ChallengeOwns.Solved,
+			"total":       progress.ChallengeOwns.Total,
+			"percentage":  progress.ChallengeOwns.Percentage,
+			"by_category": progress.ChallengeCategories,
+		}
 	default:
-		// Get general user info
-		endpoint = "/user/info"
-		dataField = "info"
+		// Get profile summary and recent activity
+		profile, err := t.client.GetUserProfile(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user profile: %w", err)
+		}
+		activity, err := t.client.GetUserActivity(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user activity: %w", err)
+		}
+		if len(activity) > limit {
+			activity = activity[:limit]
+		}
+		data = map[string]interface{}{
+			"username":        profile.Name,
+			"rank":            profile.Rank,
+			"ranking":         profile.Ranking,
+			"points":          profile.Points,
+			"user_owns":       profile.UserOwns,
+			"system_owns":     profile.SystemOwns,
+			"user_bloods":     profile.UserBloods,
+			"system_bloods":   profile.SystemBloods,
+			"recent_activity": activity,
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// GetOwnershipPercentage tool for reporting rank ownership progress
+type GetOwnershipPercentage struct {
+	client *htb.Client
+}
+
+func NewGetOwnershipPercentage(client *htb.Client) *GetOwnershipPercentage {
+	return &GetOwnershipPercentage{client: client}
+}
+
+func (t *GetOwnershipPercentage) Name() string {
+	return "get_ownership_percentage"
+}
+
+func (t *GetOwnershipPercentage) Description() string {
+	return "Get the user's current ownership percentage, progress toward the next rank, and the points each recent own contributed"
+}
+
+func (t *GetOwnershipPercentage) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"limit": {
+				Type:        "integer",
+				Description: "Number of recent owns to include",
+				Default:     10,
+			},
+		},
+	}
+}
+
+func (t *GetOwnershipPercentage) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	user, err := t.client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+
+	profile, err := t.client.GetUserProfile(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	recent, err := t.client.GetUserActivity(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	if len(recent) > limit {
+		recent = recent[:limit]
+	}
+
+	owns := make([]map[string]interface{}, 0, len(recent))
+	for _, item := range recent {
+		owns = append(owns, map[string]interface{}{
+			"date":        item.Date,
+			"object_type": item.ObjectType,
+			"own_type":    item.Type,
+			"id":          item.ID,
+			"name":        item.Name,
+			"points":      item.Points,
+			"first_blood": item.FirstBlood,
+		})
+	}
+
+	result := map[string]interface{}{
+		"rank":                  profile.Rank,
+		"next_rank":             profile.NextRank,
+		"ownership_percentage":  profile.RankOwnership,
+		"rank_requirement":      profile.RankRequirement,
+		"current_rank_progress": profile.CurrentRankProgress,
+		"points":                profile.Points,
+		"recent_owns":           owns,
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// ListCreatorContent tool for listing content released by a given creator
+type ListCreatorContent struct {
+	client *htb.Client
+}
+
+func NewListCreatorContent(client *htb.Client) *ListCreatorContent {
+	return &ListCreatorContent{client: client}
+}
+
+func (t *ListCreatorContent) Name() string {
+	return "list_creator_content"
+}
+
+func (t *ListCreatorContent) Description() string {
+	return "List all machines and challenges released by a creator, identified by user ID or username, with difficulty and ratings"
+}
+
+func (t *ListCreatorContent) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "The creator's HTB user ID",
+			},
+			"username": {
+				Type:        "string",
+				Description: "The creator's username (used when user_id is not provided)",
+			},
+		},
+	}
+}
+
+func (t *ListCreatorContent) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var userID int
+	if id, ok := args["user_id"].(float64); ok {
+		userID = int(id)
+	} else if username, ok := args["username"].(string); ok && username != "" {
+		resolved, err := t.resolveUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		userID = resolved
+	} else {
+		return nil, fmt.Errorf("user_id or username is required")
 	}
 
 	// Make API request
-	data, err := t.client.GetWithParsing(ctx, endpoint, dataField)
+	data, err := t.client.GetWithParsing(ctx, fmt.Sprintf("/user/profile/content/%d", userID), "profile")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get creator content: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"user_id": userID,
+	}
+	if profile, ok := data.(map[string]interface{}); ok {
+		if contentMap, ok := profile["content"].(map[string]interface{}); ok {
+			result["machines"] = contentMap["machines"]
+			result["challenges"] = contentMap["challenges"]
+		}
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// resolveUsername finds the user ID for an exact (case-insensitive) username match
+func (t *ListCreatorContent) resolveUsername(ctx context.Context, username string) (int, error) {
+	var results htb.SearchResult
+	if err := t.client.GetJSON(ctx, "/search/fetch?query="+url.QueryEscape(username), &results); err != nil {
+		return 0, fmt.Errorf("failed to search for user: %w", err)
+	}
+
+	var candidates []string
+	for _, user := range results.Users {
+		if strings.EqualFold(user.Value, username) {
+			return user.ID, nil
+		}
+		candidates = append(candidates, user.Value)
+	}
+
+	if len(candidates) > 0 {
+		return 0, fmt.Errorf("no user named %q found; did you mean: %s", username, strings.Join(candidates, ", "))
+	}
+	return 0, fmt.Errorf("no user named %q found", username)
+}
+
+// rankGraphPeriods maps supported rank history periods to their length
+var rankGraphPeriods = map[string]time.Duration{
+	"1W": 7 * 24 * time.Hour,
+	"1M": 30 * 24 * time.Hour,
+	"3M": 90 * 24 * time.Hour,
+	"6M": 180 * 24 * time.Hour,
+	"1Y": 365 * 24 * time.Hour,
+}
+
+// rankHistoryPoint is a single sample of a user's points and rank
+type rankHistoryPoint struct {
+	Date   string  `json:"date"`
+	Points float64 `json:"points"`
+	Rank   float64 `json:"rank,omitempty"`
+}
+
+// GetRankHistory tool for getting a user's points and rank over time
+type GetRankHistory struct {
+	client *htb.Client
+}
+
+func NewGetRankHistory(client *htb.Client) *GetRankHistory {
+	return &GetRankHistory{client: client}
+}
+
+func (t *GetRankHistory) Name() string {
+	return "get_rank_history"
+}
+
+func (t *GetRankHistory) Description() string {
+	return "Get a user's points and global rank over time as a time series suitable for plotting or trend summaries"
+}
+
+func (t *GetRankHistory) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "User ID (defaults to the authenticated user)",
+			},
+			"period": {
+				Type:        "string",
+				Description: "Time period to cover",
+				Enum:        []string{"1W", "1M", "3M", "6M", "1Y"},
+				Default:     "1M",
+			},
+		},
+	}
+}
+
+func (t *GetRankHistory) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	period := "1M"
+	if p, ok := args["period"].(string); ok && p != "" {
+		period = strings.ToUpper(p)
+	}
+	length, ok := rankGraphPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("unsupported period %q: use 1W, 1M, 3M, 6M or 1Y", period)
+	}
+
+	var userID int
+	if id, ok := args["user_id"].(float64); ok {
+		userID = int(id)
+	} else {
+		user, err := t.client.GetUserInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user info: %w", err)
+		}
+		userID = user.ID
+	}
+
+	graph, err := t.client.GetRankGraph(ctx, userID, period)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user progress: %w", err)
+		return nil, fmt.Errorf("failed to get rank history: %w", err)
+	}
+
+	series := rankHistorySeries(graph, length, time.Now().UTC())
+	result := map[string]interface{}{
+		"user_id": userID,
+		"period":  period,
+		"series":  series,
+	}
+
+	if len(series) > 0 {
+		first, last := series[0], series[len(series)-1]
+		trend := map[string]interface{}{
+			"points_start":  first.Points,
+			"points_end":    last.Points,
+			"points_change": last.Points - first.Points,
+		}
+		if first.Rank > 0 && last.Rank > 0 {
+			trend["rank_start"] = first.Rank
+			trend["rank_end"] = last.Rank
+			// Positive means the user climbed (a lower rank number is better)
+			trend["rank_change"] = first.Rank - last.Rank
+		}
+		result["trend"] = trend
 	}
 
 	// Create JSON content
-	content, err := mcp.CreateJSONContent(data)
+	content, err := mcp.CreateJSONContent(result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create JSON content: %w", err)
 	}
@@ -126,3 +484,91 @@ func (t *GetUserProgress) Execute(ctx context.Context, args map[string]interface
 		Content: []mcp.Content{content},
 	}, nil
 }
+
+// rankHistorySeries zips a rank graph into dated samples. When the API omits
+// labels, samples are assumed to be evenly spaced over the period ending now.
+func rankHistorySeries(graph *htb.RankGraph, length time.Duration, now time.Time) []rankHistoryPoint {
+	n := len(graph.Points)
+	series := make([]rankHistoryPoint, 0, n)
+	for i, points := range graph.Points {
+		point := rankHistoryPoint{Points: float64(points)}
+		if i < len(graph.Rank) {
+			point.Rank = float64(graph.Rank[i])
+		}
+
+		if len(graph.Labels) == n {
+			point.Date = graph.Labels[i]
+		} else if n > 1 {
+			offset := length * time.Duration(n-1-i) / time.Duration(n-1)
+			point.Date = now.Add(-offset).Format("2006-01-02")
+		} else {
+			point.Date = now.Format("2006-01-02")
+		}
+
+		series = append(series, point)
+	}
+	return series
+}
+
+// GetUserAvatar tool for fetching a user's avatar
+type GetUserAvatar struct {
+	client *htb.Client
+}
+
+func NewGetUserAvatar(client *htb.Client) *GetUserAvatar {
+	return &GetUserAvatar{client: client}
+}
+
+func (t *GetUserAvatar) Name() string {
+	return "get_user_avatar"
+}
+
+func (t *GetUserAvatar) Description() string {
+	return "Fetch a HackTheBox user's avatar as image content so rich clients can render it"
+}
+
+func (t *GetUserAvatar) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"user_id": {
+				Type:        "integer",
+				Description: "User ID (defaults to the authenticated user)",
+			},
+		},
+	}
+}
+
+func (t *GetUserAvatar) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var userID int
+	if id, ok := args["user_id"].(float64); ok {
+		userID = int(id)
+	} else {
+		user, err := t.client.GetUserInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user info: %w", err)
+		}
+		userID = user.ID
+	}
+
+	profile, err := t.client.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	if profile.Avatar == "" {
+		return nil, fmt.Errorf("user %s has no avatar", profile.Name)
+	}
+
+	avatarURL := htb.AssetURL(profile.Avatar)
+	image, err := downloadImage(ctx, t.client, avatarURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download user avatar: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{
+			mcp.CreateTextContent(fmt.Sprintf("Avatar of %s (%s): %s", profile.Name, profile.Rank, avatarURL)),
+			image,
+		},
+	}, nil
+}
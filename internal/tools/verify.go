@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+)
+
+// Owns confirmed by ownVerifier
+const (
+	ownUser  = "user"
+	ownRoot  = "root"
+	ownSolve = "solve"
+)
+
+// ownVerifyAttempts and ownVerifyDelay bound how long verification waits for
+// HTB to reflect an accepted flag, which can lag behind the submission
+const (
+	ownVerifyAttempts = 3
+	ownVerifyDelay    = time.Second
+)
+
+// ownVerification reports whether an accepted flag registered as an own
+type ownVerification struct {
+	Own       string `json:"own"`
+	Confirmed bool   `json:"confirmed"`
+
+	UserOwned *bool `json:"user_owned,omitempty"`
+	RootOwned *bool `json:"root_owned,omitempty"`
+	Solved    *bool `json:"solved,omitempty"`
+
+	PointsBefore *int `json:"points_before,omitempty"`
+	PointsAfter  *int `json:"points_after,omitempty"`
+	PointsDelta  *int `json:"points_delta,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ownVerifier re-fetches machine or challenge state after a submission to
+// confirm the own registered, since the submission message alone is
+// sometimes ambiguous
+type ownVerifier struct {
+	client *htb.Client
+	target string
+	id     int
+	own    string
+
+	pointsBefore *int
+}
+
+// newOwnVerifier records the user's points before a submission. Verification
+// still works without them, only the points delta is omitted.
+func newOwnVerifier(ctx context.Context, client *htb.Client, target string, id int, own string) *ownVerifier {
+	v := &ownVerifier{client: client, target: target, id: id, own: own}
+	if user, err := client.GetUserInfo(ctx); err == nil {
+		v.pointsBefore = &user.Points
+	}
+	return v
+}
+
+// verify checks an accepted submission, or returns nil for rejected ones
+func (v *ownVerifier) verify(ctx context.Context, result *htb.SubmissionResult) *ownVerification {
+	if !result.Success {
+		return nil
+	}
+
+	verification := &ownVerification{Own: v.own}
+	for attempt := 0; attempt < ownVerifyAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, ownVerifyDelay); err != nil {
+				verification.Error = err.Error()
+				return verification
+			}
+		}
+
+		if err := v.check(ctx, verification); err != nil {
+			verification.Error = err.Error()
+			return verification
+		}
+		if verification.Confirmed {
+			break
+		}
+	}
+
+	if v.pointsBefore != nil {
+		if user, err := v.client.GetUserInfo(ctx); err == nil {
+			delta := user.Points - *v.pointsBefore
+			verification.PointsBefore = v.pointsBefore
+			verification.PointsAfter = &user.Points
+			verification.PointsDelta = &delta
+		}
+	}
+	return verification
+}
+
+// check fetches the current own state into verification
+func (v *ownVerifier) check(ctx context.Context, verification *ownVerification) error {
+	switch v.target {
+	case flagTargetMachine:
+		profile, err := v.client.GetMachineProfile(ctx, strconv.Itoa(v.id))
+		if err != nil {
+			return fmt.Errorf("failed to re-fetch machine: %w", err)
+		}
+		verification.UserOwned = &profile.AuthUserInUserOwns
+		verification.RootOwned = &profile.AuthUserInRootOwns
+		if v.own == ownRoot {
+			verification.Confirmed = profile.AuthUserInRootOwns
+		} else {
+			verification.Confirmed = profile.AuthUserInUserOwns
+		}
+	case flagTargetChallenge:
+		info, err := v.client.GetChallengeInfo(ctx, v.id)
+		if err != nil {
+			return fmt.Errorf("failed to re-fetch challenge: %w", err)
+		}
+		verification.Solved = &info.AuthUserSolve
+		verification.Confirmed = info.AuthUserSolve
+	default:
+		return fmt.Errorf("own verification is not supported for %s flags", v.target)
+	}
+	return nil
+}
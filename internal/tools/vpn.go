@@ -0,0 +1,336 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ensureVPNRegion switches the lab VPN server to the given region if the
+// currently assigned server is elsewhere. It returns the server switched to,
+// or nil if no switch was necessary.
+func ensureVPNRegion(ctx context.Context, client *htb.Client, region string) (*htb.VPNServer, error) {
+	if region == "" {
+		return nil, nil
+	}
+	region = strings.ToUpper(region)
+
+	var servers htb.VPNServersResponse
+	if err := client.GetJSON(ctx, "/connections/servers?product=labs", &servers); err != nil {
+		return nil, fmt.Errorf("failed to list VPN servers: %w", err)
+	}
+
+	assigned := servers.Data.Assigned
+	if assigned != nil && strings.EqualFold(assigned.Location, region) {
+		return nil, nil
+	}
+
+	tiers, ok := servers.Data.Options[region]
+	if !ok {
+		return nil, fmt.Errorf("no VPN servers available in region %s", region)
+	}
+
+	user, err := client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect subscription: %w", err)
+	}
+
+	target := pickVPNServer(tiers, user)
+	if target == nil {
+		return nil, fmt.Errorf("no VPN server with free capacity in region %s for your subscription", region)
+	}
+
+	if _, err := client.PostWithParsing(ctx, fmt.Sprintf("/connections/servers/switch/%d", target.ID), nil, "message"); err != nil {
+		return nil, fmt.Errorf("failed to switch VPN server to %s: %w", target.FriendlyName, err)
+	}
+
+	return target, nil
+}
+
+// pickVPNServer chooses the least loaded non-full server in the tier matching
+// the user's subscription
+func pickVPNServer(tiers map[string]htb.VPNServerTier, user *htb.User) *htb.VPNServer {
+	var best *htb.VPNServer
+	for name, tier := range tiers {
+		if !vpnTierMatches(name, user) {
+			continue
+		}
+		for _, server := range tier.Servers {
+			if server.Full {
+				continue
+			}
+			if best == nil || server.CurrentClients < best.CurrentClients {
+				candidate := server
+				best = &candidate
+			}
+		}
+	}
+	return best
+}
+
+// vpnTierMatches reports whether a VPN tier (e.g. "EU - VIP+") fits the subscription
+func vpnTierMatches(tierName string, user *htb.User) bool {
+	name := strings.ToLower(tierName)
+	switch {
+	case strings.Contains(name, "vip+"):
+		return user.IsDedicatedVIP
+	case strings.Contains(name, "vip"):
+		return user.CanAccessVIP && !user.IsDedicatedVIP
+	default:
+		return !user.CanAccessVIP
+	}
+}
+
+// htbVPNNetworks are the address ranges HTB assigns to lab VPN clients
+var htbVPNNetworks = []string{"10.10.14.0/23", "10.10.16.0/20"}
+
+// localInterface describes a local network interface that may carry the lab VPN
+type localInterface struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+	HTBRange  bool     `json:"htb_range"`
+}
+
+// labIPInfo reports the attacker's lab VPN IP and where it was found
+type labIPInfo struct {
+	LabIP           string                 `json:"lab_ip,omitempty"`
+	Source          string                 `json:"source,omitempty"`
+	Connections     []htb.ConnectionStatus `json:"connections,omitempty"`
+	LocalInterfaces []localInterface       `json:"local_interfaces,omitempty"`
+	Notes           []string               `json:"notes,omitempty"`
+}
+
+// detectLabIP determines the user's lab VPN IP from the HTB connection status
+// and from local tun/tap/wg interfaces
+func detectLabIP(ctx context.Context, client *htb.Client) *labIPInfo {
+	info := &labIPInfo{}
+
+	connections, err := client.GetConnectionStatus(ctx)
+	if err != nil {
+		info.Notes = append(info.Notes, fmt.Sprintf("HTB connection status unavailable: %v", err))
+	}
+	for _, conn := range connections {
+		if conn.Connection == nil {
+			continue
+		}
+		info.Connections = append(info.Connections, conn)
+		if info.LabIP == "" && conn.Connection.IP4 != "" {
+			info.LabIP = conn.Connection.IP4
+			info.Source = "htb_connection_status"
+		}
+	}
+
+	interfaces, err := vpnInterfaces()
+	if err != nil {
+		info.Notes = append(info.Notes, fmt.Sprintf("Local interfaces unavailable: %v", err))
+	}
+	info.LocalInterfaces = interfaces
+
+	localMatch := false
+	for _, iface := range interfaces {
+		for _, addr := range iface.Addresses {
+			if addr == info.LabIP {
+				localMatch = true
+			}
+			if info.LabIP == "" && iface.HTBRange {
+				info.LabIP = addr
+				info.Source = "local_interface"
+				localMatch = true
+			}
+		}
+	}
+
+	switch {
+	case info.LabIP == "":
+		info.Notes = append(info.Notes, "No lab VPN connection found; connect to the HTB VPN or start Pwnbox")
+	case !localMatch:
+		info.Notes = append(info.Notes, "The VPN IP is not on a local interface; this server may run on a different host than the attack box (e.g. Pwnbox)")
+	}
+
+	return info
+}
+
+// vpnInterfaces lists local tunnel interfaces and any interface with an
+// address in an HTB VPN range
+func vpnInterfaces() ([]localInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []*net.IPNet
+	for _, cidr := range htbVPNNetworks {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	var result []localInterface
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		local := localInterface{Name: iface.Name}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			local.Addresses = append(local.Addresses, ipNet.IP.String())
+			for _, network := range networks {
+				if network.Contains(ipNet.IP) {
+					local.HTBRange = true
+				}
+			}
+		}
+
+		if len(local.Addresses) > 0 && (local.HTBRange || isTunnelInterface(iface.Name)) {
+			result = append(result, local)
+		}
+	}
+	return result, nil
+}
+
+// isTunnelInterface reports whether an interface name looks like a VPN tunnel
+func isTunnelInterface(name string) bool {
+	for _, prefix := range []string{"tun", "tap", "utun", "wg", "ppp"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMyLabIP tool for finding the attacker's lab VPN IP
+type GetMyLabIP struct {
+	client *htb.Client
+}
+
+func NewGetMyLabIP(client *htb.Client) *GetMyLabIP {
+	return &GetMyLabIP{client: client}
+}
+
+func (t *GetMyLabIP) Name() string {
+	return "get_my_lab_ip"
+}
+
+func (t *GetMyLabIP) Description() string {
+	return "Get your own lab VPN (tun0) IP address for reverse shells, from the HTB connection status and local network interfaces"
+}
+
+func (t *GetMyLabIP) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type:       "object",
+		Properties: map[string]mcp.Property{},
+	}
+}
+
+func (t *GetMyLabIP) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	info := detectLabIP(ctx, t.client)
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
+
+// vpnProtocols maps the OpenVPN transport to the HTB config file variant
+var vpnProtocols = map[string]int{"udp": 0, "tcp": 1}
+
+// DownloadVPNConfig tool for saving the lab VPN config into the workspace
+type DownloadVPNConfig struct {
+	client      *htb.Client
+	downloadDir string
+	roots       rootsFunc
+}
+
+func NewDownloadVPNConfig(client *htb.Client, downloadDir string, roots rootsFunc) *DownloadVPNConfig {
+	return &DownloadVPNConfig{client: client, downloadDir: downloadDir, roots: roots}
+}
+
+func (t *DownloadVPNConfig) Name() string {
+	return "download_vpn_config"
+}
+
+func (t *DownloadVPNConfig) Description() string {
+	return "Download the .ovpn config of your assigned lab VPN server into the client's workspace (htb-vpn in its first root by default). The file holds your VPN key and is written readable only by you"
+}
+
+func (t *DownloadVPNConfig) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"protocol": {
+				Type:        "string",
+				Description: "OpenVPN transport",
+				Enum:        []string{"udp", "tcp"},
+				Default:     "udp",
+			},
+			"directory": directoryProperty,
+		},
+	}
+}
+
+func (t *DownloadVPNConfig) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	protocol := "udp"
+	if p, ok := args["protocol"].(string); ok && p != "" {
+		protocol = strings.ToLower(p)
+	}
+	variant, ok := vpnProtocols[protocol]
+	if !ok {
+		return nil, fmt.Errorf("invalid protocol %q: must be udp or tcp", protocol)
+	}
+
+	requested, _ := args["directory"].(string)
+	dir, err := workspaceDir(ctx, t.roots, requested, t.downloadDir, "htb-vpn")
+	if err != nil {
+		return nil, err
+	}
+
+	var servers htb.VPNServersResponse
+	if err := t.client.GetJSON(ctx, "/connections/servers?product=labs", &servers); err != nil {
+		return nil, fmt.Errorf("failed to list VPN servers: %w", err)
+	}
+	server := servers.Data.Assigned
+	if server == nil {
+		return nil, fmt.Errorf("no lab VPN server is assigned to your account")
+	}
+
+	// Make API request
+	data, _, err := t.client.GetRaw(ctx, fmt.Sprintf("/access/ovpnfile/%d/%d", server.ID, variant))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download VPN config: %w", err)
+	}
+
+	name := fmt.Sprintf("lab-%s-%s.ovpn", safeFileName(server.FriendlyName), protocol)
+	path, err := writeWorkspaceFile(dir, name, data, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON content
+	content, err := mcp.CreateJSONContent(map[string]interface{}{
+		"server":   server.FriendlyName,
+		"location": server.Location,
+		"protocol": protocol,
+		"path":     path,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JSON content: %w", err)
+	}
+
+	return &mcp.CallToolResponse{
+		Content: []mcp.Content{content},
+	}, nil
+}
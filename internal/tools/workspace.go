@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/internal/session"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// rootsFunc lists the workspace roots declared by the connected client
+type rootsFunc func(ctx context.Context) ([]mcp.Root, error)
+
+// directoryProperty is the schema of the optional output directory argument
+// of file-producing tools
+var directoryProperty = mcp.Property{
+	Type:        "string",
+	Description: "Target directory. When the client declares workspace roots, relative paths are placed under the first root and absolute paths must lie inside one; otherwise the same applies to the configured DOWNLOAD_DIR",
+}
+
+// workspaceDir picks the directory a tool writes files into. Clients that
+// declare roots confine output to them: a relative dir is placed under the
+// first root, an absolute one must lie inside a root, and without one the
+// tool writes to def if it lies inside a root, or to sub under the first
+// root. Without roots, output is confined to def the same way, and refused
+// if no def is configured.
+func workspaceDir(ctx context.Context, roots rootsFunc, dir, def, sub string) (string, error) {
+	declared, err := roots(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list workspace roots: %w", err)
+	}
+	if len(declared) == 0 {
+		if def == "" {
+			return "", errors.New("no target directory configured: set NOTES_DIR for notes or DOWNLOAD_DIR for downloads, or use a client that declares workspace roots")
+		}
+		if dir == "" {
+			return def, nil
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(def, dir)
+		}
+		if !insideRoots([]string{def}, dir) {
+			return "", fmt.Errorf("%s is outside the configured directory %s", dir, def)
+		}
+		return dir, nil
+	}
+
+	paths := rootPaths(declared)
+	if len(paths) == 0 {
+		return "", fmt.Errorf("%s declared no file:// workspace roots", clientNameFrom(ctx))
+	}
+
+	switch {
+	case dir == "":
+		if def != "" && insideRoots(paths, def) {
+			return def, nil
+		}
+		return filepath.Join(paths[0], sub), nil
+	case !filepath.IsAbs(dir):
+		dir = filepath.Join(paths[0], dir)
+	}
+
+	if !insideRoots(paths, dir) {
+		return "", fmt.Errorf("%s is outside the workspace roots declared by %s (%s)", dir, clientNameFrom(ctx), strings.Join(paths, ", "))
+	}
+	return dir, nil
+}
+
+// rootPaths returns the local paths of file:// roots, skipping others
+func rootPaths(roots []mcp.Root) []string {
+	var paths []string
+	for _, root := range roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			continue
+		}
+		paths = append(paths, filepath.Clean(filepath.FromSlash(u.Path)))
+	}
+	return paths
+}
+
+// insideRoots reports whether path is one of roots or lies beneath one.
+// Symlinks are resolved first, so a link inside a root can't lead out of it.
+func insideRoots(roots []string, path string) bool {
+	path = resolvePath(path)
+	for _, root := range roots {
+		rel, err := filepath.Rel(resolvePath(root), path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns the absolute form of path with symlinks resolved in
+// its longest existing prefix; the rest does not exist yet and is kept as is
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	rest := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, rest)
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// clientNameFrom describes the client of the request's session
+func clientNameFrom(ctx context.Context) string {
+	if s, ok := session.FromContext(ctx); ok {
+		return clientName(s)
+	}
+	return "the connected client"
+}
+
+// writeWorkspaceFile writes data to name inside dir, creating dir if needed,
+// and returns the written path
+func writeWorkspaceFile(dir, name string, data []byte, perm os.FileMode) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	// Don't follow a link planted where the file goes
+	path := filepath.Join(dir, name)
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("refusing to write %s: it is a symlink", path)
+	}
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// safeFileName lowercases name and replaces characters unsafe in file names
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// noRoots is the rootsFunc of a client that declares no workspace roots
+func noRoots(ctx context.Context) ([]mcp.Root, error) {
+	return nil, nil
+}
+
+func TestInsideRoots(t *testing.T) {
+	root, other := t.TempDir(), t.TempDir()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{root, true},
+		{filepath.Join(root, "htb-challenges", "new"), true},
+		{filepath.Join(root, "..", filepath.Base(root)+"-sibling"), false},
+		{filepath.Join(root, "..", "elsewhere"), false},
+		{other, false},
+	}
+	for _, tt := range tests {
+		if got := insideRoots([]string{root}, tt.path); got != tt.want {
+			t.Errorf("insideRoots(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if !insideRoots([]string{root, other}, filepath.Join(other, "loot")) {
+		t.Error("Expected a path inside the second root to be accepted")
+	}
+}
+
+func TestWorkspaceDirWithoutRoots(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+
+	if _, err := workspaceDir(ctx, noRoots, "/tmp/loot", "", "htb-vpn"); err == nil || !strings.Contains(err.Error(), "DOWNLOAD_DIR") {
+		t.Errorf("Expected writes to be refused without a configured directory, got %v", err)
+	}
+
+	if dir, err := workspaceDir(ctx, noRoots, "", base, "htb-vpn"); err != nil || dir != base {
+		t.Errorf("Expected the configured directory, got %q, %v", dir, err)
+	}
+	if dir, err := workspaceDir(ctx, noRoots, "lab", base, "htb-vpn"); err != nil || dir != filepath.Join(base, "lab") {
+		t.Errorf("Expected a relative directory under the configured one, got %q, %v", dir, err)
+	}
+	if _, err := workspaceDir(ctx, noRoots, filepath.Join(base, "..", "elsewhere"), base, "htb-vpn"); err == nil || !strings.Contains(err.Error(), "outside the configured directory") {
+		t.Errorf("Expected a directory outside the configured one to be refused, got %v", err)
+	}
+}
+
+func TestWorkspaceDirResolvesSymlinks(t *testing.T) {
+	ctx := context.Background()
+	root, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("Symlinks unsupported: %v", err)
+	}
+	roots := func(ctx context.Context) ([]mcp.Root, error) {
+		return []mcp.Root{{URI: "file://" + filepath.ToSlash(root)}}, nil
+	}
+
+	// The link lies lexically inside the root but leads out of it
+	if _, err := workspaceDir(ctx, roots, "escape/vpn", "", "htb-vpn"); err == nil || !strings.Contains(err.Error(), "outside the workspace roots") {
+		t.Errorf("Expected a symlink out of the root to be refused, got %v", err)
+	}
+	if _, err := workspaceDir(ctx, noRoots, filepath.Join(root, "escape"), root, "htb-vpn"); err == nil {
+		t.Error("Expected a symlink out of the configured directory to be refused")
+	}
+	if dir, err := workspaceDir(ctx, roots, "new/dir", "", "htb-vpn"); err != nil || dir != filepath.Join(root, "new", "dir") {
+		t.Errorf("Expected a directory yet to be created inside the root, got %q, %v", dir, err)
+	}
+}
+
+func TestWriteWorkspaceFileRefusesSymlink(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	target := filepath.Join(outside, "authorized_keys")
+	if err := os.Symlink(target, filepath.Join(dir, "lab.ovpn")); err != nil {
+		t.Skipf("Symlinks unsupported: %v", err)
+	}
+
+	if _, err := writeWorkspaceFile(dir, "lab.ovpn", []byte("key"), 0o600); err == nil {
+		t.Error("Expected writing through a symlink to be refused")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the directory, got %v", err)
+	}
+}
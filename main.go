@@ -2,19 +2,58 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/NoASLR/htb-mcp-server/internal/redact"
 	"github.com/NoASLR/htb-mcp-server/internal/server"
 	"github.com/NoASLR/htb-mcp-server/pkg/config"
 )
 
 func main() {
+	// Conformance replays transcripts against a stub HTB API, so it needs no token
+	if len(os.Args) > 1 && os.Args[1] == "conformance" {
+		os.Exit(runConformance(os.Args[2:]))
+	}
+
+	// Listing tools never calls the HTB API, so it needs no token either
+	if len(os.Args) > 1 && os.Args[1] == "--list-tools" {
+		os.Exit(runListTools(os.Args[2:]))
+	}
+
 	// Load configuration from environment variables
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Keep redacted values out of the logs too
+	log.SetOutput(redact.New(cfg.Redact, cfg.HTBToken, cfg.AcademyToken, cfg.AuthToken).Writer(os.Stderr))
+
+	// Debugging modes that run without an MCP client
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "call":
+			os.Exit(runCall(cfg, os.Args[2:]))
+		case "repl":
+			os.Exit(runREPL(cfg))
+		}
+	}
+
+	// Serve sessions over HTTP until interrupted
+	if cfg.Transport == "http" {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		if err := server.NewHTTP(cfg, nil).ListenAndServe(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to serve MCP over HTTP: %v", err)
+		}
+		return
+	}
+
 	// Create and start the MCP server
 	srv := server.New(cfg)
 
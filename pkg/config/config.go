@@ -2,42 +2,180 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the HTB MCP Server
 type Config struct {
 	// HTB API Configuration
-	HTBToken   string
-	HTBBaseURL string
+	HTBToken     string
+	HTBBaseURL   string
+	HTBStatusURL string
 
-	// Server Configuration
+	// HTB Academy API Configuration (separate token from the Labs API)
+	AcademyBaseURL string
+	AcademyToken   string
+
+	// Locale requested from the HTB API via Accept-Language (e.g. en-US);
+	// the account's language is used if empty
+	Locale string
+
+	// TimeZone that timestamps in tool output are converted to, and that
+	// scheduled times without an offset are read in; timestamps are left as
+	// HTB returns them if nil
+	TimeZone *time.Location
+
+	// Server Configuration; Transport is stdio (the default) or http, served
+	// on ServerHost and ServerPort
+	Transport  string
+	ServerHost string
 	ServerPort int
 	LogLevel   string
 
-	// Rate Limiting
-	RateLimitPerMinute int
+	// Browser origins allowed to call the HTTP transport besides loopback
+	// ones, e.g. https://app.example.com
+	AllowedOrigins []string
+
+	// HTTP sessions idle for SessionIdleTimeout are ended, and at most
+	// MaxSessions may be open at once (0 disables each)
+	SessionIdleTimeout time.Duration
+	MaxSessions        int
+
+	// Network transport security. TLS is served when TLSCertFile and
+	// TLSKeyFile are set. Clients must present AuthToken as a bearer token
+	// and/or a certificate signed by TLSClientCAFile (mTLS).
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+	AuthToken       string
 
-	// Caching
-	CacheTTL time.Duration
+	// Append HTB latency, cache hits and endpoints called to tool results
+	// as _meta
+	ExecutionMeta bool
+
+	// Rate Limiting; RateLimitPerMinute limits tool calls per MCP session,
+	// HTBRateLimitPerMinute paces requests to the HTB API (0 disables)
+	RateLimitPerMinute    int
+	HTBRateLimitPerMinute int
+
+	// Per-session guards against runaway agent loops (0 disables each):
+	// requests of any kind per minute, concurrent tool calls, and the
+	// encoded size of a tool result
+	MaxRequestsPerMinute int
+	MaxInFlightCalls     int
+	MaxResultBytes       int
+
+	// Caching; CacheBackend is memory (the default) or redis, shared through
+	// RedisURL by instances serving the same token
+	CacheTTL     time.Duration
+	CacheBackend string
+	RedisURL     string
 
 	// Timeouts
 	RequestTimeout time.Duration
+
+	// Notes export
+	NotesDir string
+
+	// Directory downloads are confined to for clients without workspace roots
+	DownloadDir string
+
+	// Directory for persistent state such as cached listings
+	StateDir string
+
+	// Extension executables providing additional tools
+	Extensions       []string
+	ExtensionTimeout time.Duration
+
+	// Preferred VPN region (e.g. EU, US, AU, SG) checked before spawning
+	PreferredVPNRegion string
+
+	// Background polling of the active machine
+	PollInterval       time.Duration
+	KeepaliveEnabled   bool
+	KeepaliveThreshold time.Duration
+	ExpiryWarnings     []time.Duration
+	IPChangeWatch      bool
+
+	// Weekly practice digest
+	DigestEnabled    bool
+	DigestWebhookURL string
+	DigestInterests  []string
+
+	// Categories of sensitive values (flags, tokens, emails, all) redacted
+	// from tool results, notifications and logs
+	Redact []string
+
+	// Confirmation mode (ConfirmAsk, ConfirmRequire or ConfirmOff) of tools
+	// the user must approve through elicitation before they run
+	ConfirmationPolicy map[string]string
 }
 
-// Load creates a new configuration from environment variables
-func Load() (*Config, error) {
+// Confirmation modes of ConfirmationPolicy
+const (
+	// ConfirmAsk asks the user if the client supports elicitation and
+	// otherwise runs the tool
+	ConfirmAsk = "ask"
+
+	// ConfirmRequire asks the user, refusing the call if the client cannot
+	ConfirmRequire = "require"
+
+	// ConfirmOff never asks
+	ConfirmOff = "off"
+)
+
+// defaultConfirmationPolicy confirms machine resets and flag submissions
+// with clients that support elicitation
+var defaultConfirmationPolicy = map[string]string{
+	"reset_machine_instance": ConfirmAsk,
+	"vote_machine_reset":     ConfirmAsk,
+	"submit_user_flag":       ConfirmAsk,
+	"submit_root_flag":       ConfirmAsk,
+	"submit_challenge_flag":  ConfirmAsk,
+	"submit_flags_batch":     ConfirmAsk,
+}
+
+// Default returns the configuration used when no environment variables are
+// set. It has no HTB token, so it only suits code that never calls the API,
+// such as listing the built-in tools.
+func Default() *Config {
 	cfg := &Config{
 		// Default values
-		HTBBaseURL:         "https://labs.hackthebox.com/api/v4",
-		ServerPort:         3000,
-		LogLevel:           "INFO",
-		RateLimitPerMinute: 100,
-		CacheTTL:           5 * time.Minute,
-		RequestTimeout:     30 * time.Second,
+		HTBBaseURL:           "https://labs.hackthebox.com/api/v4",
+		HTBStatusURL:         "https://status.hackthebox.com/api/v2/summary.json",
+		AcademyBaseURL:       "https://academy.hackthebox.com/api/v2",
+		ServerHost:           "127.0.0.1",
+		ServerPort:           3000,
+		SessionIdleTimeout:   30 * time.Minute,
+		MaxSessions:          32,
+		LogLevel:             "INFO",
+		RateLimitPerMinute:   100,
+		MaxRequestsPerMinute: 600,
+		MaxInFlightCalls:     8,
+		MaxResultBytes:       4 << 20,
+		CacheTTL:             5 * time.Minute,
+		RequestTimeout:       30 * time.Second,
+		PollInterval:         60 * time.Second,
+		KeepaliveThreshold:   30 * time.Minute,
+		ExpiryWarnings:       []time.Duration{30 * time.Minute, 10 * time.Minute, 2 * time.Minute},
+		IPChangeWatch:        true,
+		ExtensionTimeout:     30 * time.Second,
+		ConfirmationPolicy:   make(map[string]string),
 	}
+	for tool, mode := range defaultConfirmationPolicy {
+		cfg.ConfirmationPolicy[tool] = mode
+	}
+	return cfg
+}
+
+// Load creates a new configuration from environment variables
+func Load() (*Config, error) {
+	cfg := Default()
 
 	// Required environment variables
 	cfg.HTBToken = os.Getenv("HTB_TOKEN")
@@ -50,38 +188,284 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid HTB_TOKEN format: %v", err)
 	}
 
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cfg.StateDir = filepath.Join(cacheDir, "htb-mcp-server")
+	}
+
 	// Optional environment variables
+	if statusURL := os.Getenv("HTB_STATUS_URL"); statusURL != "" {
+		cfg.HTBStatusURL = statusURL
+	}
+
+	if academyURL := os.Getenv("ACADEMY_BASE_URL"); academyURL != "" {
+		cfg.AcademyBaseURL = academyURL
+	}
+
+	if academyToken := os.Getenv("ACADEMY_TOKEN"); academyToken != "" {
+		cfg.AcademyToken = academyToken
+	}
+
 	if port := os.Getenv("SERVER_PORT"); port != "" {
 		if p, err := strconv.Atoi(port); err == nil {
 			cfg.ServerPort = p
 		}
 	}
 
+	if transport := os.Getenv("TRANSPORT"); transport != "" {
+		cfg.Transport = strings.ToLower(transport)
+	}
+
+	if host := os.Getenv("SERVER_HOST"); host != "" {
+		cfg.ServerHost = host
+	}
+
+	if origins := os.Getenv("ALLOWED_ORIGINS"); origins != "" {
+		for _, origin := range parseList(origins) {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+
+	if idle := os.Getenv("SESSION_IDLE_TIMEOUT_SECONDS"); idle != "" {
+		if seconds, err := strconv.Atoi(idle); err == nil {
+			cfg.SessionIdleTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if maxSessions := os.Getenv("MAX_SESSIONS"); maxSessions != "" {
+		if m, err := strconv.Atoi(maxSessions); err == nil {
+			cfg.MaxSessions = m
+		}
+	}
+
+	cfg.TLSCertFile = os.Getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TLS_KEY_FILE")
+	cfg.TLSClientCAFile = os.Getenv("TLS_CLIENT_CA_FILE")
+	cfg.AuthToken = os.Getenv("MCP_AUTH_TOKEN")
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	switch cfg.Transport {
+	case "", "stdio":
+	case "http":
+		// Anyone reaching an unauthenticated daemon could act on the HTB account
+		if !IsLoopback(cfg.ServerHost) && cfg.AuthToken == "" && cfg.TLSClientCAFile == "" {
+			return nil, fmt.Errorf("TRANSPORT http on %s requires MCP_AUTH_TOKEN or TLS_CLIENT_CA_FILE", cfg.ServerHost)
+		}
+	default:
+		return nil, fmt.Errorf("invalid TRANSPORT %q: use stdio or http", cfg.Transport)
+	}
+
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		cfg.LogLevel = logLevel
 	}
 
+	if rateLimit := os.Getenv("HTB_RATE_LIMIT_PER_MINUTE"); rateLimit != "" {
+		if rl, err := strconv.Atoi(rateLimit); err == nil {
+			cfg.HTBRateLimitPerMinute = rl
+		}
+	}
+
+	if meta := os.Getenv("EXECUTION_META"); meta != "" {
+		if m, err := strconv.ParseBool(meta); err == nil {
+			cfg.ExecutionMeta = m
+		}
+	}
+
 	if rateLimit := os.Getenv("RATE_LIMIT_PER_MINUTE"); rateLimit != "" {
 		if rl, err := strconv.Atoi(rateLimit); err == nil {
 			cfg.RateLimitPerMinute = rl
 		}
 	}
 
+	if maxRequests := os.Getenv("MAX_REQUESTS_PER_MINUTE"); maxRequests != "" {
+		if m, err := strconv.Atoi(maxRequests); err == nil {
+			cfg.MaxRequestsPerMinute = m
+		}
+	}
+
+	if maxCalls := os.Getenv("MAX_INFLIGHT_CALLS"); maxCalls != "" {
+		if m, err := strconv.Atoi(maxCalls); err == nil {
+			cfg.MaxInFlightCalls = m
+		}
+	}
+
+	if maxBytes := os.Getenv("MAX_RESULT_BYTES"); maxBytes != "" {
+		if m, err := strconv.Atoi(maxBytes); err == nil {
+			cfg.MaxResultBytes = m
+		}
+	}
+
 	if cacheTTL := os.Getenv("CACHE_TTL_SECONDS"); cacheTTL != "" {
 		if ttl, err := strconv.Atoi(cacheTTL); err == nil {
 			cfg.CacheTTL = time.Duration(ttl) * time.Second
 		}
 	}
 
+	if backend := os.Getenv("CACHE_BACKEND"); backend != "" {
+		cfg.CacheBackend = strings.ToLower(backend)
+	}
+
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		cfg.RedisURL = redisURL
+		if cfg.CacheBackend == "" {
+			cfg.CacheBackend = "redis"
+		}
+	}
+
+	switch cfg.CacheBackend {
+	case "", "memory":
+	case "redis":
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("CACHE_BACKEND redis requires REDIS_URL")
+		}
+	default:
+		return nil, fmt.Errorf("invalid CACHE_BACKEND %q: use memory or redis", cfg.CacheBackend)
+	}
+
 	if timeout := os.Getenv("REQUEST_TIMEOUT_SECONDS"); timeout != "" {
 		if t, err := strconv.Atoi(timeout); err == nil {
 			cfg.RequestTimeout = time.Duration(t) * time.Second
 		}
 	}
 
+	if notesDir := os.Getenv("NOTES_DIR"); notesDir != "" {
+		cfg.NotesDir = notesDir
+	}
+
+	if downloadDir := os.Getenv("DOWNLOAD_DIR"); downloadDir != "" {
+		cfg.DownloadDir = downloadDir
+	}
+
+	if stateDir := os.Getenv("STATE_DIR"); stateDir != "" {
+		cfg.StateDir = stateDir
+	}
+
+	if region := os.Getenv("PREFERRED_VPN_REGION"); region != "" {
+		cfg.PreferredVPNRegion = strings.ToUpper(region)
+	}
+
+	if interval := os.Getenv("POLL_INTERVAL_SECONDS"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil && i > 0 {
+			cfg.PollInterval = time.Duration(i) * time.Second
+		}
+	}
+
+	if keepalive := os.Getenv("KEEPALIVE_ENABLED"); keepalive != "" {
+		if k, err := strconv.ParseBool(keepalive); err == nil {
+			cfg.KeepaliveEnabled = k
+		}
+	}
+
+	if threshold := os.Getenv("KEEPALIVE_THRESHOLD_MINUTES"); threshold != "" {
+		if t, err := strconv.Atoi(threshold); err == nil && t > 0 {
+			cfg.KeepaliveThreshold = time.Duration(t) * time.Minute
+		}
+	}
+
+	if extensions := os.Getenv("EXTENSIONS"); extensions != "" {
+		cfg.Extensions = parseList(extensions)
+	}
+
+	if timeout := os.Getenv("EXTENSION_TIMEOUT_SECONDS"); timeout != "" {
+		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+			cfg.ExtensionTimeout = time.Duration(t) * time.Second
+		}
+	}
+
+	// An explicitly empty value disables expiry warnings
+	if warnings, ok := os.LookupEnv("EXPIRY_WARNING_MINUTES"); ok {
+		cfg.ExpiryWarnings = parseMinuteList(warnings)
+	}
+
+	if watch := os.Getenv("IP_CHANGE_WATCH"); watch != "" {
+		if w, err := strconv.ParseBool(watch); err == nil {
+			cfg.IPChangeWatch = w
+		}
+	}
+
+	if digest := os.Getenv("DIGEST_ENABLED"); digest != "" {
+		if d, err := strconv.ParseBool(digest); err == nil {
+			cfg.DigestEnabled = d
+		}
+	}
+
+	if webhook := os.Getenv("DIGEST_WEBHOOK_URL"); webhook != "" {
+		cfg.DigestWebhookURL = webhook
+	}
+
+	if interests := os.Getenv("DIGEST_INTERESTS"); interests != "" {
+		cfg.DigestInterests = parseList(interests)
+	}
+
+	if locale := os.Getenv("HTB_LOCALE"); locale != "" {
+		cfg.Locale = locale
+	}
+
+	if tz := os.Getenv("TIMEZONE"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TIMEZONE %q: %v", tz, err)
+		}
+		cfg.TimeZone = loc
+	}
+
+	if redact := os.Getenv("REDACT"); redact != "" {
+		cfg.Redact = parseList(redact)
+	}
+
+	// Entries override the defaults tool by tool
+	if policy := os.Getenv("CONFIRMATION_POLICY"); policy != "" {
+		for _, entry := range parseList(policy) {
+			tool, mode, ok := strings.Cut(entry, "=")
+			tool, mode = strings.TrimSpace(tool), strings.ToLower(strings.TrimSpace(mode))
+			if !ok || tool == "" || (mode != ConfirmAsk && mode != ConfirmRequire && mode != ConfirmOff) {
+				return nil, fmt.Errorf("invalid CONFIRMATION_POLICY entry %q: use tool=ask, tool=require or tool=off", entry)
+			}
+			cfg.ConfirmationPolicy[tool] = mode
+		}
+	}
+
 	return cfg, nil
 }
 
+// IsLoopback reports whether host, a hostname or IP address, refers to the
+// local machine only
+func IsLoopback(host string) bool {
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parseList parses a comma-separated list, trimming entries and skipping empty ones
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMinuteList parses a comma-separated list of minutes, skipping invalid entries
+func parseMinuteList(value string) []time.Duration {
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		if m, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && m > 0 {
+			durations = append(durations, time.Duration(m)*time.Minute)
+		}
+	}
+	return durations
+}
+
 // validateHTBToken checks if the token has the correct JWT format
 func validateHTBToken(token string) error {
 	// Basic JWT validation - should have 3 parts separated by dots
@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/NoASLR/htb-mcp-server/pkg/config"
 )
@@ -16,17 +20,65 @@ type Client struct {
 	httpClient *http.Client
 	config     *config.Config
 	baseURL    string
+	limits     *rateLimitTracker
+	drift      *driftDetector
+
+	throttleMu sync.RWMutex
+	throttle   Throttle
+}
+
+// AssetBaseURL is the host serving HTB static assets such as machine avatars
+const AssetBaseURL = "https://www.hackthebox.com"
+
+// AssetURL returns the absolute URL of an HTB static asset path, leaving
+// absolute URLs unchanged
+func AssetURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return AssetBaseURL + "/" + strings.TrimPrefix(path, "/")
 }
 
 // NewClient creates a new HTB API client
 func NewClient(cfg *config.Config) *Client {
-	return &Client{
+	client := &Client{
 		httpClient: &http.Client{
 			Timeout: cfg.RequestTimeout,
 		},
 		config:  cfg,
 		baseURL: cfg.HTBBaseURL,
+		limits:  &rateLimitTracker{},
+		drift:   newDriftDetector(),
 	}
+	if cfg.HTBRateLimitPerMinute > 0 {
+		client.throttle = NewThrottle(cfg.HTBRateLimitPerMinute)
+	}
+	return client
+}
+
+// NewAcademyClient creates a client for the HTB Academy API, which uses its own base URL and token
+func NewAcademyClient(cfg *config.Config) *Client {
+	academy := *cfg
+	academy.HTBBaseURL = cfg.AcademyBaseURL
+	academy.HTBToken = cfg.AcademyToken
+	academy.HTBRateLimitPerMinute = 0
+	return NewClient(&academy)
+}
+
+// SetThrottle replaces the throttle pacing requests, e.g. with one shared by
+// other instances serving the same token; nil disables pacing. Requests
+// already waiting keep their current throttle.
+func (c *Client) SetThrottle(throttle Throttle) {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+	c.throttle = throttle
+}
+
+// pacer returns the throttle pacing requests, or nil
+func (c *Client) pacer() Throttle {
+	c.throttleMu.RLock()
+	defer c.throttleMu.RUnlock()
+	return c.throttle
 }
 
 // Request makes an authenticated HTTP request to the HTB API
@@ -51,6 +103,11 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 	req.Header.Set("User-Agent", "htb-mcp-server/1.0")
 	req.Header.Set("Authorization", "Bearer "+c.config.HTBToken)
 
+	// Ask for localized strings in the configured language where supported
+	if c.config.Locale != "" {
+		req.Header.Set("Accept-Language", c.config.Locale)
+	}
+
 	if method == http.MethodPost {
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Accept", "application/json, text/plain, */*")
@@ -58,10 +115,21 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 		req.Header.Set("Host", "labs.hackthebox.com")
 	}
 
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
+	TraceFrom(ctx).record(method, endpoint, resp, time.Since(start))
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
+	if retryAfter := c.limits.observe(resp, time.Now()); retryAfter != nil {
+		if throttle := c.pacer(); throttle != nil {
+			throttle.Backoff(*retryAfter)
+		}
+	}
 
 	// Check for authentication errors
 	if resp.StatusCode == 302 && resp.Header.Get("Location") != "" {
@@ -77,6 +145,11 @@ func (c *Client) Request(ctx context.Context, method, endpoint string, body inte
 	return resp, nil
 }
 
+// RateLimitStatus returns the HTB API rate limiting observed by this client
+func (c *Client) RateLimitStatus() RateLimitStatus {
+	return c.limits.snapshot(time.Now())
+}
+
 // Get makes a GET request to the HTB API
 func (c *Client) Get(ctx context.Context, endpoint string) (*http.Response, error) {
 	return c.Request(ctx, http.MethodGet, endpoint, nil)
@@ -87,7 +160,8 @@ func (c *Client) Post(ctx context.Context, endpoint string, body interface{}) (*
 	return c.Request(ctx, http.MethodPost, endpoint, body)
 }
 
-// ParseResponse parses a JSON response and extracts a specific field
+// ParseResponse parses a JSON response and extracts a specific field,
+// returning an HTBAPIError for 4xx and 5xx status codes
 func (c *Client) ParseResponse(resp *http.Response, field string) (interface{}, error) {
 	defer resp.Body.Close()
 
@@ -96,11 +170,23 @@ func (c *Client) ParseResponse(resp *http.Response, field string) (interface{},
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if err := maintenanceError(resp, body, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, apiError(resp, body)
+	}
+
 	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
+	if resp.Request != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.drift.checkField(c.endpointPath(resp.Request.URL.Path), result, field)
+	}
+
 	if field == "" {
 		return result, nil
 	}
@@ -128,6 +214,522 @@ func (c *Client) PostWithParsing(ctx context.Context, endpoint string, body inte
 	return c.ParseResponse(resp, field)
 }
 
+// DecodeResponse decodes a JSON response body into target, returning an
+// HTBAPIError for non-2xx status codes
+func (c *Client) DecodeResponse(resp *http.Response, target interface{}) error {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := maintenanceError(resp, body, time.Now()); err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return apiError(resp, body)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse JSON response: %w", err)
+	}
+
+	if resp.Request != nil {
+		c.drift.check(c.endpointPath(resp.Request.URL.Path), body, target)
+	}
+
+	return nil
+}
+
+// apiError returns the HTBAPIError for a non-2xx response, preferring the
+// API's own message when the error body is JSON
+func apiError(resp *http.Response, body []byte) *HTBAPIError {
+	apiErr := &HTBAPIError{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		Response:   string(body),
+	}
+
+	var errBody struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &errBody) == nil && errBody.Message != "" {
+		apiErr.Message = errBody.Message
+	}
+
+	return apiErr
+}
+
+// maintenanceError returns a MaintenanceError for a 503 response, or an HTML
+// page served with a server error where the API should have answered with
+// JSON. HTML 4xx pages, such as a Cloudflare 403, remain API errors so rate
+// limit and authentication handling still applies.
+func maintenanceError(resp *http.Response, body []byte, now time.Time) *MaintenanceError {
+	if resp.StatusCode != http.StatusServiceUnavailable && (resp.StatusCode < 500 || !isHTML(resp, body)) {
+		return nil
+	}
+
+	maintErr := &MaintenanceError{StatusCode: resp.StatusCode}
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		maintErr.RetryAfter = &retryAfter
+	}
+	return maintErr
+}
+
+// isHTML reports whether a response is an HTML page
+func isHTML(resp *http.Response, body []byte) bool {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
+// endpointPath strips the API base path from a request path
+func (c *Client) endpointPath(path string) string {
+	if base, err := url.Parse(c.baseURL); err == nil {
+		return "/" + strings.TrimPrefix(strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/")), "/")
+	}
+	return path
+}
+
+// SchemaDrift returns the differences detected between HTB API responses
+// and the models they were decoded into
+func (c *Client) SchemaDrift() []SchemaDrift {
+	return c.drift.snapshot()
+}
+
+// OnSchemaDrift adds a callback invoked once for each newly detected
+// difference. Every callback added is invoked; call the returned function to
+// remove this one, e.g. when a session sharing the client ends.
+func (c *Client) OnSchemaDrift(fn func(SchemaDrift)) func() {
+	return c.drift.subscribe(fn)
+}
+
+// GetJSON performs a GET request and decodes the response into target
+func (c *Client) GetJSON(ctx context.Context, endpoint string, target interface{}) error {
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	return c.DecodeResponse(resp, target)
+}
+
+// PostJSON performs a POST request and decodes the response into target
+func (c *Client) PostJSON(ctx context.Context, endpoint string, body interface{}, target interface{}) error {
+	resp, err := c.Post(ctx, endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	return c.DecodeResponse(resp, target)
+}
+
+// GetRaw performs a GET request and returns the raw body and its content type
+func (c *Client) GetRaw(ctx context.Context, endpoint string) ([]byte, string, error) {
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return readRaw(resp)
+}
+
+// Download fetches an absolute URL such as a pre-signed file download link.
+// The request is sent without credentials so the HTB token never leaves the API host.
+func (c *Client) Download(ctx context.Context, rawURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "htb-mcp-server/1.0")
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	TraceFrom(ctx).record(http.MethodGet, rawURL, resp, time.Since(start))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	return readRaw(resp)
+}
+
+// readRaw reads a raw response body, returning an error for non-2xx statuses
+func readRaw(resp *http.Response) ([]byte, string, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := maintenanceError(resp, body, time.Now()); err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", &HTBAPIError{
+			StatusCode: resp.StatusCode,
+			Message:    http.StatusText(resp.StatusCode),
+			Response:   string(body),
+		}
+	}
+
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// GetUserInfo returns the authenticated user's account information
+func (c *Client) GetUserInfo(ctx context.Context) (*User, error) {
+	var result UserInfoResponse
+	if err := c.GetJSON(ctx, "/user/info", &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Info, nil
+}
+
+// GetUserProfile returns the public profile of a user
+func (c *Client) GetUserProfile(ctx context.Context, userID int) (*UserProfile, error) {
+	var result UserProfileResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/user/profile/basic/%d", userID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Profile, nil
+}
+
+// GetUserActivity returns the recent owns of a user
+func (c *Client) GetUserActivity(ctx context.Context, userID int) ([]ActivityItem, error) {
+	var result UserActivityResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/user/profile/activity/%d", userID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Profile.Activity, nil
+}
+
+// GetMachineProgress returns a user's machine progress by operating system
+func (c *Client) GetMachineProgress(ctx context.Context, userID int) (*MachineProgress, error) {
+	var result MachineProgressResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/user/profile/progress/machines/os/%d", userID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Profile, nil
+}
+
+// GetChallengeProgress returns a user's challenge progress by category
+func (c *Client) GetChallengeProgress(ctx context.Context, userID int) (*ChallengeProgress, error) {
+	var result ChallengeProgressResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/user/profile/progress/challenges/%d", userID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Profile, nil
+}
+
+// GetRankGraph returns a user's points and rank history for a period (1W, 1M, 3M, 6M or 1Y)
+func (c *Client) GetRankGraph(ctx context.Context, userID int, period string) (*RankGraph, error) {
+	var result RankGraphResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/user/profile/graph/%s/%d", url.PathEscape(period), userID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Profile.GraphData, nil
+}
+
+// GetActiveMachine returns the currently active machine, or nil if none is running
+func (c *Client) GetActiveMachine(ctx context.Context) (*ActiveMachineInfo, error) {
+	var result ActiveMachineResponse
+	if err := c.GetJSON(ctx, "/machine/active", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Info, nil
+}
+
+// GetResetVote returns the pending reset vote on a machine's shared server,
+// or nil if there is none
+func (c *Client) GetResetVote(ctx context.Context, machineID int) (*ResetVote, error) {
+	var result ResetVoteResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/vm/reset/vote/%d", machineID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Info, nil
+}
+
+// VoteMachineReset votes for resetting a machine on its shared server,
+// starting a vote if none is pending, and returns the API message
+func (c *Client) VoteMachineReset(ctx context.Context, machineID int) (string, error) {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.PostJSON(ctx, "/vm/reset/vote", MachineActionRequest{MachineID: machineID}, &result); err != nil {
+		return "", err
+	}
+
+	return result.Message, nil
+}
+
+// GetArenaMachine returns the active release-arena machine, or nil if none is running
+func (c *Client) GetArenaMachine(ctx context.Context) (*ActiveMachineInfo, error) {
+	var result ActiveMachineResponse
+	if err := c.GetJSON(ctx, "/release_arena/active", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Info, nil
+}
+
+// GetConnectionStatus returns the account's VPN and Pwnbox connections
+func (c *Client) GetConnectionStatus(ctx context.Context) ([]ConnectionStatus, error) {
+	var result []ConnectionStatus
+	if err := c.GetJSON(ctx, "/connection/status", &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetMachineProfile returns the profile of a machine by ID or name
+func (c *Client) GetMachineProfile(ctx context.Context, idOrName string) (*MachineProfile, error) {
+	var result MachineProfileResponse
+	if err := c.GetJSON(ctx, "/machine/profile/"+url.PathEscape(idOrName), &result); err != nil {
+		return nil, err
+	}
+
+	result.Info.AvatarURL = AssetURL(result.Info.Avatar)
+	return &result.Info, nil
+}
+
+// GetMachineReviews returns all user reviews of a machine
+func (c *Client) GetMachineReviews(ctx context.Context, machineID int) ([]MachineReview, error) {
+	var result MachineReviewsResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/machine/reviews/%d", machineID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Message, nil
+}
+
+// GetUnreleasedMachines returns announced machines with the machines they replace
+func (c *Client) GetUnreleasedMachines(ctx context.Context) ([]UnreleasedMachine, error) {
+	var result UnreleasedMachinesResponse
+	if err := c.GetJSON(ctx, "/machine/unreleased", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetChallengeInfo returns the details of a challenge
+func (c *Client) GetChallengeInfo(ctx context.Context, challengeID int) (*ChallengeInfo, error) {
+	var result ChallengeInfoResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/challenge/info/%d", challengeID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Challenge, nil
+}
+
+// GetChallengeActivity returns the recorded solves of a challenge, newest first
+func (c *Client) GetChallengeActivity(ctx context.Context, challengeID int) ([]ChallengeActivity, error) {
+	var result ChallengeActivityResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/challenge/activity/%d", challengeID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Info.Activity, nil
+}
+
+// GetSeasons returns all competitive seasons
+func (c *Client) GetSeasons(ctx context.Context) ([]Season, error) {
+	var result SeasonsResponse
+	if err := c.GetJSON(ctx, "/season/list", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetSeasonRank returns the authenticated user's rank in a season, or nil if unranked
+func (c *Client) GetSeasonRank(ctx context.Context, seasonID int) (*SeasonRank, error) {
+	var result SeasonRankResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/season/user/rank/%d", seasonID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetTracks returns the published tracks
+func (c *Client) GetTracks(ctx context.Context) ([]Track, error) {
+	var result []Track
+	if err := c.GetJSON(ctx, "/tracks", &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetTrack returns a track with its machines and challenges and the
+// authenticated user's completion of each
+func (c *Client) GetTrack(ctx context.Context, trackID int) (*Track, error) {
+	var result Track
+	if err := c.GetJSON(ctx, fmt.Sprintf("/tracks/%d", trackID), &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// EnrollTrack enrolls the authenticated user in a track and returns the API
+// message
+func (c *Client) EnrollTrack(ctx context.Context, trackID int) (string, error) {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.PostJSON(ctx, fmt.Sprintf("/tracks/%d/enroll", trackID), nil, &result); err != nil {
+		return "", err
+	}
+
+	return result.Message, nil
+}
+
+// GetBookmarks returns the machines and challenges the authenticated user
+// has bookmarked
+func (c *Client) GetBookmarks(ctx context.Context) (*Bookmarks, error) {
+	var result BookmarksResponse
+	if err := c.GetJSON(ctx, "/bookmarks", &result); err != nil {
+		return nil, err
+	}
+
+	return &result.Info, nil
+}
+
+// AddBookmark bookmarks a machine or challenge (BookmarkMachine or
+// BookmarkChallenge) and returns the API message
+func (c *Client) AddBookmark(ctx context.Context, itemType string, id int) (string, error) {
+	return c.bookmark(ctx, http.MethodPost, itemType, id)
+}
+
+// RemoveBookmark removes a machine or challenge from the bookmarks and
+// returns the API message
+func (c *Client) RemoveBookmark(ctx context.Context, itemType string, id int) (string, error) {
+	return c.bookmark(ctx, http.MethodDelete, itemType, id)
+}
+
+func (c *Client) bookmark(ctx context.Context, method, itemType string, id int) (string, error) {
+	resp, err := c.Request(ctx, method, fmt.Sprintf("/bookmarks/%s/%d", itemType, id), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.DecodeResponse(resp, &result); err != nil {
+		return "", err
+	}
+
+	return result.Message, nil
+}
+
+// GetNotifications returns the authenticated user's notification inbox,
+// newest first
+func (c *Client) GetNotifications(ctx context.Context) ([]Notification, error) {
+	var result NotificationsResponse
+	if err := c.GetJSON(ctx, "/notifications", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// MarkNotificationsRead marks the given notifications as read and returns
+// the API message
+func (c *Client) MarkNotificationsRead(ctx context.Context, ids []int) (string, error) {
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no notification IDs given")
+	}
+	return c.markNotificationsRead(ctx, "/notifications/read", NotificationReadRequest{IDs: ids})
+}
+
+// MarkAllNotificationsRead marks every notification in the inbox as read and
+// returns the API message
+func (c *Client) MarkAllNotificationsRead(ctx context.Context) (string, error) {
+	return c.markNotificationsRead(ctx, "/notifications/read/all", nil)
+}
+
+func (c *Client) markNotificationsRead(ctx context.Context, endpoint string, body interface{}) (string, error) {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if err := c.PostJSON(ctx, endpoint, body, &result); err != nil {
+		return "", err
+	}
+
+	return result.Message, nil
+}
+
+// GetCertificationExams returns the Academy certification exams available to the user
+func (c *Client) GetCertificationExams(ctx context.Context) ([]CertificationExam, error) {
+	var result CertificationExamsResponse
+	if err := c.GetJSON(ctx, "/exams", &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetPathModules returns the modules of an Academy job-role path with the user's completion
+func (c *Client) GetPathModules(ctx context.Context, pathID int) ([]PathModule, error) {
+	var result PathModulesResponse
+	if err := c.GetJSON(ctx, fmt.Sprintf("/paths/%d/modules", pathID), &result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetSherlockDownloadLink returns a temporary download link for a Sherlock's evidence archive
+func (c *Client) GetSherlockDownloadLink(ctx context.Context, sherlockID string) (*SherlockDownloadLink, error) {
+	var link SherlockDownloadLink
+	if err := c.GetJSON(ctx, "/sherlocks/"+url.PathEscape(sherlockID)+"/download_link", &link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// GetStatusPage fetches the public HTB status page summary. The request is
+// sent without credentials since the status page is a third-party service.
+func (c *Client) GetStatusPage(ctx context.Context) (*StatusPageSummary, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.HTBStatusURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "htb-mcp-server/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	TraceFrom(ctx).record(http.MethodGet, c.config.HTBStatusURL, resp, time.Since(start))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	var summary StatusPageSummary
+	if err := c.DecodeResponse(resp, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
 // HealthCheck verifies the HTB API connection and token validity
 func (c *Client) HealthCheck(ctx context.Context) error {
 	resp, err := c.Get(ctx, "/user/info")
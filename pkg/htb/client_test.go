@@ -0,0 +1,534 @@
+package htb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewClient(&config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     server.URL,
+		RequestTimeout: 5 * time.Second,
+	})
+}
+
+func TestGetJSON(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/machine/active" {
+			t.Errorf("Expected path /machine/active, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer header.payload.signature" {
+			t.Errorf("Expected bearer token, got %s", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3","expires_at":"2024-01-01 12:00:00"}}`))
+	})
+
+	machine, err := client.GetActiveMachine(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if machine == nil || machine.ID != 42 || machine.IP != "10.10.10.3" {
+		t.Fatalf("Unexpected machine: %+v", machine)
+	}
+
+	expires, err := machine.ExpiresTime()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing expiry: %v", err)
+	}
+	if !expires.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected expiry: %v", expires)
+	}
+}
+
+func TestGetJSONNoActiveMachine(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":null}`))
+	})
+
+	machine, err := client.GetActiveMachine(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if machine != nil {
+		t.Errorf("Expected no active machine, got %+v", machine)
+	}
+}
+
+func TestDecodeResponseAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"Incorrect flag!"}`))
+	})
+
+	var result map[string]interface{}
+	err := client.PostJSON(context.Background(), "/machine/own", FlagSubmissionRequest{Flag: "x"}, &result)
+
+	var apiErr *HTBAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected HTBAPIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", apiErr.StatusCode)
+	}
+	if apiErr.Message != "Incorrect flag!" {
+		t.Errorf("Expected API message to be used, got %q", apiErr.Message)
+	}
+}
+
+func TestMaintenanceError(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		retry   bool
+	}{
+		{
+			name: "503",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "600")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"message":"Service Unavailable"}`))
+			},
+			retry: true,
+		},
+		{
+			name: "html",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte("<!DOCTYPE html><html><body>We'll be back soon</body></html>"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.handler)
+
+			_, err := client.GetUserInfo(context.Background())
+
+			var maintErr *MaintenanceError
+			if !errors.As(err, &maintErr) {
+				t.Fatalf("Expected MaintenanceError, got %v", err)
+			}
+			if (maintErr.RetryAfter != nil) != tt.retry {
+				t.Errorf("Expected retry after set: %v, got %v", tt.retry, maintErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestHTMLClientErrorIsNotMaintenance(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<!DOCTYPE html><html><body>Attention Required! | Cloudflare</body></html>"))
+	})
+
+	_, err := client.GetUserInfo(context.Background())
+
+	var maintErr *MaintenanceError
+	if errors.As(err, &maintErr) {
+		t.Fatalf("Expected an HTML 403 not to be reported as maintenance, got %v", err)
+	}
+	var apiErr *HTBAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a 403 HTBAPIError, got %v", err)
+	}
+}
+
+func TestFlexFloat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected FlexFloat
+	}{
+		{`{"rank_ownership": 12.5}`, 12.5},
+		{`{"rank_ownership": "7.25"}`, 7.25},
+		{`{"rank_ownership": ""}`, 0},
+		{`{"rank_ownership": null}`, 0},
+	}
+
+	for _, tt := range tests {
+		var profile UserProfile
+		if err := json.Unmarshal([]byte(tt.input), &profile); err != nil {
+			t.Errorf("Unexpected error for %s: %v", tt.input, err)
+			continue
+		}
+		if profile.RankOwnership != tt.expected {
+			t.Errorf("Expected %v for %s, got %v", tt.expected, tt.input, profile.RankOwnership)
+		}
+	}
+}
+
+func TestPerceivedDifficulty(t *testing.T) {
+	var listing []interface{}
+	input := `[
+		{"id": 1, "feedbackForChart": {"counterCake": 0, "counterVeryEasy": 2, "counterEasy": 6, "counterTooEasy": 2, "counterMedium": 0, "counterBitHard": 0, "counterHard": 0, "counterTooHard": 0, "counterExHard": 0, "counterBrainFuck": 0}},
+		{"id": 2, "feedbackForChart": {"counterMedium": 1, "counterHard": 1, "counterBrainFuck": 2}},
+		{"id": 3, "feedbackForChart": {}},
+		{"id": 4}
+	]`
+	if err := json.Unmarshal([]byte(input), &listing); err != nil {
+		t.Fatalf("Failed to decode listing: %v", err)
+	}
+
+	AddPerceivedDifficulty(listing)
+
+	perceived := func(i int) *PerceivedDifficulty {
+		p, _ := listing[i].(map[string]interface{})["perceived_difficulty"].(*PerceivedDifficulty)
+		return p
+	}
+	if p := perceived(0); p == nil || p.Score != 3 || p.Label != "Easy" || p.Votes != 10 {
+		t.Errorf("Expected an Easy score of 3 from 10 votes, got %+v", p)
+	}
+	if p := perceived(1); p == nil || p.Score != 8 || p.Label != "Too Hard" || p.Votes != 4 {
+		t.Errorf("Expected a Too Hard score of 8 from 4 votes, got %+v", p)
+	}
+	if perceived(2) != nil || perceived(3) != nil {
+		t.Errorf("Expected no perceived difficulty without votes")
+	}
+}
+
+func TestSubmissionResultUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected SubmissionResult
+	}{
+		{
+			name:     "message only",
+			input:    `{"message":"Lame user is now owned."}`,
+			expected: SubmissionResult{Success: true, Message: "Lame user is now owned."},
+		},
+		{
+			name:     "string success with points",
+			input:    `{"success":"1","message":"Congratulations","points":"20"}`,
+			expected: SubmissionResult{Success: true, Message: "Congratulations", PointsAwarded: 20},
+		},
+		{
+			name:     "error status in body",
+			input:    `{"status":400,"message":"Incorrect flag!"}`,
+			expected: SubmissionResult{Success: false, Message: "Incorrect flag!"},
+		},
+		{
+			name:     "first blood",
+			input:    `{"success":true,"message":"Owned","points_awarded":30,"first_blood":true}`,
+			expected: SubmissionResult{Success: true, Message: "Owned", PointsAwarded: 30, FirstBlood: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result SubmissionResult
+			if err := json.Unmarshal([]byte(tt.input), &result); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDownloadOmitsToken(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header, got %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Write([]byte("PK"))
+	})
+
+	data, contentType, err := client.Download(context.Background(), client.baseURL+"/evidence.zip")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if string(data) != "PK" || contentType != "application/zip" {
+		t.Errorf("Unexpected download: %q (%s)", data, contentType)
+	}
+}
+
+func TestAssetURL(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"", ""},
+		{"/storage/avatars/lame.png", "https://www.hackthebox.com/storage/avatars/lame.png"},
+		{"storage/avatars/lame.png", "https://www.hackthebox.com/storage/avatars/lame.png"},
+		{"https://cdn.example.com/lame.png", "https://cdn.example.com/lame.png"},
+	}
+
+	for _, tt := range tests {
+		if got := AssetURL(tt.path); got != tt.expected {
+			t.Errorf("AssetURL(%q) = %q, expected %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"Too Many Attempts."}`))
+	})
+
+	var result map[string]interface{}
+	if err := client.GetJSON(context.Background(), "/machine/active", &result); err == nil {
+		t.Fatal("Expected an error for a 429 response")
+	}
+
+	status := client.RateLimitStatus()
+	if status.Limit == nil || *status.Limit != 60 {
+		t.Errorf("Expected limit 60, got %v", status.Limit)
+	}
+	if status.Remaining == nil || *status.Remaining != 0 {
+		t.Errorf("Expected remaining 0, got %v", status.Remaining)
+	}
+	if len(status.Recent429s) != 1 {
+		t.Errorf("Expected 1 recent 429, got %d", len(status.Recent429s))
+	}
+	if status.RetryAfter == nil || time.Until(*status.RetryAfter) > 30*time.Second {
+		t.Errorf("Expected retry after within 30s, got %v", status.RetryAfter)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/search/fetch" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"info":null}`))
+	})
+
+	ctx, trace := WithTrace(context.Background())
+	client.GetActiveMachine(ctx)
+	client.Get(ctx, "/search/fetch?query=secret")
+	trace.CacheHit()
+
+	summary := trace.Summary()
+	if summary.Requests != 2 || summary.CacheHits != 1 || summary.RateLimited != 1 {
+		t.Errorf("Unexpected trace summary: %+v", summary)
+	}
+	if call := summary.Calls[1]; call.Endpoint != "/search/fetch" || call.Status != http.StatusTooManyRequests {
+		t.Errorf("Expected the query string to be dropped, got %+v", call)
+	}
+
+	// Requests without a trace are not recorded anywhere
+	if TraceFrom(context.Background()) != nil {
+		t.Error("Expected no trace on a plain context")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := NewThrottle(1)
+	if delay := throttle.Reserve(); delay != 0 {
+		t.Errorf("Expected the first request to proceed, got a %v wait", delay)
+	}
+	if delay := throttle.Reserve(); delay <= 0 || delay > time.Minute {
+		t.Errorf("Expected the second request to wait for the next minute, got %v", delay)
+	}
+
+	backoff := NewThrottle(100)
+	backoff.Backoff(time.Now().Add(time.Hour))
+	if delay := backoff.Reserve(); delay < 59*time.Minute {
+		t.Errorf("Expected requests to be held for the backoff, got %v", delay)
+	}
+}
+
+func TestSchemaDrift(t *testing.T) {
+	body := `{"info":{"id":42,"name":"Lame","legacy":true}}`
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+
+	var reported []SchemaDrift
+	client.OnSchemaDrift(func(drift SchemaDrift) {
+		reported = append(reported, drift)
+	})
+
+	var result struct {
+		Info struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+			IP   string `json:"ip"`
+			OS   string `json:"os,omitempty"`
+		} `json:"info"`
+	}
+
+	// The first response sets the baseline of fields the model ignores
+	if err := client.GetJSON(context.Background(), "/machine/profile/42", &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reported) != 1 || len(reported[0].MissingFields) != 1 || reported[0].MissingFields[0] != "info.ip" {
+		t.Fatalf("Expected info.ip to be reported missing, got %+v", reported)
+	}
+	if reported[0].Endpoint != "/machine/profile/{id}" || len(reported[0].UnknownFields) != 0 {
+		t.Errorf("Unexpected drift report: %+v", reported[0])
+	}
+
+	// Repeated drift is counted but reported only once
+	if err := client.GetJSON(context.Background(), "/machine/profile/7", &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reported) != 1 {
+		t.Errorf("Expected repeated drift not to be reported again, got %d reports", len(reported))
+	}
+
+	// Fields appearing after the baseline are reported as new
+	body = `{"info":{"id":42,"name":"Lame","ip":"10.10.10.3","legacy":true,"ipv6":"dead:beef::1"}}`
+	if err := client.GetJSON(context.Background(), "/machine/profile/42", &result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reported) != 2 || len(reported[1].UnknownFields) != 1 || reported[1].UnknownFields[0] != "info.ipv6" {
+		t.Errorf("Expected info.ipv6 to be reported as new, got %+v", reported)
+	}
+
+	drifts := client.SchemaDrift()
+	if len(drifts) != 1 || drifts[0].Occurrences != 3 {
+		t.Errorf("Expected one drift record seen 3 times, got %+v", drifts)
+	}
+}
+
+func TestSchemaDriftWithParsing(t *testing.T) {
+	body := `{"info":[]}`
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+
+	// Every subscriber is told, until it unsubscribes
+	var first, second []SchemaDrift
+	unsubscribe := client.OnSchemaDrift(func(drift SchemaDrift) {
+		first = append(first, drift)
+	})
+	client.OnSchemaDrift(func(drift SchemaDrift) {
+		second = append(second, drift)
+	})
+
+	// A response without the extracted field is reported missing
+	if _, err := client.GetWithParsing(context.Background(), "/season/42/rewards", "data"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("Expected both subscribers to be told, got %+v and %+v", first, second)
+	}
+	if first[0].Endpoint != "/season/{id}/rewards" || len(first[0].MissingFields) != 1 || first[0].MissingFields[0] != "data" {
+		t.Errorf("Expected data to be reported missing, got %+v", first[0])
+	}
+
+	unsubscribe()
+	if _, err := client.GetWithParsing(context.Background(), "/season/42/rewards/v2", "data"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(first) != 1 || len(second) != 2 {
+		t.Errorf("Expected only the remaining subscriber to be told, got %d and %d reports", len(first), len(second))
+	}
+
+	// Responses carrying the field are not drift
+	body = `{"data":[]}`
+	if _, err := client.GetWithParsing(context.Background(), "/season/list", "data"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(second) != 2 {
+		t.Errorf("Expected no drift for a complete response, got %+v", second)
+	}
+}
+
+func TestSchemaDriftBounded(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+
+	// Names in paths are not normalized, so the tracked endpoints are capped
+	for i := 0; i < maxDriftKeys+10; i++ {
+		if _, err := client.GetWithParsing(context.Background(), fmt.Sprintf("/machine/profile/box%d", i), "info"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if drifts := client.SchemaDrift(); len(drifts) != maxDriftKeys {
+		t.Errorf("Expected %d tracked endpoints, got %d", maxDriftKeys, len(drifts))
+	}
+}
+
+func TestLocaleNormalization(t *testing.T) {
+	var acceptLanguage string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptLanguage = r.Header.Get("Accept-Language")
+		w.Write([]byte(`{"info":{"id":1,"name":"Lame","os":"Linux","difficultyText":"Fácil"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     server.URL,
+		RequestTimeout: 5 * time.Second,
+		Locale:         "es-ES",
+	})
+
+	profile, err := client.GetMachineProfile(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if acceptLanguage != "es-ES" {
+		t.Errorf("Expected Accept-Language es-ES, got %q", acceptLanguage)
+	}
+	if profile.DifficultyText != DifficultyEasy {
+		t.Errorf("Expected difficulty Easy, got %q", profile.DifficultyText)
+	}
+
+	tests := []struct {
+		input    string
+		expected ContentStatus
+	}{
+		{`"Retirado"`, StatusRetired},
+		{`"actif"`, StatusActive},
+		{`"unreleased"`, "unreleased"},
+		{`null`, ""},
+	}
+	for _, tt := range tests {
+		var status ContentStatus
+		if err := json.Unmarshal([]byte(tt.input), &status); err != nil {
+			t.Errorf("Unexpected error for %s: %v", tt.input, err)
+		}
+		if status != tt.expected {
+			t.Errorf("Expected %s to normalize to %q, got %q", tt.input, tt.expected, status)
+		}
+	}
+}
+
+func TestMarkNotificationsReadRequiresIDs(t *testing.T) {
+	var paths []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"message":"ok"}`))
+	})
+
+	if _, err := client.MarkNotificationsRead(context.Background(), nil); err == nil {
+		t.Errorf("Expected an error marking no notifications as read")
+	}
+	if _, err := client.MarkAllNotificationsRead(context.Background()); err != nil {
+		t.Fatalf("MarkAllNotificationsRead failed: %v", err)
+	}
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "/notifications/read/all") {
+		t.Errorf("Expected only the explicit mark-all request, got %v", paths)
+	}
+}
@@ -0,0 +1,328 @@
+package htb
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaDrift describes how HTB API responses for an endpoint differ from
+// the model they are decoded into. Missing fields decode as zero values, so
+// drift usually shows up as silently empty data.
+type SchemaDrift struct {
+	Endpoint string `json:"endpoint"`
+	Model    string `json:"model"`
+
+	// MissingFields are model fields absent from the response
+	MissingFields []string `json:"missing_fields,omitempty"`
+
+	// UnknownFields are response fields that appeared after the first
+	// response seen for the endpoint and are not part of the model
+	UnknownFields []string `json:"unknown_fields,omitempty"`
+
+	Occurrences int       `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// maxDriftKeys bounds how many endpoint and model pairs are tracked. Numeric
+// ids are normalized out of paths, but names (e.g. /machine/profile/Lame)
+// are not, so the set of paths seen is otherwise unbounded.
+const maxDriftKeys = 512
+
+// driftDetector compares decoded responses with their models
+type driftDetector struct {
+	mu sync.Mutex
+
+	// baselines holds the unknown fields of the first response per endpoint
+	// and model; models are often partial, so only later changes count
+	baselines   map[string]map[string]bool
+	drifts      map[string]*SchemaDrift
+	subscribers map[int]func(SchemaDrift)
+	nextID      int
+}
+
+func newDriftDetector() *driftDetector {
+	return &driftDetector{
+		baselines:   make(map[string]map[string]bool),
+		drifts:      make(map[string]*SchemaDrift),
+		subscribers: make(map[int]func(SchemaDrift)),
+	}
+}
+
+// subscribe adds a callback for newly detected differences and returns a
+// function removing it
+func (d *driftDetector) subscribe(fn func(SchemaDrift)) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := d.nextID
+	d.nextID++
+	d.subscribers[id] = fn
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.subscribers, id)
+	}
+}
+
+// check compares a response body with the model target was decoded into
+func (d *driftDetector) check(endpoint string, body []byte, target interface{}) {
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return
+	}
+
+	missing, unknown := compareSchema(t, raw, "")
+	d.record(endpoint, t.String(), missing, unknown)
+}
+
+// checkField records drift when a response read without a model lacks the
+// field its caller extracts, which would otherwise be returned as nil data
+func (d *driftDetector) checkField(endpoint string, result map[string]interface{}, field string) {
+	if field == "" {
+		return
+	}
+	if _, ok := result[field]; ok {
+		return
+	}
+	d.record(endpoint, "map[string]interface {}", []string{field}, nil)
+}
+
+// record compares a response's missing and unknown fields with the baseline
+// of its endpoint and model, reporting differences not reported before
+func (d *driftDetector) record(endpoint, model string, missing, unknown []string) {
+	endpoint = normalizeEndpoint(endpoint)
+	key := endpoint + " " + model
+
+	d.mu.Lock()
+	baseline, seen := d.baselines[key]
+	if !seen {
+		if len(d.baselines) >= maxDriftKeys {
+			d.mu.Unlock()
+			return
+		}
+		baseline = make(map[string]bool)
+		for _, field := range unknown {
+			baseline[field] = true
+		}
+		d.baselines[key] = baseline
+	}
+
+	var added []string
+	for _, field := range unknown {
+		if !baseline[field] {
+			added = append(added, field)
+		}
+	}
+	if len(missing) == 0 && len(added) == 0 {
+		d.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	drift, exists := d.drifts[key]
+	if !exists {
+		drift = &SchemaDrift{Endpoint: endpoint, Model: model, FirstSeen: now}
+		d.drifts[key] = drift
+	}
+	changed := !exists
+	drift.MissingFields, changed = mergeFields(drift.MissingFields, missing, changed)
+	drift.UnknownFields, changed = mergeFields(drift.UnknownFields, added, changed)
+	drift.Occurrences++
+	drift.LastSeen = now
+
+	report := *drift
+	subscribers := make([]func(SchemaDrift), 0, len(d.subscribers))
+	for _, fn := range d.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	d.mu.Unlock()
+
+	// Report each new difference once rather than on every response
+	if changed {
+		log.Printf("HTB API schema drift on %s (%s): missing %v, new %v", report.Endpoint, report.Model, report.MissingFields, report.UnknownFields)
+		for _, fn := range subscribers {
+			fn(report)
+		}
+	}
+}
+
+// snapshot returns all recorded drift, sorted by endpoint
+func (d *driftDetector) snapshot() []SchemaDrift {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	drifts := make([]SchemaDrift, 0, len(d.drifts))
+	for _, drift := range d.drifts {
+		drifts = append(drifts, *drift)
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Endpoint != drifts[j].Endpoint {
+			return drifts[i].Endpoint < drifts[j].Endpoint
+		}
+		return drifts[i].Model < drifts[j].Model
+	})
+	return drifts
+}
+
+// mergeFields adds new fields to a sorted field list, reporting whether any were added
+func mergeFields(fields, add []string, changed bool) ([]string, bool) {
+	for _, field := range add {
+		i := sort.SearchStrings(fields, field)
+		if i < len(fields) && fields[i] == field {
+			continue
+		}
+		fields = append(fields, "")
+		copy(fields[i+1:], fields[i:])
+		fields[i] = field
+		changed = true
+	}
+	return fields, changed
+}
+
+// normalizeEndpoint replaces numeric path segments with {id} and drops the query
+func normalizeEndpoint(endpoint string) string {
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+	segments := strings.Split(endpoint, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// schemaField is a JSON field of a model struct
+type schemaField struct {
+	typ      reflect.Type
+	optional bool
+}
+
+// compareSchema returns the paths of model fields missing from v and of
+// fields in v the model does not declare
+func compareSchema(t reflect.Type, v interface{}, path string) (missing, unknown []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types with custom decoding accept shapes the struct doesn't describe
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+
+		fields := schemaFields(t)
+		keys := make(map[string]string, len(obj))
+		for key := range obj {
+			keys[strings.ToLower(key)] = key
+		}
+
+		for name, field := range fields {
+			key, present := keys[name]
+			if !present {
+				if !field.optional {
+					missing = append(missing, path+name)
+				}
+				continue
+			}
+			m, u := compareSchema(field.typ, obj[key], path+name+".")
+			missing = append(missing, m...)
+			unknown = append(unknown, u...)
+		}
+		for lower, key := range keys {
+			if _, declared := fields[lower]; !declared {
+				unknown = append(unknown, path+key)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]interface{})
+		if !ok || len(items) == 0 {
+			return nil, nil
+		}
+
+		// Optional values often vary per item, so a field only counts as
+		// missing when no item has it
+		missingCount := make(map[string]int)
+		unknownSet := make(map[string]bool)
+		for _, item := range items {
+			m, u := compareSchema(t.Elem(), item, path+"[].")
+			for _, field := range m {
+				missingCount[field]++
+			}
+			for _, field := range u {
+				unknownSet[field] = true
+			}
+		}
+		for field, count := range missingCount {
+			if count == len(items) {
+				missing = append(missing, field)
+			}
+		}
+		for field := range unknownSet {
+			unknown = append(unknown, field)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	return missing, unknown
+}
+
+// schemaFields returns a struct's JSON fields by lower-cased name, flattening
+// embedded structs the way encoding/json does
+func schemaFields(t reflect.Type) map[string]schemaField {
+	fields := make(map[string]schemaField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for n, sf := range schemaFields(embedded) {
+					fields[n] = sf
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = schemaField{
+			typ:      f.Type,
+			optional: strings.Contains(opts, "omitempty"),
+		}
+	}
+	return fields
+}
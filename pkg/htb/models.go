@@ -1,53 +1,176 @@
 package htb
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Challenge represents a HackTheBox challenge
 type Challenge struct {
-	ID          int      `json:"id"`
-	Name        string   `json:"name"`
-	Category    string   `json:"category"`
-	Difficulty  string   `json:"difficulty"`
-	Points      int      `json:"points"`
-	Solves      int      `json:"solves"`
-	Description string   `json:"description"`
-	Status      string   `json:"status"`
-	Tags        []string `json:"tags,omitempty"`
-	Released    string   `json:"released,omitempty"`
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Category    string          `json:"category"`
+	Difficulty  DifficultyLevel `json:"difficulty"`
+	Points      int             `json:"points"`
+	Solves      int             `json:"solves"`
+	Description string          `json:"description"`
+	Status      ContentStatus   `json:"status"`
+	Tags        []string        `json:"tags,omitempty"`
+	Released    string          `json:"released,omitempty"`
+}
+
+// ChallengeInfo represents the detailed info of a challenge
+type ChallengeInfo struct {
+	ID               int             `json:"id"`
+	Name             string          `json:"name"`
+	Category         string          `json:"category_name"`
+	Difficulty       DifficultyLevel `json:"difficulty"`
+	Points           FlexFloat       `json:"points"`
+	Solves           int             `json:"solves"`
+	Stars            FlexFloat       `json:"stars"`
+	Likes            int             `json:"likes"`
+	Dislikes         int             `json:"dislikes"`
+	ReleaseDate      string          `json:"release_date,omitempty"`
+	Retired          bool            `json:"retired"`
+	FirstBloodUserID int             `json:"first_blood_user_id,omitempty"`
+	FirstBloodUser   string          `json:"first_blood_user,omitempty"`
+	FirstBloodTime   string          `json:"first_blood_time,omitempty"`
+	AuthUserSolve    bool            `json:"authUserSolve"`
+}
+
+// ChallengeInfoResponse represents the response from the challenge info API
+type ChallengeInfoResponse struct {
+	Challenge ChallengeInfo `json:"challenge"`
+}
+
+// ChallengeActivity is a solve of a challenge
+type ChallengeActivity struct {
+	UserID    int    `json:"user_id"`
+	UserName  string `json:"user_name"`
+	Type      string `json:"type"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ChallengeActivityResponse represents the response from the challenge activity API
+type ChallengeActivityResponse struct {
+	Info struct {
+		Activity []ChallengeActivity `json:"activity"`
+	} `json:"info"`
 }
 
 // Machine represents a HackTheBox machine
 type Machine struct {
-	ID         int     `json:"id"`
-	Name       string  `json:"name"`
-	OS         string  `json:"os"`
-	Difficulty string  `json:"difficulty"`
-	IPAddress  string  `json:"ip_address,omitempty"`
-	Status     string  `json:"status"`
-	UserOwned  bool    `json:"user_owned"`
-	RootOwned  bool    `json:"root_owned"`
-	Released   string  `json:"released,omitempty"`
-	Rating     float64 `json:"rating,omitempty"`
-	Active     bool    `json:"active"`
-	Retired    bool    `json:"retired"`
-	ExpiresAt  string  `json:"expires_at,omitempty"`
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	OS         string          `json:"os"`
+	Difficulty DifficultyLevel `json:"difficulty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	Status     ContentStatus   `json:"status"`
+	UserOwned  bool            `json:"user_owned"`
+	RootOwned  bool            `json:"root_owned"`
+	Released   string          `json:"released,omitempty"`
+	Rating     float64         `json:"rating,omitempty"`
+	Active     bool            `json:"active"`
+	Retired    bool            `json:"retired"`
+	ExpiresAt  string          `json:"expires_at,omitempty"`
+}
+
+// DifficultyFeedback is the community difficulty histogram of a machine
+// (feedbackForChart): how many players rated it at each of ten steps from
+// "Piece of Cake" to "Brainfuck"
+type DifficultyFeedback struct {
+	Cake      int `json:"counterCake"`
+	VeryEasy  int `json:"counterVeryEasy"`
+	Easy      int `json:"counterEasy"`
+	TooEasy   int `json:"counterTooEasy"`
+	Medium    int `json:"counterMedium"`
+	BitHard   int `json:"counterBitHard"`
+	Hard      int `json:"counterHard"`
+	TooHard   int `json:"counterTooHard"`
+	ExHard    int `json:"counterExHard"`
+	BrainFuck int `json:"counterBrainFuck"`
+}
+
+// feedbackSteps names the steps of the difficulty histogram, easiest first
+var feedbackSteps = []string{"Piece of Cake", "Very Easy", "Easy", "Not Too Easy", "Medium", "A Bit Hard", "Hard", "Too Hard", "Extremely Hard", "Brainfuck"}
+
+// PerceivedDifficulty summarizes a difficulty histogram. Score is the mean
+// rating from 1 (Piece of Cake) to 10 (Brainfuck), and Label the step
+// nearest to it.
+type PerceivedDifficulty struct {
+	Score float64 `json:"score"`
+	Label string  `json:"label"`
+	Votes int     `json:"votes"`
+}
+
+// Perceived summarizes the histogram, or returns nil if nobody rated the machine
+func (f DifficultyFeedback) Perceived() *PerceivedDifficulty {
+	counts := []int{f.Cake, f.VeryEasy, f.Easy, f.TooEasy, f.Medium, f.BitHard, f.Hard, f.TooHard, f.ExHard, f.BrainFuck}
+
+	votes, sum := 0, 0
+	for i, count := range counts {
+		votes += count
+		sum += count * (i + 1)
+	}
+	if votes == 0 {
+		return nil
+	}
+
+	score := float64(sum) / float64(votes)
+	return &PerceivedDifficulty{
+		Score: math.Round(score*10) / 10,
+		Label: feedbackSteps[int(math.Round(score))-1],
+		Votes: votes,
+	}
+}
+
+// AddPerceivedDifficulty adds a perceived_difficulty summary to every item
+// of a decoded machine listing that carries a feedbackForChart histogram
+func AddPerceivedDifficulty(listing interface{}) {
+	items, _ := listing.([]interface{})
+	for _, item := range items {
+		machine, ok := item.(map[string]interface{})
+		if !ok || machine["feedbackForChart"] == nil {
+			continue
+		}
+
+		data, err := json.Marshal(machine["feedbackForChart"])
+		if err != nil {
+			continue
+		}
+		var feedback DifficultyFeedback
+		if err := json.Unmarshal(data, &feedback); err != nil {
+			continue
+		}
+		if perceived := feedback.Perceived(); perceived != nil {
+			machine["perceived_difficulty"] = perceived
+		}
+	}
 }
 
 // User represents a HackTheBox user profile
 type User struct {
-	ID             int    `json:"id"`
-	Username       string `json:"username"`
-	Points         int    `json:"points"`
-	Rank           string `json:"rank"`
-	Subscription   string `json:"subscription"`
-	SolvesCount    int    `json:"solves_count"`
-	Country        string `json:"country,omitempty"`
-	University     string `json:"university,omitempty"`
-	CanAccessVIP   bool   `json:"canAccessVIP"`
-	IsDedicatedVIP bool   `json:"isDedicatedVip"`
+	ID             int       `json:"id"`
+	Username       string    `json:"username"`
+	Points         int       `json:"points"`
+	Rank           string    `json:"rank"`
+	Subscription   string    `json:"subscription"`
+	SolvesCount    int       `json:"solves_count"`
+	Country        string    `json:"country,omitempty"`
+	University     string    `json:"university,omitempty"`
+	CanAccessVIP   bool      `json:"canAccessVIP"`
+	IsDedicatedVIP bool      `json:"isDedicatedVip"`
+	Team           *UserTeam `json:"team,omitempty"`
+}
+
+// UserTeam is the team a user belongs to
+type UserTeam struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 // SubmissionResult represents the result of a flag submission
@@ -58,6 +181,46 @@ type SubmissionResult struct {
 	FirstBlood    bool   `json:"first_blood,omitempty"`
 }
 
+// UnmarshalJSON decodes the several shapes of flag submission responses
+// returned by the HTB API. A response without an explicit success or error
+// status is treated as successful, since rejected flags use 4xx statuses.
+func (r *SubmissionResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Success       interface{} `json:"success"`
+		Status        interface{} `json:"status"`
+		Message       string      `json:"message"`
+		Points        FlexFloat   `json:"points"`
+		PointsAwarded FlexFloat   `json:"points_awarded"`
+		FirstBlood    bool        `json:"first_blood"`
+		Blood         bool        `json:"blood"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.Success = true
+	switch v := raw.Success.(type) {
+	case bool:
+		r.Success = v
+	case float64:
+		r.Success = v != 0
+	case string:
+		r.Success = v == "1" || strings.EqualFold(v, "true")
+	}
+	if status, ok := raw.Status.(float64); ok && status >= 400 {
+		r.Success = false
+	}
+
+	r.Message = raw.Message
+	r.PointsAwarded = int(raw.PointsAwarded)
+	if r.PointsAwarded == 0 {
+		r.PointsAwarded = int(raw.Points)
+	}
+	r.FirstBlood = raw.FirstBlood || raw.Blood
+
+	return nil
+}
+
 // SearchResult represents search results from HTB API
 type SearchResult struct {
 	Machines   []SearchItem `json:"machines,omitempty"`
@@ -86,9 +249,169 @@ type UserInfoResponse struct {
 	Info User `json:"info"`
 }
 
+// UserProfile represents the public profile of a user
+type UserProfile struct {
+	ID                  int       `json:"id"`
+	Name                string    `json:"name"`
+	Points              int       `json:"points"`
+	Ranking             int       `json:"ranking"`
+	Rank                string    `json:"rank"`
+	NextRank            string    `json:"next_rank"`
+	RankOwnership       FlexFloat `json:"rank_ownership"`
+	RankRequirement     FlexFloat `json:"rank_requirement"`
+	CurrentRankProgress FlexFloat `json:"current_rank_progress"`
+	UserOwns            int       `json:"user_owns"`
+	SystemOwns          int       `json:"system_owns"`
+	UserBloods          int       `json:"user_bloods"`
+	SystemBloods        int       `json:"system_bloods"`
+	Avatar              string    `json:"avatar,omitempty"`
+	Country             string    `json:"country_name,omitempty"`
+}
+
+// UserProfileResponse represents the response from the basic profile API
+type UserProfileResponse struct {
+	Profile UserProfile `json:"profile"`
+}
+
+// ActivityItem represents a single own in a user's activity feed
+type ActivityItem struct {
+	Date           string `json:"date"`
+	DateDiff       string `json:"date_diff,omitempty"`
+	ObjectType     string `json:"object_type"`
+	Type           string `json:"type"`
+	FirstBlood     bool   `json:"first_blood"`
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Points         int    `json:"points"`
+	MachineAvatar  string `json:"machine_avatar,omitempty"`
+	ChallengeCat   string `json:"challenge_category,omitempty"`
+	FlagTitle      string `json:"flag_title,omitempty"`
+	ActivityDetail string `json:"activity_detail,omitempty"`
+}
+
+// UserActivityResponse represents the response from the profile activity API
+type UserActivityResponse struct {
+	Profile struct {
+		Activity []ActivityItem `json:"activity"`
+	} `json:"profile"`
+}
+
+// ProgressCount represents an owned/total counter in progress responses
+type ProgressCount struct {
+	Solved     int       `json:"solved"`
+	Total      int       `json:"total"`
+	Percentage FlexFloat `json:"percentage"`
+}
+
+// OSProgress represents machine completion for one operating system
+type OSProgress struct {
+	Name                 string    `json:"name"`
+	OwnedMachines        int       `json:"owned_machines"`
+	TotalMachines        int       `json:"total_machines"`
+	CompletionPercentage FlexFloat `json:"completion_percentage"`
+}
+
+// MachineProgress represents the user's machine progress by operating system
+type MachineProgress struct {
+	MachineOwns      ProgressCount `json:"machine_owns"`
+	OperatingSystems []OSProgress  `json:"operating_systems"`
+}
+
+// MachineProgressResponse represents the response from the machine progress API
+type MachineProgressResponse struct {
+	Profile MachineProgress `json:"profile"`
+}
+
+// CategoryProgress represents challenge completion for one category
+type CategoryProgress struct {
+	Name                 string    `json:"name"`
+	OwnedFlags           int       `json:"owned_flags"`
+	TotalFlags           int       `json:"total_flags"`
+	CompletionPercentage FlexFloat `json:"completion_percentage"`
+}
+
+// ChallengeProgress represents the user's challenge progress by category
+type ChallengeProgress struct {
+	ChallengeOwns       ProgressCount      `json:"challenge_owns"`
+	ChallengeCategories []CategoryProgress `json:"challenge_categories"`
+}
+
+// ChallengeProgressResponse represents the response from the challenge progress API
+type ChallengeProgressResponse struct {
+	Profile ChallengeProgress `json:"profile"`
+}
+
 // ActiveMachineResponse represents the response from active machine API
 type ActiveMachineResponse struct {
-	Info *Machine `json:"info"`
+	Info *ActiveMachineInfo `json:"info"`
+}
+
+// ActiveMachineInfo represents the currently active machine instance
+type ActiveMachineInfo struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Avatar      string `json:"avatar,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	Type        string `json:"type,omitempty"`
+	LabServer   string `json:"lab_server,omitempty"`
+	VPNServerID int    `json:"vpn_server_id,omitempty"`
+	IP          string `json:"ip,omitempty"`
+	IsSpawning  bool   `json:"isSpawning,omitempty"`
+}
+
+// ResetVote describes a pending community reset vote on a shared server
+type ResetVote struct {
+	MachineID     int    `json:"machine_id"`
+	Votes         int    `json:"votes"`
+	VotesRequired int    `json:"votes_required"`
+	InitiatedBy   string `json:"initiated_by,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"`
+	UserVoted     bool   `json:"user_voted"`
+}
+
+// ResetVoteResponse represents the response from the reset vote status API
+type ResetVoteResponse struct {
+	Info *ResetVote `json:"info"`
+}
+
+// MachineProfile represents the detailed profile of a machine
+type MachineProfile struct {
+	ID                 int             `json:"id"`
+	Name               string          `json:"name"`
+	OS                 string          `json:"os"`
+	Active             bool            `json:"active"`
+	Retired            bool            `json:"retired"`
+	IP                 string          `json:"ip,omitempty"`
+	Avatar             string          `json:"avatar,omitempty"`
+	AvatarURL          string          `json:"avatar_url,omitempty"`
+	Points             int             `json:"points"`
+	Stars              FlexFloat       `json:"stars"`
+	Difficulty         int             `json:"difficulty"`
+	DifficultyText     DifficultyLevel `json:"difficultyText"`
+	Release            string          `json:"release,omitempty"`
+	UserOwnsCount      int             `json:"user_owns_count"`
+	RootOwnsCount      int             `json:"root_owns_count"`
+	AuthUserInUserOwns bool            `json:"authUserInUserOwns"`
+	AuthUserInRootOwns bool            `json:"authUserInRootOwns"`
+	PlayInfo           *PlayInfo       `json:"playInfo,omitempty"`
+}
+
+// PlayInfo describes the user's current instance of a machine
+type PlayInfo struct {
+	IsSpawned  bool   `json:"isSpawned"`
+	IsSpawning bool   `json:"isSpawning"`
+	IsActive   bool   `json:"isActive"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+}
+
+// MachineProfileResponse represents the response from the machine profile API
+type MachineProfileResponse struct {
+	Info MachineProfile `json:"info"`
+}
+
+// ExpiresTime parses the expiry timestamp of the active machine
+func (m *ActiveMachineInfo) ExpiresTime() (time.Time, error) {
+	return ParseTime(m.ExpiresAt)
 }
 
 // FlagSubmissionRequest represents a flag submission request
@@ -99,6 +422,50 @@ type FlagSubmissionRequest struct {
 	Difficulty  string `json:"difficulty,omitempty"`
 }
 
+// FlexFloat decodes numeric fields that the HTB API returns either as
+// numbers or as strings
+type FlexFloat float64
+
+// UnmarshalJSON accepts numbers, numeric strings, empty strings and null
+func (f *FlexFloat) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch v := raw.(type) {
+	case nil:
+		*f = 0
+	case float64:
+		*f = FlexFloat(v)
+	case string:
+		if v == "" {
+			*f = 0
+			return nil
+		}
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid numeric value %q: %w", v, err)
+		}
+		*f = FlexFloat(parsed)
+	default:
+		return fmt.Errorf("unexpected numeric value: %s", string(data))
+	}
+
+	return nil
+}
+
+// ParseTime parses the timestamp formats returned by the HTB API
+func ParseTime(value string) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05.000000Z"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time format: %q", value)
+}
+
 // MachineActionRequest represents a machine action request (start/stop)
 type MachineActionRequest struct {
 	MachineID int `json:"machine_id,omitempty"`
@@ -136,6 +503,97 @@ type ServerStatus struct {
 	HTBAPIStatus string    `json:"htb_api_status"`
 	Uptime       string    `json:"uptime"`
 	Timestamp    time.Time `json:"timestamp"`
+
+	// Client describes the connected MCP client and its declared capabilities
+	Client interface{} `json:"client,omitempty"`
+
+	// LastPing is when the client last sent a ping, if ever
+	LastPing *time.Time `json:"last_ping,omitempty"`
+
+	// SchemaDrift lists HTB API responses that no longer match their models
+	SchemaDrift []SchemaDrift `json:"schema_drift"`
+}
+
+// VPNServer represents a lab VPN server
+type VPNServer struct {
+	ID             int    `json:"id"`
+	FriendlyName   string `json:"friendly_name"`
+	Location       string `json:"location"`
+	Full           bool   `json:"full"`
+	CurrentClients int    `json:"current_clients"`
+}
+
+// VPNServerTier groups the VPN servers of one subscription tier in a region
+type VPNServerTier struct {
+	Location string               `json:"location"`
+	Name     string               `json:"name"`
+	Servers  map[string]VPNServer `json:"servers"`
+}
+
+// VPNServersResponse represents the response from the VPN servers API
+type VPNServersResponse struct {
+	Data struct {
+		Assigned *VPNServer                          `json:"assigned"`
+		Options  map[string]map[string]VPNServerTier `json:"options"`
+	} `json:"data"`
+}
+
+// ConnectionStatus represents one of the account's VPN or Pwnbox connections
+type ConnectionStatus struct {
+	Type                 string `json:"type"`
+	LocationTypeFriendly string `json:"location_type_friendly,omitempty"`
+	Server               struct {
+		ID           int    `json:"id"`
+		Hostname     string `json:"hostname,omitempty"`
+		FriendlyName string `json:"friendly_name,omitempty"`
+	} `json:"server"`
+	Connection *VPNConnection `json:"connection"`
+}
+
+// VPNConnection holds the addresses assigned to an established connection
+type VPNConnection struct {
+	Name          string `json:"name,omitempty"`
+	IP4           string `json:"ip4,omitempty"`
+	IP6           string `json:"ip6,omitempty"`
+	ThroughPwnbox bool   `json:"through_pwnbox,omitempty"`
+}
+
+// PwnboxUsage represents the monthly Pwnbox usage quota in hours
+type PwnboxUsage struct {
+	Used      FlexFloat `json:"used"`
+	Limit     FlexFloat `json:"limit"`
+	Unlimited bool      `json:"unlimited"`
+	ResetsAt  string    `json:"resets_at,omitempty"`
+}
+
+// PwnboxUsageResponse represents the response from the Pwnbox usage API
+type PwnboxUsageResponse struct {
+	Data PwnboxUsage `json:"data"`
+}
+
+// StatusPageSummary represents the public HTB status page summary
+type StatusPageSummary struct {
+	Status struct {
+		Indicator   string `json:"indicator"`
+		Description string `json:"description"`
+	} `json:"status"`
+	Components []StatusComponent `json:"components"`
+	Incidents  []StatusIncident  `json:"incidents"`
+}
+
+// StatusComponent represents one monitored component on the status page
+type StatusComponent struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// StatusIncident represents an unresolved incident on the status page
+type StatusIncident struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Impact    string `json:"impact"`
+	UpdatedAt string `json:"updated_at"`
+	Shortlink string `json:"shortlink,omitempty"`
 }
 
 // Error represents an API error response
@@ -160,16 +618,113 @@ func (e *HTBAPIError) Error() string {
 	return fmt.Sprintf("HTB API error (status %d): %s", e.StatusCode, e.Message)
 }
 
+// MaintenanceError is returned when HTB answers with a 503, or with an HTML
+// server error page instead of JSON, as it does while under maintenance
+type MaintenanceError struct {
+	StatusCode int
+
+	// RetryAfter is when HTB asked to be retried, if it said so
+	RetryAfter *time.Time
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.RetryAfter != nil {
+		return fmt.Sprintf("HackTheBox is under maintenance (status %d), retry after %s", e.StatusCode, e.RetryAfter.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("HackTheBox is under maintenance (status %d)", e.StatusCode)
+}
+
 // DifficultyLevel represents the difficulty levels used by HTB
 type DifficultyLevel string
 
 const (
-	DifficultyEasy   DifficultyLevel = "Easy"
-	DifficultyMedium DifficultyLevel = "Medium"
-	DifficultyHard   DifficultyLevel = "Hard"
-	DifficultyInsane DifficultyLevel = "Insane"
+	DifficultyVeryEasy DifficultyLevel = "Very Easy"
+	DifficultyEasy     DifficultyLevel = "Easy"
+	DifficultyMedium   DifficultyLevel = "Medium"
+	DifficultyHard     DifficultyLevel = "Hard"
+	DifficultyInsane   DifficultyLevel = "Insane"
+)
+
+// localizedDifficulties maps difficulty names, including those returned for
+// non-English account locales, to their canonical English values
+var localizedDifficulties = map[string]DifficultyLevel{
+	"very easy": DifficultyVeryEasy, "muy fácil": DifficultyVeryEasy, "très facile": DifficultyVeryEasy, "sehr einfach": DifficultyVeryEasy, "molto facile": DifficultyVeryEasy, "muito fácil": DifficultyVeryEasy,
+	"easy": DifficultyEasy, "fácil": DifficultyEasy, "facile": DifficultyEasy, "einfach": DifficultyEasy, "leicht": DifficultyEasy,
+	"medium": DifficultyMedium, "medio": DifficultyMedium, "media": DifficultyMedium, "moyen": DifficultyMedium, "moyenne": DifficultyMedium, "mittel": DifficultyMedium, "médio": DifficultyMedium,
+	"hard": DifficultyHard, "difícil": DifficultyHard, "difficile": DifficultyHard, "schwer": DifficultyHard, "schwierig": DifficultyHard,
+	"insane": DifficultyInsane, "insano": DifficultyInsane, "insensé": DifficultyInsane, "démentiel": DifficultyInsane, "wahnsinnig": DifficultyInsane, "folle": DifficultyInsane,
+}
+
+// NormalizeDifficulty returns the canonical English difficulty for a
+// possibly localized name, or the name unchanged if it is not recognized
+func NormalizeDifficulty(name string) DifficultyLevel {
+	if level, ok := localizedDifficulties[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return level
+	}
+	return DifficultyLevel(name)
+}
+
+// UnmarshalJSON decodes a difficulty name, normalizing localized names
+func (d *DifficultyLevel) UnmarshalJSON(data []byte) error {
+	name, err := decodeEnum(data)
+	if err != nil {
+		return err
+	}
+	*d = NormalizeDifficulty(name)
+	return nil
+}
+
+// ContentStatus is the lifecycle status of a machine or challenge
+type ContentStatus string
+
+const (
+	StatusActive  ContentStatus = "active"
+	StatusRetired ContentStatus = "retired"
 )
 
+// localizedStatuses maps status names, including those returned for
+// non-English account locales, to their canonical English values
+var localizedStatuses = map[string]ContentStatus{
+	"active": StatusActive, "activo": StatusActive, "activa": StatusActive, "actif": StatusActive, "aktiv": StatusActive, "attivo": StatusActive, "ativo": StatusActive,
+	"retired": StatusRetired, "retirado": StatusRetired, "retirada": StatusRetired, "retiré": StatusRetired, "retirée": StatusRetired, "im ruhestand": StatusRetired, "ausgemustert": StatusRetired, "ritirato": StatusRetired,
+}
+
+// NormalizeStatus returns the canonical English status for a possibly
+// localized name, or the name unchanged if it is not recognized
+func NormalizeStatus(name string) ContentStatus {
+	if status, ok := localizedStatuses[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return status
+	}
+	return ContentStatus(name)
+}
+
+// UnmarshalJSON decodes a status name, normalizing localized names
+func (s *ContentStatus) UnmarshalJSON(data []byte) error {
+	name, err := decodeEnum(data)
+	if err != nil {
+		return err
+	}
+	*s = NormalizeStatus(name)
+	return nil
+}
+
+// decodeEnum decodes an enum value sent as a string, number or null
+func decodeEnum(data []byte) (string, error) {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return name, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", nil
+	}
+	return fmt.Sprint(v), nil
+}
+
 // MachineType represents the type of machine
 type MachineType string
 
@@ -187,3 +742,223 @@ const (
 	SubscriptionVIP     SubscriptionType = "vip"
 	SubscriptionVIPPlus SubscriptionType = "vip+"
 )
+
+// SherlockDownloadLink is a pre-signed link to a Sherlock's evidence archive
+type SherlockDownloadLink struct {
+	URL       string `json:"url"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// MachineReview represents a user review of a machine
+type MachineReview struct {
+	ID       int       `json:"id"`
+	Stars    FlexFloat `json:"stars"`
+	Headline string    `json:"headline"`
+	Review   string    `json:"review"`
+	Released bool      `json:"released"`
+	Date     string    `json:"created_at,omitempty"`
+	User     struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+// MachineReviewsResponse represents the response from the machine reviews API
+type MachineReviewsResponse struct {
+	Message []MachineReview `json:"message"`
+}
+
+// UnreleasedMachine is an announced machine and the active machine retiring
+// when it is released
+type UnreleasedMachine struct {
+	ID             int              `json:"id"`
+	Name           string           `json:"name"`
+	OS             string           `json:"os"`
+	DifficultyText DifficultyLevel  `json:"difficulty_text"`
+	Release        string           `json:"release"`
+	Retiring       *RetiringMachine `json:"retiring,omitempty"`
+}
+
+// RetiringMachine is an active machine scheduled to retire
+type RetiringMachine struct {
+	ID             int             `json:"id"`
+	Name           string          `json:"name"`
+	OS             string          `json:"os"`
+	DifficultyText DifficultyLevel `json:"difficulty_text"`
+}
+
+// UnreleasedMachinesResponse represents the response from the unreleased machines API
+type UnreleasedMachinesResponse struct {
+	Data []UnreleasedMachine `json:"data"`
+}
+
+// Season represents a competitive HTB season
+type Season struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Subtitle  string `json:"subtitle,omitempty"`
+	Active    bool   `json:"active"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// SeasonsResponse represents the response from the season list API
+type SeasonsResponse struct {
+	Data []Season `json:"data"`
+}
+
+// Track is a curated learning path of machines and challenges
+type Track struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Difficulty  DifficultyLevel `json:"difficulty,omitempty"`
+	Official    bool            `json:"official"`
+	Creator     *TrackCreator   `json:"creator,omitempty"`
+	Enrolled    bool            `json:"enrolled"`
+	Items       []TrackItem     `json:"items,omitempty"`
+}
+
+// TrackCreator is the user or team that published a track
+type TrackCreator struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// TrackItem is a machine or challenge in a track
+type TrackItem struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	Name       string          `json:"name"`
+	Difficulty DifficultyLevel `json:"difficulty,omitempty"`
+	Complete   bool            `json:"complete"`
+}
+
+// Track item types
+const (
+	TrackItemMachine   = "machine"
+	TrackItemChallenge = "challenge"
+)
+
+// Bookmarks are the machines and challenges the user has favorited
+type Bookmarks struct {
+	Machines   []BookmarkedItem `json:"machines"`
+	Challenges []BookmarkedItem `json:"challenges"`
+}
+
+// BookmarkedItem is a favorited machine or challenge
+type BookmarkedItem struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Difficulty DifficultyLevel `json:"difficulty,omitempty"`
+	Retired    bool            `json:"retired"`
+}
+
+// Has reports whether the item of itemType with id is bookmarked
+func (b *Bookmarks) Has(itemType string, id int) bool {
+	items := b.Machines
+	if itemType == BookmarkChallenge {
+		items = b.Challenges
+	}
+	for _, item := range items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Bookmark item types
+const (
+	BookmarkMachine   = "machine"
+	BookmarkChallenge = "challenge"
+)
+
+// BookmarksResponse represents the response from the bookmarks API
+type BookmarksResponse struct {
+	Info Bookmarks `json:"info"`
+}
+
+// Notification is an entry in the user's platform notification inbox, such
+// as respect received, a team invite or a content update
+type Notification struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+	URL       string `json:"url,omitempty"`
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NotificationsResponse represents the response from the notifications API
+type NotificationsResponse struct {
+	Data []Notification `json:"data"`
+}
+
+// NotificationReadRequest marks notifications as read
+type NotificationReadRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// SeasonRank represents the user's standing in a season
+type SeasonRank struct {
+	League            string    `json:"league"`
+	Rank              int       `json:"rank"`
+	TotalRanks        int       `json:"total_ranks"`
+	TotalSeasonPoints FlexFloat `json:"total_season_points"`
+	FlagsToNextRank   struct {
+		Obtained int `json:"obtained"`
+		Total    int `json:"total"`
+	} `json:"flags_to_next_rank"`
+}
+
+// SeasonRankResponse represents the response from the season rank API
+type SeasonRankResponse struct {
+	Data *SeasonRank `json:"data"`
+}
+
+// RankGraph holds a user's points and rank series for a profile graph period
+type RankGraph struct {
+	Labels []string    `json:"labels,omitempty"`
+	Points []FlexFloat `json:"value"`
+	Rank   []FlexFloat `json:"rank"`
+}
+
+// RankGraphResponse represents the response from the profile graph API
+type RankGraphResponse struct {
+	Profile struct {
+		GraphData RankGraph `json:"graphData"`
+	} `json:"profile"`
+}
+
+// CertificationExam represents an HTB Academy certification exam and the user's voucher
+type CertificationExam struct {
+	ID               int    `json:"id"`
+	Name             string `json:"name"`
+	ShortName        string `json:"short_name"`
+	Status           string `json:"status"`
+	VoucherStatus    string `json:"voucher_status"`
+	VoucherExpiresAt string `json:"voucher_expires_at,omitempty"`
+	AttemptsUsed     int    `json:"attempts_used"`
+	AttemptsTotal    int    `json:"attempts_total"`
+	PathID           int    `json:"path_id"`
+}
+
+// CertificationExamsResponse represents the response from the Academy exams API
+type CertificationExamsResponse struct {
+	Data []CertificationExam `json:"data"`
+}
+
+// PathModule represents a module in an Academy job-role path
+type PathModule struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Completed bool      `json:"completed"`
+	Progress  FlexFloat `json:"progress"`
+}
+
+// PathModulesResponse represents the response from the Academy path modules API
+type PathModulesResponse struct {
+	Data []PathModule `json:"data"`
+}
@@ -0,0 +1,194 @@
+package htb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitWindow is how long HTTP 429 responses are remembered
+const rateLimitWindow = 15 * time.Minute
+
+// maxRecentRateLimits bounds how many HTTP 429 responses are remembered
+const maxRecentRateLimits = 20
+
+// RateLimitStatus summarizes the HTB API rate limiting observed by a client
+type RateLimitStatus struct {
+	// Limit, Remaining and ResetAt come from the X-RateLimit-* headers of the
+	// most recent response that carried them
+	Limit     *int       `json:"limit,omitempty"`
+	Remaining *int       `json:"remaining,omitempty"`
+	ResetAt   *time.Time `json:"reset_at,omitempty"`
+
+	// Recent429s lists HTTP 429 responses received within the last 15 minutes
+	Recent429s []time.Time `json:"recent_429s"`
+
+	// RetryAfter is when the API asked to be retried after the latest 429
+	RetryAfter *time.Time `json:"retry_after,omitempty"`
+}
+
+// rateLimitTracker records rate limit headers and 429 responses
+type rateLimitTracker struct {
+	mu     sync.Mutex
+	status RateLimitStatus
+}
+
+// observe records the rate limit information of a response, returning when
+// to retry if it is a 429
+func (t *rateLimitTracker) observe(resp *http.Response, now time.Time) *time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		t.status.Limit = &limit
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		t.status.Remaining = &remaining
+	}
+	if reset, ok := parseReset(resp.Header.Get("X-RateLimit-Reset"), now); ok {
+		t.status.ResetAt = &reset
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.status.Recent429s = append(t.status.Recent429s, now)
+		if len(t.status.Recent429s) > maxRecentRateLimits {
+			t.status.Recent429s = t.status.Recent429s[len(t.status.Recent429s)-maxRecentRateLimits:]
+		}
+
+		retryAfter := now.Add(time.Minute)
+		if parsed, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+			retryAfter = parsed
+		}
+		t.status.RetryAfter = &retryAfter
+		return &retryAfter
+	}
+	return nil
+}
+
+// snapshot returns the current status, dropping 429s outside the window
+func (t *rateLimitTracker) snapshot(now time.Time) RateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := t.status
+	status.Recent429s = []time.Time{}
+	for _, at := range t.status.Recent429s {
+		if now.Sub(at) <= rateLimitWindow {
+			status.Recent429s = append(status.Recent429s, at)
+		}
+	}
+	if status.RetryAfter != nil && status.RetryAfter.Before(now) {
+		status.RetryAfter = nil
+	}
+	return status
+}
+
+// parseReset parses an X-RateLimit-Reset header, given either as a Unix
+// timestamp or as seconds until the reset
+func parseReset(value string, now time.Time) (time.Time, bool) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if n > 1_000_000_000 {
+		return time.Unix(n, 0), true
+	}
+	return now.Add(time.Duration(n) * time.Second), true
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at, true
+	}
+	return time.Time{}, false
+}
+
+// Throttle paces HTB API requests to stay under HTB's rate limits
+type Throttle interface {
+	// Reserve takes a request slot, or returns how long to wait before
+	// trying again
+	Reserve() time.Duration
+
+	// Backoff holds requests until the time HTB asked to be retried after
+	Backoff(until time.Time)
+}
+
+// localThrottle is a Throttle for one process, allowing perMinute requests
+// per clock minute
+type localThrottle struct {
+	perMinute int
+
+	mu      sync.Mutex
+	window  int64
+	count   int
+	backoff time.Time
+}
+
+// NewThrottle creates a Throttle allowing perMinute requests per clock
+// minute within this process
+func NewThrottle(perMinute int) Throttle {
+	return &localThrottle{perMinute: perMinute}
+}
+
+func (t *localThrottle) Reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(t.backoff) {
+		return t.backoff.Sub(now)
+	}
+
+	window := now.Unix() / 60
+	if window != t.window {
+		t.window, t.count = window, 0
+	}
+	if t.count >= t.perMinute {
+		return time.Unix((window+1)*60, 0).Sub(now)
+	}
+	t.count++
+	return 0
+}
+
+func (t *localThrottle) Backoff(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until.After(t.backoff) {
+		t.backoff = until
+	}
+}
+
+// wait blocks until the throttle allows a request or ctx is done, recording
+// the time spent waiting in the context's trace
+func (c *Client) wait(ctx context.Context) error {
+	throttle := c.pacer()
+	if throttle == nil {
+		return nil
+	}
+
+	for {
+		delay := throttle.Reserve()
+		if delay <= 0 {
+			return nil
+		}
+		TraceFrom(ctx).waited(delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting for the HTB API rate limit: %w", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package htb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Trace records the HTB API activity of one operation, such as a tool call.
+// All methods are safe on a nil Trace, which records nothing.
+type Trace struct {
+	mu          sync.Mutex
+	calls       []TracedCall
+	cacheHits   int
+	rateLimited int
+	waiting     time.Duration
+}
+
+// TracedCall is an HTB API request made while tracing
+type TracedCall struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+
+	// Status is the HTTP status, or 0 if the request failed without one
+	Status    int   `json:"status"`
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+// TraceSummary summarizes a trace
+type TraceSummary struct {
+	Requests    int          `json:"htb_requests"`
+	LatencyMS   int64        `json:"htb_latency_ms"`
+	CacheHits   int          `json:"cache_hits"`
+	RateLimited int          `json:"rate_limited"`
+	WaitMS      int64        `json:"rate_limit_wait_ms"`
+	Calls       []TracedCall `json:"endpoints"`
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context recording HTB API requests made with it into
+// the returned trace
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// TraceFrom returns the trace recording ctx, or nil
+func TraceFrom(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// CacheHit records that a cached value was used instead of an API request
+func (t *Trace) CacheHit() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cacheHits++
+}
+
+// waited records time spent waiting for the rate limit
+func (t *Trace) waited(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waiting += d
+}
+
+// record records a request; query strings are dropped as they may carry
+// search terms or signed download tokens
+func (t *Trace) record(method, endpoint string, resp *http.Response, latency time.Duration) {
+	if t == nil {
+		return
+	}
+
+	endpoint, _, _ = strings.Cut(endpoint, "?")
+	call := TracedCall{Method: method, Endpoint: endpoint, LatencyMS: latency.Milliseconds()}
+	if resp != nil {
+		call.Status = resp.StatusCode
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, call)
+	if call.Status == http.StatusTooManyRequests {
+		t.rateLimited++
+	}
+}
+
+// Summary returns the requests and cache hits recorded so far
+func (t *Trace) Summary() TraceSummary {
+	summary := TraceSummary{Calls: []TracedCall{}}
+	if t == nil {
+		return summary
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	summary.Calls = append(summary.Calls, t.calls...)
+	summary.Requests = len(t.calls)
+	summary.CacheHits = t.cacheHits
+	summary.RateLimited = t.rateLimited
+	summary.WaitMS = t.waiting.Milliseconds()
+	for _, call := range t.calls {
+		summary.LatencyMS += call.LatencyMS
+	}
+	return summary
+}
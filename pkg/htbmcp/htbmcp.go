@@ -0,0 +1,127 @@
+// Package htbmcp exposes the HackTheBox MCP server as a library so other Go
+// programs can embed the HTB toolset, run it over custom transports and
+// register their own tools next to the built-in ones.
+package htbmcp
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/NoASLR/htb-mcp-server/internal/server"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// Tool is implemented by tools registered with the server
+type Tool interface {
+	Name() string
+	Description() string
+	Schema() mcp.ToolSchema
+	Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error)
+}
+
+// Transport carries newline-delimited JSON-RPC messages: the server reads
+// requests from it and writes responses and notifications to it. A net.Conn
+// satisfies Transport.
+type Transport interface {
+	io.Reader
+	io.Writer
+}
+
+// readWriter joins a separate reader and writer into a Transport
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+// Stdio returns a transport on the process's standard input and output
+func Stdio() Transport {
+	return readWriter{Reader: os.Stdin, Writer: os.Stdout}
+}
+
+// Pipe returns a transport reading requests from r and writing responses to w
+func Pipe(r io.Reader, w io.Writer) Transport {
+	return readWriter{Reader: r, Writer: w}
+}
+
+// Option customizes a Server
+type Option func(*settings)
+
+type settings struct {
+	client    *htb.Client
+	transport Transport
+	tools     []Tool
+}
+
+// WithHTBClient injects the HTB API client used by all tools
+func WithHTBClient(client *htb.Client) Option {
+	return func(s *settings) { s.client = client }
+}
+
+// WithTransport sets the transport the server speaks MCP over (default: Stdio)
+func WithTransport(transport Transport) Option {
+	return func(s *settings) { s.transport = transport }
+}
+
+// WithTools registers additional tools alongside the built-in HTB tools. A
+// tool named like a built-in or earlier tool is skipped with a warning.
+func WithTools(tools ...Tool) Option {
+	return func(s *settings) { s.tools = append(s.tools, tools...) }
+}
+
+// Server is an embeddable HTB MCP server
+type Server struct {
+	srv *server.Server
+}
+
+// New creates a server from cfg; see config.Load for building cfg from the environment
+func New(cfg *config.Config, opts ...Option) *Server {
+	s := settings{transport: Stdio()}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	srv := server.NewWithOptions(cfg, server.Options{
+		HTBClient: s.client,
+		Input:     s.transport,
+		Output:    s.transport,
+	})
+	for _, tool := range s.tools {
+		srv.RegisterTool(tool)
+	}
+
+	return &Server{srv: srv}
+}
+
+// Start verifies the HTB API connection and begins serving in the background
+func (s *Server) Start(ctx context.Context) error {
+	return s.srv.Start(ctx)
+}
+
+// Serve processes messages until the transport's input is closed, without
+// verifying the HTB API connection first
+func (s *Server) Serve(ctx context.Context) {
+	s.srv.Serve(ctx)
+}
+
+// Handler returns the server's MCP methods, so the HTB toolset can be served
+// by another MCP implementation (e.g. an SDK) instead of the built-in
+// JSON-RPC transport. Call StartBackground before serving requests, and
+// forward notifications/initialized to Initialized: tools are refused until
+// the initialize handshake completes.
+func (s *Server) Handler() mcp.Handler {
+	return s.srv.Handler()
+}
+
+// StartBackground starts background work (scheduler, active machine poller)
+// for servers driven through Handler
+func (s *Server) StartBackground(ctx context.Context) {
+	s.srv.StartBackground(ctx)
+}
+
+// Close stops background work owned by the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
@@ -0,0 +1,137 @@
+package htbmcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/htb"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcptest"
+)
+
+type echoTool struct{}
+
+func (echoTool) Name() string        { return "echo" }
+func (echoTool) Description() string { return "Echo the message argument" }
+func (echoTool) Schema() mcp.ToolSchema {
+	return mcp.ToolSchema{
+		Type: "object",
+		Properties: map[string]mcp.Property{
+			"message": {Type: "string"},
+		},
+	}
+}
+func (echoTool) Execute(ctx context.Context, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	message, _ := args["message"].(string)
+	return &mcp.CallToolResponse{Content: []mcp.Content{mcp.CreateTextContent(message)}}, nil
+}
+
+// shadowTool tries to replace a built-in tool
+type shadowTool struct{ echoTool }
+
+func (shadowTool) Name() string { return "get_time_remaining" }
+
+func TestEmbeddedServer(t *testing.T) {
+	var apiCalls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiCalls.Add(1)
+		w.Write([]byte(`{"info":null}`))
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     "http://unused.invalid",
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Minute,
+	}
+
+	// The injected client points at the stub API rather than cfg.HTBBaseURL
+	clientCfg := *cfg
+	clientCfg.HTBBaseURL = api.URL
+
+	client, transport := mcptest.NewPipe()
+	defer client.Close()
+
+	srv := New(cfg,
+		WithHTBClient(htb.NewClient(&clientCfg)),
+		WithTransport(Pipe(transport.ServerIn, transport.ServerOut)),
+		WithTools(echoTool{}),
+	)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go srv.Serve(ctx)
+
+	if _, err := client.Initialize(ctx, mcp.ClientCapabilities{}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	result, err := client.CallTool(ctx, "echo", map[string]interface{}{"message": "hello"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "hello" {
+		t.Errorf("Unexpected echo result: %+v", result)
+	}
+
+	if _, err := client.CallTool(ctx, "get_time_remaining", map[string]interface{}{}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if apiCalls.Load() == 0 {
+		t.Errorf("Expected built-in tools to use the injected HTB client")
+	}
+}
+
+func TestHandlerWithoutTransport(t *testing.T) {
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     "http://unused.invalid",
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Minute,
+	}
+
+	srv := New(cfg, WithTools(echoTool{}, shadowTool{}))
+	defer srv.Close()
+
+	// An adapter for another MCP implementation calls the handler directly
+	handler := srv.Handler()
+	ctx := context.Background()
+
+	// Tools are refused until the handshake completes, as over JSON-RPC
+	if _, err := handler.CallTool(ctx, &mcp.CallToolRequest{Name: "echo"}); err == nil {
+		t.Error("Expected tools/call before initialize to be refused")
+	}
+
+	initResp, err := handler.Initialize(ctx, &mcp.InitializeRequest{ProtocolVersion: mcp.MCPVersion})
+	if err != nil || initResp.ServerInfo.Name != "htb-mcp-server" {
+		t.Fatalf("Unexpected initialize result: %+v, %v", initResp, err)
+	}
+	if err := handler.Initialized(ctx); err != nil {
+		t.Fatalf("Initialized failed: %v", err)
+	}
+
+	result, err := handler.CallTool(ctx, &mcp.CallToolRequest{Name: "echo", Arguments: map[string]interface{}{"message": "hi"}})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0].Text != "hi" {
+		t.Errorf("Unexpected echo result: %+v", result)
+	}
+
+	list, err := handler.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	for _, tool := range list.Tools {
+		if tool.Name == "get_time_remaining" && tool.Description == (echoTool{}).Description() {
+			t.Errorf("Expected the built-in get_time_remaining to be kept, got %+v", tool)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Handler implements the MCP server methods independently of how messages
+// are encoded and transported. The built-in JSON-RPC implementation serves a
+// Handler through a Router; an adapter for another MCP implementation, such
+// as an SDK, only needs to forward its typed requests to these methods.
+type Handler interface {
+	Initialize(ctx context.Context, req *InitializeRequest) (*InitializeResponse, error)
+	Initialized(ctx context.Context) error
+	ListTools(ctx context.Context) (*ListToolsResponse, error)
+	CallTool(ctx context.Context, req *CallToolRequest) (*CallToolResponse, error)
+	ListResources(ctx context.Context) (*ListResourcesResponse, error)
+	ListResourceTemplates(ctx context.Context) (*ListResourceTemplatesResponse, error)
+	ReadResource(ctx context.Context, req *ReadResourceRequest) (*ReadResourceResponse, error)
+	ListPrompts(ctx context.Context) (*ListPromptsResponse, error)
+	GetPrompt(ctx context.Context, req *GetPromptRequest) (*GetPromptResponse, error)
+}
+
+type ListToolsResponse struct {
+	Tools []Tool `json:"tools"`
+}
+
+// Error makes a JSON-RPC error usable as a Go error. Handlers return one to
+// choose the error code sent to the client; other errors become internal errors.
+func (e *Error) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Data)
+	}
+	return e.Message
+}
+
+// NewError creates a JSON-RPC error
+func NewError(code int, message string, data interface{}) *Error {
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+// MethodFunc handles a request's raw params and returns its result
+type MethodFunc func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+// Method adapts a typed handler to a MethodFunc, decoding params into Req
+func Method[Req any, Resp any](fn func(ctx context.Context, req *Req) (Resp, error)) MethodFunc {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		var req Req
+		if err := DecodeParams(params, &req); err != nil {
+			return nil, NewError(ErrorCodeInvalidParams, "Invalid params", err.Error())
+		}
+		return fn(ctx, &req)
+	}
+}
+
+// NoParams adapts a handler taking no params to a MethodFunc
+func NoParams[Resp any](fn func(ctx context.Context) (Resp, error)) MethodFunc {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return fn(ctx)
+	}
+}
+
+// DecodeParams decodes raw message parameters into target
+func DecodeParams(params json.RawMessage, target interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return fmt.Errorf("missing parameters")
+	}
+
+	if err := json.Unmarshal(params, target); err != nil {
+		return fmt.Errorf("failed to unmarshal params: %w", err)
+	}
+
+	return nil
+}
+
+// Router maps JSON-RPC methods to handlers. New spec methods are added with
+// Handle without touching existing handlers.
+type Router struct {
+	mu      sync.RWMutex
+	methods map[string]MethodFunc
+}
+
+// NewRouter creates a router serving the standard MCP methods from h
+func NewRouter(h Handler) *Router {
+	r := &Router{methods: make(map[string]MethodFunc)}
+
+	r.Handle(MethodInitialize, Method(h.Initialize))
+	r.Handle(MethodNotificationInitialized, func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return nil, h.Initialized(ctx)
+	})
+	r.Handle(MethodListTools, NoParams(h.ListTools))
+	r.Handle(MethodCallTool, Method(h.CallTool))
+	r.Handle(MethodListResources, NoParams(h.ListResources))
+	r.Handle(MethodListResourceTemplates, NoParams(h.ListResourceTemplates))
+	r.Handle(MethodReadResource, Method(h.ReadResource))
+	r.Handle(MethodListPrompts, NoParams(h.ListPrompts))
+	r.Handle(MethodGetPrompt, Method(h.GetPrompt))
+
+	return r
+}
+
+// Handle registers or replaces the handler for a method
+func (r *Router) Handle(method string, fn MethodFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methods[method] = fn
+}
+
+// Dispatch runs the handler for a request or notification and returns the
+// response to send, or nil for notifications, which never get a response
+func (r *Router) Dispatch(ctx context.Context, msg *Message) *Message {
+	r.mu.RLock()
+	fn, ok := r.methods[msg.Method]
+	r.mu.RUnlock()
+
+	if !ok {
+		if msg.ID == nil {
+			return nil
+		}
+		return NewErrorResponse(msg.ID, ErrorCodeMethodNotFound, "Method not found", fmt.Sprintf("Unknown method: %s", msg.Method))
+	}
+
+	result, err := fn(ctx, msg.Params)
+	if msg.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return NewErrorResponse(msg.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data)
+		}
+		return NewErrorResponse(msg.ID, ErrorCodeInternalError, "Internal error", err.Error())
+	}
+
+	return NewResponse(msg.ID, result)
+}
+
+// WithContext returns a Handler that derives each request's context with
+// wrap before calling h, e.g. to attach per-session state for adapters that
+// call the handler directly
+func WithContext(h Handler, wrap func(ctx context.Context) context.Context) Handler {
+	return contextHandler{h: h, wrap: wrap}
+}
+
+type contextHandler struct {
+	h    Handler
+	wrap func(ctx context.Context) context.Context
+}
+
+func (c contextHandler) Initialize(ctx context.Context, req *InitializeRequest) (*InitializeResponse, error) {
+	return c.h.Initialize(c.wrap(ctx), req)
+}
+
+func (c contextHandler) Initialized(ctx context.Context) error {
+	return c.h.Initialized(c.wrap(ctx))
+}
+
+func (c contextHandler) ListTools(ctx context.Context) (*ListToolsResponse, error) {
+	return c.h.ListTools(c.wrap(ctx))
+}
+
+func (c contextHandler) CallTool(ctx context.Context, req *CallToolRequest) (*CallToolResponse, error) {
+	return c.h.CallTool(c.wrap(ctx), req)
+}
+
+func (c contextHandler) ListResources(ctx context.Context) (*ListResourcesResponse, error) {
+	return c.h.ListResources(c.wrap(ctx))
+}
+
+func (c contextHandler) ListResourceTemplates(ctx context.Context) (*ListResourceTemplatesResponse, error) {
+	return c.h.ListResourceTemplates(c.wrap(ctx))
+}
+
+func (c contextHandler) ReadResource(ctx context.Context, req *ReadResourceRequest) (*ReadResourceResponse, error) {
+	return c.h.ReadResource(c.wrap(ctx), req)
+}
+
+func (c contextHandler) ListPrompts(ctx context.Context) (*ListPromptsResponse, error) {
+	return c.h.ListPrompts(c.wrap(ctx))
+}
+
+func (c contextHandler) GetPrompt(ctx context.Context, req *GetPromptRequest) (*GetPromptResponse, error) {
+	return c.h.GetPrompt(c.wrap(ctx), req)
+}
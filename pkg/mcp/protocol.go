@@ -1,13 +1,42 @@
 package mcp
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 )
 
 // Protocol version
 const MCPVersion = "2024-11-05"
 
+// Later protocol versions the server also speaks
+const (
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20250618 = "2025-06-18"
+)
+
+// SupportedVersions lists the protocol versions the server speaks, newest first
+var SupportedVersions = []string{ProtocolVersion20250618, ProtocolVersion20250326, MCPVersion}
+
+// NegotiateVersion returns the protocol version to use with a client that
+// requested the given one: the same version if supported, else the newest
+func NegotiateVersion(requested string) string {
+	for _, version := range SupportedVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return SupportedVersions[0]
+}
+
+// SupportsStructuredContent reports whether a protocol version has
+// structuredContent tool results and resource_link content
+func SupportsStructuredContent(version string) bool {
+	return version >= ProtocolVersion20250618
+}
+
 // Message types
 const (
 	MessageTypeRequest      = "request"
@@ -17,23 +46,184 @@ const (
 
 // Request methods
 const (
-	MethodInitialize    = "initialize"
-	MethodListTools     = "tools/list"
-	MethodCallTool      = "tools/call"
-	MethodListResources = "resources/list"
-	MethodReadResource  = "resources/read"
-	MethodListPrompts   = "prompts/list"
-	MethodGetPrompt     = "prompts/get"
+	MethodInitialize            = "initialize"
+	MethodListTools             = "tools/list"
+	MethodCallTool              = "tools/call"
+	MethodListResources         = "resources/list"
+	MethodReadResource          = "resources/read"
+	MethodListResourceTemplates = "resources/templates/list"
+	MethodSubscribe             = "resources/subscribe"
+	MethodUnsubscribe           = "resources/unsubscribe"
+	MethodListPrompts           = "prompts/list"
+	MethodGetPrompt             = "prompts/get"
+	MethodComplete              = "completion/complete"
+	MethodSetLevel              = "logging/setLevel"
+	MethodPing                  = "ping"
+)
+
+// Server-to-client request methods
+const (
+	MethodCreateMessage = "sampling/createMessage"
+	MethodListRoots     = "roots/list"
+	MethodElicit        = "elicitation/create"
+)
+
+// Notification methods
+const (
+	MethodNotificationInitialized = "notifications/initialized"
+	MethodNotificationMessage     = "notifications/message"
+	MethodNotificationProgress    = "notifications/progress"
+	MethodNotificationCancelled   = "notifications/cancelled"
+
+	MethodNotificationResourceUpdated = "notifications/resources/updated"
+	MethodNotificationToolsChanged    = "notifications/tools/list_changed"
+	MethodNotificationRootsChanged    = "notifications/roots/list_changed"
 )
 
-// Base message structure
+// Log levels used in notifications/message, the syslog severities of RFC 5424
+const (
+	LogLevelDebug     = "debug"
+	LogLevelInfo      = "info"
+	LogLevelNotice    = "notice"
+	LogLevelWarning   = "warning"
+	LogLevelError     = "error"
+	LogLevelCritical  = "critical"
+	LogLevelAlert     = "alert"
+	LogLevelEmergency = "emergency"
+)
+
+// logLevels lists the log levels from least to most severe
+var logLevels = []string{
+	LogLevelDebug, LogLevelInfo, LogLevelNotice, LogLevelWarning,
+	LogLevelError, LogLevelCritical, LogLevelAlert, LogLevelEmergency,
+}
+
+// LogSeverity returns the rank of a log level, higher being more severe, or
+// -1 for an unknown level
+func LogSeverity(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Base message structure. Params and Result hold raw JSON so they are
+// decoded exactly once, directly into the handler's request type.
 type Message struct {
-	JSONRPCVersion string      `json:"jsonrpc"`
-	ID             interface{} `json:"id,omitempty"`
-	Method         string      `json:"method,omitempty"`
-	Params         interface{} `json:"params,omitempty"`
-	Result         interface{} `json:"result,omitempty"`
-	Error          *Error      `json:"error,omitempty"`
+	JSONRPCVersion string          `json:"jsonrpc"`
+	ID             interface{}     `json:"id,omitempty"`
+	Method         string          `json:"method,omitempty"`
+	Params         json.RawMessage `json:"params,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	Error          *Error          `json:"error,omitempty"`
+
+	// marshalErr records a failure to encode params passed to a constructor
+	marshalErr error
+
+	// response records that ParseMessage read a message without a method
+	// but with an id, result or error
+	response bool
+}
+
+// Err returns any error encountered while encoding the message's params
+func (m *Message) Err() error {
+	return m.marshalErr
+}
+
+// IsResponse reports whether a message returned by ParseMessage is shaped
+// like a response, even one ParseMessage rejected. JSON-RPC forbids replying
+// to responses, so malformed ones are dropped rather than answered.
+func (m *Message) IsResponse() bool {
+	return m.response
+}
+
+// IDKey returns a map key for a JSON-RPC request ID that keeps its type,
+// so the number 1 and the string "1" name different requests
+func IDKey(id interface{}) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(data)
+}
+
+// ParseMessage decodes a JSON-RPC 2.0 message, rejecting input that is not a
+// single well-formed request, notification or response. The error carries
+// the code to reply with: ErrorCodeParseError for invalid JSON,
+// ErrorCodeInvalidRequest for malformed messages, and ErrorCodeInvalidParams
+// for params that are not an object. The message is returned with the error
+// whenever its ID could be read, so the reply can carry it.
+func ParseMessage(data []byte) (*Message, *Error) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return nil, NewError(ErrorCodeParseError, "Parse error", err.Error())
+	}
+	if len(data) > 0 && data[0] == '[' {
+		return nil, NewError(ErrorCodeInvalidRequest, "Invalid Request", "batch requests are not supported")
+	}
+
+	var raw struct {
+		JSONRPC *string         `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  *string         `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		Result  json.RawMessage `json:"result"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return nil, NewError(ErrorCodeInvalidRequest, "Invalid Request", "message must be a JSON object")
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, NewError(ErrorCodeInvalidRequest, "Invalid Request", err.Error())
+	}
+
+	// Requests must have a string or number ID; only error responses to
+	// unreadable messages may have a null one
+	msg := &Message{response: raw.Method == nil && (raw.ID != nil || raw.Result != nil || raw.Error != nil)}
+	hasID := raw.ID != nil && string(raw.ID) != "null"
+	if hasID {
+		switch raw.ID[0] {
+		case '"', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			json.Unmarshal(raw.ID, &msg.ID)
+		default:
+			return nil, NewError(ErrorCodeInvalidRequest, "Invalid Request", "id must be a string or number")
+		}
+	}
+
+	if raw.JSONRPC == nil || *raw.JSONRPC != "2.0" {
+		return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", `jsonrpc must be "2.0"`)
+	}
+
+	if raw.Method != nil {
+		switch {
+		case *raw.Method == "":
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "method must not be empty")
+		case raw.ID != nil && !hasID:
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "id must be a string or number")
+		case raw.Result != nil || raw.Error != nil:
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "a request must not carry result or error")
+		case raw.Params != nil && string(raw.Params) != "null" && raw.Params[0] != '{':
+			return msg, NewError(ErrorCodeInvalidParams, "Invalid params", "params must be an object")
+		}
+	} else {
+		switch {
+		case raw.ID == nil:
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "message has neither method nor id")
+		case (raw.Result == nil) == (raw.Error == nil):
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "a response must carry exactly one of result or error")
+		case !hasID && raw.Error == nil:
+			return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", "id must be a string or number")
+		}
+	}
+
+	if err := json.Unmarshal(data, msg); err != nil {
+		return msg, NewError(ErrorCodeInvalidRequest, "Invalid Request", err.Error())
+	}
+	return msg, nil
 }
 
 // Error represents a JSON-RPC error
@@ -60,16 +250,81 @@ type InitializeResponse struct {
 type ClientCapabilities struct {
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
 	Sampling     map[string]interface{} `json:"sampling,omitempty"`
+	Elicitation  map[string]interface{} `json:"elicitation,omitempty"`
+	Roots        *RootsCapability       `json:"roots,omitempty"`
+}
+
+// Client features that may be declared in ClientCapabilities
+const (
+	FeatureSampling    = "sampling"
+	FeatureElicitation = "elicitation"
+	FeatureRoots       = "roots"
+
+	// FeatureStructuredContent is an experimental capability letting clients
+	// on older protocol versions opt in to structured tool results
+	FeatureStructuredContent = "structuredContent"
+)
+
+// Supports reports whether the client declared the given feature. Unknown
+// features are looked up in the experimental capabilities.
+func (c ClientCapabilities) Supports(feature string) bool {
+	switch feature {
+	case FeatureSampling:
+		return c.Sampling != nil
+	case FeatureElicitation:
+		return c.Elicitation != nil
+	case FeatureRoots:
+		return c.Roots != nil
+	default:
+		_, ok := c.Experimental[feature]
+		return ok
+	}
+}
+
+// Features returns the names of all features the client declared
+func (c ClientCapabilities) Features() []string {
+	features := []string{}
+	for _, feature := range []string{FeatureSampling, FeatureElicitation, FeatureRoots} {
+		if c.Supports(feature) {
+			features = append(features, feature)
+		}
+	}
+
+	experimental := make([]string, 0, len(c.Experimental))
+	for feature := range c.Experimental {
+		experimental = append(experimental, feature)
+	}
+	sort.Strings(experimental)
+	return append(features, experimental...)
+}
+
+type RootsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// Root is a workspace location the client lets servers operate in, given as
+// a file:// URI
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// ListRootsResponse is the client's answer to roots/list
+type ListRootsResponse struct {
+	Roots []Root `json:"roots"`
 }
 
 type ServerCapabilities struct {
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
-	Logging      map[string]interface{} `json:"logging,omitempty"`
+	Logging      *LoggingCapability     `json:"logging,omitempty"`
 	Prompts      *PromptsCapability     `json:"prompts,omitempty"`
 	Resources    *ResourcesCapability   `json:"resources,omitempty"`
 	Tools        *ToolsCapability       `json:"tools,omitempty"`
 }
 
+// LoggingCapability advertises logging/setLevel and notifications/message
+type LoggingCapability struct{}
+
 type PromptsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
@@ -95,9 +350,22 @@ type ServerInfo struct {
 
 // Tool definitions
 type Tool struct {
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	InputSchema ToolSchema `json:"inputSchema"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	InputSchema ToolSchema       `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+
+	// OutputSchema describes the structuredContent of the tool's results
+	OutputSchema *ToolSchema `json:"outputSchema,omitempty"`
+}
+
+// ToolAnnotations are hints describing a tool's behavior to clients
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 type ToolSchema struct {
@@ -108,30 +376,77 @@ type ToolSchema struct {
 }
 
 type Property struct {
-	Type        string      `json:"type"`
-	Description string      `json:"description"`
-	Enum        []string    `json:"enum,omitempty"`
-	Default     interface{} `json:"default,omitempty"`
-	Items       *Property   `json:"items,omitempty"`
+	Type        string              `json:"type,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Enum        []string            `json:"enum,omitempty"`
+	Default     interface{}         `json:"default,omitempty"`
+	Items       *Property           `json:"items,omitempty"`
+	Properties  map[string]Property `json:"properties,omitempty"`
+	Required    []string            `json:"required,omitempty"`
 }
 
 // Tool call request and response
 type CallToolRequest struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the _meta object a client may attach to a request
+type RequestMeta struct {
+	// ProgressToken asks for notifications/progress while the request runs;
+	// it is a string or a number
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressToken returns the request's progress token, or nil if none was sent
+func (r *CallToolRequest) ProgressToken() interface{} {
+	if r.Meta == nil {
+		return nil
+	}
+	return r.Meta.ProgressToken
 }
 
 type CallToolResponse struct {
 	Content []Content `json:"content"`
 	IsError bool      `json:"isError,omitempty"`
+
+	// StructuredContent is the result as a JSON object, for clients that
+	// support it; Content still carries it serialized for older clients
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+
+	// Meta carries metadata about the call, such as how it was executed
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// RewriteStructuredContent replaces the structured content with fn applied
+// to its JSON encoding, so filters over result text also cover it. The
+// content is kept as is if fn does not return valid JSON.
+func (r *CallToolResponse) RewriteStructuredContent(fn func(string) string) {
+	if r.StructuredContent == nil {
+		return
+	}
+
+	encoded, err := json.Marshal(r.StructuredContent)
+	if err != nil {
+		return
+	}
+	if rewritten := fn(string(encoded)); json.Valid([]byte(rewritten)) {
+		r.StructuredContent = json.RawMessage(rewritten)
+	}
 }
 
 // Content types
 type Content struct {
-	Type     string `json:"type"`
-	Text     string `json:"text,omitempty"`
-	Data     string `json:"data,omitempty"`
-	MimeType string `json:"mimeType,omitempty"`
+	Type     string           `json:"type"`
+	Text     string           `json:"text,omitempty"`
+	Data     string           `json:"data,omitempty"`
+	MimeType string           `json:"mimeType,omitempty"`
+	Resource *ResourceContent `json:"resource,omitempty"`
+
+	// URI and Name identify the resource of a resource_link
+	URI  string `json:"uri,omitempty"`
+	Name string `json:"name,omitempty"`
 }
 
 // Resource definitions
@@ -142,10 +457,36 @@ type Resource struct {
 	MimeType    string `json:"mimeType,omitempty"`
 }
 
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourcesResponse struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ListResourceTemplatesResponse struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
 type ReadResourceRequest struct {
 	URI string `json:"uri"`
 }
 
+// SubscribeRequest is the params of resources/subscribe and resources/unsubscribe
+type SubscribeRequest struct {
+	URI string `json:"uri"`
+}
+
+// ResourceUpdatedNotification tells the client a subscribed resource changed
+// and should be read again
+type ResourceUpdatedNotification struct {
+	URI string `json:"uri"`
+}
+
 type ReadResourceResponse struct {
 	Contents []ResourceContent `json:"contents"`
 }
@@ -157,21 +498,175 @@ type ResourceContent struct {
 	Blob     string `json:"blob,omitempty"`
 }
 
+// Prompt definitions
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type ListPromptsResponse struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+type GetPromptRequest struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type GetPromptResponse struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// Completion definitions
+type CompleteRequest struct {
+	Ref      CompleteReference `json:"ref"`
+	Argument CompleteArgument  `json:"argument"`
+}
+
+// Reference types of a completion request
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+)
+
+// CompleteReference identifies the prompt (by name) or resource template
+// (by URI) whose argument is being completed
+type CompleteReference struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompleteArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CompleteResponse struct {
+	Completion Completion `json:"completion"`
+}
+
+// Completion holds at most MaxCompletionValues suggestions
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore,omitempty"`
+}
+
+// MaxCompletionValues is the most values a completion response may carry
+const MaxCompletionValues = 100
+
+// Sampling definitions
+type SamplingMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+type CreateMessageRequest struct {
+	Messages       []SamplingMessage `json:"messages"`
+	SystemPrompt   string            `json:"systemPrompt,omitempty"`
+	IncludeContext string            `json:"includeContext,omitempty"`
+	Temperature    float64           `json:"temperature,omitempty"`
+	MaxTokens      int               `json:"maxTokens"`
+}
+
+type CreateMessageResponse struct {
+	Role       string  `json:"role"`
+	Content    Content `json:"content"`
+	Model      string  `json:"model"`
+	StopReason string  `json:"stopReason,omitempty"`
+}
+
+// ElicitRequest asks the client to collect input from the user. The
+// requested schema is a flat object of primitive properties.
+type ElicitRequest struct {
+	Message         string     `json:"message"`
+	RequestedSchema ToolSchema `json:"requestedSchema"`
+}
+
+// Elicitation actions taken by the user
+const (
+	ElicitAccept  = "accept"
+	ElicitDecline = "decline"
+	ElicitCancel  = "cancel"
+)
+
+// ElicitResponse is the user's answer to an elicitation; Content is set
+// when the user accepted
+type ElicitResponse struct {
+	Action  string                 `json:"action"`
+	Content map[string]interface{} `json:"content,omitempty"`
+}
+
+// LoggingMessageNotification is the payload of a notifications/message notification
+type LoggingMessageNotification struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+// SetLevelRequest is the payload of a logging/setLevel request, asking the
+// server to send log messages at level or above
+type SetLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// CancelledNotification is the payload of a notifications/cancelled
+// notification, sent by either side to abandon one of its requests
+type CancelledNotification struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+// ProgressNotification is the payload of a notifications/progress notification.
+// Progress increases with each notification for a token; Total is set when known.
+type ProgressNotification struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
 // Helper functions
 func NewRequest(id interface{}, method string, params interface{}) *Message {
+	raw, err := marshalRaw(params)
 	return &Message{
 		JSONRPCVersion: "2.0",
 		ID:             id,
 		Method:         method,
-		Params:         params,
+		Params:         raw,
+		marshalErr:     err,
 	}
 }
 
+// NewResponse creates a successful response, or an internal error response
+// if the result cannot be encoded
 func NewResponse(id interface{}, result interface{}) *Message {
+	raw, err := marshalRaw(result)
+	if err != nil {
+		return NewErrorResponse(id, ErrorCodeInternalError, "Internal error", err.Error())
+	}
+	if raw == nil {
+		raw = json.RawMessage("null")
+	}
+
 	return &Message{
 		JSONRPCVersion: "2.0",
 		ID:             id,
-		Result:         result,
+		Result:         raw,
 	}
 }
 
@@ -188,13 +683,31 @@ func NewErrorResponse(id interface{}, code int, message string, data interface{}
 }
 
 func NewNotification(method string, params interface{}) *Message {
+	raw, err := marshalRaw(params)
 	return &Message{
 		JSONRPCVersion: "2.0",
 		Method:         method,
-		Params:         params,
+		Params:         raw,
+		marshalErr:     err,
 	}
 }
 
+// marshalRaw encodes v as raw JSON, leaving nil values unset
+func marshalRaw(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal params: %w", err)
+	}
+	return data, nil
+}
+
 // Error codes
 const (
 	ErrorCodeParseError     = -32700
@@ -202,6 +715,12 @@ const (
 	ErrorCodeMethodNotFound = -32601
 	ErrorCodeInvalidParams  = -32602
 	ErrorCodeInternalError  = -32603
+
+	// MCP-specific error codes
+	ErrorCodeResourceNotFound = -32002
+
+	// Server error codes
+	ErrorCodeRateLimited = -32000
 )
 
 // CreateTextContent creates a text content object
@@ -225,3 +744,105 @@ func CreateJSONContent(data interface{}) (Content, error) {
 		MimeType: "application/json",
 	}, nil
 }
+
+// CreateStructuredResponse creates a tool response carrying data as
+// structuredContent, with its JSON as text for clients that don't read it
+func CreateStructuredResponse(data interface{}) (*CallToolResponse, error) {
+	content, err := CreateJSONContent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CallToolResponse{
+		Content:           []Content{content},
+		StructuredContent: data,
+	}, nil
+}
+
+// CreateEmbeddedResourceContent creates a content object embedding the
+// contents of a resource, as returned by resources/read
+func CreateEmbeddedResourceContent(resource ResourceContent) Content {
+	return Content{
+		Type:     "resource",
+		Resource: &resource,
+	}
+}
+
+// CreateJSONResourceContent creates an embedded resource content object
+// carrying data as JSON text
+func CreateJSONResourceContent(uri string, data interface{}) (Content, error) {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return Content{}, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	return CreateEmbeddedResourceContent(ResourceContent{
+		URI:      uri,
+		MimeType: "application/json",
+		Text:     string(jsonData),
+	}), nil
+}
+
+// CreateBlobResourceContent creates an embedded resource content object
+// carrying base64-encoded binary data
+func CreateBlobResourceContent(uri, mimeType string, data []byte) Content {
+	return CreateEmbeddedResourceContent(ResourceContent{
+		URI:      uri,
+		MimeType: mimeType,
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	})
+}
+
+// CreateResourceLinkContent creates a link to a resource the client can read
+// with resources/read
+func CreateResourceLinkContent(uri, name, mimeType string) Content {
+	return Content{
+		Type:     "resource_link",
+		URI:      uri,
+		Name:     name,
+		MimeType: mimeType,
+	}
+}
+
+// CreateImageContent creates an image content object carrying base64-encoded image data
+func CreateImageContent(mimeType string, data []byte) Content {
+	return Content{
+		Type:     "image",
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: mimeType,
+	}
+}
+
+// IsBinary reports whether the content carries base64-encoded data, as image
+// content or an embedded blob resource
+func (c Content) IsBinary() bool {
+	return c.Data != "" || (c.Resource != nil && c.Resource.Blob != "")
+}
+
+// Bytes returns the decoded data of image content or an embedded blob
+// resource, or the text of text content and embedded text resources
+func (c Content) Bytes() ([]byte, error) {
+	switch {
+	case c.Data != "":
+		return decodeBase64(c.Data)
+	case c.Resource != nil:
+		return c.Resource.Bytes()
+	}
+	return []byte(c.Text), nil
+}
+
+// Bytes returns the decoded blob of a binary resource, or its text
+func (r ResourceContent) Bytes() ([]byte, error) {
+	if r.Blob != "" {
+		return decodeBase64(r.Blob)
+	}
+	return []byte(r.Text), nil
+}
+
+func decodeBase64(data string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	return decoded, nil
+}
@@ -1,8 +1,11 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewRequest(t *testing.T) {
@@ -147,6 +150,90 @@ func TestCreateJSONContent(t *testing.T) {
 	}
 }
 
+func TestCreateBlobResourceContent(t *testing.T) {
+	content := CreateBlobResourceContent("htb://challenge/1/writeup", "application/pdf", []byte("%PDF"))
+
+	if content.Type != "resource" {
+		t.Errorf("Expected type 'resource', got %s", content.Type)
+	}
+
+	if content.Resource == nil {
+		t.Fatalf("Expected resource to be set")
+	}
+
+	if content.Resource.URI != "htb://challenge/1/writeup" {
+		t.Errorf("Expected URI 'htb://challenge/1/writeup', got %s", content.Resource.URI)
+	}
+
+	if content.Resource.Blob != "JVBERg==" {
+		t.Errorf("Expected base64 blob 'JVBERg==', got %s", content.Resource.Blob)
+	}
+}
+
+func TestContentBytes(t *testing.T) {
+	zip := []byte("PK\x03\x04")
+	for _, content := range []Content{
+		CreateBlobResourceContent("htb://challenge/1/files", "application/zip", zip),
+		CreateImageContent("image/png", zip),
+	} {
+		if !content.IsBinary() {
+			t.Errorf("Expected %s content to be binary", content.Type)
+		}
+		data, err := content.Bytes()
+		if err != nil || string(data) != string(zip) {
+			t.Errorf("Expected the original bytes from %s content, got %q, %v", content.Type, data, err)
+		}
+	}
+
+	text := CreateTextContent("hello")
+	if data, err := text.Bytes(); text.IsBinary() || err != nil || string(data) != "hello" {
+		t.Errorf("Expected text content to yield its text, got %q, %v", data, err)
+	}
+
+	corrupt := Content{Type: "resource", Resource: &ResourceContent{URI: "htb://x", Blob: "not base64!"}}
+	if _, err := corrupt.Bytes(); err == nil {
+		t.Errorf("Expected an error decoding an invalid blob")
+	}
+}
+
+func TestCreateJSONResourceContent(t *testing.T) {
+	content, err := CreateJSONResourceContent("htb://challenge/9", map[string]int{"id": 9})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if content.Type != "resource" || content.Resource == nil {
+		t.Fatalf("Expected an embedded resource, got %+v", content)
+	}
+	if content.Resource.MimeType != "application/json" || !strings.Contains(content.Resource.Text, `"id": 9`) {
+		t.Errorf("Expected JSON text, got %+v", content.Resource)
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+	if strings.Contains(string(data), `"blob"`) || !strings.Contains(string(data), `"uri":"htb://challenge/9"`) {
+		t.Errorf("Unexpected encoding %s", data)
+	}
+}
+
+func TestCreateImageContent(t *testing.T) {
+	content := CreateImageContent("image/png", []byte("\x89PNG"))
+
+	if content.Type != "image" {
+		t.Errorf("Expected type 'image', got %s", content.Type)
+	}
+
+	if content.MimeType != "image/png" {
+		t.Errorf("Expected MIME type 'image/png', got %s", content.MimeType)
+	}
+
+	if content.Data != "iVBORw==" {
+		t.Errorf("Expected base64 data 'iVBORw==', got %s", content.Data)
+	}
+}
+
 func TestCreateJSONContentError(t *testing.T) {
 	// Test with data that cannot be marshaled to JSON
 	data := make(chan int) // channels cannot be marshaled to JSON
@@ -211,6 +298,41 @@ func TestToolSchema(t *testing.T) {
 	}
 }
 
+func TestSchemaFor(t *testing.T) {
+	type base struct {
+		ID int `json:"id"`
+	}
+	type item struct {
+		*base
+		Name    string    `json:"name"`
+		Tags    []string  `json:"tags,omitempty"`
+		Seen    time.Time `json:"seen"`
+		Parent  *base     `json:"parent"`
+		private bool
+	}
+
+	schema := SchemaFor(item{})
+	if schema.Type != "object" {
+		t.Errorf("Expected type 'object', got %s", schema.Type)
+	}
+
+	want := map[string]string{"id": "integer", "name": "string", "tags": "array", "seen": "string", "parent": "object"}
+	if len(schema.Properties) != len(want) {
+		t.Errorf("Expected %d properties, got %+v", len(want), schema.Properties)
+	}
+	for name, typ := range want {
+		if schema.Properties[name].Type != typ {
+			t.Errorf("Expected %s to be %s, got %+v", name, typ, schema.Properties[name])
+		}
+	}
+	if schema.Properties["tags"].Items.Type != "string" {
+		t.Errorf("Expected string tag items, got %+v", schema.Properties["tags"].Items)
+	}
+	if strings.Join(schema.Required, ",") != "id,name,seen" {
+		t.Errorf("Expected id, name and seen to be required, got %v", schema.Required)
+	}
+}
+
 func TestMessageSerialization(t *testing.T) {
 	// Test request serialization
 	req := NewRequest(123, MethodListTools, nil)
@@ -261,6 +383,7 @@ func TestConstants(t *testing.T) {
 		ErrorCodeMethodNotFound,
 		ErrorCodeInvalidParams,
 		ErrorCodeInternalError,
+		ErrorCodeResourceNotFound,
 	}
 
 	for _, code := range errorCodes {
@@ -276,6 +399,7 @@ func TestConstants(t *testing.T) {
 		MethodCallTool,
 		MethodListResources,
 		MethodReadResource,
+		MethodListResourceTemplates,
 		MethodListPrompts,
 		MethodGetPrompt,
 	}
@@ -286,3 +410,204 @@ func TestConstants(t *testing.T) {
 		}
 	}
 }
+
+func TestNewResponseMarshalError(t *testing.T) {
+	resp := NewResponse(1, make(chan int))
+
+	if resp.Error == nil {
+		t.Fatalf("Expected error response for unencodable result")
+	}
+
+	if resp.Error.Code != ErrorCodeInternalError {
+		t.Errorf("Expected error code %d, got %d", ErrorCodeInternalError, resp.Error.Code)
+	}
+}
+
+func TestNewRequestMarshalError(t *testing.T) {
+	req := NewRequest(1, MethodCallTool, make(chan int))
+
+	if req.Err() == nil {
+		t.Errorf("Expected marshal error for unencodable params")
+	}
+}
+
+func TestRawParamsFidelity(t *testing.T) {
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"x","arguments":{"id":9007199254740993}}}`), &msg); err != nil {
+		t.Fatalf("Failed to unmarshal message: %v", err)
+	}
+
+	// Params are kept verbatim until decoded by the handler
+	if !strings.Contains(string(msg.Params), "9007199254740993") {
+		t.Errorf("Expected raw params to preserve large integers, got %s", msg.Params)
+	}
+}
+
+func TestClientCapabilitiesSupports(t *testing.T) {
+	var req InitializeRequest
+	if err := json.Unmarshal([]byte(`{"protocolVersion":"2024-11-05","capabilities":{"sampling":{},"roots":{"listChanged":true},"experimental":{"progress":{}}},"clientInfo":{"name":"test","version":"1.0"}}`), &req); err != nil {
+		t.Fatalf("Failed to unmarshal initialize request: %v", err)
+	}
+
+	caps := req.Capabilities
+	if !caps.Supports(FeatureSampling) || !caps.Supports(FeatureRoots) || !caps.Supports("progress") {
+		t.Errorf("Expected sampling, roots and progress to be supported: %+v", caps)
+	}
+	if caps.Supports(FeatureElicitation) {
+		t.Errorf("Expected elicitation to be unsupported")
+	}
+
+	features := strings.Join(caps.Features(), ",")
+	if features != "sampling,roots,progress" {
+		t.Errorf("Expected features sampling,roots,progress, got %s", features)
+	}
+}
+
+type stubHandler struct {
+	Handler
+}
+
+func (stubHandler) ListTools(ctx context.Context) (*ListToolsResponse, error) {
+	return &ListToolsResponse{Tools: []Tool{{Name: "echo"}}}, nil
+}
+
+func (stubHandler) ReadResource(ctx context.Context, req *ReadResourceRequest) (*ReadResourceResponse, error) {
+	return nil, NewError(ErrorCodeResourceNotFound, "Resource not found", req.URI)
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := map[string]string{
+		MCPVersion:              MCPVersion,
+		ProtocolVersion20250618: ProtocolVersion20250618,
+		"2099-01-01":            SupportedVersions[0],
+		"":                      SupportedVersions[0],
+	}
+	for requested, want := range tests {
+		if got := NegotiateVersion(requested); got != want {
+			t.Errorf("NegotiateVersion(%q) = %q, want %q", requested, got, want)
+		}
+	}
+
+	if SupportsStructuredContent(MCPVersion) || !SupportsStructuredContent(ProtocolVersion20250618) {
+		t.Errorf("Expected structured content from %s only", ProtocolVersion20250618)
+	}
+}
+
+func TestRouterDispatch(t *testing.T) {
+	router := NewRouter(stubHandler{})
+	ctx := context.Background()
+
+	resp := router.Dispatch(ctx, NewRequest(1, MethodListTools, nil))
+	if resp.Error != nil || !strings.Contains(string(resp.Result), `"name":"echo"`) {
+		t.Errorf("Unexpected tools/list response: %+v", resp)
+	}
+
+	resp = router.Dispatch(ctx, NewRequest(2, MethodReadResource, ReadResourceRequest{URI: "htb://nope"}))
+	if resp.Error == nil || resp.Error.Code != ErrorCodeResourceNotFound {
+		t.Errorf("Expected resource not found error, got %+v", resp)
+	}
+
+	resp = router.Dispatch(ctx, NewRequest(3, MethodReadResource, nil))
+	if resp.Error == nil || resp.Error.Code != ErrorCodeInvalidParams {
+		t.Errorf("Expected invalid params error, got %+v", resp)
+	}
+
+	resp = router.Dispatch(ctx, NewRequest(4, "bogus/method", nil))
+	if resp.Error == nil || resp.Error.Code != ErrorCodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %+v", resp)
+	}
+
+	if resp := router.Dispatch(ctx, NewNotification("notifications/bogus", nil)); resp != nil {
+		t.Errorf("Expected no response to a notification, got %+v", resp)
+	}
+
+	// New methods can be added without changing the handler
+	router.Handle("ping", func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{}, nil
+	})
+	if resp := router.Dispatch(ctx, NewRequest(5, "ping", nil)); resp.Error != nil || string(resp.Result) != "{}" {
+		t.Errorf("Unexpected ping response: %+v", resp)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		code  int
+		id    interface{}
+	}{
+		{"request", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, 0, float64(1)},
+		{"notification", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, 0, nil},
+		{"response", `{"jsonrpc":"2.0","id":"a","result":{}}`, 0, "a"},
+		{"error response without id", `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`, 0, nil},
+		{"invalid JSON", `{"jsonrpc":`, ErrorCodeParseError, nil},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, ErrorCodeInvalidRequest, nil},
+		{"not an object", `42`, ErrorCodeInvalidRequest, nil},
+		{"missing jsonrpc", `{"id":2,"method":"ping"}`, ErrorCodeInvalidRequest, float64(2)},
+		{"wrong jsonrpc", `{"jsonrpc":"1.0","id":2,"method":"ping"}`, ErrorCodeInvalidRequest, float64(2)},
+		{"boolean id", `{"jsonrpc":"2.0","id":true,"method":"ping"}`, ErrorCodeInvalidRequest, nil},
+		{"object id", `{"jsonrpc":"2.0","id":{},"method":"ping"}`, ErrorCodeInvalidRequest, nil},
+		{"null request id", `{"jsonrpc":"2.0","id":null,"method":"ping"}`, ErrorCodeInvalidRequest, nil},
+		{"empty method", `{"jsonrpc":"2.0","id":3,"method":""}`, ErrorCodeInvalidRequest, float64(3)},
+		{"request with result", `{"jsonrpc":"2.0","id":4,"method":"ping","result":{}}`, ErrorCodeInvalidRequest, float64(4)},
+		{"response without result", `{"jsonrpc":"2.0","id":5}`, ErrorCodeInvalidRequest, float64(5)},
+		{"response with result and error", `{"jsonrpc":"2.0","id":5,"result":{},"error":{"code":1,"message":"x"}}`, ErrorCodeInvalidRequest, float64(5)},
+		{"array params", `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":["x"]}`, ErrorCodeInvalidParams, float64(6)},
+		{"string params", `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":"x"}`, ErrorCodeInvalidParams, float64(7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage([]byte(tt.input))
+			if tt.code == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else if err == nil || err.Code != tt.code {
+				t.Fatalf("Expected error code %d, got %v", tt.code, err)
+			}
+
+			var id interface{}
+			if msg != nil {
+				id = msg.ID
+			}
+			if id != tt.id {
+				t.Errorf("Expected id %v, got %v", tt.id, id)
+			}
+		})
+	}
+}
+
+func TestParseMessageIsResponse(t *testing.T) {
+	tests := []struct {
+		input    string
+		response bool
+	}{
+		{`{"jsonrpc":"2.0","id":"a","result":{}}`, true},
+		{`{"jsonrpc":"2.0","id":5,"result":{},"error":{"code":1,"message":"x"}}`, true},
+		{`{"jsonrpc":"2.0","id":5}`, true},
+		{`{"jsonrpc":"2.0","id":1,"method":"ping"}`, false},
+		{`{"jsonrpc":"2.0","id":4,"method":"ping","result":{}}`, false},
+		{`{"jsonrpc":"2.0"}`, false},
+	}
+
+	for _, tt := range tests {
+		msg, _ := ParseMessage([]byte(tt.input))
+		if msg == nil || msg.IsResponse() != tt.response {
+			t.Errorf("Expected IsResponse %v for %s, got %+v", tt.response, tt.input, msg)
+		}
+	}
+}
+
+func TestIDKey(t *testing.T) {
+	number, _ := ParseMessage([]byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	str, _ := ParseMessage([]byte(`{"jsonrpc":"2.0","id":"1","method":"ping"}`))
+
+	if IDKey(number.ID) == IDKey(str.ID) {
+		t.Errorf("Expected the number 1 and the string \"1\" to have different keys, both got %s", IDKey(number.ID))
+	}
+	if IDKey(number.ID) != IDKey(1) {
+		t.Errorf("Expected a decoded ID to match the ID it was sent as, got %s and %s", IDKey(number.ID), IDKey(1))
+	}
+}
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor derives a tool output schema from the Go type of v, which must be
+// a struct or a pointer to one. Properties follow the JSON encoding of the
+// type: fields are named by their json tags, embedded structs are inlined,
+// and fields without omitempty are required.
+func SchemaFor(v interface{}) ToolSchema {
+	object := propertyFor(reflect.TypeOf(v))
+	return ToolSchema{
+		Type:       "object",
+		Properties: object.Properties,
+		Required:   object.Required,
+	}
+}
+
+// propertyFor returns the schema of a Go type
+func propertyFor(t reflect.Type) Property {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Property{Type: "string"}
+	case t.Kind() == reflect.Struct:
+		object := Property{Type: "object", Properties: make(map[string]Property)}
+		addFields(&object, t)
+		return object
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Property{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Property{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Property{Type: "number"}
+	case reflect.String:
+		return Property{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		items := propertyFor(t.Elem())
+		return Property{Type: "array", Items: &items}
+	case reflect.Map:
+		return Property{Type: "object"}
+	default:
+		// Interfaces and other dynamic values may hold anything
+		return Property{}
+	}
+}
+
+// addFields adds the JSON-encoded fields of struct type t to object
+func addFields(object *Property, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(object, embedded)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		object.Properties[name] = propertyFor(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package mcptest provides an in-memory MCP transport and a minimal client
+// for driving an MCP server programmatically, e.g. in end-to-end tests or
+// when embedding the server in another Go program.
+package mcptest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// ErrClosed is returned for calls made after the transport was closed
+var ErrClosed = errors.New("mcptest: transport closed")
+
+// RequestHandler answers server-initiated requests such as sampling/createMessage
+type RequestHandler func(msg *mcp.Message) (interface{}, *mcp.Error)
+
+// Transport is the server side of an in-memory connection: the server reads
+// requests from ServerIn and writes responses to ServerOut
+type Transport struct {
+	ServerIn  io.Reader
+	ServerOut io.Writer
+}
+
+// Client is the client side of an in-memory MCP connection
+type Client struct {
+	out io.WriteCloser
+	in  io.ReadCloser
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	nextID        int
+	pending       map[string]chan *mcp.Message
+	handler       RequestHandler
+	closed        bool
+	notifications chan *mcp.Message
+}
+
+// NewPipe creates a connected client and server transport pair
+func NewPipe() (*Client, *Transport) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &Client{
+		out:           clientOut,
+		in:            clientIn,
+		pending:       make(map[string]chan *mcp.Message),
+		notifications: make(chan *mcp.Message, 100),
+	}
+	go c.readLoop()
+
+	return c, &Transport{ServerIn: serverIn, ServerOut: serverOut}
+}
+
+// SetRequestHandler sets the handler for server-initiated requests. Without
+// one, such requests are answered with a method-not-found error.
+func (c *Client) SetRequestHandler(handler RequestHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+// Notifications returns the channel of notifications sent by the server.
+// Notifications are dropped if the channel is full.
+func (c *Client) Notifications() <-chan *mcp.Message {
+	return c.notifications
+}
+
+// Call sends a request and decodes the result into result (which may be nil)
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *mcp.Message, 1)
+	c.pending[fmt.Sprint(id)] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, fmt.Sprint(id))
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(mcp.NewRequest(id, method, params)); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		// Tell the server to stop working on the abandoned request
+		c.Notify(mcp.MethodNotificationCancelled, mcp.CancelledNotification{RequestID: id, Reason: ctx.Err().Error()})
+		return ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return ErrClosed
+		}
+		if resp.Error != nil {
+			return &RPCError{Err: *resp.Error}
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+// Notify sends a notification to the server
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.send(mcp.NewNotification(method, params))
+}
+
+// Initialize performs the initialize handshake and sends notifications/initialized
+func (c *Client) Initialize(ctx context.Context, capabilities mcp.ClientCapabilities) (*mcp.InitializeResponse, error) {
+	var result mcp.InitializeResponse
+	err := c.Call(ctx, mcp.MethodInitialize, mcp.InitializeRequest{
+		ProtocolVersion: mcp.MCPVersion,
+		Capabilities:    capabilities,
+		ClientInfo:      mcp.ClientInfo{Name: "mcptest", Version: "1.0.0"},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Notify(mcp.MethodNotificationInitialized, nil); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Ping checks the server is alive
+func (c *Client) Ping(ctx context.Context) error {
+	var result map[string]interface{}
+	if err := c.Call(ctx, mcp.MethodPing, nil, &result); err != nil {
+		return err
+	}
+	if len(result) != 0 {
+		return fmt.Errorf("unexpected ping result %v", result)
+	}
+	return nil
+}
+
+// ListTools returns the tools advertised by the server
+func (c *Client) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	var result struct {
+		Tools []mcp.Tool `json:"tools"`
+	}
+	if err := c.Call(ctx, mcp.MethodListTools, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
+
+// CallTool invokes a tool and returns its result
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResponse, error) {
+	var result mcp.CallToolResponse
+	if err := c.Call(ctx, mcp.MethodCallTool, mcp.CallToolRequest{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReadResource reads a resource by URI
+func (c *Client) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResponse, error) {
+	var result mcp.ReadResourceResponse
+	if err := c.Call(ctx, mcp.MethodReadResource, mcp.ReadResourceRequest{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close closes the connection; the server sees end of input
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	err := c.out.Close()
+	c.in.Close()
+	return err
+}
+
+// send writes a single message to the server
+func (c *Client) send(msg *mcp.Message) error {
+	if err := msg.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(c.out, "%s\n", data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// readLoop dispatches server messages to waiting calls, the request handler and the notification channel
+func (c *Client) readLoop() {
+	scanner := bufio.NewScanner(c.in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var msg mcp.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		switch {
+		case msg.Method == "" && msg.ID != nil:
+			c.mu.Lock()
+			ch, ok := c.pending[fmt.Sprint(msg.ID)]
+			c.mu.Unlock()
+			if !ok {
+				continue
+			}
+			// A duplicated response must not block reading
+			select {
+			case ch <- &msg:
+			default:
+			}
+		case msg.ID != nil:
+			go c.answer(&msg)
+		default:
+			select {
+			case c.notifications <- &msg:
+			default:
+			}
+		}
+	}
+
+	// Wake any calls still waiting for a response
+	c.mu.Lock()
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+// answer responds to a server-initiated request using the request handler
+func (c *Client) answer(msg *mcp.Message) {
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+
+	if handler == nil {
+		c.send(mcp.NewErrorResponse(msg.ID, mcp.ErrorCodeMethodNotFound, "Method not found", msg.Method))
+		return
+	}
+
+	result, rpcErr := handler(msg)
+	if rpcErr != nil {
+		c.send(mcp.NewErrorResponse(msg.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data))
+		return
+	}
+	c.send(mcp.NewResponse(msg.ID, result))
+}
+
+// RPCError is a JSON-RPC error returned by the server
+type RPCError struct {
+	Err mcp.Error
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Err.Code, e.Err.Message)
+}
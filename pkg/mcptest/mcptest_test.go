@@ -0,0 +1,1658 @@
+package mcptest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NoASLR/htb-mcp-server/internal/server"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+// startServer starts a server against a stub HTB API served by handler and
+// returns a client that has completed the initialize handshake
+func startServer(t *testing.T, handler http.HandlerFunc, configure ...func(cfg *config.Config)) *Client {
+	t.Helper()
+
+	client := startUninitialized(t, handler, configure...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Initialize(ctx, mcp.ClientCapabilities{}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return client
+}
+
+// startUninitialized is startServer without the initialize handshake
+func startUninitialized(t *testing.T, handler http.HandlerFunc, configure ...func(cfg *config.Config)) *Client {
+	t.Helper()
+
+	api := httptest.NewServer(handler)
+	t.Cleanup(api.Close)
+
+	cfg := &config.Config{
+		HTBToken:       "header.payload.signature",
+		HTBBaseURL:     api.URL,
+		RequestTimeout: 5 * time.Second,
+		PollInterval:   time.Minute,
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	client, transport := NewPipe()
+	srv := server.NewWithIO(cfg, transport.ServerIn, transport.ServerOut)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go srv.Serve(ctx)
+
+	t.Cleanup(func() {
+		client.Close()
+		cancel()
+		srv.Close()
+	})
+
+	return client
+}
+
+func TestInitializeAndCallTool(t *testing.T) {
+	client := startUninitialized(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Tool calls are refused until the handshake completes
+	_, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Err.Code != mcp.ErrorCodeInvalidRequest {
+		t.Errorf("Expected tools/call before initialize to be refused, got %v", err)
+	}
+
+	resp, err := client.Initialize(ctx, mcp.ClientCapabilities{})
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if resp.ServerInfo.Name != "htb-mcp-server" {
+		t.Errorf("Expected server name htb-mcp-server, got %s", resp.ServerInfo.Name)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) == 0 {
+		t.Fatalf("Expected tools to be listed")
+	}
+
+	result, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if result.IsError || len(result.Content) == 0 {
+		t.Fatalf("Unexpected tool result: %+v", result)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.Call(ctx, "bogus/method", nil, nil)
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Err.Code != mcp.ErrorCodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %v", err)
+	}
+}
+
+func TestMalformedResponseIsNotAnswered(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Catch any reply the server sends to the malformed response
+	replies := make(chan *mcp.Message, 1)
+	client.mu.Lock()
+	client.pending["9"] = replies
+	client.mu.Unlock()
+
+	malformed := &mcp.Message{JSONRPCVersion: "2.0", ID: 9, Result: json.RawMessage(`{}`), Error: &mcp.Error{Code: 1, Message: "x"}}
+	if err := client.send(malformed); err != nil {
+		t.Fatalf("Failed to send response: %v", err)
+	}
+	// Messages are handled in order, so the ping's reply comes after any reply to it
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	select {
+	case reply := <-replies:
+		t.Errorf("Expected no reply to a malformed response, got %+v", reply)
+	default:
+	}
+}
+
+// TestConcurrentRequests drives one session from many goroutines at once;
+// run with -race (make test-race) to check shared state is synchronized
+func TestConcurrentRequests(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.ExecutionMeta = true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 120)
+	for i := 0; i < 20; i++ {
+		wg.Add(6)
+		go func() {
+			defer wg.Done()
+			result, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+			if err == nil && result.IsError {
+				err = fmt.Errorf("get_machine_ip failed: %s", result.Content[0].Text)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			result, err := client.CallTool(ctx, "get_server_status", map[string]interface{}{})
+			if err == nil && result.IsError {
+				err = fmt.Errorf("get_server_status failed: %s", result.Content[0].Text)
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.ListTools(ctx)
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- client.Ping(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.ReadResource(ctx, "htb://machine/active")
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- client.Call(ctx, mcp.MethodListPrompts, nil, &mcp.ListPromptsResponse{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSessionGuards(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":42,"name":"Lame","ip":"10.10.10.3"}}`))
+		case "/machine/profile/7":
+			close(started)
+			<-release
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.MaxRequestsPerMinute = 4
+		cfg.MaxInFlightCalls = 1
+		cfg.MaxResultBytes = 32
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Hold one call in flight while a second is attempted
+	slow := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{"machine_id": 7})
+		slow <- err
+	}()
+	<-started
+
+	result, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+	close(release)
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "at most 1 at once") {
+		t.Fatalf("Expected the concurrent call to be refused, got %+v, %v", result, err)
+	}
+	if err := <-slow; err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	result, err = client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "byte limit") {
+		t.Fatalf("Expected an oversized result to be refused, got %+v, %v", result, err)
+	}
+
+	// The budget of 4 requests per minute, counting initialize, is spent by now
+	err = client.Ping(ctx)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Err.Code != mcp.ErrorCodeRateLimited {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	client := startUninitialized(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":null}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Ping is answered before initialize too
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := client.Initialize(ctx, mcp.ClientCapabilities{}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	result, err := client.CallTool(ctx, "get_server_status", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	var status struct {
+		LastPing *time.Time `json:"last_ping"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.LastPing == nil || time.Since(*status.LastPing) > time.Minute {
+		t.Errorf("Expected a recent last_ping, got %v", status.LastPing)
+	}
+}
+
+func TestRedactedToolResult(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"info":{"id":7,"name":"alice","email":"alice@example.com","last_flag":"HTB{s3cr3t}"},"profile":{"id":7,"name":"alice HTB{s3cr3t}"}}`))
+	}, func(cfg *config.Config) {
+		cfg.Redact = []string{"flags", "emails"}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_user_profile", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) == 0 {
+		t.Fatalf("Unexpected tool result: %+v", result)
+	}
+
+	text := result.Content[0].Text
+	if strings.Contains(text, "alice@example.com") || strings.Contains(text, "s3cr3t") {
+		t.Errorf("Expected email and flag to be redacted, got %s", text)
+	}
+	if !strings.Contains(text, "[REDACTED EMAIL]") || !strings.Contains(text, "HTB{[REDACTED]}") || !strings.Contains(text, "alice") {
+		t.Errorf("Expected redaction markers and other fields intact, got %s", text)
+	}
+
+	// Resources are redacted like tool results
+	resource, err := client.ReadResource(ctx, "htb://user/profile")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if text := resource.Contents[0].Text; strings.Contains(text, "s3cr3t") || !strings.Contains(text, "HTB{[REDACTED]}") {
+		t.Errorf("Expected the flag to be redacted from the resource, got %s", text)
+	}
+}
+
+// machineCatalogAPI serves two pages of active machines and one of retired
+// machines, counting listing requests
+func machineCatalogAPI(listCalls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/paginated/":
+			listCalls.Add(1)
+			if r.URL.Query().Get("page") == "2" {
+				w.Write([]byte(`{"data":[{"id":3,"name":"Cap","os":"Linux","difficultyText":"Easy"}],"meta":{"last_page":2}}`))
+				return
+			}
+			w.Write([]byte(`{"data":[{"id":1,"name":"Lame","os":"Linux","difficultyText":"Easy"},{"id":2,"name":"Blue","os":"Windows","difficultyText":"Easy","feedbackForChart":{"counterEasy":3}}],"meta":{"last_page":2}}`))
+		case "/machine/list/retired/paginated/":
+			listCalls.Add(1)
+			w.Write([]byte(`{"data":[{"id":4,"name":"Legacy","os":"Windows","difficultyText":"Easy","tags":[{"name":"SMB"}]}],"meta":{"last_page":1}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}
+}
+
+func TestExportServesListings(t *testing.T) {
+	var listCalls atomic.Int32
+	client := startServer(t, machineCatalogAPI(&listCalls))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "export_all_machines", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("Export failed: %+v, %v", result, err)
+	}
+	if text := result.Content[0].Text; !strings.Contains(text, `"total": 4`) || !strings.Contains(text, `"active": 3`) {
+		t.Errorf("Unexpected export summary: %s", text)
+	}
+	exportCalls := listCalls.Load()
+
+	result, err = client.CallTool(ctx, "list_machines", map[string]interface{}{"os": "Windows"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if listCalls.Load() != exportCalls {
+		t.Errorf("Expected list_machines to be served from the export")
+	}
+	if len(result.Content) != 2 || !strings.Contains(result.Content[1].Text, "Blue") || strings.Contains(result.Content[1].Text, "Legacy") {
+		t.Errorf("Expected only active Windows machines from the export, got %+v", result.Content)
+	}
+	if !strings.Contains(result.Content[1].Text, `"perceived_difficulty"`) {
+		t.Errorf("Expected perceived difficulty in the exported listing, got %s", result.Content[1].Text)
+	}
+}
+
+func TestIndexedSearchAndCompletion(t *testing.T) {
+	var listCalls atomic.Int32
+	client := startServer(t, machineCatalogAPI(&listCalls))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.CallTool(ctx, "export_all_machines", map[string]interface{}{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	exportCalls := listCalls.Load()
+
+	result, err := client.CallTool(ctx, "search_content", map[string]interface{}{"query": "smb", "type": "machines"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(result.Content) != 2 || !strings.Contains(result.Content[1].Text, "Legacy") || strings.Contains(result.Content[1].Text, "Blue") {
+		t.Errorf("Expected tag search to find Legacy only, got %+v", result.Content)
+	}
+
+	var completion mcp.CompleteResponse
+	err = client.Call(ctx, mcp.MethodComplete, mcp.CompleteRequest{
+		Ref:      mcp.CompleteReference{Type: mcp.RefResource, URI: "htb://machine/{id}"},
+		Argument: mcp.CompleteArgument{Name: "id", Value: "l"},
+	}, &completion)
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if got := strings.Join(completion.Completion.Values, ","); got != "Lame,Legacy" {
+		t.Errorf("Expected completions Lame,Legacy, got %s", got)
+	}
+
+	if listCalls.Load() != exportCalls {
+		t.Errorf("Expected search and completion to be served from the index")
+	}
+}
+
+func TestMachineListingResource(t *testing.T) {
+	var listCalls atomic.Int32
+	client := startServer(t, machineCatalogAPI(&listCalls), func(cfg *config.Config) {
+		cfg.CacheTTL = time.Minute
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		result, err := client.ReadResource(ctx, "htb://machines/active")
+		if err != nil {
+			t.Fatalf("ReadResource failed: %v", err)
+		}
+		if len(result.Contents) != 1 || result.Contents[0].MimeType != "application/json" ||
+			!strings.Contains(result.Contents[0].Text, "Lame") || !strings.Contains(result.Contents[0].Text, "Cap") {
+			t.Fatalf("Expected every page of active machines, got %+v", result.Contents)
+		}
+	}
+	if listCalls.Load() != 2 {
+		t.Errorf("Expected two page requests with the second read cached, got %d", listCalls.Load())
+	}
+}
+
+func TestChallengeProgressResource(t *testing.T) {
+	var progressCalls atomic.Int32
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":42,"name":"alice"}}`))
+		case "/user/profile/progress/challenges/42":
+			progressCalls.Add(1)
+			w.Write([]byte(`{"profile":{"challenge_owns":{"solved":6,"total":30},"challenge_categories":[
+				{"name":"Web","owned_flags":4,"total_flags":10},
+				{"name":"Crypto","owned_flags":0,"total_flags":8},
+				{"name":"Pwn","owned_flags":2,"total_flags":12}]}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.CacheTTL = time.Minute
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var progress struct {
+		UserID  int    `json:"user_id"`
+		Weakest string `json:"weakest"`
+	}
+	for i := 0; i < 2; i++ {
+		result, err := client.ReadResource(ctx, "htb://user/challenge-progress")
+		if err != nil {
+			t.Fatalf("ReadResource failed: %v", err)
+		}
+		if err := json.Unmarshal([]byte(result.Contents[0].Text), &progress); err != nil {
+			t.Fatalf("Failed to parse challenge progress: %v", err)
+		}
+	}
+
+	if progress.UserID != 42 || progress.Weakest != "Crypto" {
+		t.Errorf("Unexpected challenge progress %+v", progress)
+	}
+	if n := progressCalls.Load(); n != 1 {
+		t.Errorf("Expected the second read to be cached, got %d progress requests", n)
+	}
+}
+
+func TestWorkflowPrompts(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/profile/Lame":
+			w.Write([]byte(`{"info":{"id":1,"name":"Lame","os":"Linux","difficultyText":"Easy","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var prompt mcp.GetPromptResponse
+	err := client.Call(ctx, mcp.MethodGetPrompt, mcp.GetPromptRequest{Name: "enumerate_machine", Arguments: map[string]string{"machine_id": "Lame"}}, &prompt)
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if len(prompt.Messages) != 1 || !strings.Contains(prompt.Messages[0].Content.Text, "Lame (Linux, Easy) at 10.10.10.3") {
+		t.Errorf("Expected interpolated machine details, got %+v", prompt.Messages)
+	}
+
+	err = client.Call(ctx, mcp.MethodGetPrompt, mcp.GetPromptRequest{Name: "privesc_checklist", Arguments: map[string]string{"os": "Windows"}}, &prompt)
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if !strings.Contains(prompt.Messages[0].Content.Text, "whoami /priv") {
+		t.Errorf("Expected the Windows checklist, got %+v", prompt.Messages)
+	}
+}
+
+func TestChallengeStats(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge/info/5":
+			w.Write([]byte(`{"challenge":{"id":5,"name":"Emdee","solves":3,"stars":4.5,"likes":9,"dislikes":1,"release_date":"2024-01-01T00:00:00Z","first_blood_user":"alice","first_blood_time":"2024-01-01T02:30:00Z"}}`))
+		case "/challenge/activity/5":
+			w.Write([]byte(`{"info":{"activity":[{"type":"own","created_at":"2024-02-10T00:00:00Z"},{"type":"own","created_at":"2024-01-20T00:00:00Z"},{"type":"blood","created_at":"2024-01-01T02:30:00Z"}]}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_challenge_stats", map[string]interface{}{"challenge_id": 5})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+
+	text := result.Content[0].Text
+	for _, want := range []string{`"period": "2024-01"`, `"cumulative": 3`, `"time_to_blood": "2h30m0s"`, `"like_ratio": 0.9`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in challenge stats, got %s", want, text)
+		}
+	}
+}
+
+func TestFavorites(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bookmarks":
+			w.Write([]byte(`{"info":{"machines":[{"id":7,"name":"Lame","difficulty":"Easy","retired":true}],"challenges":[]}}`))
+		case "/bookmarks/challenge/5", "/bookmarks/machine/7":
+			mu.Lock()
+			changes = append(changes, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			w.Write([]byte(`{"message":"Bookmarks updated"}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "favorite_content", map[string]interface{}{"challenge_id": 5})
+	if err != nil || result.IsError || !strings.Contains(result.Content[0].Text, "Bookmarks updated") {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	// Already a favorite, so nothing is sent
+	result, err = client.CallTool(ctx, "favorite_content", map[string]interface{}{"machine_id": 7})
+	if err != nil || result.IsError || !strings.Contains(result.Content[0].Text, "already a favorite") {
+		t.Errorf("Expected the machine to already be a favorite, got %+v, %v", result, err)
+	}
+	result, err = client.CallTool(ctx, "unfavorite_content", map[string]interface{}{"machine_id": 7})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+
+	mu.Lock()
+	got := strings.Join(changes, ", ")
+	mu.Unlock()
+	if want := "POST /bookmarks/challenge/5, DELETE /bookmarks/machine/7"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	result, err = client.CallTool(ctx, "list_favorites", map[string]interface{}{"type": "machines"})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	text := result.Content[0].Text
+	if !strings.Contains(text, `"name": "Lame"`) || strings.Contains(text, "challenges") {
+		t.Errorf("Expected only favorite machines, got %s", text)
+	}
+
+	result, _ = client.CallTool(ctx, "favorite_content", map[string]interface{}{"machine_id": 7, "challenge_id": 5})
+	if result == nil || !result.IsError {
+		t.Errorf("Expected an error for both a machine and a challenge, got %+v", result)
+	}
+}
+
+func TestNotificationInbox(t *testing.T) {
+	var marked atomic.Value
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/notifications":
+			w.Write([]byte(`{"data":[{"id":3,"type":"respect","title":"alice respected you","read":false},{"id":2,"type":"team_invite","title":"Invited to Pwners","read":false},{"id":1,"type":"content_update","title":"Lame was patched","read":true}]}`))
+		case "/notifications/read", "/notifications/read/all":
+			body, _ := io.ReadAll(r.Body)
+			marked.Store(r.URL.Path + " " + string(body))
+			w.Write([]byte(`{"message":"Notifications marked as read"}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "list_notifications", map[string]interface{}{"type": "Team_Invite"})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	var inbox struct {
+		Unread        int                      `json:"unread"`
+		UnreadByType  map[string]int           `json:"unread_by_type"`
+		Notifications []map[string]interface{} `json:"notifications"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &inbox); err != nil {
+		t.Fatalf("Failed to parse inbox: %v", err)
+	}
+	if inbox.Unread != 2 || inbox.UnreadByType["respect"] != 1 || len(inbox.Notifications) != 1 || inbox.Notifications[0]["id"] != float64(2) {
+		t.Errorf("Expected the unread team invite and unread counts, got %+v", inbox)
+	}
+
+	result, err = client.CallTool(ctx, "mark_notifications_read", map[string]interface{}{"notification_ids": []int{2, 3}})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if got := marked.Load(); got != `/notifications/read {"ids":[2,3]}` {
+		t.Errorf("Expected the IDs to be marked read, got %v", got)
+	}
+
+	result, err = client.CallTool(ctx, "mark_notifications_read", map[string]interface{}{"all": true})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if got := marked.Load(); got != "/notifications/read/all " {
+		t.Errorf("Expected the whole inbox to be marked read, got %v", got)
+	}
+
+	if result, _ := client.CallTool(ctx, "mark_notifications_read", nil); result == nil || !result.IsError {
+		t.Errorf("Expected an error without notification_ids or all, got %+v", result)
+	}
+}
+
+func TestFlagOwnVerification(t *testing.T) {
+	var owned atomic.Bool
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/own":
+			owned.Store(true)
+			w.Write([]byte(`{"success":true,"message":"Lame user is now owned."}`))
+		case "/machine/profile/7":
+			if owned.Load() {
+				w.Write([]byte(`{"info":{"id":7,"name":"Lame","authUserInUserOwns":true}}`))
+				return
+			}
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		case "/user/info":
+			if owned.Load() {
+				w.Write([]byte(`{"info":{"id":1,"name":"tester","points":30}}`))
+				return
+			}
+			w.Write([]byte(`{"info":{"id":1,"name":"tester","points":10}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "submit_user_flag", map[string]interface{}{"machine_id": 7, "flag": "0123456789abcdef0123456789abcdef"})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+
+	text := result.Content[0].Text
+	for _, want := range []string{`"confirmed": true`, `"user_owned": true`, `"points_delta": 20`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in submission result, got %s", want, text)
+		}
+	}
+}
+
+func TestChallengeFlagEmbedsChallenge(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge/own":
+			w.Write([]byte(`{"success":true,"message":"Congratulations"}`))
+		case "/challenge/info/9":
+			w.Write([]byte(`{"challenge":{"id":9,"name":"Baby RE","solves":101,"authUserSolve":true}}`))
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "submit_challenge_flag", map[string]interface{}{"challenge_id": 9, "flag": "HTB{test}"})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+
+	last := result.Content[len(result.Content)-1]
+	if last.Type != "resource" || last.Resource == nil || last.Resource.URI != "htb://challenge/9" {
+		t.Fatalf("Expected an embedded challenge resource, got %+v", result.Content)
+	}
+	if !strings.Contains(last.Resource.Text, `"solves": 101`) {
+		t.Errorf("Expected the challenge details, got %s", last.Resource.Text)
+	}
+
+	// The embedded URI can be read back as a resource
+	resource, err := client.ReadResource(ctx, last.Resource.URI)
+	if err != nil || len(resource.Contents) == 0 || !strings.Contains(resource.Contents[0].Text, "Baby RE") {
+		t.Errorf("Expected to read the embedded resource, got %+v, %v", resource, err)
+	}
+}
+
+func TestFlagQueueDuringOutage(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() && r.URL.Path != "/machine/profile/7" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/machine/own":
+			w.Write([]byte(`{"success":true,"message":"Lame user is now owned."}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.PollInterval = 20 * time.Millisecond
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := map[string]interface{}{"machine_id": 7, "flag": "0123456789abcdef0123456789abcdef", "on_outage": "queue"}
+	result, err := client.CallTool(ctx, "submit_user_flag", args)
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Content[0].Text, "queued as #1") {
+		t.Errorf("Expected the flag to be queued, got %s", result.Content[0].Text)
+	}
+
+	down.Store(false)
+	result, err = client.CallTool(ctx, "retry_queued_flags", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Content[0].Text, "Queued flag #1 for machine 7 was accepted") {
+		t.Errorf("Expected the queued flag to be accepted, got %s", result.Content[0].Text)
+	}
+
+	// Flags queued for automatic retry are submitted once a poll succeeds
+	down.Store(true)
+	args["on_outage"] = "retry"
+	if result, err = client.CallTool(ctx, "submit_user_flag", args); err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	down.Store(false)
+
+	for {
+		select {
+		case msg := <-client.Notifications():
+			if msg.Method == mcp.MethodNotificationMessage && strings.Contains(string(msg.Params), "queued_flag_submitted") {
+				result, err := client.CallTool(ctx, "list_queued_flags", map[string]interface{}{})
+				if err != nil || !strings.Contains(result.Content[0].Text, `"count": 0`) {
+					t.Errorf("Expected an empty queue, got %+v, %v", result, err)
+				}
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the queued flag to be retried")
+		}
+	}
+}
+
+func TestMaintenanceResult(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_user_profile", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "under maintenance") {
+		t.Errorf("Expected maintenance error result, got %+v", result)
+	}
+}
+
+func TestStartMachineProgress(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/play/7":
+			w.Write([]byte(`{"message":"Playing machine."}`))
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3"}}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result mcp.CallToolResponse
+	req := mcp.CallToolRequest{
+		Name:      "start_machine",
+		Arguments: map[string]interface{}{"machine_id": 7, "wait": true},
+		Meta:      &mcp.RequestMeta{ProgressToken: "spawn-7"},
+	}
+	if err := client.Call(ctx, mcp.MethodCallTool, req, &result); err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Content[0].Text, `"ip": "10.10.10.3"`) {
+		t.Errorf("Expected the machine IP in the result, got %s", result.Content[0].Text)
+	}
+
+	for {
+		select {
+		case msg := <-client.Notifications():
+			if msg.Method != mcp.MethodNotificationProgress {
+				continue
+			}
+			var progress mcp.ProgressNotification
+			if err := json.Unmarshal(msg.Params, &progress); err != nil {
+				t.Fatalf("Failed to decode progress: %v", err)
+			}
+			if progress.ProgressToken != "spawn-7" || progress.Progress != progress.Total {
+				t.Errorf("Unexpected progress notification: %+v", progress)
+			}
+			return
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for a progress notification")
+		}
+	}
+}
+
+func TestStructuredContentNegotiation(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/play/7":
+			w.Write([]byte(`{"message":"Playing machine."}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := map[string]interface{}{"machine_id": 7, "wait": false}
+	legacy, err := client.CallTool(ctx, "start_machine", args)
+	if err != nil || legacy.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", legacy, err)
+	}
+	if legacy.StructuredContent != nil || legacy.Content[1].Type != "text" {
+		t.Errorf("Expected plain content before a newer protocol is negotiated, got %+v", legacy)
+	}
+
+	var init mcp.InitializeResponse
+	req := mcp.InitializeRequest{ProtocolVersion: mcp.ProtocolVersion20250618, ClientInfo: mcp.ClientInfo{Name: "test"}}
+	if err := client.Call(ctx, mcp.MethodInitialize, req, &init); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if init.ProtocolVersion != mcp.ProtocolVersion20250618 {
+		t.Errorf("Expected protocol version %s, got %s", mcp.ProtocolVersion20250618, init.ProtocolVersion)
+	}
+
+	rich, err := client.CallTool(ctx, "start_machine", args)
+	if err != nil || rich.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", rich, err)
+	}
+	structured, ok := rich.StructuredContent.(map[string]interface{})
+	if !ok || structured["message"] != "Playing machine." {
+		t.Errorf("Expected structured content, got %+v", rich.StructuredContent)
+	}
+	if rich.Content[1].Type != "resource_link" || rich.Content[1].URI != "htb://machines/7" {
+		t.Errorf("Expected a resource link, got %+v", rich.Content[1])
+	}
+}
+
+func TestOutputSchemas(t *testing.T) {
+	var denied atomic.Bool
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			if denied.Load() {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message":"Forbidden"}`))
+				return
+			}
+			w.Write([]byte(`{"info":{"id":1,"username":"tester","points":10,"avatar":"/a.png"}}`))
+		case "/machine/paginated/":
+			w.Write([]byte(`{"data":[{"id":1,"name":"Lame","os":"Linux","difficultyText":"Easy","feedbackForChart":{"counterCake":1}}]}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	outputSchema := func() *mcp.ToolSchema {
+		tools, err := client.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		for _, tool := range tools {
+			if tool.Name == "get_user_profile" {
+				return tool.OutputSchema
+			}
+		}
+		t.Fatal("get_user_profile not listed")
+		return nil
+	}
+
+	if schema := outputSchema(); schema != nil {
+		t.Errorf("Expected no output schema before a newer protocol is negotiated, got %+v", schema)
+	}
+
+	req := mcp.InitializeRequest{ProtocolVersion: mcp.ProtocolVersion20250618, ClientInfo: mcp.ClientInfo{Name: "test"}}
+	if err := client.Call(ctx, mcp.MethodInitialize, req, nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	schema := outputSchema()
+	if schema == nil || schema.Properties["username"].Type != "string" || schema.Properties["points"].Type != "integer" {
+		t.Fatalf("Expected the user output schema, got %+v", schema)
+	}
+
+	result, err := client.CallTool(ctx, "get_user_profile", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	structured, ok := result.StructuredContent.(map[string]interface{})
+	if !ok || structured["username"] != "tester" || structured["avatar"] != nil {
+		t.Errorf("Expected the typed profile as structured content, got %+v", result.StructuredContent)
+	}
+	if !strings.Contains(result.Content[0].Text, `"avatar"`) {
+		t.Errorf("Expected the full profile as text, got %s", result.Content[0].Text)
+	}
+
+	// A refused request is an error, not an empty typed profile
+	denied.Store(true)
+	if result, err := client.CallTool(ctx, "get_user_profile", map[string]interface{}{}); err != nil || !result.IsError {
+		t.Errorf("Expected a refused profile request to fail, got %+v, %v", result, err)
+	}
+
+	// Listings carry their items typed with the machine model
+	result, err = client.CallTool(ctx, "list_machines", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	listing, _ := result.StructuredContent.(map[string]interface{})
+	items, _ := listing["items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("Expected one machine as structured content, got %+v", result.StructuredContent)
+	}
+	machine, _ := items[0].(map[string]interface{})
+	if machine["name"] != "Lame" || machine["feedbackForChart"] != nil || listing["count"] != 1.0 {
+		t.Errorf("Expected the typed machine listing as structured content, got %+v", result.StructuredContent)
+	}
+}
+
+func TestCancelAbortsToolCall(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan struct{})
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/machine/active" {
+			w.Write([]byte(`{"info":null}`))
+			return
+		}
+		close(started)
+		<-r.Context().Done()
+		close(aborted)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{})
+		errc <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the HTB API request")
+	}
+	cancel()
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the HTB API request to be aborted")
+	}
+}
+
+func TestDashboardFetchesConcurrently(t *testing.T) {
+	var inflight, peak atomic.Int32
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		defer inflight.Add(-1)
+		time.Sleep(50 * time.Millisecond)
+
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1,"name":"tester","points":10}}`))
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3"}}`))
+		case "/season/list":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_dashboard", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+
+	text := result.Content[0].Text
+	for _, want := range []string{`"profile"`, `"active_machine"`, `"season": "failed to get seasons`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in dashboard, got %s", want, text)
+		}
+	}
+	if peak.Load() < 2 {
+		t.Errorf("Expected concurrent HTB API requests, peak was %d", peak.Load())
+	}
+}
+
+func TestTimestampsInTimeZone(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3","expires_at":"2024-01-01 12:00:00"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.TimeZone = time.FixedZone("UTC+2", 2*60*60)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "list_active_instances", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Content[0].Text, `"expires_at": "2024-01-01T14:00:00+02:00"`) {
+		t.Errorf("Expected expiry in the configured time zone, got %s", result.Content[0].Text)
+	}
+}
+
+func TestSubscriptionRequirements(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1,"name":"tester","canAccessVIP":false,"isDedicatedVip":false}}`))
+		case "/challenge/info/3":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Forbidden"}`))
+		case "/challenge/info/4":
+			w.Write([]byte(`{"challenge":{"id":4,"name":"Active","retired":false}}`))
+		case "/challenge/4/start":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Forbidden"}`))
+		case "/challenge/info/5":
+			w.Write([]byte(`{"challenge":{"id":5,"name":"Old","retired":true}}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","retired":true}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The subscription is detected after initialize, annotating the tool list
+	for changed := false; !changed; {
+		select {
+		case msg := <-client.Notifications():
+			changed = msg.Method == mcp.MethodNotificationToolsChanged
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for notifications/tools/list_changed")
+		}
+	}
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	for _, tool := range tools {
+		if tool.Name == "get_challenge_writeup" && !strings.HasPrefix(tool.Description, "[Requires VIP; this account is Free]") {
+			t.Errorf("Expected get_challenge_writeup to be annotated, got %q", tool.Description)
+		}
+	}
+
+	result, err := client.CallTool(ctx, "get_challenge_writeup", map[string]interface{}{"challenge_id": 1})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "require a VIP subscription, but this account is Free") {
+		t.Errorf("Expected a subscription error, got %+v", result)
+	}
+
+	result, err = client.CallTool(ctx, "get_challenge_stats", map[string]interface{}{"challenge_id": 3})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "this account is Free") {
+		t.Errorf("Expected the 403 to name the subscription, got %+v", result)
+	}
+
+	// Tools parsing responses by field also explain a 403
+	result, err = client.CallTool(ctx, "start_challenge", map[string]interface{}{"challenge_id": 4})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "this account is Free") {
+		t.Errorf("Expected the 403 to name the subscription, got %+v", result)
+	}
+
+	// Retired content is refused before calling HTB
+	for name, args := range map[string]map[string]interface{}{
+		"start_machine":            {"machine_id": 7},
+		"start_challenge":          {"challenge_id": 5},
+		"download_challenge_files": {"challenge_id": 5, "inline": true},
+	} {
+		result, err = client.CallTool(ctx, name, args)
+		if err != nil {
+			t.Fatalf("CallTool %s failed: %v", name, err)
+		}
+		if !result.IsError || !strings.Contains(result.Content[0].Text, "require a VIP subscription, but this account is Free") {
+			t.Errorf("Expected %s to need VIP for retired content, got %+v", name, result)
+		}
+	}
+}
+
+func TestExecutionMeta(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.ExecutionMeta = true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var execution struct {
+		Requests  int `json:"htb_requests"`
+		CacheHits int `json:"cache_hits"`
+		Endpoints []struct {
+			Method   string `json:"method"`
+			Endpoint string `json:"endpoint"`
+			Status   int    `json:"status"`
+		} `json:"endpoints"`
+	}
+	for i, wantHits := range []int{0, 1} {
+		result, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{"machine_id": 7})
+		if err != nil || result.IsError {
+			t.Fatalf("CallTool failed: %+v, %v", result, err)
+		}
+		encoded, _ := json.Marshal(result.Meta["execution"])
+		if err := json.Unmarshal(encoded, &execution); err != nil {
+			t.Fatalf("Expected execution metadata, got %+v", result.Meta)
+		}
+		if execution.CacheHits != wantHits || execution.Requests != len(execution.Endpoints) || execution.Requests == 0 {
+			t.Errorf("Call %d: unexpected execution metadata %s", i+1, encoded)
+		}
+	}
+	if call := execution.Endpoints[0]; call.Method != http.MethodGet || call.Status != http.StatusOK {
+		t.Errorf("Expected a successful GET, got %+v", call)
+	}
+}
+
+func TestSamplingTools(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","os":"Linux","difficultyText":"Easy","ip":"10.10.10.3"}}`))
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		case "/user/profile/activity/1":
+			w.Write([]byte(`{"profile":{"activity":[{"date":"2024-01-01","object_type":"machine","type":"root","id":7,"name":"Lame"}]}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hold := make(chan struct{})
+	defer close(hold)
+	client.SetRequestHandler(func(msg *mcp.Message) (interface{}, *mcp.Error) {
+		var req mcp.CreateMessageRequest
+		json.Unmarshal(msg.Params, &req)
+		if strings.Contains(req.Messages[0].Content.Text, "activity feed") {
+			<-hold
+		}
+		return mcp.CreateMessageResponse{Role: "assistant", Content: mcp.CreateTextContent("1. nmap -sC -sV 10.10.10.3"), Model: "test-model"}, nil
+	})
+	// Sent raw, as an empty sampling object is dropped by omitempty
+	initialize := json.RawMessage(`{"protocolVersion":"` + mcp.MCPVersion + `","capabilities":{"sampling":{}},"clientInfo":{"name":"mcptest","version":"1.0.0"}}`)
+	if err := client.Call(ctx, mcp.MethodInitialize, initialize, nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	client.Notify(mcp.MethodNotificationInitialized, nil)
+
+	result, err := client.CallTool(ctx, "plan_recon", map[string]interface{}{"machine_id": 7})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if text := result.Content[0].Text; !strings.Contains(text, "nmap -sC -sV 10.10.10.3") || !strings.Contains(text, `"model": "test-model"`) {
+		t.Errorf("Expected the sampled recon plan, got %s", text)
+	}
+
+	// Abandoning the tool call cancels the sampling request sent to the client
+	callCtx, abandon := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		abandon()
+	}()
+	client.CallTool(callCtx, "summarize_activity", map[string]interface{}{})
+	for {
+		select {
+		case msg := <-client.Notifications():
+			if msg.Method == mcp.MethodNotificationCancelled && strings.Contains(string(msg.Params), "srv-") {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the sampling request to be cancelled")
+		}
+	}
+}
+
+func TestUserAvatar(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		case "/user/profile/basic/1":
+			w.Write([]byte(`{"profile":{"id":1,"name":"tester","rank":"Hacker","avatar":"http://` + r.Host + `/avatars/1.png"}}`))
+		case "/avatars/1.png":
+			w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_user_avatar", map[string]interface{}{})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if len(result.Content) != 2 || result.Content[1].Type != "image" || result.Content[1].MimeType != "image/png" {
+		t.Errorf("Expected the avatar as PNG image content, got %+v", result.Content)
+	}
+}
+
+func TestUserAvatarRejectsNonImage(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		case "/user/profile/basic/1":
+			w.Write([]byte(`{"profile":{"id":1,"name":"tester","avatar":"http://` + r.Host + `/avatars/1.png"}}`))
+		case "/avatars/1.png":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Not found</body></html>"))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_user_avatar", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "is not an image") {
+		t.Errorf("Expected an error for a non-image avatar, got %+v", result)
+	}
+}
+
+func TestTrackProgress(t *testing.T) {
+	var enrolled atomic.Bool
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tracks":
+			w.Write([]byte(`[{"id":4,"name":"Intro to Dante","difficulty":"Easy"},{"id":5,"name":"Pwn Basics","difficulty":"Medium"}]`))
+		case "/tracks/4":
+			w.Write([]byte(`{"id":4,"name":"Intro to Dante","items":[` +
+				`{"id":7,"type":"machine","name":"Lame","difficulty":"Easy","complete":true},` +
+				`{"id":3,"type":"challenge","name":"Baby RE","difficulty":"Easy","complete":false},` +
+				`{"id":9,"type":"machine","name":"Legacy","difficulty":"Medium","complete":false},` +
+				`{"id":11,"type":"machine","name":"Bastion","difficulty":"Hard","complete":false}]}`))
+		case "/tracks/4/enroll":
+			enrolled.Store(true)
+			w.Write([]byte(`{"message":"Enrolled successfully"}`))
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		case "/user/profile/activity/1":
+			w.Write([]byte(`{"profile":{"activity":[{"object_type":"machine","type":"root","id":9,"name":"Legacy"}]}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.CallTool(ctx, "get_track_progress", map[string]interface{}{"track_name": "dante"})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	text := result.Content[0].Text
+	for _, want := range []string{`"completed": 2`, `"total": 4`, `"percent": 50`, `"name": "Baby RE"`, `"estimated_difficulty": "Hard"`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in track progress, got %s", want, text)
+		}
+	}
+
+	result, err = client.CallTool(ctx, "list_track_items", map[string]interface{}{"track_id": 4, "type": "machine", "incomplete": true})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if text := result.Content[0].Text; !strings.Contains(text, `"count": 1`) || !strings.Contains(text, "Bastion") {
+		t.Errorf("Expected only the incomplete machine, got %s", text)
+	}
+
+	result, err = client.CallTool(ctx, "enroll_in_track", map[string]interface{}{"track_id": 4})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if !enrolled.Load() || !strings.Contains(result.Content[0].Text, "Enrolled successfully") {
+		t.Errorf("Expected to be enrolled, got %s", result.Content[0].Text)
+	}
+}
+
+func TestMachineIPChange(t *testing.T) {
+	var ip atomic.Value
+	ip.Store("10.10.10.3")
+	polled := make(chan struct{}, 1)
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/machine/active":
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+			fmt.Fprintf(w, `{"info":{"id":42,"name":"Lame","ip":%q}}`, ip.Load())
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}, func(cfg *config.Config) {
+		cfg.PollInterval = 20 * time.Millisecond
+		cfg.IPChangeWatch = true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Call(ctx, mcp.MethodSubscribe, mcp.SubscribeRequest{URI: "htb://machine/active"}, nil); err != nil {
+		t.Fatalf("resources/subscribe failed: %v", err)
+	}
+
+	// Move the machine once the poller has seen its first address
+	<-polled
+	<-polled
+	ip.Store("10.10.10.4")
+
+	// Subscribed clients are told the resource changed
+	for updated := false; !updated; {
+		select {
+		case msg := <-client.Notifications():
+			if msg.Method == mcp.MethodNotificationResourceUpdated {
+				var params mcp.ResourceUpdatedNotification
+				json.Unmarshal(msg.Params, &params)
+				updated = params.URI == "htb://machine/active"
+			}
+		case <-ctx.Done():
+			t.Fatal("Expected a resource update for the active machine")
+		}
+	}
+
+	result, err := client.ReadResource(ctx, "htb://machine/active")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if !strings.Contains(result.Contents[0].Text, `"ip": "10.10.10.4"`) {
+		t.Errorf("Expected the new IP in the active machine resource, got %s", result.Contents[0].Text)
+	}
+}
+
+func TestWorkspaceRoots(t *testing.T) {
+	client := startUninitialized(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/challenge/download/5":
+			w.Write([]byte("PK\x03\x04"))
+		case "/challenge/info/5":
+			w.Write([]byte(`{"challenge":{"id":5,"name":"Weak RSA"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	root := t.TempDir()
+	var listed atomic.Int32
+	client.SetRequestHandler(func(msg *mcp.Message) (interface{}, *mcp.Error) {
+		if msg.Method != mcp.MethodListRoots {
+			return nil, &mcp.Error{Code: mcp.ErrorCodeMethodNotFound, Message: "Method not found"}
+		}
+		listed.Add(1)
+		return mcp.ListRootsResponse{Roots: []mcp.Root{{URI: "file://" + filepath.ToSlash(root), Name: "workspace"}}}, nil
+	})
+	if _, err := client.Initialize(ctx, mcp.ClientCapabilities{Roots: &mcp.RootsCapability{ListChanged: true}}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	download := func(dir string) (*mcp.CallToolResponse, error) {
+		return client.CallTool(ctx, "download_challenge_files", map[string]interface{}{"challenge_id": 5, "directory": dir})
+	}
+
+	result, err := download("")
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	want := filepath.Join(root, "htb-challenges", "weak-rsa.zip")
+	if data, err := os.ReadFile(want); err != nil || string(data) != "PK\x03\x04" {
+		t.Errorf("Expected the challenge files at %s, got %q, %v", want, data, err)
+	}
+
+	if n := listed.Load(); n != 1 {
+		t.Errorf("Expected roots to be listed once and cached, got %d requests", n)
+	}
+	client.Notify(mcp.MethodNotificationRootsChanged, nil)
+	if result, err := download("solved"); err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if n := listed.Load(); n != 2 {
+		t.Errorf("Expected roots to be listed again after a change, got %d requests", n)
+	}
+	if _, err := os.Stat(filepath.Join(root, "solved", "weak-rsa.zip")); err != nil {
+		t.Errorf("Expected a relative directory under the first root: %v", err)
+	}
+
+	// Inline downloads embed the zip instead of writing it
+	result, err = client.CallTool(ctx, "download_challenge_files", map[string]interface{}{"challenge_id": 5, "inline": true})
+	if err != nil || result.IsError || len(result.Content) != 2 {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if data, err := result.Content[1].Bytes(); err != nil || string(data) != "PK\x03\x04" || result.Content[1].Resource.MimeType != "application/zip" {
+		t.Errorf("Expected the zip inline, got %+v, %v", result.Content[1].Resource, err)
+	}
+}
+
+func TestConfirmationPolicy(t *testing.T) {
+	var resets atomic.Int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1,"isDedicatedVip":true}}`))
+		case "/vm/reset":
+			resets.Add(1)
+			w.Write([]byte(`{"message":"Machine reset"}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	}
+	policy := func(mode string) func(cfg *config.Config) {
+		return func(cfg *config.Config) {
+			cfg.ConfirmationPolicy = map[string]string{"reset_machine_instance": mode}
+		}
+	}
+	args := map[string]interface{}{"machine_id": 7}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := startUninitialized(t, handler, policy(config.ConfirmAsk))
+	answers := make(chan mcp.ElicitResponse, 2)
+	answers <- mcp.ElicitResponse{Action: mcp.ElicitDecline}
+	answers <- mcp.ElicitResponse{Action: mcp.ElicitAccept, Content: map[string]interface{}{"confirm": true}}
+	client.SetRequestHandler(func(msg *mcp.Message) (interface{}, *mcp.Error) {
+		var req mcp.ElicitRequest
+		json.Unmarshal(msg.Params, &req)
+		if msg.Method != mcp.MethodElicit || !strings.Contains(req.Message, "reset_machine_instance") {
+			return nil, &mcp.Error{Code: mcp.ErrorCodeInvalidRequest, Message: "unexpected request"}
+		}
+		return <-answers, nil
+	})
+	// Sent raw, as an empty elicitation object is dropped by omitempty
+	initialize := json.RawMessage(`{"protocolVersion":"` + mcp.MCPVersion + `","capabilities":{"elicitation":{}},"clientInfo":{"name":"mcptest","version":"1.0.0"}}`)
+	if err := client.Call(ctx, mcp.MethodInitialize, initialize, nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	client.Notify(mcp.MethodNotificationInitialized, nil)
+
+	result, err := client.CallTool(ctx, "reset_machine_instance", args)
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "the user chose to decline") {
+		t.Errorf("Expected a declined reset to be refused, got %+v, %v", result, err)
+	}
+	if n := resets.Load(); n != 0 {
+		t.Errorf("Expected no reset before confirmation, got %d", n)
+	}
+
+	result, err = client.CallTool(ctx, "reset_machine_instance", args)
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool failed: %+v, %v", result, err)
+	}
+	if n := resets.Load(); n != 1 {
+		t.Errorf("Expected one reset after confirmation, got %d", n)
+	}
+
+	// Clients that cannot elicit run ask tools unconfirmed, but not require tools
+	if result, err := startServer(t, handler, policy(config.ConfirmAsk)).CallTool(ctx, "reset_machine_instance", args); err != nil || result.IsError {
+		t.Errorf("Expected ask to run without elicitation support, got %+v, %v", result, err)
+	}
+	result, err = startServer(t, handler, policy(config.ConfirmRequire)).CallTool(ctx, "reset_machine_instance", args)
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].Text, "does not support elicitation") {
+		t.Errorf("Expected require to refuse without elicitation support, got %+v, %v", result, err)
+	}
+}
+
+func TestLoggingSetLevel(t *testing.T) {
+	client := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/info":
+			w.Write([]byte(`{"info":{"id":1}}`))
+		case "/machine/profile/7":
+			w.Write([]byte(`{"info":{"id":7,"name":"Lame","ip":"10.10.10.3"}}`))
+		default:
+			w.Write([]byte(`{"info":null}`))
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// drain returns the log messages received so far
+	drain := func() []mcp.LoggingMessageNotification {
+		var messages []mcp.LoggingMessageNotification
+		for {
+			select {
+			case msg := <-client.Notifications():
+				if msg.Method != mcp.MethodNotificationMessage {
+					continue
+				}
+				var params mcp.LoggingMessageNotification
+				if err := json.Unmarshal(msg.Params, &params); err != nil {
+					t.Fatalf("Failed to decode log message: %v", err)
+				}
+				messages = append(messages, params)
+			default:
+				return messages
+			}
+		}
+	}
+
+	if err := client.Call(ctx, mcp.MethodSetLevel, mcp.SetLevelRequest{Level: "error"}, nil); err != nil {
+		t.Fatalf("logging/setLevel failed: %v", err)
+	}
+	if _, err := client.CallTool(ctx, "get_machine_ip", map[string]interface{}{"machine_id": 7}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if messages := drain(); len(messages) > 0 {
+		t.Errorf("Expected warnings to be filtered at level error, got %+v", messages)
+	}
+
+	if err := client.Call(ctx, mcp.MethodSetLevel, mcp.SetLevelRequest{Level: "warning"}, nil); err != nil {
+		t.Fatalf("logging/setLevel failed: %v", err)
+	}
+	if _, err := client.CallTool(ctx, "get_dashboard", map[string]interface{}{}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	messages := drain()
+	if len(messages) == 0 || messages[0].Level != mcp.LogLevelWarning || messages[0].Logger != "schema" {
+		t.Errorf("Expected a schema drift warning, got %+v", messages)
+	}
+
+	if err := client.Call(ctx, mcp.MethodSetLevel, mcp.SetLevelRequest{Level: "verbose"}, nil); err == nil {
+		t.Error("Expected an error for an unknown log level")
+	}
+}
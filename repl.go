@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/NoASLR/htb-mcp-server/internal/server"
+	"github.com/NoASLR/htb-mcp-server/pkg/config"
+	"github.com/NoASLR/htb-mcp-server/pkg/mcp"
+)
+
+const replHelp = `Commands:
+  init                     send initialize (+ notifications/initialized)
+  tools                    tools/list
+  call <tool> [json-args]  tools/call, e.g. call get_machine_ip {"machine_id":42}
+  resources                resources/list
+  templates                resources/templates/list
+  read <uri>               resources/read
+  prompts                  prompts/list
+  prompt <name> [json]     prompts/get with string arguments
+  {...}                    send a raw JSON-RPC message
+  history                  show command history
+  !<n>                     re-run history entry n
+  help                     show this help
+  quit                     exit`
+
+// runREPL implements `htb-mcp-server repl`, an interactive terminal session
+// that speaks the MCP wire format to an in-process server
+func runREPL(cfg *config.Config) int {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	srv := server.NewWithIO(cfg, serverIn, serverOut)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Serve(ctx)
+
+	// Pretty-print everything the server sends
+	go func() {
+		scanner := bufio.NewScanner(clientIn)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, scanner.Bytes(), "", "  "); err != nil {
+				pretty.Reset()
+				pretty.Write(scanner.Bytes())
+			}
+			fmt.Printf("\n<- %s\nmcp> ", pretty.String())
+		}
+	}()
+
+	fmt.Println("HTB MCP REPL - type 'help' for commands")
+
+	var history []string
+	nextID := 0
+	input := bufio.NewScanner(os.Stdin)
+	for fmt.Print("mcp> "); input.Scan(); fmt.Print("mcp> ") {
+		line := strings.TrimSpace(input.Text())
+		if line == "" {
+			continue
+		}
+
+		// Recall a previous command
+		if strings.HasPrefix(line, "!") {
+			n, err := strconv.Atoi(line[1:])
+			if err != nil || n < 1 || n > len(history) {
+				fmt.Println("No such history entry")
+				continue
+			}
+			line = history[n-1]
+			fmt.Println(line)
+		}
+
+		switch line {
+		case "quit", "exit":
+			clientOut.Close()
+			return 0
+		case "help":
+			fmt.Println(replHelp)
+			continue
+		case "history":
+			for i, entry := range history {
+				fmt.Printf("%3d  %s\n", i+1, entry)
+			}
+			continue
+		}
+		history = append(history, line)
+
+		messages, err := replMessages(line, &nextID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+
+		for _, msg := range messages {
+			fmt.Printf("-> %s\n", msg)
+			if _, err := fmt.Fprintf(clientOut, "%s\n", msg); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	clientOut.Close()
+	return 0
+}
+
+// replMessages translates a REPL command into the JSON-RPC messages to send
+func replMessages(line string, nextID *int) ([][]byte, error) {
+	if strings.HasPrefix(line, "{") {
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("invalid JSON")
+		}
+		return [][]byte{[]byte(line)}, nil
+	}
+
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	request := func(method string, params interface{}) ([][]byte, error) {
+		*nextID++
+		data, err := json.Marshal(mcp.NewRequest(*nextID, method, params))
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{data}, nil
+	}
+
+	switch command {
+	case "init":
+		msgs, err := request(mcp.MethodInitialize, mcp.InitializeRequest{
+			ProtocolVersion: mcp.MCPVersion,
+			ClientInfo:      mcp.ClientInfo{Name: "htb-mcp-repl", Version: "1.0.0"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		initialized, err := json.Marshal(mcp.NewNotification(mcp.MethodNotificationInitialized, nil))
+		if err != nil {
+			return nil, err
+		}
+		return append(msgs, initialized), nil
+	case "tools":
+		return request(mcp.MethodListTools, nil)
+	case "call":
+		name, argsJSON, _ := strings.Cut(rest, " ")
+		if name == "" {
+			return nil, fmt.Errorf("usage: call <tool> [json-args]")
+		}
+		args := map[string]interface{}{}
+		if argsJSON = strings.TrimSpace(argsJSON); argsJSON != "" {
+			if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		return request(mcp.MethodCallTool, mcp.CallToolRequest{Name: name, Arguments: args})
+	case "resources":
+		return request(mcp.MethodListResources, nil)
+	case "templates":
+		return request(mcp.MethodListResourceTemplates, nil)
+	case "read":
+		if rest == "" {
+			return nil, fmt.Errorf("usage: read <uri>")
+		}
+		return request(mcp.MethodReadResource, mcp.ReadResourceRequest{URI: rest})
+	case "prompts":
+		return request(mcp.MethodListPrompts, nil)
+	case "prompt":
+		name, argsJSON, _ := strings.Cut(rest, " ")
+		if name == "" {
+			return nil, fmt.Errorf("usage: prompt <name> [json-args]")
+		}
+		args := map[string]string{}
+		if argsJSON = strings.TrimSpace(argsJSON); argsJSON != "" {
+			if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		return request(mcp.MethodGetPrompt, mcp.GetPromptRequest{Name: name, Arguments: args})
+	}
+
+	return nil, fmt.Errorf("unknown command %q (type 'help')", command)
+}